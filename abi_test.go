@@ -0,0 +1,148 @@
+//go:build cgo && !v4l2cgo
+
+package v4l2
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Charleye/v4l2-go/internal/cabi"
+)
+
+// abiTypes are the structs of ztypes_linux_$GOARCH.go
+var abiTypes = []any{
+	v4l2_buffer{},
+	v4l2_capability{},
+	v4l2_captureparm{},
+	v4l2_control{},
+	v4l2_crop{},
+	v4l2_cropcap{},
+	v4l2_ctrl_h264_decode_params{},
+	v4l2_ctrl_h264_pps{},
+	v4l2_ctrl_h264_pred_weights{},
+	v4l2_ctrl_h264_scaling_matrix{},
+	v4l2_ctrl_h264_slice_params{},
+	v4l2_ctrl_h264_sps{},
+	v4l2_ctrl_vp8_frame{},
+	v4l2_decoder_cmd{},
+	v4l2_encoder_cmd{},
+	v4l2_event{},
+	v4l2_event_ctrl{},
+	v4l2_event_frame_sync{},
+	v4l2_event_motion_det{},
+	v4l2_event_src_change{},
+	v4l2_event_subscription{},
+	v4l2_event_vsync{},
+	v4l2_exportbuffer{},
+	v4l2_ext_control{},
+	v4l2_ext_controls{},
+	v4l2_fmtdesc{},
+	v4l2_format{},
+	v4l2_fract{},
+	v4l2_frmival_stepwise{},
+	v4l2_frmivalenum{},
+	v4l2_frmsize_discrete{},
+	v4l2_frmsize_stepwise{},
+	v4l2_frmsizeenum{},
+	v4l2_h264_dpb_entry{},
+	v4l2_h264_weight_factors{},
+	v4l2_input{},
+	v4l2_mbus_framefmt{},
+	v4l2_output{},
+	v4l2_outputparm{},
+	v4l2_pix_format{},
+	v4l2_pix_format_mplane{},
+	v4l2_plane{},
+	v4l2_plane_pix_format{},
+	v4l2_query_ext_ctrl{},
+	v4l2_queryctrl{},
+	v4l2_querymenu{},
+	v4l2_rect{},
+	v4l2_requestbuffers{},
+	v4l2_selection{},
+	v4l2_streamparm{},
+	v4l2_subdev_format{},
+	v4l2_subdev_frame_interval{},
+	v4l2_subdev_frame_size_enum{},
+	v4l2_subdev_mbus_code_enum{},
+	v4l2_subdev_selection{},
+	v4l2_timecode{},
+}
+
+var abiIoctls = map[string]uint64{
+	"MEDIA_IOC_REQUEST_ALLOC":        MEDIA_IOC_REQUEST_ALLOC,
+	"MEDIA_REQUEST_IOC_QUEUE":        MEDIA_REQUEST_IOC_QUEUE,
+	"MEDIA_REQUEST_IOC_REINIT":       MEDIA_REQUEST_IOC_REINIT,
+	"VIDIOC_CROPCAP":                 VIDIOC_CROPCAP,
+	"VIDIOC_DECODER_CMD":             VIDIOC_DECODER_CMD,
+	"VIDIOC_DQBUF":                   VIDIOC_DQBUF,
+	"VIDIOC_DQEVENT":                 VIDIOC_DQEVENT,
+	"VIDIOC_ENCODER_CMD":             VIDIOC_ENCODER_CMD,
+	"VIDIOC_ENUMINPUT":               VIDIOC_ENUMINPUT,
+	"VIDIOC_ENUMOUTPUT":              VIDIOC_ENUMOUTPUT,
+	"VIDIOC_ENUM_FMT":                VIDIOC_ENUM_FMT,
+	"VIDIOC_ENUM_FRAMEINTERVALS":     VIDIOC_ENUM_FRAMEINTERVALS,
+	"VIDIOC_ENUM_FRAMESIZES":         VIDIOC_ENUM_FRAMESIZES,
+	"VIDIOC_EXPBUF":                  VIDIOC_EXPBUF,
+	"VIDIOC_G_CROP":                  VIDIOC_G_CROP,
+	"VIDIOC_G_CTRL":                  VIDIOC_G_CTRL,
+	"VIDIOC_G_EXT_CTRLS":             VIDIOC_G_EXT_CTRLS,
+	"VIDIOC_G_FMT":                   VIDIOC_G_FMT,
+	"VIDIOC_G_INPUT":                 VIDIOC_G_INPUT,
+	"VIDIOC_G_OUTPUT":                VIDIOC_G_OUTPUT,
+	"VIDIOC_G_PARM":                  VIDIOC_G_PARM,
+	"VIDIOC_G_SELECTION":             VIDIOC_G_SELECTION,
+	"VIDIOC_QBUF":                    VIDIOC_QBUF,
+	"VIDIOC_QUERYBUF":                VIDIOC_QUERYBUF,
+	"VIDIOC_QUERYCAP":                VIDIOC_QUERYCAP,
+	"VIDIOC_QUERYCTRL":               VIDIOC_QUERYCTRL,
+	"VIDIOC_QUERYMENU":               VIDIOC_QUERYMENU,
+	"VIDIOC_QUERY_EXT_CTRL":          VIDIOC_QUERY_EXT_CTRL,
+	"VIDIOC_REQBUFS":                 VIDIOC_REQBUFS,
+	"VIDIOC_STREAMOFF":               VIDIOC_STREAMOFF,
+	"VIDIOC_STREAMON":                VIDIOC_STREAMON,
+	"VIDIOC_SUBDEV_ENUM_FRAME_SIZE":  VIDIOC_SUBDEV_ENUM_FRAME_SIZE,
+	"VIDIOC_SUBDEV_ENUM_MBUS_CODE":   VIDIOC_SUBDEV_ENUM_MBUS_CODE,
+	"VIDIOC_SUBDEV_G_FMT":            VIDIOC_SUBDEV_G_FMT,
+	"VIDIOC_SUBDEV_G_FRAME_INTERVAL": VIDIOC_SUBDEV_G_FRAME_INTERVAL,
+	"VIDIOC_SUBDEV_G_SELECTION":      VIDIOC_SUBDEV_G_SELECTION,
+	"VIDIOC_SUBDEV_S_FMT":            VIDIOC_SUBDEV_S_FMT,
+	"VIDIOC_SUBDEV_S_FRAME_INTERVAL": VIDIOC_SUBDEV_S_FRAME_INTERVAL,
+	"VIDIOC_SUBDEV_S_SELECTION":      VIDIOC_SUBDEV_S_SELECTION,
+	"VIDIOC_SUBSCRIBE_EVENT":         VIDIOC_SUBSCRIBE_EVENT,
+	"VIDIOC_S_CROP":                  VIDIOC_S_CROP,
+	"VIDIOC_S_CTRL":                  VIDIOC_S_CTRL,
+	"VIDIOC_S_EXT_CTRLS":             VIDIOC_S_EXT_CTRLS,
+	"VIDIOC_S_FMT":                   VIDIOC_S_FMT,
+	"VIDIOC_S_INPUT":                 VIDIOC_S_INPUT,
+	"VIDIOC_S_OUTPUT":                VIDIOC_S_OUTPUT,
+	"VIDIOC_S_PARM":                  VIDIOC_S_PARM,
+	"VIDIOC_S_SELECTION":             VIDIOC_S_SELECTION,
+	"VIDIOC_TRY_DECODER_CMD":         VIDIOC_TRY_DECODER_CMD,
+	"VIDIOC_TRY_ENCODER_CMD":         VIDIOC_TRY_ENCODER_CMD,
+	"VIDIOC_TRY_EXT_CTRLS":           VIDIOC_TRY_EXT_CTRLS,
+	"VIDIOC_TRY_FMT":                 VIDIOC_TRY_FMT,
+	"VIDIOC_UNSUBSCRIBE_EVENT":       VIDIOC_UNSUBSCRIBE_EVENT,
+}
+
+func TestABILayout(t *testing.T) {
+	for _, v := range abiTypes {
+		typ := reflect.TypeOf(v)
+		c, ok := cabi.Types[typ.Name()]
+		if !ok {
+			t.Errorf("%s: not in the C headers", typ.Name())
+			continue
+		}
+		for _, d := range cabi.Compare(typ, c) {
+			t.Error(d)
+		}
+	}
+}
+
+func TestABIIoctls(t *testing.T) {
+	for name, v := range abiIoctls {
+		if c := cabi.Ioctls[name]; v != c {
+			t.Errorf("%s = %#x, C %#x", name, v, c)
+		}
+	}
+}
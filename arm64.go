@@ -1,39 +0,0 @@
-// +build linux
-// +build arm64
-
-/////////////////////////////////////////////////////
-//                                                 //
-//  !!! THIS IS A GENERATED FILE, DO NOT EDIT !!!  //
-//                                                 //
-/////////////////////////////////////////////////////
-
-package v4l2
-
-const (
-	__SIZEOF_POINTER__    =  8
-)
-
-const (
-	offset_format_type                = 0
-	offset_streamparm_type            = 0
-	offset_requestbuffers_type        = 4
-	offset_buffer_type                = 4
-	offset_cropcap_type               = 0
-	offset_crop_type                  = 0
-	offset_fmtdesc_type               = 4
-	offset_frmsizeenum_type           = 8
-	offset_frmivalenum_type           = 16
-	offset_queryctrl_type             = 4
-	offset_event_subscription_type    = 0
-	offset_event_type                 = 0
-	offset_querymenu_union            = 8
-	offset_input_type                 = 36
-	offset_output_type                = 36
-	offset_selection_type             = 0
-	offset_timecode_type              = 0
-	offset_pix_format_encoding        = 36
-	offset_pix_format_mplane_encoding = 182
-	offset_ext_controls_ctrl_class    = 0
-	offset_ext_control_union          = 12
-)
-
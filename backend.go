@@ -0,0 +1,190 @@
+package v4l2
+
+import (
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+)
+
+// Backend serves the system calls of a device file in place of the kernel,
+// the way a driver does. Ioctl gets the request and argument of ioctl(2)
+// as they are passed to the kernel, Mmap and Munmap map the memory of
+// V4L2_MEMORY_MMAP buffers, and Poll returns the EPOLLIN, EPOLLOUT and
+// EPOLLPRI events the device is ready for, and EPOLLERR, e.g. when no
+// buffers are queued on a memory-to-memory device.
+//
+// A Backend is opened with OpenBackend. Every Ioctl function, the Camera,
+// the BufferQueue and the pollers of the package reach it through the file
+// descriptor of the Device, so the code above runs unchanged on it.
+type Backend interface {
+	Ioctl(request uint, argp unsafe.Pointer) error
+	Mmap(offset int64, length int) ([]byte, error)
+	Munmap(data []byte) error
+	Poll() uint32
+	Close() error
+}
+
+// backendEvents are the poll events a Backend may report, each one is
+// signalled to pollers by a pipe of its own
+var backendEvents = [4]uint32{syscall.EPOLLIN, syscall.EPOLLOUT, syscall.EPOLLPRI, syscall.EPOLLERR}
+
+// backendFile is the file descriptor of a Backend. The read end of the
+// pipe of each of backendEvents is readable while the backend is ready for
+// the event, the read end of the EPOLLIN pipe is the file descriptor.
+type backendFile struct {
+	b     Backend
+	mu    sync.Mutex // serializes the updates of the pipes
+	pipes [len(backendEvents)][2]int
+	ready uint32 // events signalled by the pipes
+}
+
+var backends struct {
+	n      atomic.Int32 // number of open files, no lookups while 0
+	mapped atomic.Int32 // number of mappings, which outlive their file
+	mu     sync.RWMutex
+	files  map[int]*backendFile
+	maps   map[*byte]*backendFile // mapped memory by its first byte
+}
+
+// OpenBackend returns a Device whose file descriptor is served by b. The
+// file descriptor is a real one, but any system call on it other than
+// those made by this package fails or does not reach b.
+func OpenBackend(b Backend) (*Device, error) {
+	f := &backendFile{b: b}
+	for i := range f.pipes {
+		err := syscall.Pipe2(f.pipes[i][:], syscall.O_CLOEXEC|syscall.O_NONBLOCK)
+		if err != nil {
+			f.closePipes(i)
+			return nil, err
+		}
+	}
+	fd := f.pipes[0][0]
+
+	backends.mu.Lock()
+	if backends.files == nil {
+		backends.files = make(map[int]*backendFile)
+		backends.maps = make(map[*byte]*backendFile)
+	}
+	backends.files[fd] = f
+	backends.n.Add(1)
+	backends.mu.Unlock()
+
+	f.update()
+	return &Device{FD: fd, Backend: b}, nil
+}
+
+// lookupBackend returns the backend file of fd, nil for kernel files
+func lookupBackend(fd int) *backendFile {
+	if backends.n.Load() == 0 {
+		return nil
+	}
+	backends.mu.RLock()
+	defer backends.mu.RUnlock()
+	return backends.files[fd]
+}
+
+func (f *backendFile) ioctl(request uint, argp unsafe.Pointer) error {
+	err := f.b.Ioctl(request, argp)
+	f.update()
+	return err
+}
+
+// update makes the pipes readable for the events the backend is ready for
+func (f *backendFile) update() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ready := f.b.Poll()
+	for i, ev := range backendEvents {
+		switch {
+		case ready&ev != 0 && f.ready&ev == 0:
+			syscall.Write(f.pipes[i][1], []byte{0})
+		case ready&ev == 0 && f.ready&ev != 0:
+			var buf [1]byte
+			syscall.Read(f.pipes[i][0], buf[:])
+		}
+	}
+	f.ready = ready
+}
+
+// pollFDs returns the pipes to poll for events, and for EPOLLERR, which
+// epoll always reports
+func (f *backendFile) pollFDs(events uint32) []syscall.EpollEvent {
+	var fds []syscall.EpollEvent
+	for i, ev := range backendEvents {
+		if (events|syscall.EPOLLERR)&ev != 0 {
+			fds = append(fds, syscall.EpollEvent{
+				Events: syscall.EPOLLIN,
+				Fd:     int32(f.pipes[i][0]),
+			})
+		}
+	}
+	return fds
+}
+
+// event returns the event signalled by the pipe fd
+func (f *backendFile) event(fd int32) uint32 {
+	for i, ev := range backendEvents {
+		if int32(f.pipes[i][0]) == fd {
+			return ev
+		}
+	}
+	return 0
+}
+
+func (f *backendFile) closePipes(n int) {
+	for i := 0; i < n; i++ {
+		syscall.Close(f.pipes[i][0])
+		syscall.Close(f.pipes[i][1])
+	}
+}
+
+// closeFile closes fd and the backend behind it, if any
+func closeFile(fd int) error {
+	f := lookupBackend(fd)
+	if f == nil {
+		return syscall.Close(fd)
+	}
+	backends.mu.Lock()
+	delete(backends.files, fd)
+	backends.n.Add(-1)
+	backends.mu.Unlock()
+
+	f.closePipes(len(f.pipes))
+	return f.b.Close()
+}
+
+// mmap maps length bytes of the buffer at offset of the device fd
+func mmap(fd int, offset int64, length int) ([]byte, error) {
+	f := lookupBackend(fd)
+	if f == nil {
+		return syscall.Mmap(fd, offset, length,
+			syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	}
+	data, err := f.b.Mmap(offset, length)
+	if err != nil || len(data) == 0 {
+		return data, err
+	}
+	backends.mu.Lock()
+	backends.maps[&data[0]] = f
+	backends.mapped.Add(1)
+	backends.mu.Unlock()
+	return data, nil
+}
+
+// munmap unmaps data returned by mmap
+func munmap(data []byte) error {
+	if len(data) > 0 && backends.mapped.Load() > 0 {
+		backends.mu.Lock()
+		f, ok := backends.maps[&data[0]]
+		if ok {
+			delete(backends.maps, &data[0])
+			backends.mapped.Add(-1)
+		}
+		backends.mu.Unlock()
+		if f != nil {
+			return f.b.Munmap(data)
+		}
+	}
+	return syscall.Munmap(data)
+}
@@ -1,8 +1,10 @@
 package v4l2
 
 import (
-	"log"
+	"errors"
+	"fmt"
 	"syscall"
+	"unsafe"
 )
 
 type Camera struct {
@@ -12,29 +14,49 @@ type Camera struct {
 	Height            uint32
 	PixelFormat       uint32
 	PixFmtDescription string
+	Input             int  // video input selected before setting format
+	SelectInput       bool // select Input, else the current input is kept
 }
 
-func (c *Camera) VerifyCaps() {
+func (c *Camera) VerifyCaps() error {
 	var caps V4L2_Capability
 	err := IoctlQueryCap(c.FD, &caps)
 	if err != nil {
-		log.Fatal("Failed to query capability")
+		return fmt.Errorf("Failed to query capability: %w", err)
 	}
 	if caps.Capabilities&V4L2_CAP_VIDEO_CAPTURE == 0 {
-		log.Fatal("The device not support video capture")
+		return fmt.Errorf("%w: video capture", ErrorUnsupportedCap)
 	}
+	return nil
 }
 
-func (c *Camera) SetFormat() {
+func (c *Camera) SetFormat() error {
 	if c.Width == 0 || c.Height == 0 {
-		log.Fatal("Not configure width or height in pixel")
+		return errors.New("Not configure width or height in pixel")
 	}
 	if c.PixelFormat == 0 && c.PixFmtDescription == "" {
-		log.Fatal("Not assign pixel format")
+		return errors.New("Not assign pixel format")
 	}
-	if c.PixelFormat > 0 && c.PixFmtDescription != "" {
-		if GetFourCCByName(c.PixFmtDescription) != c.PixelFormat {
-			log.Fatal("Inconsistent in pixel format")
+
+	pixelformat := c.PixelFormat
+	if c.PixFmtDescription != "" {
+		fourcc, err := GetFourCCByName(c.PixFmtDescription)
+		if err != nil {
+			return err
+		}
+		if pixelformat > 0 && fourcc != pixelformat {
+			return errors.New("Inconsistent in pixel format")
+		}
+		pixelformat = fourcc
+	}
+
+	if c.SelectInput {
+		// devices without selectable inputs do not implement
+		// VIDIOC_S_INPUT, their only input is 0
+		input := c.Input
+		err := IoctlSetInput(c.FD, &input)
+		if err != nil && (err != syscall.ENOTTY || c.Input != 0) {
+			return fmt.Errorf("Failed to select input: %w", err)
 		}
 	}
 
@@ -42,31 +64,28 @@ func (c *Camera) SetFormat() {
 	var pixfmt V4L2_Pix_Format
 	pixfmt.Width = c.Width
 	pixfmt.Height = c.Height
-	if c.PixelFormat > 0 {
-		pixfmt.PixelFormat = c.PixelFormat
-	} else if c.PixFmtDescription != "" {
-		pixfmt.PixelFormat = GetFourCCByName(c.PixFmtDescription)
-	}
+	pixfmt.PixelFormat = pixelformat
 	pixfmt.Priv = 0
 	format.Type = V4L2_BUF_TYPE_VIDEO_CAPTURE
 	format.Fmt = &pixfmt
 	err := IoctlSetFmt(c.FD, &format)
 	if err != nil {
-		log.Fatal("Failed to set format")
+		return fmt.Errorf("Failed to set format: %w", err)
 	}
+	return nil
 }
 
-func (c *Camera) AllocBuffers(count uint32) {
+func (c *Camera) AllocBuffers(count uint32) error {
 	var reqbufs V4L2_Requestbuffers
 	reqbufs.Count = count
 	reqbufs.Memory = V4L2_MEMORY_MMAP
 	reqbufs.Type = V4L2_BUF_TYPE_VIDEO_CAPTURE
 	err := IoctlRequestBuffers(c.FD, &reqbufs)
 	if err != nil {
-		log.Fatal("Failed to request buffers: ", err)
+		return fmt.Errorf("Failed to request buffers: %w", err)
 	}
 	if reqbufs.Count == 0 {
-		log.Fatal("Out of memory")
+		return fmt.Errorf("%w: out of memory", ErrorBufferAlloc)
 	}
 
 	var bufs Buffers
@@ -83,57 +102,157 @@ func (c *Camera) AllocBuffers(count uint32) {
 			Memory: c.Type,
 		}
 		if err := IoctlQueryBuf(c.FD, &vb); err != nil {
-			log.Fatal("Failed to query buffers: ", err)
+			c.freeBuffers()
+			return fmt.Errorf("Failed to query buffers: %w", err)
 		}
 		var offset uint32
-		GetValueFromUnion(vb.M, &offset)
-		buf, err := syscall.Mmap(c.FD, int64(offset), int(vb.Length),
-			syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+		if err := GetValueFromUnion(vb.M, &offset); err != nil {
+			c.freeBuffers()
+			return err
+		}
+		buf, err := mmap(c.FD, int64(offset), int(vb.Length))
+		if err != nil {
+			c.freeBuffers()
+			return fmt.Errorf("%w: mmap: %w", ErrorBufferAlloc, err)
+		}
+		data = append(data, buf)
+		bufs.Data = data
+		if err := IoctlQBuf(c.FD, &vb); err != nil {
+			c.freeBuffers()
+			return fmt.Errorf("Failed to enqueue buffer: %w", err)
+		}
+	}
+	return nil
+}
+
+// AllocUserBuffers is like AllocBuffers, but captures into page aligned
+// buffers allocated by AllocUserBuffer through V4L2_MEMORY_USERPTR.
+func (c *Camera) AllocUserBuffers(count uint32) error {
+	var format V4L2_Format
+	var pixfmt V4L2_Pix_Format
+	format.Type = V4L2_BUF_TYPE_VIDEO_CAPTURE
+	format.Fmt = &pixfmt
+	if err := IoctlGetFmt(c.FD, &format); err != nil {
+		return fmt.Errorf("Failed to get format: %w", err)
+	}
+
+	var reqbufs V4L2_Requestbuffers
+	reqbufs.Count = count
+	reqbufs.Memory = V4L2_MEMORY_USERPTR
+	reqbufs.Type = V4L2_BUF_TYPE_VIDEO_CAPTURE
+	err := IoctlRequestBuffers(c.FD, &reqbufs)
+	if err != nil {
+		return fmt.Errorf("Failed to request buffers: %w", err)
+	}
+	if reqbufs.Count == 0 {
+		return fmt.Errorf("%w: out of memory", ErrorBufferAlloc)
+	}
+
+	var bufs Buffers
+	bufs.Count = reqbufs.Count
+	bufs.NPlanes = 1
+	c.Type = reqbufs.Memory
+	c.Bufs = &bufs
+
+	data := make([][]byte, 0, bufs.Count)
+	for i := 0; i < int(bufs.Count); i++ {
+		buf, err := AllocUserBuffer(int(pixfmt.SizeImage))
 		if err != nil {
-			log.Fatal("Failed to mmap: ", err)
+			c.freeBuffers()
+			return err
 		}
 		data = append(data, buf)
+		bufs.Data = data
+
+		vb := V4L2_Buffer{
+			Index:  uint32(i),
+			Type:   V4L2_BUF_TYPE_VIDEO_CAPTURE,
+			Memory: c.Type,
+			Length: uint32(len(buf)),
+		}
+		vb.SetUserPtr(uintptr(unsafe.Pointer(&buf[0])))
 		if err := IoctlQBuf(c.FD, &vb); err != nil {
-			log.Fatal("Failed ro enqueue buffer: ", err)
+			c.freeBuffers()
+			return fmt.Errorf("Failed to enqueue buffer: %w", err)
 		}
 	}
-	bufs.Data = data
+	return nil
 }
 
-func (c *Camera) TurnOn() {
+// freeBuffers releases the buffers of a failed allocation, the memory
+// allocated so far and the buffers of the driver
+func (c *Camera) freeBuffers() {
+	reqbufs := V4L2_Requestbuffers{
+		Type:   V4L2_BUF_TYPE_VIDEO_CAPTURE,
+		Memory: c.Type,
+	}
+	if c.Type == V4L2_MEMORY_USERPTR {
+		// make the driver drop its references before freeing
+		IoctlRequestBuffers(c.FD, &reqbufs)
+	}
+	for _, v := range c.Bufs.Data {
+		if c.Type == V4L2_MEMORY_USERPTR {
+			FreeUserBuffer(v)
+		} else {
+			munmap(v)
+		}
+	}
+	if c.Type == V4L2_MEMORY_MMAP {
+		IoctlRequestBuffers(c.FD, &reqbufs)
+	}
+	c.Bufs = nil
+}
+
+func (c *Camera) TurnOn() error {
 	var stream int = V4L2_BUF_TYPE_VIDEO_CAPTURE
 	err := IoctlStreamOn(c.FD, &stream)
 	if err != nil {
-		log.Fatal("Failed to stream on: ", err)
+		return fmt.Errorf("Failed to stream on: %w", err)
 	}
+	return nil
 }
 
-func (c *Camera) TurnOff() {
+func (c *Camera) TurnOff() error {
 	var stream int = V4L2_BUF_TYPE_VIDEO_CAPTURE
 	err := IoctlStreamOff(c.FD, &stream)
 	if err != nil {
-		log.Fatal("Failed to stream off: ", err)
+		return fmt.Errorf("Failed to stream off: %w", err)
 	}
 
+	if c.Bufs == nil {
+		return nil
+	}
+	if c.Type == V4L2_MEMORY_USERPTR {
+		// make the driver drop its references before unmapping
+		reqbufs := V4L2_Requestbuffers{
+			Type:   V4L2_BUF_TYPE_VIDEO_CAPTURE,
+			Memory: V4L2_MEMORY_USERPTR,
+		}
+		if err := IoctlRequestBuffers(c.FD, &reqbufs); err != nil {
+			return fmt.Errorf("Failed to release buffers: %w", err)
+		}
+	}
 	for _, v := range c.Bufs.Data {
-		err := syscall.Munmap(v)
+		err := munmap(v)
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
 	}
+	c.Bufs.Data = nil
+	return nil
 }
 
-func (c *Camera) Capture() []byte {
+func (c *Camera) Capture() ([]byte, error) {
 	vb := V4L2_Buffer{
 		Type:   V4L2_BUF_TYPE_VIDEO_CAPTURE,
 		Memory: c.Type,
 	}
 	if err := IoctlDQBuf(c.FD, &vb); err != nil {
-		log.Fatal("Failed to dequeue buffer: ", err)
+		return nil, fmt.Errorf("Failed to dequeue buffer: %w", err)
 	}
 	data := c.Bufs.Data[vb.Index][:vb.BytesUsed]
 	if err := IoctlQBuf(c.FD, &vb); err != nil {
-		log.Fatal("Failed to enqueue buffer: ", err)
+		return nil, fmt.Errorf("Failed to enqueue buffer: %w", err)
 	}
-	return data
+	return data, nil
 }
@@ -0,0 +1,212 @@
+package v4l2
+
+import (
+	"errors"
+	"image/color"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+// checkBars checks that line y of an RGB24 frame of width w shows the
+// color bars
+func checkBars(t *testing.T, data []byte, w, y int) {
+	t.Helper()
+	for i, want := range fakeBars {
+		x := (2*i + 1) * w / (2 * len(fakeBars))
+		p := data[(y*w+x)*3:]
+		if got := (color.RGBA{p[0], p[1], p[2], 0xff}); got != want {
+			t.Errorf("bar %d at %d,%d is %v, want %v", i, x, y, got, want)
+		}
+	}
+}
+
+func newFakeCamera(t *testing.T) *Camera {
+	d, _ := openFake(t)
+	c := &Camera{
+		Device:      *d,
+		Width:       320,
+		Height:      240,
+		PixelFormat: V4L2_PIX_FMT_RGB24,
+	}
+	if err := c.VerifyCaps(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetFormat(); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCameraCapture(t *testing.T) {
+	c := newFakeCamera(t)
+	if err := c.AllocBuffers(4); err != nil {
+		t.Fatal(err)
+	}
+	if c.Bufs.Count != 4 || len(c.Bufs.Data) != 4 {
+		t.Fatalf("%d buffers, %d mapped", c.Bufs.Count, len(c.Bufs.Data))
+	}
+	if err := c.TurnOn(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		data, err := c.Capture()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) != 320*240*3 {
+			t.Fatalf("frame %d has %d bytes", i, len(data))
+		}
+		checkBars(t, data, 320, 120)
+	}
+	if err := c.TurnOff(); err != nil {
+		t.Fatal(err)
+	}
+	if c.Bufs.Data != nil {
+		t.Error("buffers still mapped after TurnOff")
+	}
+}
+
+func TestCameraUserBuffers(t *testing.T) {
+	c := newFakeCamera(t)
+	hflip := V4L2_Control{ID: V4L2_CID_HFLIP, Value: 1}
+	if err := IoctlSetCtrl(c.FD, &hflip); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AllocUserBuffers(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.TurnOn(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		data, err := c.Capture()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// flipped bars start with black
+		if data[0] != 0 || data[len(data)-1] != 0xff {
+			t.Errorf("frame %d: first byte %#x, last byte %#x", i, data[0], data[len(data)-1])
+		}
+	}
+	if err := c.TurnOff(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCameraSetFormat(t *testing.T) {
+	c := newFakeCamera(t)
+	c.PixelFormat = 0
+	c.PixFmtDescription = "GREY"
+	c.Width, c.Height = 600, 500
+	if err := c.SetFormat(); err != nil {
+		t.Fatal(err)
+	}
+	pix := V4L2_Pix_Format{}
+	format := V4L2_Format{Type: V4L2_BUF_TYPE_VIDEO_CAPTURE, Fmt: &pix}
+	if err := IoctlGetFmt(c.FD, &format); err != nil {
+		t.Fatal(err)
+	}
+	if pix.PixelFormat != V4L2_PIX_FMT_GREY || pix.Width != 640 || pix.Height != 480 ||
+		pix.SizeImage != 640*480 {
+		t.Errorf("format %+v", pix)
+	}
+
+	c.PixelFormat = V4L2_PIX_FMT_YUYV
+	if err := c.SetFormat(); err == nil {
+		t.Error("inconsistent pixel formats accepted")
+	}
+	c.PixelFormat = 0
+	c.Input = 1
+	if err := c.SetFormat(); err != nil {
+		t.Errorf("input 1 not selected: %v", err)
+	}
+	c.SelectInput = true
+	if err := c.SetFormat(); !errors.Is(err, syscall.EINVAL) {
+		t.Errorf("select input 1: %v, want EINVAL", err)
+	}
+	c.Input = 0
+	if err := c.SetFormat(); err != nil {
+		t.Errorf("select input 0: %v", err)
+	}
+}
+
+func TestCameraCaptureStreamedOff(t *testing.T) {
+	c := newFakeCamera(t)
+	if err := c.AllocBuffers(2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Capture(); !errors.Is(err, syscall.EINVAL) {
+		t.Errorf("capture before TurnOn: %v, want EINVAL", err)
+	}
+}
+
+// failingDriver is a FakeDriver failing an ioctl, or mmap for request 0,
+// with EIO after a number of calls succeeded
+type failingDriver struct {
+	*FakeDriver
+	request uint
+	after   int
+}
+
+func (d *failingDriver) fail(request uint) bool {
+	if request != d.request {
+		return false
+	}
+	d.after--
+	return d.after < 0
+}
+
+func (d *failingDriver) Ioctl(request uint, argp unsafe.Pointer) error {
+	if d.fail(request) {
+		return syscall.EIO
+	}
+	return d.FakeDriver.Ioctl(request, argp)
+}
+
+func (d *failingDriver) Mmap(offset int64, length int) ([]byte, error) {
+	if d.fail(0) {
+		return nil, syscall.EIO
+	}
+	return d.FakeDriver.Mmap(offset, length)
+}
+
+func TestCameraAllocFailure(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		user    bool
+		request uint
+		after   int
+	}{
+		{"mmap", false, 0, 2},
+		{"query", false, VIDIOC_QUERYBUF, 1},
+		{"queue", false, VIDIOC_QBUF, 3},
+		{"queue user", true, VIDIOC_QBUF, 1},
+	} {
+		drv := &failingDriver{NewFakeDriver(), tc.request, tc.after}
+		d, err := OpenBackend(drv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(d.Close)
+		c := &Camera{Device: *d, Width: 320, Height: 240, PixelFormat: V4L2_PIX_FMT_RGB24}
+		if err := c.SetFormat(); err != nil {
+			t.Fatal(err)
+		}
+
+		mapped := backends.mapped.Load()
+		if tc.user {
+			err = c.AllocUserBuffers(4)
+		} else {
+			err = c.AllocBuffers(4)
+		}
+		if !errors.Is(err, syscall.EIO) {
+			t.Errorf("%s: %v, want EIO", tc.name, err)
+		}
+		if n := backends.mapped.Load() - mapped; n != 0 || c.Bufs != nil {
+			t.Errorf("%s: %d mappings left, buffers kept %v", tc.name, n, c.Bufs != nil)
+		}
+		// the format can only be changed without buffers
+		setFakeFormat(t, d, V4L2_PIX_FMT_YUYV, 640, 480)
+	}
+}
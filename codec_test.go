@@ -0,0 +1,556 @@
+package v4l2
+
+import (
+	"fmt"
+	"sync"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+// fakeCodec is a Backend emulating a stateful decoder or encoder with
+// single-planar queues of MMAP buffers, for testing Decoder and Encoder.
+//
+// The encoder copies each raw frame into an encoded frame, which is a key
+// frame every gop frames or when forced by V4L2_CID_MPEG_VIDEO_FORCE_KEY_FRAME.
+//
+// The bitstream is made up of chunks of text: a chunk "WxH" is a header
+// announcing frames of W x H pixels, any other chunk is decoded into a
+// frame holding the chunk. A header of another resolution while decoding
+// ends the frames of the previous one with a V4L2_BUF_FLAG_LAST buffer and
+// stalls decoding until the CAPTURE queue is streamed on again. Chunks are
+// decoded as soon as they are queued, unless hold is set.
+type fakeCodec struct {
+	mu         sync.Mutex
+	encoder    bool
+	coded      uint32 // coded format of the OUTPUT queue
+	width      uint32
+	height     uint32
+	src, dst   fakeQueue
+	subs       map[uint32]bool
+	events     []v4l2_event
+	configured bool // a header was decoded
+	resChange  bool // stalled until the CAPTURE queue is set up again
+	draining   bool // V4L2_DEC_CMD_STOP or V4L2_ENC_CMD_STOP issued
+	last       bool // a V4L2_BUF_FLAG_LAST buffer is due
+	sequence   uint32
+	frameRate  uint32 // of the encoder, 0 if not set
+	encoded    uint32 // frames encoded
+	gop        int32  // distance of key frames of the encoder
+	forced     bool   // the next encoded frame is a key frame
+
+	hold  bool // nothing is decoded or encoded
+	gone  bool // the device was unplugged, polling reports all events
+	noCmd bool // V4L2_ENC_CMD_STOP and _START are not supported
+}
+
+// fakeQueue is one queue of fakeCodec
+type fakeQueue struct {
+	typ       uint32
+	offset    uint32 // of the memory of the first buffer
+	sizeimage uint32
+	bufs      []*fakeBuffer
+	queued    []*fakeBuffer // owned by the driver
+	done      []fakeDone    // processed, ready to be dequeued
+	streaming bool
+	last      bool // the V4L2_BUF_FLAG_LAST buffer was dequeued
+}
+
+type fakeDone struct {
+	b     *fakeBuffer
+	flags uint32
+}
+
+func newFakeCodec(encoder bool) *fakeCodec {
+	return &fakeCodec{
+		encoder: encoder,
+		src:     fakeQueue{typ: V4L2_BUF_TYPE_VIDEO_OUTPUT, sizeimage: fakePageSize},
+		dst:     fakeQueue{typ: V4L2_BUF_TYPE_VIDEO_CAPTURE, offset: 1 << 24},
+		subs:    make(map[uint32]bool),
+		gop:     12,
+	}
+}
+
+// openFakeCodec opens an M2MDevice on a fakeCodec
+func openFakeCodec(t *testing.T, encoder bool) (*M2MDevice, *fakeCodec) {
+	t.Helper()
+	c := newFakeCodec(encoder)
+	dev, err := OpenBackend(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewM2MDevice(dev)
+	if err != nil {
+		dev.Close()
+		t.Fatal(err)
+	}
+	return m, c
+}
+
+// openDecoder opens a Decoder of H.264 on a fakeCodec, which is closed
+// when the test ends
+func openDecoder(t *testing.T) (*Decoder, *fakeCodec) {
+	t.Helper()
+	m, c := openFakeCodec(t, false)
+	d, err := NewDecoder(m, V4L2_PIX_FMT_H264, fakePageSize, 4)
+	if err != nil {
+		m.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { d.Close() })
+	return d, c
+}
+
+func (c *fakeCodec) queue(typ uint32) *fakeQueue {
+	switch typ {
+	case V4L2_BUF_TYPE_VIDEO_OUTPUT:
+		return &c.src
+	case V4L2_BUF_TYPE_VIDEO_CAPTURE:
+		return &c.dst
+	}
+	return nil
+}
+
+// Ioctl implements Backend
+func (c *fakeCodec) Ioctl(request uint, argp unsafe.Pointer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.process()
+
+	switch request {
+	case VIDIOC_QUERYCAP:
+		p := (*v4l2_capability)(argp)
+		*p = v4l2_capability{}
+		setCString(p.driver[:], "fake-codec")
+		p.device_caps = V4L2_CAP_VIDEO_M2M | V4L2_CAP_STREAMING
+		p.capabilities = p.device_caps | V4L2_CAP_DEVICE_CAPS
+		return nil
+	case VIDIOC_G_FMT, VIDIOC_S_FMT:
+		return c.fmt(request, (*v4l2_format)(argp))
+	case VIDIOC_G_CTRL:
+		p := (*v4l2_control)(argp)
+		if p.id != V4L2_CID_MIN_BUFFERS_FOR_CAPTURE {
+			return syscall.EINVAL
+		}
+		p.value = 1
+		return nil
+	case VIDIOC_SUBSCRIBE_EVENT:
+		c.subs[uint32((*v4l2_event_subscription)(argp)._type)] = true
+		return nil
+	case VIDIOC_DQEVENT:
+		if len(c.events) == 0 {
+			return syscall.ENOENT
+		}
+		p := (*v4l2_event)(argp)
+		*p = c.events[0]
+		c.events = c.events[1:]
+		p.pending = __u32(len(c.events))
+		return nil
+	case VIDIOC_REQBUFS:
+		return c.reqBufs((*v4l2_requestbuffers)(argp))
+	case VIDIOC_QUERYBUF, VIDIOC_QBUF, VIDIOC_DQBUF:
+		return c.buffer(request, (*v4l2_buffer)(argp))
+	case VIDIOC_STREAMON, VIDIOC_STREAMOFF:
+		return c.stream(request == VIDIOC_STREAMON, uint32(*(*int32)(argp)))
+	case VIDIOC_S_PARM:
+		p := (*v4l2_streamparm)(argp)
+		if !c.encoder || uint32(p._type) != V4L2_BUF_TYPE_VIDEO_OUTPUT {
+			return syscall.EINVAL
+		}
+		tpf := &(*v4l2_outputparm)(unsafe.Pointer(&p.parm)).timeperframe
+		if tpf.numerator != 0 {
+			c.frameRate = uint32(tpf.denominator / tpf.numerator)
+		}
+		return nil
+	case VIDIOC_S_EXT_CTRLS:
+		if !c.encoder {
+			break
+		}
+		return c.setCtrls((*v4l2_ext_controls)(argp))
+	case VIDIOC_DECODER_CMD:
+		if c.encoder {
+			break
+		}
+		return c.command(uint32((*v4l2_decoder_cmd)(argp).cmd))
+	case VIDIOC_ENCODER_CMD:
+		if !c.encoder || c.noCmd {
+			break
+		}
+		return c.command(uint32((*v4l2_encoder_cmd)(argp).cmd))
+	}
+	return syscall.ENOTTY
+}
+
+// Mmap implements Backend
+func (c *fakeCodec) Mmap(offset int64, length int) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, q := range []*fakeQueue{&c.src, &c.dst} {
+		for _, b := range q.bufs {
+			if int64(b.offset) == offset && length <= int(b.length) {
+				return b.data[:length:length], nil
+			}
+		}
+	}
+	return nil, syscall.EINVAL
+}
+
+// Munmap implements Backend
+func (c *fakeCodec) Munmap([]byte) error { return nil }
+
+// Poll implements Backend, reporting EPOLLERR the way v4l2_m2m_poll does
+// when there is nothing to wait for
+func (c *fakeCodec) Poll() uint32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.gone {
+		return syscall.EPOLLIN | syscall.EPOLLOUT | syscall.EPOLLPRI | syscall.EPOLLERR
+	}
+	var events uint32
+	if len(c.events) > 0 {
+		events |= syscall.EPOLLPRI
+	}
+	if c.src.idle() && c.dst.idle() {
+		return events | syscall.EPOLLERR
+	}
+	if len(c.src.done) > 0 {
+		events |= syscall.EPOLLOUT
+	}
+	if len(c.dst.done) > 0 || c.dst.last {
+		events |= syscall.EPOLLIN
+	}
+	return events
+}
+
+// Close implements Backend
+func (c *fakeCodec) Close() error { return nil }
+
+// idle reports whether the queue has no buffers to wait for
+func (q *fakeQueue) idle() bool {
+	return !q.streaming || len(q.queued) == 0 && len(q.done) == 0 && !q.last
+}
+
+func (c *fakeCodec) fmt(request uint, p *v4l2_format) error {
+	pix := (*v4l2_pix_format)(unsafe.Pointer(&p.fmt))
+	if c.encoder {
+		return c.encoderFmt(request, uint32(p._type), pix)
+	}
+	switch uint32(p._type) {
+	case V4L2_BUF_TYPE_VIDEO_OUTPUT:
+		if request == VIDIOC_S_FMT {
+			if len(c.src.bufs) > 0 {
+				return syscall.EBUSY
+			}
+			c.coded = uint32(pix.pixelformat)
+			if pix.sizeimage != 0 {
+				c.src.sizeimage = uint32(pix.sizeimage)
+			}
+		}
+		*pix = v4l2_pix_format{}
+		pix.pixelformat = __u32(c.coded)
+		pix.sizeimage = __u32(c.src.sizeimage)
+	case V4L2_BUF_TYPE_VIDEO_CAPTURE:
+		if request == VIDIOC_S_FMT {
+			return syscall.EINVAL
+		}
+		*pix = v4l2_pix_format{}
+		pix.width = __u32(c.width)
+		pix.height = __u32(c.height)
+		pix.pixelformat = V4L2_PIX_FMT_NV12
+		pix.bytesperline = __u32(c.width)
+		pix.sizeimage = __u32(c.dst.sizeimage)
+	default:
+		return syscall.EINVAL
+	}
+	return nil
+}
+
+// encoderFmt sets the raw format on the OUTPUT queue and the coded format
+// on the CAPTURE queue
+func (c *fakeCodec) encoderFmt(request uint, typ uint32, pix *v4l2_pix_format) error {
+	var raw uint32
+	switch typ {
+	case V4L2_BUF_TYPE_VIDEO_OUTPUT:
+		if request == VIDIOC_S_FMT {
+			if len(c.src.bufs) > 0 {
+				return syscall.EBUSY
+			}
+			if uint32(pix.pixelformat) != V4L2_PIX_FMT_NV12 {
+				return syscall.EINVAL
+			}
+			c.width, c.height = uint32(pix.width), uint32(pix.height)
+			c.src.sizeimage = c.width * c.height * 3 / 2
+		}
+		raw = V4L2_PIX_FMT_NV12
+	case V4L2_BUF_TYPE_VIDEO_CAPTURE:
+		if request == VIDIOC_S_FMT {
+			if len(c.dst.bufs) > 0 {
+				return syscall.EBUSY
+			}
+			c.coded = uint32(pix.pixelformat)
+			c.dst.sizeimage = max(uint32(pix.sizeimage), fakePageSize)
+		}
+	default:
+		return syscall.EINVAL
+	}
+	*pix = v4l2_pix_format{}
+	pix.width = __u32(c.width)
+	pix.height = __u32(c.height)
+	pix.field = V4L2_FIELD_NONE
+	if raw != 0 {
+		pix.pixelformat = __u32(raw)
+		pix.bytesperline = __u32(c.width)
+		pix.sizeimage = __u32(c.src.sizeimage)
+	} else {
+		pix.pixelformat = __u32(c.coded)
+		pix.sizeimage = __u32(c.dst.sizeimage)
+	}
+	return nil
+}
+
+// setCtrls sets the codec controls of the encoder, accepting any control
+// but the GOP size and forced key frames
+func (c *fakeCodec) setCtrls(p *v4l2_ext_controls) error {
+	if p.controls == nil {
+		return nil
+	}
+	for _, ctrl := range unsafe.Slice(p.controls, p.count) {
+		value := int32(*(*__s32)(unsafe.Pointer(&ctrl.anon0)))
+		switch uint32(ctrl.id) {
+		case V4L2_CID_MPEG_VIDEO_GOP_SIZE:
+			if value < 1 {
+				return syscall.ERANGE
+			}
+			c.gop = value
+		case V4L2_CID_MPEG_VIDEO_FORCE_KEY_FRAME:
+			c.forced = true
+		}
+	}
+	return nil
+}
+
+func (c *fakeCodec) reqBufs(p *v4l2_requestbuffers) error {
+	q := c.queue(uint32(p._type))
+	if q == nil || p.memory != V4L2_MEMORY_MMAP {
+		return syscall.EINVAL
+	}
+	if q.streaming {
+		return syscall.EBUSY
+	}
+	q.bufs, q.queued, q.done = nil, nil, nil
+	count := min(uint32(p.count), fakeMaxBuffers)
+	offset := q.offset
+	for i := uint32(0); i < count; i++ {
+		q.bufs = append(q.bufs, &fakeBuffer{
+			index:  i,
+			offset: offset,
+			length: q.sizeimage,
+			data:   make([]byte, q.sizeimage),
+		})
+		offset += roundUp(q.sizeimage, fakePageSize)
+	}
+	p.count = __u32(count)
+	p.capabilities = V4L2_BUF_CAP_SUPPORTS_MMAP
+	return nil
+}
+
+func (c *fakeCodec) buffer(request uint, p *v4l2_buffer) error {
+	q := c.queue(uint32(p._type))
+	if q == nil || p.memory != V4L2_MEMORY_MMAP {
+		return syscall.EINVAL
+	}
+
+	var b *fakeBuffer
+	var flags uint32
+	switch request {
+	case VIDIOC_DQBUF:
+		if len(q.done) == 0 {
+			if q.last {
+				return syscall.EPIPE
+			}
+			return syscall.EAGAIN
+		}
+		b, flags = q.done[0].b, q.done[0].flags|V4L2_BUF_FLAG_DONE
+		q.done = q.done[1:]
+		b.queued = false
+		q.last = flags&V4L2_BUF_FLAG_LAST != 0
+	default:
+		if uint32(p.index) >= uint32(len(q.bufs)) {
+			return syscall.EINVAL
+		}
+		b = q.bufs[p.index]
+		if request == VIDIOC_QBUF {
+			if b.queued {
+				return syscall.EINVAL
+			}
+			b.queued = true
+			b.bytesused = uint32(p.bytesused)
+			b.timestamp = timevalDuration(p.timestamp.tv_sec, p.timestamp.tv_usec)
+			q.queued = append(q.queued, b)
+		}
+	}
+	if b.queued {
+		flags |= V4L2_BUF_FLAG_QUEUED
+	}
+
+	p.index = __u32(b.index)
+	p.bytesused = __u32(b.bytesused)
+	p.flags = __u32(flags)
+	p.field = V4L2_FIELD_NONE
+	setTimeval(&p.timestamp.tv_sec, &p.timestamp.tv_usec, b.timestamp)
+	p.sequence = __u32(b.sequence)
+	clear(p.m[:])
+	*(*__u32)(unsafe.Pointer(&p.m)) = __u32(b.offset)
+	p.length = __u32(b.length)
+	return nil
+}
+
+func (c *fakeCodec) stream(on bool, typ uint32) error {
+	q := c.queue(typ)
+	if q == nil {
+		return syscall.EINVAL
+	}
+	if on {
+		if len(q.bufs) == 0 {
+			return syscall.EINVAL
+		}
+		q.streaming = true
+		if q == &c.dst {
+			// the CAPTURE queue was set up for the new resolution
+			c.resChange = false
+		}
+		return nil
+	}
+
+	// all buffers return to userspace
+	q.streaming, q.last = false, false
+	for _, b := range q.bufs {
+		b.queued = false
+	}
+	q.queued, q.done = nil, nil
+	return nil
+}
+
+func (c *fakeCodec) command(cmd uint32) error {
+	// V4L2_ENC_CMD_STOP and _START equal V4L2_DEC_CMD_STOP and _START
+	switch cmd {
+	case V4L2_DEC_CMD_STOP:
+		c.draining = true
+	case V4L2_DEC_CMD_START:
+		c.draining, c.last = false, false
+		c.dst.last = false
+	default:
+		return syscall.EINVAL
+	}
+	return nil
+}
+
+func (c *fakeCodec) queueEvent(typ uint32, fill func(u unsafe.Pointer)) {
+	if !c.subs[typ] {
+		return
+	}
+	var e v4l2_event
+	e._type = __u32(typ)
+	if fill != nil {
+		fill(unsafe.Pointer(&e.u))
+	}
+	c.events = append(c.events, e)
+}
+
+// process decodes or encodes the queued buffers as far as the queues allow
+func (c *fakeCodec) process() {
+	for !c.hold && !c.gone {
+		if c.last {
+			// the last buffer of a resolution or of the stream
+			if !c.dst.streaming || len(c.dst.queued) == 0 {
+				return
+			}
+			c.finish(&c.dst, 0, V4L2_BUF_FLAG_LAST)
+			c.last = false
+			if !c.resChange {
+				c.queueEvent(V4L2_EVENT_EOS, nil)
+			}
+		}
+		if c.resChange || !c.src.streaming {
+			return
+		}
+		if len(c.src.queued) == 0 {
+			if c.draining && (c.configured || c.encoder) {
+				c.draining, c.last = false, true
+				continue
+			}
+			return
+		}
+
+		in := c.src.queued[0]
+		if c.encoder && !c.encode(in) || !c.encoder && !c.decode(in) {
+			return
+		}
+		c.finish(&c.src, in.bytesused, 0)
+	}
+}
+
+// decode decodes one chunk, it returns false while no CAPTURE buffer is
+// available for the frame
+func (c *fakeCodec) decode(in *fakeBuffer) bool {
+	chunk := in.data[:in.bytesused]
+	var width, height uint32
+	if n, _ := fmt.Sscanf(string(chunk), "%dx%d", &width, &height); n == 2 {
+		if !c.configured || width != c.width || height != c.height {
+			c.resChange, c.last = c.configured, c.configured
+			c.configured = true
+			c.width, c.height = width, height
+			c.dst.sizeimage = width * height * 3 / 2
+			c.queueEvent(V4L2_EVENT_SOURCE_CHANGE, func(u unsafe.Pointer) {
+				(*v4l2_event_src_change)(u).changes = V4L2_EVENT_SRC_CH_RESOLUTION
+			})
+		}
+		return true
+	}
+	if !c.configured {
+		return true
+	}
+	if !c.dst.streaming || len(c.dst.queued) == 0 {
+		return false
+	}
+	out := c.dst.queued[0]
+	out.timestamp = in.timestamp
+	c.finish(&c.dst, uint32(copy(out.data, chunk)), 0)
+	return true
+}
+
+// encode encodes one raw frame, it returns false while no CAPTURE buffer
+// is available. An empty frame stops the encoder the way older drivers do,
+// with an empty buffer.
+func (c *fakeCodec) encode(in *fakeBuffer) bool {
+	if !c.dst.streaming || len(c.dst.queued) == 0 {
+		return false
+	}
+	out := c.dst.queued[0]
+	out.timestamp = in.timestamp
+	if in.bytesused == 0 {
+		c.finish(&c.dst, 0, 0)
+		return true
+	}
+
+	flags := uint32(V4L2_BUF_FLAG_PFRAME)
+	if c.forced || c.encoded%uint32(c.gop) == 0 {
+		flags = V4L2_BUF_FLAG_KEYFRAME
+		c.forced = false
+	}
+	c.encoded++
+	c.finish(&c.dst, uint32(copy(out.data, in.data[:in.bytesused])), flags)
+	return true
+}
+
+// finish moves the first queued buffer of q to its done buffers
+func (c *fakeCodec) finish(q *fakeQueue, bytesused, flags uint32) {
+	b := q.queued[0]
+	q.queued = q.queued[1:]
+	b.bytesused = bytesused
+	if q == &c.dst {
+		b.sequence = c.sequence
+		c.sequence++
+	}
+	q.done = append(q.done, fakeDone{b, flags})
+}
@@ -1,23 +1,265 @@
 package v4l2
 
-/*
-#include <linux/v4l2-controls.h>
-*/
-import "C"
-
 const (
 	/* User-class control IDs */
-	V4L2_CID_BRIGHTNESS           = C.V4L2_CID_BRIGHTNESS
-	V4L2_CID_HUE                  = C.V4L2_CID_HUE
-	V4L2_CID_EXPOSURE             = C.V4L2_CID_EXPOSURE
-	V4L2_CID_AUTOBRIGHTNESS       = C.V4L2_CID_AUTOBRIGHTNESS
-	V4L2_CID_POWER_LINE_FREQUENCY = C.V4L2_CID_POWER_LINE_FREQUENCY
+	V4L2_CID_BRIGHTNESS           = 0x980900
+	V4L2_CID_CONTRAST             = 0x980901
+	V4L2_CID_HUE                  = 0x980903
+	V4L2_CID_EXPOSURE             = 0x980911
+	V4L2_CID_HFLIP                = 0x980914
+	V4L2_CID_AUTOBRIGHTNESS       = 0x980920
+	V4L2_CID_POWER_LINE_FREQUENCY = 0x980918
+
+	// Minimum number of buffers the driver needs
+	V4L2_CID_MIN_BUFFERS_FOR_CAPTURE = 0x980927
+	V4L2_CID_MIN_BUFFERS_FOR_OUTPUT  = 0x980928
 )
 
 /* Control classes */
 const (
-	V4L2_CTRL_CLASS_USER   = C.V4L2_CTRL_CLASS_USER
-	V4L2_CTRL_CLASS_MPEG   = C.V4L2_CTRL_CLASS_MPEG
-	V4L2_CTRL_CLASS_CAMERA = C.V4L2_CTRL_CLASS_CAMERA
-	V4L2_CTRL_CLASS_JPEG   = C.V4L2_CTRL_CLASS_JPEG
+	V4L2_CTRL_CLASS_USER       = 0x00980000
+	V4L2_CTRL_CLASS_MPEG       = 0x990000
+	V4L2_CTRL_CLASS_CAMERA     = 0x009a0000
+	V4L2_CTRL_CLASS_JPEG       = 0x009d0000
+	V4L2_CTRL_CLASS_IMAGE_PROC = 0x009f0000
+)
+
+/* Image processing class control IDs */
+const (
+	V4L2_CID_TEST_PATTERN = 0x9f0903
+)
+
+/* MPEG-class control IDs */
+const (
+	V4L2_CID_MPEG_BASE                        = 0x990900
+	V4L2_CID_MPEG_VIDEO_B_FRAMES              = 0x9909ca
+	V4L2_CID_MPEG_VIDEO_GOP_SIZE              = 0x9909cb
+	V4L2_CID_MPEG_VIDEO_BITRATE_MODE          = 0x9909ce
+	V4L2_CID_MPEG_VIDEO_BITRATE               = 0x9909cf
+	V4L2_CID_MPEG_VIDEO_BITRATE_PEAK          = 0x9909d0
+	V4L2_CID_MPEG_VIDEO_FRAME_RC_ENABLE       = 0x9909d7
+	V4L2_CID_MPEG_VIDEO_HEADER_MODE           = 0x9909d8
+	V4L2_CID_MPEG_VIDEO_MB_RC_ENABLE          = 0x9909da
+	V4L2_CID_MPEG_VIDEO_FORCE_KEY_FRAME       = 0x9909e5
+	V4L2_CID_MPEG_VIDEO_PREPEND_SPSPPS_TO_IDR = 0x990b84
+	V4L2_CID_MPEG_VIDEO_H264_MIN_QP           = 0x990a61
+	V4L2_CID_MPEG_VIDEO_H264_MAX_QP           = 0x990a62
+	V4L2_CID_MPEG_VIDEO_H264_I_PERIOD         = 0x990a66
+	V4L2_CID_MPEG_VIDEO_H264_LEVEL            = 0x990a67
+	V4L2_CID_MPEG_VIDEO_H264_PROFILE          = 0x990a6b
+	V4L2_CID_MPEG_VIDEO_MPEG4_LEVEL           = 0x990a95
+	V4L2_CID_MPEG_VIDEO_MPEG4_PROFILE         = 0x990a96
+	V4L2_CID_MPEG_VIDEO_VP8_PROFILE           = 0x990aff
+)
+
+// V4L2_CID_MPEG_VIDEO_BITRATE_MODE values
+const (
+	V4L2_MPEG_VIDEO_BITRATE_MODE_VBR = 0
+	V4L2_MPEG_VIDEO_BITRATE_MODE_CBR = 1
+	V4L2_MPEG_VIDEO_BITRATE_MODE_CQ  = 2
+)
+
+// V4L2_CID_MPEG_VIDEO_HEADER_MODE values
+const (
+	V4L2_MPEG_VIDEO_HEADER_MODE_SEPARATE              = 0
+	V4L2_MPEG_VIDEO_HEADER_MODE_JOINED_WITH_1ST_FRAME = 1
+)
+
+// V4L2_CID_MPEG_VIDEO_H264_LEVEL values
+const (
+	V4L2_MPEG_VIDEO_H264_LEVEL_1_0 = 0
+	V4L2_MPEG_VIDEO_H264_LEVEL_1B  = 1
+	V4L2_MPEG_VIDEO_H264_LEVEL_1_1 = 2
+	V4L2_MPEG_VIDEO_H264_LEVEL_1_2 = 3
+	V4L2_MPEG_VIDEO_H264_LEVEL_1_3 = 4
+	V4L2_MPEG_VIDEO_H264_LEVEL_2_0 = 5
+	V4L2_MPEG_VIDEO_H264_LEVEL_2_1 = 6
+	V4L2_MPEG_VIDEO_H264_LEVEL_2_2 = 7
+	V4L2_MPEG_VIDEO_H264_LEVEL_3_0 = 8
+	V4L2_MPEG_VIDEO_H264_LEVEL_3_1 = 9
+	V4L2_MPEG_VIDEO_H264_LEVEL_3_2 = 10
+	V4L2_MPEG_VIDEO_H264_LEVEL_4_0 = 11
+	V4L2_MPEG_VIDEO_H264_LEVEL_4_1 = 12
+	V4L2_MPEG_VIDEO_H264_LEVEL_4_2 = 13
+	V4L2_MPEG_VIDEO_H264_LEVEL_5_0 = 14
+	V4L2_MPEG_VIDEO_H264_LEVEL_5_1 = 15
+	V4L2_MPEG_VIDEO_H264_LEVEL_5_2 = 16
+	V4L2_MPEG_VIDEO_H264_LEVEL_6_0 = 17
+	V4L2_MPEG_VIDEO_H264_LEVEL_6_1 = 18
+	V4L2_MPEG_VIDEO_H264_LEVEL_6_2 = 19
+)
+
+// V4L2_CID_MPEG_VIDEO_H264_PROFILE values
+const (
+	V4L2_MPEG_VIDEO_H264_PROFILE_BASELINE             = 0
+	V4L2_MPEG_VIDEO_H264_PROFILE_CONSTRAINED_BASELINE = 1
+	V4L2_MPEG_VIDEO_H264_PROFILE_MAIN                 = 2
+	V4L2_MPEG_VIDEO_H264_PROFILE_EXTENDED             = 3
+	V4L2_MPEG_VIDEO_H264_PROFILE_HIGH                 = 4
+	V4L2_MPEG_VIDEO_H264_PROFILE_HIGH_10              = 5
+	V4L2_MPEG_VIDEO_H264_PROFILE_HIGH_422             = 6
+	V4L2_MPEG_VIDEO_H264_PROFILE_HIGH_444_PREDICTIVE  = 7
+	V4L2_MPEG_VIDEO_H264_PROFILE_HIGH_10_INTRA        = 8
+	V4L2_MPEG_VIDEO_H264_PROFILE_HIGH_422_INTRA       = 9
+	V4L2_MPEG_VIDEO_H264_PROFILE_HIGH_444_INTRA       = 10
+	V4L2_MPEG_VIDEO_H264_PROFILE_CAVLC_444_INTRA      = 11
+	V4L2_MPEG_VIDEO_H264_PROFILE_SCALABLE_BASELINE    = 12
+	V4L2_MPEG_VIDEO_H264_PROFILE_SCALABLE_HIGH        = 13
+	V4L2_MPEG_VIDEO_H264_PROFILE_SCALABLE_HIGH_INTRA  = 14
+	V4L2_MPEG_VIDEO_H264_PROFILE_STEREO_HIGH          = 15
+	V4L2_MPEG_VIDEO_H264_PROFILE_MULTIVIEW_HIGH       = 16
+	V4L2_MPEG_VIDEO_H264_PROFILE_CONSTRAINED_HIGH     = 17
+)
+
+// V4L2_CID_MPEG_VIDEO_MPEG4_LEVEL values
+const (
+	V4L2_MPEG_VIDEO_MPEG4_LEVEL_0  = 0
+	V4L2_MPEG_VIDEO_MPEG4_LEVEL_0B = 1
+	V4L2_MPEG_VIDEO_MPEG4_LEVEL_1  = 2
+	V4L2_MPEG_VIDEO_MPEG4_LEVEL_2  = 3
+	V4L2_MPEG_VIDEO_MPEG4_LEVEL_3  = 4
+	V4L2_MPEG_VIDEO_MPEG4_LEVEL_3B = 5
+	V4L2_MPEG_VIDEO_MPEG4_LEVEL_4  = 6
+	V4L2_MPEG_VIDEO_MPEG4_LEVEL_5  = 7
+)
+
+// V4L2_CID_MPEG_VIDEO_MPEG4_PROFILE values
+const (
+	V4L2_MPEG_VIDEO_MPEG4_PROFILE_SIMPLE                     = 0
+	V4L2_MPEG_VIDEO_MPEG4_PROFILE_ADVANCED_SIMPLE            = 1
+	V4L2_MPEG_VIDEO_MPEG4_PROFILE_CORE                       = 2
+	V4L2_MPEG_VIDEO_MPEG4_PROFILE_SIMPLE_SCALABLE            = 3
+	V4L2_MPEG_VIDEO_MPEG4_PROFILE_ADVANCED_CODING_EFFICIENCY = 4
+)
+
+// V4L2_CID_MPEG_VIDEO_VP8_PROFILE values
+const (
+	V4L2_MPEG_VIDEO_VP8_PROFILE_0 = 0
+	V4L2_MPEG_VIDEO_VP8_PROFILE_1 = 1
+	V4L2_MPEG_VIDEO_VP8_PROFILE_2 = 2
+	V4L2_MPEG_VIDEO_VP8_PROFILE_3 = 3
+)
+
+/* Stateless codec control IDs */
+const (
+	V4L2_CTRL_CLASS_CODEC_STATELESS        = 0x00a40000
+	V4L2_CID_CODEC_STATELESS_BASE          = 0xa40900
+	V4L2_CID_STATELESS_H264_DECODE_MODE    = 0xa40900
+	V4L2_CID_STATELESS_H264_START_CODE     = 0xa40901
+	V4L2_CID_STATELESS_H264_SPS            = 0xa40902
+	V4L2_CID_STATELESS_H264_PPS            = 0xa40903
+	V4L2_CID_STATELESS_H264_SCALING_MATRIX = 0xa40904
+	V4L2_CID_STATELESS_H264_PRED_WEIGHTS   = 0xa40905
+	V4L2_CID_STATELESS_H264_SLICE_PARAMS   = 0xa40906
+	V4L2_CID_STATELESS_H264_DECODE_PARAMS  = 0xa40907
+	V4L2_CID_STATELESS_VP8_FRAME           = 0xa409c8
+)
+
+// V4L2_CID_STATELESS_H264_DECODE_MODE values
+const (
+	V4L2_STATELESS_H264_DECODE_MODE_SLICE_BASED = 0
+	V4L2_STATELESS_H264_DECODE_MODE_FRAME_BASED = 1
+)
+
+// V4L2_CID_STATELESS_H264_START_CODE values
+const (
+	V4L2_STATELESS_H264_START_CODE_NONE    = 0
+	V4L2_STATELESS_H264_START_CODE_ANNEX_B = 1
+)
+
+// V4L2_Ctrl_H264_SPS flags
+const (
+	V4L2_H264_SPS_CONSTRAINT_SET0_FLAG = 0x01
+	V4L2_H264_SPS_CONSTRAINT_SET1_FLAG = 0x02
+	V4L2_H264_SPS_CONSTRAINT_SET2_FLAG = 0x04
+	V4L2_H264_SPS_CONSTRAINT_SET3_FLAG = 0x08
+	V4L2_H264_SPS_CONSTRAINT_SET4_FLAG = 0x10
+	V4L2_H264_SPS_CONSTRAINT_SET5_FLAG = 0x20
+
+	V4L2_H264_SPS_FLAG_SEPARATE_COLOUR_PLANE           = 0x01
+	V4L2_H264_SPS_FLAG_QPPRIME_Y_ZERO_TRANSFORM_BYPASS = 0x02
+	V4L2_H264_SPS_FLAG_DELTA_PIC_ORDER_ALWAYS_ZERO     = 0x04
+	V4L2_H264_SPS_FLAG_GAPS_IN_FRAME_NUM_VALUE_ALLOWED = 0x08
+	V4L2_H264_SPS_FLAG_FRAME_MBS_ONLY                  = 0x10
+	V4L2_H264_SPS_FLAG_MB_ADAPTIVE_FRAME_FIELD         = 0x20
+	V4L2_H264_SPS_FLAG_DIRECT_8X8_INFERENCE            = 0x40
+)
+
+// V4L2_Ctrl_H264_PPS flags
+const (
+	V4L2_H264_PPS_FLAG_ENTROPY_CODING_MODE                     = 0x0001
+	V4L2_H264_PPS_FLAG_BOTTOM_FIELD_PIC_ORDER_IN_FRAME_PRESENT = 0x0002
+	V4L2_H264_PPS_FLAG_WEIGHTED_PRED                           = 0x0004
+	V4L2_H264_PPS_FLAG_DEBLOCKING_FILTER_CONTROL_PRESENT       = 0x0008
+	V4L2_H264_PPS_FLAG_CONSTRAINED_INTRA_PRED                  = 0x0010
+	V4L2_H264_PPS_FLAG_REDUNDANT_PIC_CNT_PRESENT               = 0x0020
+	V4L2_H264_PPS_FLAG_TRANSFORM_8X8_MODE                      = 0x0040
+	V4L2_H264_PPS_FLAG_SCALING_MATRIX_PRESENT                  = 0x0080
+)
+
+// V4L2_Ctrl_H264_Slice_Params slice types and flags
+const (
+	V4L2_H264_SLICE_TYPE_P  = 0
+	V4L2_H264_SLICE_TYPE_B  = 1
+	V4L2_H264_SLICE_TYPE_I  = 2
+	V4L2_H264_SLICE_TYPE_SP = 3
+	V4L2_H264_SLICE_TYPE_SI = 4
+
+	V4L2_H264_SLICE_FLAG_DIRECT_SPATIAL_MV_PRED = 0x01
+	V4L2_H264_SLICE_FLAG_SP_FOR_SWITCH          = 0x02
+)
+
+// V4L2_H264_Reference and V4L2_H264_DPB_Entry fields
+const (
+	V4L2_H264_TOP_FIELD_REF    = 0x1
+	V4L2_H264_BOTTOM_FIELD_REF = 0x2
+	V4L2_H264_FRAME_REF        = 0x3
+
+	V4L2_H264_NUM_DPB_ENTRIES = 16
+	V4L2_H264_REF_LIST_LEN    = 0x20
+)
+
+// V4L2_H264_DPB_Entry flags
+const (
+	V4L2_H264_DPB_ENTRY_FLAG_VALID     = 0x01
+	V4L2_H264_DPB_ENTRY_FLAG_ACTIVE    = 0x02
+	V4L2_H264_DPB_ENTRY_FLAG_LONG_TERM = 0x04
+	V4L2_H264_DPB_ENTRY_FLAG_FIELD     = 0x08
+)
+
+// V4L2_Ctrl_H264_Decode_Params flags
+const (
+	V4L2_H264_DECODE_PARAM_FLAG_IDR_PIC      = 0x01
+	V4L2_H264_DECODE_PARAM_FLAG_FIELD_PIC    = 0x02
+	V4L2_H264_DECODE_PARAM_FLAG_BOTTOM_FIELD = 0x04
+	V4L2_H264_DECODE_PARAM_FLAG_PFRAME       = 0x08
+	V4L2_H264_DECODE_PARAM_FLAG_BFRAME       = 0x10
+)
+
+// V4L2_VP8_Segment flags
+const (
+	V4L2_VP8_SEGMENT_FLAG_ENABLED             = 0x01
+	V4L2_VP8_SEGMENT_FLAG_UPDATE_MAP          = 0x02
+	V4L2_VP8_SEGMENT_FLAG_UPDATE_FEATURE_DATA = 0x04
+	V4L2_VP8_SEGMENT_FLAG_DELTA_VALUE_MODE    = 0x08
+)
+
+// V4L2_VP8_Loop_Filter flags
+const (
+	V4L2_VP8_LF_ADJ_ENABLE         = 0x01
+	V4L2_VP8_LF_DELTA_UPDATE       = 0x02
+	V4L2_VP8_LF_FILTER_TYPE_SIMPLE = 0x04
+)
+
+// V4L2_Ctrl_VP8_Frame flags
+const (
+	V4L2_VP8_FRAME_FLAG_KEY_FRAME        = 0x01
+	V4L2_VP8_FRAME_FLAG_EXPERIMENTAL     = 0x02
+	V4L2_VP8_FRAME_FLAG_SHOW_FRAME       = 0x04
+	V4L2_VP8_FRAME_FLAG_MB_NO_SKIP_COEFF = 0x08
+	V4L2_VP8_FRAME_FLAG_SIGN_BIAS_GOLDEN = 0x10
+	V4L2_VP8_FRAME_FLAG_SIGN_BIAS_ALT    = 0x20
+
+	V4L2_VP8_COEFF_PROB_CNT = 11
+	V4L2_VP8_MV_PROB_CNT    = 19
 )
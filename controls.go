@@ -0,0 +1,252 @@
+package v4l2
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// Control describes one control of a device, as reported by
+// VIDIOC_QUERY_EXT_CTRL, and reads or writes its value.
+type Control struct {
+	ID       uint32
+	Type     uint32
+	Name     string
+	Minimum  int64
+	Maximum  int64
+	Step     uint64
+	Default  int64
+	Flags    uint32
+	ElemSize uint32
+	Elems    uint32
+	Dims     []uint32
+	Menu     []MenuItem // items of menu and integer menu controls
+
+	fd int
+}
+
+// MenuItem is one valid item of a menu or integer menu control.
+type MenuItem struct {
+	Index uint32
+	Name  string // V4L2_CTRL_TYPE_MENU only
+	Value int64  // V4L2_CTRL_TYPE_INTEGER_MENU only
+}
+
+// QueryControls enumerates every control of the device, including
+// compound controls. Disabled controls and control class headers are
+// skipped.
+func (d *Device) QueryControls() ([]*Control, error) {
+	var ctrls []*Control
+
+	qc := V4L2_Query_Ext_Ctrl{
+		ID: V4L2_CTRL_FLAG_NEXT_CTRL | V4L2_CTRL_FLAG_NEXT_COMPOUND,
+	}
+	for {
+		err := IoctlQueryExtCtrl(d.FD, &qc)
+		if err == syscall.EINVAL {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if qc.Flags&V4L2_CTRL_FLAG_DISABLED == 0 &&
+			qc.Type != V4L2_CTRL_TYPE_CTRL_CLASS {
+			c, err := newControl(d.FD, &qc)
+			if err != nil {
+				return nil, err
+			}
+			ctrls = append(ctrls, c)
+		}
+		qc.ID |= V4L2_CTRL_FLAG_NEXT_CTRL | V4L2_CTRL_FLAG_NEXT_COMPOUND
+	}
+	return ctrls, nil
+}
+
+// QueryControl returns the control with the given ID.
+func (d *Device) QueryControl(id uint32) (*Control, error) {
+	qc := V4L2_Query_Ext_Ctrl{ID: id}
+	if err := IoctlQueryExtCtrl(d.FD, &qc); err != nil {
+		return nil, err
+	}
+	return newControl(d.FD, &qc)
+}
+
+func newControl(fd int, qc *V4L2_Query_Ext_Ctrl) (*Control, error) {
+	c := &Control{
+		ID:       qc.ID,
+		Type:     qc.Type,
+		Name:     qc.Name,
+		Minimum:  qc.Minimum,
+		Maximum:  qc.Maximum,
+		Step:     qc.Step,
+		Default:  qc.DefaultValue,
+		Flags:    qc.Flags,
+		ElemSize: qc.ElemSize,
+		Elems:    qc.Elems,
+		Dims:     append([]uint32(nil), qc.Dims[:qc.NrOfDims]...),
+		fd:       fd,
+	}
+
+	if c.Type != V4L2_CTRL_TYPE_MENU && c.Type != V4L2_CTRL_TYPE_INTEGER_MENU {
+		return c, nil
+	}
+	for i := c.Minimum; i <= c.Maximum; i++ {
+		qm := V4L2_Querymenu{ID: c.ID, Index: uint32(i)}
+		err := IoctlQueryMenu(fd, &qm)
+		if err == syscall.EINVAL {
+			// menus may have holes
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		item := MenuItem{Index: qm.Index}
+		if c.Type == V4L2_CTRL_TYPE_MENU {
+			item.Name = qm.Name
+		} else {
+			item.Value = qm.Value
+		}
+		c.Menu = append(c.Menu, item)
+	}
+	return c, nil
+}
+
+// HasPayload reports whether the value of the control is an array or a
+// compound type, which is accessed through GetPayload and SetPayload.
+func (c *Control) HasPayload() bool {
+	return c.Flags&V4L2_CTRL_FLAG_HAS_PAYLOAD != 0
+}
+
+// Get returns the current value of an integer, boolean, menu, bitmask or
+// 64-bit integer control.
+func (c *Control) Get() (int64, error) {
+	if c.HasPayload() || c.Type == V4L2_CTRL_TYPE_STRING {
+		return 0, fmt.Errorf("%w: control %q has no scalar value", ErrorUnexpectedType, c.Name)
+	}
+
+	if c.Type == V4L2_CTRL_TYPE_INTEGER64 {
+		ctrl := V4L2_Ext_Control{ID: c.ID, Union: int64(0)}
+		if err := c.ext(VIDIOC_G_EXT_CTRLS, ctrlID2Which(c.ID), &ctrl); err != nil {
+			return 0, err
+		}
+		return ctrl.Union.(int64), nil
+	}
+
+	ctrl := V4L2_Control{ID: c.ID}
+	if err := IoctlGetCtrl(c.fd, &ctrl); err != nil {
+		return 0, err
+	}
+	return int64(ctrl.Value), nil
+}
+
+// Set changes the value of an integer, boolean, menu, button, bitmask or
+// 64-bit integer control.
+func (c *Control) Set(value int64) error {
+	if c.HasPayload() || c.Type == V4L2_CTRL_TYPE_STRING {
+		return fmt.Errorf("%w: control %q has no scalar value", ErrorUnexpectedType, c.Name)
+	}
+
+	if c.Type == V4L2_CTRL_TYPE_INTEGER64 {
+		ctrl := V4L2_Ext_Control{ID: c.ID, Union: value}
+		return c.ext(VIDIOC_S_EXT_CTRLS, ctrlID2Which(c.ID), &ctrl)
+	}
+
+	ctrl := V4L2_Control{ID: c.ID, Value: int32(value)}
+	return IoctlSetCtrl(c.fd, &ctrl)
+}
+
+// GetString returns the current value of a string control.
+func (c *Control) GetString() (string, error) {
+	return c.getString(ctrlID2Which(c.ID))
+}
+
+// SetString changes the value of a string control.
+func (c *Control) SetString(value string) error {
+	if c.Type != V4L2_CTRL_TYPE_STRING {
+		return fmt.Errorf("%w: control %q is not a string", ErrorUnexpectedType, c.Name)
+	}
+	ctrl := V4L2_Ext_Control{ID: c.ID, Union: value}
+	return c.ext(VIDIOC_S_EXT_CTRLS, ctrlID2Which(c.ID), &ctrl)
+}
+
+// GetPayload returns the raw value of an array or compound control.
+func (c *Control) GetPayload() ([]byte, error) {
+	return c.getPayload(ctrlID2Which(c.ID))
+}
+
+// SetPayload changes the raw value of an array or compound control.
+func (c *Control) SetPayload(payload []byte) error {
+	if !c.HasPayload() {
+		return fmt.Errorf("%w: control %q has no payload", ErrorUnexpectedType, c.Name)
+	}
+	ctrl := V4L2_Ext_Control{ID: c.ID, Union: payload}
+	return c.ext(VIDIOC_S_EXT_CTRLS, ctrlID2Which(c.ID), &ctrl)
+}
+
+// Reset restores the default value of the control.
+func (c *Control) Reset() error {
+	switch {
+	case c.Flags&(V4L2_CTRL_FLAG_READ_ONLY|V4L2_CTRL_FLAG_WRITE_ONLY) != 0,
+		c.Type == V4L2_CTRL_TYPE_BUTTON:
+		// nothing to restore
+		return nil
+	case c.HasPayload():
+		payload, err := c.getPayload(V4L2_CTRL_WHICH_DEF_VAL)
+		if err != nil {
+			return err
+		}
+		return c.SetPayload(payload)
+	case c.Type == V4L2_CTRL_TYPE_STRING:
+		value, err := c.getString(V4L2_CTRL_WHICH_DEF_VAL)
+		if err != nil {
+			return err
+		}
+		return c.SetString(value)
+	}
+	return c.Set(c.Default)
+}
+
+func (c *Control) getString(which uint32) (string, error) {
+	if c.Type != V4L2_CTRL_TYPE_STRING {
+		return "", fmt.Errorf("%w: control %q is not a string", ErrorUnexpectedType, c.Name)
+	}
+	var value string
+	ctrl := V4L2_Ext_Control{
+		ID:    c.ID,
+		Size:  uint32(c.Maximum) + 1,
+		Union: &value,
+	}
+	if err := c.ext(VIDIOC_G_EXT_CTRLS, which, &ctrl); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+func (c *Control) getPayload(which uint32) ([]byte, error) {
+	if !c.HasPayload() {
+		return nil, fmt.Errorf("%w: control %q has no payload", ErrorUnexpectedType, c.Name)
+	}
+	payload := make([]byte, c.ElemSize*c.Elems)
+	ctrl := V4L2_Ext_Control{ID: c.ID, Union: payload}
+	if err := c.ext(VIDIOC_G_EXT_CTRLS, which, &ctrl); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+func (c *Control) ext(request uint, which uint32, ctrl *V4L2_Ext_Control) error {
+	ctrls := V4L2_Ext_Controls{
+		ClassWhich: which,
+		Count:      1,
+		Controls:   []V4L2_Ext_Control{*ctrl},
+	}
+	err := ioctlExtCtrls(c.fd, request, &ctrls)
+	*ctrl = ctrls.Controls[0]
+	return err
+}
+
+// same as V4L2_CTRL_ID2WHICH in videodev2.h
+func ctrlID2Which(id uint32) uint32 {
+	return id & 0x0fff0000
+}
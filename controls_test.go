@@ -0,0 +1,132 @@
+package v4l2
+
+import (
+	"fmt"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+func TestQueryExtCtrlNext(t *testing.T) {
+	d, _ := openFake(t)
+	want := []uint32{V4L2_CID_BRIGHTNESS, V4L2_CID_CONTRAST, V4L2_CID_HFLIP,
+		V4L2_CID_TEST_PATTERN}
+
+	qc := V4L2_Query_Ext_Ctrl{ID: V4L2_CTRL_FLAG_NEXT_CTRL}
+	for i := 0; ; i++ {
+		err := IoctlQueryExtCtrl(d.FD, &qc)
+		if err == syscall.EINVAL {
+			if i != len(want) {
+				t.Errorf("%d controls, want %d", i, len(want))
+			}
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i >= len(want) || qc.ID != want[i] {
+			t.Fatalf("control %d is %#x %q", i, qc.ID, qc.Name)
+		}
+		qc.ID |= V4L2_CTRL_FLAG_NEXT_CTRL
+	}
+}
+
+func TestQueryControls(t *testing.T) {
+	d, _ := openFake(t)
+	ctrls, err := d.QueryControls()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		id   uint32
+		name string
+		typ  uint32
+	}{
+		{V4L2_CID_BRIGHTNESS, "Brightness", V4L2_CTRL_TYPE_INTEGER},
+		{V4L2_CID_CONTRAST, "Contrast", V4L2_CTRL_TYPE_INTEGER},
+		{V4L2_CID_HFLIP, "Horizontal Flip", V4L2_CTRL_TYPE_BOOLEAN},
+		{V4L2_CID_TEST_PATTERN, "Test Pattern", V4L2_CTRL_TYPE_MENU},
+	}
+	if len(ctrls) != len(want) {
+		t.Fatalf("%d controls, want %d", len(ctrls), len(want))
+	}
+	for i, w := range want {
+		c := ctrls[i]
+		if c.ID != w.id || c.Name != w.name || c.Type != w.typ {
+			t.Errorf("control %d is %#x %q of type %d, want %#x %q of type %d",
+				i, c.ID, c.Name, c.Type, w.id, w.name, w.typ)
+		}
+	}
+
+	// the controls read and write their own values
+	contrast := ctrls[1]
+	if err := contrast.Set(20); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := ctrls[0].Get(); err != nil || v != 128 {
+		t.Errorf("brightness %d, %v, want 128", v, err)
+	}
+	if v, err := contrast.Get(); err != nil || v != 20 {
+		t.Errorf("contrast %d, %v, want 20", v, err)
+	}
+}
+
+// stringBackend serves VIDIOC_G_EXT_CTRLS of string controls holding
+// their names, and fails with ENOSPC for buffers too small
+type stringBackend struct{}
+
+func (stringBackend) Ioctl(request uint, argp unsafe.Pointer) error {
+	if request != VIDIOC_G_EXT_CTRLS {
+		return syscall.ENOTTY
+	}
+	p := (*v4l2_ext_controls)(argp)
+	ctrls := unsafe.Slice(p.controls, p.count)
+	var err error
+	for i := range ctrls {
+		value := fmt.Sprintf("control %#x", uint32(ctrls[i].id))
+		if int(ctrls[i].size) <= len(value) {
+			ctrls[i].size = __u32(len(value) + 1)
+			err = syscall.ENOSPC
+			continue
+		}
+		// the union is not aligned for a pointer, read the address byte-wise
+		addr := BytesToUintptr(ctrls[i].anon0[:])
+		buf := unsafe.Slice(*(**byte)(unsafe.Pointer(&addr)), ctrls[i].size)
+		buf[copy(buf, value)] = 0
+	}
+	return err
+}
+
+func (stringBackend) Mmap(int64, int) ([]byte, error) { return nil, syscall.ENODEV }
+func (stringBackend) Munmap([]byte) error             { return syscall.EINVAL }
+func (stringBackend) Poll() uint32                    { return 0 }
+func (stringBackend) Close() error                    { return nil }
+
+func TestGetExtCtrlsStringSize(t *testing.T) {
+	d, err := OpenBackend(stringBackend{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	var short string
+	ctrls := V4L2_Ext_Controls{
+		Count: 2,
+		Controls: []V4L2_Ext_Control{
+			{ID: 0x9a0001, Union: ""},
+			{ID: 0x9a0002, Size: 64, Union: &short},
+		},
+	}
+	if err := IoctlGetExtCtrls(d.FD, &ctrls); err != syscall.ENOSPC {
+		t.Fatalf("get with an empty buffer: %v, want ENOSPC", err)
+	}
+	if size := ctrls.Controls[0].Size; size != uint32(len("control 0x9a0001")+1) {
+		t.Errorf("size %d read back", size)
+	}
+	if err := IoctlGetExtCtrls(d.FD, &ctrls); err != nil {
+		t.Fatal(err)
+	}
+	if v := ctrls.Controls[0].Union; v != "control 0x9a0001" || short != "control 0x9a0002" {
+		t.Errorf("strings %q and %q", v, short)
+	}
+}
@@ -0,0 +1,282 @@
+package convert
+
+import (
+	"math"
+
+	"github.com/Charleye/v4l2-go"
+)
+
+// colorimetry of a frame with the defaults of the kernel filled in
+type colorimetry struct {
+	colorspace uint32
+	encoding   uint32
+	quant      uint32
+	xfer       uint32
+}
+
+// resolveColorimetry replaces the DEFAULT fields of f the way
+// V4L2_MAP_*_DEFAULT of videodev2.h do. A frame without a colorspace takes
+// the colorspace and transfer function of from, the source frame, if any.
+// Otherwise it is taken as sRGB if it is RGB, SMPTE 170M if it is SD video
+// and Rec. 709 if it is HD.
+func resolveColorimetry(f *v4l2.Frame, rgb bool, from *colorimetry) colorimetry {
+	c := colorimetry{f.ColorSpace, f.Encoding, f.Quantization, f.XferFunc}
+	if c.colorspace == v4l2.V4L2_COLORSPACE_DEFAULT && from != nil {
+		c.colorspace = from.colorspace
+		if c.xfer == v4l2.V4L2_XFER_FUNC_DEFAULT {
+			c.xfer = from.xfer
+		}
+	}
+	if c.colorspace == v4l2.V4L2_COLORSPACE_DEFAULT {
+		switch {
+		case rgb:
+			c.colorspace = v4l2.V4L2_COLORSPACE_SRGB
+		case f.Height < 720:
+			c.colorspace = v4l2.V4L2_COLORSPACE_SMPTE170M
+		default:
+			c.colorspace = v4l2.V4L2_COLORSPACE_REC709
+		}
+	}
+	if c.xfer == v4l2.V4L2_XFER_FUNC_DEFAULT {
+		switch c.colorspace {
+		case v4l2.V4L2_COLORSPACE_OPRGB:
+			c.xfer = v4l2.V4L2_XFER_FUNC_OPRGB
+		case v4l2.V4L2_COLORSPACE_SMPTE240M:
+			c.xfer = v4l2.V4L2_XFER_FUNC_SMPTE240M
+		case v4l2.V4L2_COLORSPACE_DCI_P3:
+			c.xfer = v4l2.V4L2_XFER_FUNC_DCI_P3
+		case v4l2.V4L2_COLORSPACE_RAW:
+			c.xfer = v4l2.V4L2_XFER_FUNC_NONE
+		case v4l2.V4L2_COLORSPACE_SRGB, v4l2.V4L2_COLORSPACE_JPEG:
+			c.xfer = v4l2.V4L2_XFER_FUNC_SRGB
+		default:
+			c.xfer = v4l2.V4L2_XFER_FUNC_709
+		}
+	}
+	if c.encoding == v4l2.V4L2_YCBCR_ENC_DEFAULT {
+		switch c.colorspace {
+		case v4l2.V4L2_COLORSPACE_REC709, v4l2.V4L2_COLORSPACE_DCI_P3:
+			c.encoding = v4l2.V4L2_YCBCR_ENC_709
+		case v4l2.V4L2_COLORSPACE_BT2020:
+			c.encoding = v4l2.V4L2_YCBCR_ENC_BT2020
+		case v4l2.V4L2_COLORSPACE_SMPTE240M:
+			c.encoding = v4l2.V4L2_YCBCR_ENC_SMPTE240M
+		default:
+			c.encoding = v4l2.V4L2_YCBCR_ENC_601
+		}
+	}
+	if c.quant == v4l2.V4L2_QUANTIZATION_DEFAULT {
+		switch {
+		case rgb, c.colorspace == v4l2.V4L2_COLORSPACE_JPEG:
+			c.quant = v4l2.V4L2_QUANTIZATION_FULL_RANGE
+		default:
+			c.quant = v4l2.V4L2_QUANTIZATION_LIM_RANGE
+		}
+	}
+	return c
+}
+
+// affine maps three 8-bit components to three others, the fourth column
+// is the offset
+type affine [3][4]float64
+
+var identity = affine{{1, 0, 0, 0}, {0, 1, 0, 0}, {0, 0, 1, 0}}
+
+// mul returns the map applying b, then a
+func (a affine) mul(b affine) affine {
+	var m affine
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 4; j++ {
+			for k := 0; k < 3; k++ {
+				m[i][j] += a[i][k] * b[k][j]
+			}
+		}
+		m[i][3] += a[i][3]
+	}
+	return m
+}
+
+func (a affine) inverse() affine {
+	var m affine
+	det := a[0][0]*(a[1][1]*a[2][2]-a[1][2]*a[2][1]) -
+		a[0][1]*(a[1][0]*a[2][2]-a[1][2]*a[2][0]) +
+		a[0][2]*(a[1][0]*a[2][1]-a[1][1]*a[2][0])
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			// cofactor of a[j][i]
+			r0, r1 := (j+1)%3, (j+2)%3
+			c0, c1 := (i+1)%3, (i+2)%3
+			m[i][j] = (a[r0][c0]*a[r1][c1] - a[r0][c1]*a[r1][c0]) / det
+		}
+	}
+	for i := 0; i < 3; i++ {
+		m[i][3] = -(m[i][0]*a[0][3] + m[i][1]*a[1][3] + m[i][2]*a[2][3])
+	}
+	return m
+}
+
+func (a affine) isIdentity() bool {
+	for i := range a {
+		for j := range a[i] {
+			if math.Abs(a[i][j]-identity[i][j]) > 1e-9 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// lumaCoeffs returns Kr and Kb of a Y'CbCr encoding
+func lumaCoeffs(encoding uint32) (kr, kb float64) {
+	switch encoding {
+	case v4l2.V4L2_YCBCR_ENC_709, v4l2.V4L2_YCBCR_ENC_XV709:
+		return 0.2126, 0.0722
+	case v4l2.V4L2_YCBCR_ENC_BT2020, v4l2.V4L2_YCBCR_ENC_BT2020_CONST_LUM:
+		// the constant luminance variant is approximated by the matrix
+		return 0.2627, 0.0593
+	case v4l2.V4L2_YCBCR_ENC_SMPTE240M:
+		return 0.212, 0.087
+	}
+	return 0.299, 0.114
+}
+
+// ycbcrToRGB returns the map of 8-bit Y'CbCr of c to full range R'G'B'
+func ycbcrToRGB(c colorimetry) affine {
+	kr, kb := lumaCoeffs(c.encoding)
+	kg := 1 - kr - kb
+	ys, cs, yo := 1.0, 1.0, 0.0
+	if c.quant == v4l2.V4L2_QUANTIZATION_LIM_RANGE {
+		ys, cs, yo = 255.0/219, 255.0/224, 16
+	}
+	m := affine{
+		{ys, 0, cs * 2 * (1 - kr), 0},
+		{ys, cs * -2 * kb * (1 - kb) / kg, cs * -2 * kr * (1 - kr) / kg, 0},
+		{ys, cs * 2 * (1 - kb), 0, 0},
+	}
+	for i := range m {
+		m[i][3] = -m[i][0]*yo - (m[i][1]+m[i][2])*128
+	}
+	return m
+}
+
+// rgbRange returns the map of 8-bit R'G'B' of c to full range R'G'B'
+func rgbRange(c colorimetry) affine {
+	if c.quant != v4l2.V4L2_QUANTIZATION_LIM_RANGE {
+		return identity
+	}
+	s := 255.0 / 219
+	return affine{{s, 0, 0, -16 * s}, {0, s, 0, -16 * s}, {0, 0, s, -16 * s}}
+}
+
+// primaries returns the CIE xy chromaticities of the red, green and blue
+// primaries of a colorspace
+func primaries(colorspace uint32) [3][2]float64 {
+	switch colorspace {
+	case v4l2.V4L2_COLORSPACE_SMPTE170M, v4l2.V4L2_COLORSPACE_SMPTE240M,
+		v4l2.V4L2_COLORSPACE_BT878:
+		return [3][2]float64{{0.630, 0.340}, {0.310, 0.595}, {0.155, 0.070}}
+	case v4l2.V4L2_COLORSPACE_470_SYSTEM_M:
+		return [3][2]float64{{0.67, 0.33}, {0.21, 0.71}, {0.14, 0.08}}
+	case v4l2.V4L2_COLORSPACE_OPRGB:
+		return [3][2]float64{{0.64, 0.33}, {0.21, 0.71}, {0.15, 0.06}}
+	case v4l2.V4L2_COLORSPACE_BT2020:
+		return [3][2]float64{{0.708, 0.292}, {0.170, 0.797}, {0.131, 0.046}}
+	case v4l2.V4L2_COLORSPACE_DCI_P3:
+		return [3][2]float64{{0.680, 0.320}, {0.265, 0.690}, {0.150, 0.060}}
+	}
+	// Rec. 709, sRGB, JPEG and 470 System B, G
+	return [3][2]float64{{0.64, 0.33}, {0.30, 0.60}, {0.15, 0.06}}
+}
+
+// rgbToXYZ returns the matrix of linear RGB of the primaries p to CIE XYZ,
+// white is D65 for all colorspaces
+func rgbToXYZ(p [3][2]float64) affine {
+	const wx, wy = 0.3127, 0.3290
+	var m affine
+	for i := 0; i < 3; i++ {
+		x, y := p[i][0], p[i][1]
+		m[0][i] = x / y
+		m[1][i] = 1
+		m[2][i] = (1 - x - y) / y
+	}
+	w := [3]float64{wx / wy, 1, (1 - wx - wy) / wy}
+	inv := m.inverse()
+	for i := 0; i < 3; i++ {
+		s := inv[i][0]*w[0] + inv[i][1]*w[1] + inv[i][2]*w[2]
+		for j := 0; j < 3; j++ {
+			m[j][i] *= s
+		}
+	}
+	return m
+}
+
+// gamut returns the matrix of linear RGB of colorspace src to dst
+func gamut(src, dst uint32) affine {
+	ps, pd := primaries(src), primaries(dst)
+	if ps == pd {
+		return identity
+	}
+	return rgbToXYZ(pd).inverse().mul(rgbToXYZ(ps))
+}
+
+// toLinear decodes a non-linear value v in [0, 1] with transfer function xfer
+func toLinear(xfer uint32, v float64) float64 {
+	switch xfer {
+	case v4l2.V4L2_XFER_FUNC_SRGB:
+		if v <= 0.04045 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	case v4l2.V4L2_XFER_FUNC_OPRGB:
+		return math.Pow(v, 2.19921875)
+	case v4l2.V4L2_XFER_FUNC_SMPTE240M:
+		if v < 0.0913 {
+			return v / 4
+		}
+		return math.Pow((v+0.1115)/1.1115, 1/0.45)
+	case v4l2.V4L2_XFER_FUNC_NONE:
+		return v
+	case v4l2.V4L2_XFER_FUNC_DCI_P3:
+		return math.Pow(v, 2.6)
+	case v4l2.V4L2_XFER_FUNC_SMPTE2084:
+		const m1, m2 = 0.1593017578125, 78.84375
+		const c1, c2, c3 = 0.8359375, 18.8515625, 18.6875
+		p := math.Pow(v, 1/m2)
+		return math.Pow(math.Max(p-c1, 0)/(c2-c3*p), 1/m1)
+	}
+	if v < 0.081 {
+		return v / 4.5
+	}
+	return math.Pow((v+0.099)/1.099, 1/0.45)
+}
+
+// fromLinear encodes a linear value l in [0, 1] with transfer function xfer
+func fromLinear(xfer uint32, l float64) float64 {
+	switch xfer {
+	case v4l2.V4L2_XFER_FUNC_SRGB:
+		if l <= 0.0031308 {
+			return l * 12.92
+		}
+		return 1.055*math.Pow(l, 1/2.4) - 0.055
+	case v4l2.V4L2_XFER_FUNC_OPRGB:
+		return math.Pow(l, 1/2.19921875)
+	case v4l2.V4L2_XFER_FUNC_SMPTE240M:
+		if l < 0.0228 {
+			return l * 4
+		}
+		return 1.1115*math.Pow(l, 0.45) - 0.1115
+	case v4l2.V4L2_XFER_FUNC_NONE:
+		return l
+	case v4l2.V4L2_XFER_FUNC_DCI_P3:
+		return math.Pow(l, 1/2.6)
+	case v4l2.V4L2_XFER_FUNC_SMPTE2084:
+		const m1, m2 = 0.1593017578125, 78.84375
+		const c1, c2, c3 = 0.8359375, 18.8515625, 18.6875
+		p := math.Pow(l, m1)
+		return math.Pow((c1+c2*p)/(1+c3*p), m2)
+	}
+	if l < 0.018 {
+		return l * 4.5
+	}
+	return 1.099*math.Pow(l, 0.45) - 0.099
+}
@@ -0,0 +1,326 @@
+package convert
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"math/rand"
+	"runtime"
+	"testing"
+
+	"github.com/Charleye/v4l2-go"
+)
+
+// newFrame returns a zeroed frame of fourcc with the colorimetry left to
+// the defaults
+func newFrame(t testing.TB, fourcc uint32, width, height int) *v4l2.Frame {
+	pf, err := v4l2.LookupPixelFormat(fourcc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bpl, size, err := pf.SizeImage(uint32(width), uint32(height), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	format := v4l2.V4L2_Format{
+		Type: v4l2.V4L2_BUF_TYPE_VIDEO_CAPTURE,
+		Fmt: &v4l2.V4L2_Pix_Format{
+			Width:        uint32(width),
+			Height:       uint32(height),
+			PixelFormat:  fourcc,
+			BytesPerLine: bpl,
+			SizeImage:    size,
+		},
+	}
+	fr, err := v4l2.NewFrame(&format, make([]byte, size))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fr
+}
+
+// randomFrame returns a frame of fourcc filled with random bytes
+func randomFrame(t testing.TB, fourcc uint32, width, height int, seed int64) *v4l2.Frame {
+	fr := newFrame(t, fourcc, width, height)
+	r := rand.New(rand.NewSource(seed))
+	for _, p := range fr.Planes {
+		r.Read(p)
+	}
+	return fr
+}
+
+// blockFrame returns an RGB24 frame of random colors constant over blocks
+// of 2x2 pixels, so that no chroma is lost by subsampling it
+func blockFrame(t testing.TB, width, height int) *v4l2.Frame {
+	fr := newFrame(t, v4l2.V4L2_PIX_FMT_RGB24, width, height)
+	r := rand.New(rand.NewSource(1))
+	var colors [][3]uint8
+	for i := 0; i < (width+1)/2*((height+1)/2); i++ {
+		colors = append(colors, [3]uint8{uint8(r.Intn(256)), uint8(r.Intn(256)), uint8(r.Intn(256))})
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := colors[y/2*((width+1)/2)+x/2]
+			copy(fr.Planes[0][y*fr.Strides[0]+x*3:], c[:])
+		}
+	}
+	return fr
+}
+
+// maxDiff returns the largest difference of the bytes of the pixels of two
+// frames of the same format and size
+func maxDiff(a, b *v4l2.Frame) int {
+	var d int
+	for i, p := range a.Format.Planes {
+		w, h := a.Width, a.Height
+		if i > 0 {
+			w = (w + a.Format.HSub - 1) / a.Format.HSub
+			h = (h + a.Format.VSub - 1) / a.Format.VSub
+		}
+		n := (w + p.Pixels - 1) / p.Pixels * p.Bytes
+		for y := 0; y < h; y++ {
+			la := a.Planes[i][y*a.Strides[i]:][:n]
+			lb := b.Planes[i][y*b.Strides[i]:][:n]
+			for x := range la {
+				d = max(d, int(la[x])-int(lb[x]), int(lb[x])-int(la[x]))
+			}
+		}
+	}
+	return d
+}
+
+func TestConvertRoundTrip(t *testing.T) {
+	for _, size := range [][2]int{{32, 16}, {33, 17}, {7, 3}, {1, 1}} {
+		w, h := size[0], size[1]
+		ref := newFrame(t, v4l2.V4L2_PIX_FMT_YUV420, w, h)
+		if err := Convert(ref, blockFrame(t, w, h), image.Rectangle{}); err != nil {
+			t.Fatal(err)
+		}
+		for _, path := range [][]uint32{
+			{v4l2.V4L2_PIX_FMT_YUYV},
+			{v4l2.V4L2_PIX_FMT_NV12},
+			{v4l2.V4L2_PIX_FMT_RGB24},
+			{v4l2.V4L2_PIX_FMT_YUYV, v4l2.V4L2_PIX_FMT_NV12},
+			{v4l2.V4L2_PIX_FMT_NV12, v4l2.V4L2_PIX_FMT_YUYV},
+			{v4l2.V4L2_PIX_FMT_YUYV, v4l2.V4L2_PIX_FMT_RGB24},
+			{v4l2.V4L2_PIX_FMT_NV12, v4l2.V4L2_PIX_FMT_RGB24, v4l2.V4L2_PIX_FMT_YUYV},
+			{v4l2.V4L2_PIX_FMT_UYVY, v4l2.V4L2_PIX_FMT_NV21, v4l2.V4L2_PIX_FMT_YVU420},
+		} {
+			name := fmt.Sprintf("%dx%d YU12", w, h)
+			src := ref
+			for _, fourcc := range append(path, v4l2.V4L2_PIX_FMT_YUV420) {
+				name += " -> " + v4l2.GetNameByFourCC(fourcc)
+				dst := newFrame(t, fourcc, w, h)
+				if err := Convert(dst, src, image.Rectangle{}); err != nil {
+					t.Fatalf("%s: %v", name, err)
+				}
+				src = dst
+			}
+			if d := maxDiff(src, ref); d > 1 {
+				t.Errorf("%s: off by %d", name, d)
+			}
+		}
+	}
+}
+
+func TestConvertReferencePixels(t *testing.T) {
+	const (
+		bt601 = v4l2.V4L2_YCBCR_ENC_601
+		bt709 = v4l2.V4L2_YCBCR_ENC_709
+		lim   = v4l2.V4L2_QUANTIZATION_LIM_RANGE
+		full  = v4l2.V4L2_QUANTIZATION_FULL_RANGE
+	)
+	for _, tc := range []struct {
+		name     string
+		encoding uint32
+		quant    uint32
+		rgb      [3]uint8
+		ycbcr    [3]uint8
+	}{
+		{"black", bt601, lim, [3]uint8{0, 0, 0}, [3]uint8{16, 128, 128}},
+		{"white", bt601, lim, [3]uint8{255, 255, 255}, [3]uint8{235, 128, 128}},
+		{"black", bt601, full, [3]uint8{0, 0, 0}, [3]uint8{0, 128, 128}},
+		{"white", bt601, full, [3]uint8{255, 255, 255}, [3]uint8{255, 128, 128}},
+		{"red", bt601, lim, [3]uint8{255, 0, 0}, [3]uint8{81, 90, 240}},
+		{"green", bt601, lim, [3]uint8{0, 255, 0}, [3]uint8{145, 54, 34}},
+		{"blue", bt601, lim, [3]uint8{0, 0, 255}, [3]uint8{41, 240, 110}},
+		{"red", bt709, lim, [3]uint8{255, 0, 0}, [3]uint8{63, 102, 240}},
+		{"green", bt709, lim, [3]uint8{0, 255, 0}, [3]uint8{173, 42, 26}},
+		{"blue", bt709, lim, [3]uint8{0, 0, 255}, [3]uint8{32, 240, 118}},
+		{"red", bt601, full, [3]uint8{255, 0, 0}, [3]uint8{76, 85, 255}},
+		{"red", bt709, full, [3]uint8{255, 0, 0}, [3]uint8{54, 99, 255}},
+	} {
+		name := fmt.Sprintf("%s encoding %d quantization %d", tc.name, tc.encoding, tc.quant)
+		rgb := newFrame(t, v4l2.V4L2_PIX_FMT_RGB24, 2, 2)
+		yuv := newFrame(t, v4l2.V4L2_PIX_FMT_YUV420, 2, 2)
+		yuv.Encoding, yuv.Quantization = tc.encoding, tc.quant
+		for i := 0; i < 4; i++ {
+			copy(rgb.Planes[0][i/2*rgb.Strides[0]+i%2*3:], tc.rgb[:])
+		}
+		if err := Convert(yuv, rgb, image.Rectangle{}); err != nil {
+			t.Fatal(err)
+		}
+		got := [3]uint8{yuv.Planes[0][0], yuv.Planes[1][0], yuv.Planes[2][0]}
+		if !near(got[:], tc.ycbcr[:]) {
+			t.Errorf("%s: Y'CbCr %v, want %v", name, got, tc.ycbcr)
+		}
+
+		clear(rgb.Planes[0])
+		for i := 0; i < 4; i++ {
+			yuv.Planes[0][i/2*yuv.Strides[0]+i%2] = tc.ycbcr[0]
+		}
+		yuv.Planes[1][0], yuv.Planes[2][0] = tc.ycbcr[1], tc.ycbcr[2]
+		if err := Convert(rgb, yuv, image.Rectangle{}); err != nil {
+			t.Fatal(err)
+		}
+		if got := rgb.Planes[0][rgb.Strides[0]+3:][:3]; !near(got, tc.rgb[:]) {
+			t.Errorf("%s: R'G'B' %v, want %v", name, got, tc.rgb)
+		}
+	}
+}
+
+func near(a, b []uint8) bool {
+	for i := range a {
+		if int(a[i])-int(b[i]) > 1 || int(b[i])-int(a[i]) > 1 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestConvertQuantization(t *testing.T) {
+	for _, tc := range []struct {
+		lim, full uint8
+	}{
+		{16, 0}, {235, 255}, {126, 128}, {0, 0}, {255, 255},
+	} {
+		src := newFrame(t, v4l2.V4L2_PIX_FMT_GREY, 3, 1)
+		dst := newFrame(t, v4l2.V4L2_PIX_FMT_GREY, 3, 1)
+		src.Quantization = v4l2.V4L2_QUANTIZATION_LIM_RANGE
+		dst.Quantization = v4l2.V4L2_QUANTIZATION_FULL_RANGE
+		src.Planes[0][0], src.Planes[0][1], src.Planes[0][2] = tc.lim, tc.lim, tc.lim
+		if err := Convert(dst, src, image.Rectangle{}); err != nil {
+			t.Fatal(err)
+		}
+		if got := dst.Planes[0][:3]; !near(got, []uint8{tc.full, tc.full, tc.full}) {
+			t.Errorf("limited %d to full range: %v, want %d", tc.lim, got, tc.full)
+		}
+	}
+}
+
+func TestConvertCrop(t *testing.T) {
+	const w, h = 33, 17
+	for _, fourcc := range []uint32{v4l2.V4L2_PIX_FMT_YUYV, v4l2.V4L2_PIX_FMT_NV12,
+		v4l2.V4L2_PIX_FMT_YUV420, v4l2.V4L2_PIX_FMT_RGB24} {
+		src := randomFrame(t, fourcc, w, h, int64(fourcc))
+		whole := newFrame(t, v4l2.V4L2_PIX_FMT_RGB24, w, h)
+		if err := Convert(whole, src, image.Rectangle{}); err != nil {
+			t.Fatal(err)
+		}
+		for _, crop := range []image.Rectangle{
+			image.Rect(0, 0, w, h),
+			image.Rect(3, 5, 13, 12),
+			image.Rect(4, 2, w, h),
+			image.Rect(1, 1, 2, 2),
+			image.Rect(w-1, h-1, w, h),
+		} {
+			name := fmt.Sprintf("%s crop %v", v4l2.GetNameByFourCC(fourcc), crop)
+			dst := newFrame(t, v4l2.V4L2_PIX_FMT_RGB24, crop.Dx(), crop.Dy())
+			if err := Convert(dst, src, crop); err != nil {
+				t.Errorf("%s: %v", name, err)
+				continue
+			}
+			for y := 0; y < crop.Dy(); y++ {
+				got := dst.Planes[0][y*dst.Strides[0]:][:crop.Dx()*3]
+				want := whole.Planes[0][(crop.Min.Y+y)*whole.Strides[0]+crop.Min.X*3:][:crop.Dx()*3]
+				if !bytes.Equal(got, want) {
+					t.Errorf("%s: line %d differs from the whole frame", name, y)
+					break
+				}
+			}
+		}
+	}
+
+	src := newFrame(t, v4l2.V4L2_PIX_FMT_YUYV, w, h)
+	dst := newFrame(t, v4l2.V4L2_PIX_FMT_RGB24, w, h)
+	if err := Convert(dst, src, image.Rect(1, 0, w+1, h)); !errors.Is(err, ErrorCrop) {
+		t.Errorf("crop outside of frame: %v, want ErrorCrop", err)
+	}
+	if err := Convert(&v4l2.Frame{Format: dst.Format}, src, image.Rectangle{}); !errors.Is(err, ErrorSize) {
+		t.Errorf("empty frame: %v, want ErrorSize", err)
+	}
+}
+
+// TestConvertBands checks that a frame converted in bands of lines on many
+// CPUs is the one converted on one
+func TestConvertBands(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(0))
+	for _, tc := range []struct {
+		name     string
+		src, dst uint32
+		sw, sh   int
+		dw, dh   int
+		crop     image.Rectangle
+	}{
+		{"YUYV to NV12", v4l2.V4L2_PIX_FMT_YUYV, v4l2.V4L2_PIX_FMT_NV12, 33, 37, 33, 37, image.Rectangle{}},
+		{"NV12 to RGB24 scaled", v4l2.V4L2_PIX_FMT_NV12, v4l2.V4L2_PIX_FMT_RGB24, 64, 48, 20, 15, image.Rectangle{}},
+		{"YUYV to I420 cropped and scaled", v4l2.V4L2_PIX_FMT_YUYV, v4l2.V4L2_PIX_FMT_YUV420, 33, 37, 41, 23, image.Rect(1, 2, 30, 35)},
+	} {
+		src := randomFrame(t, tc.src, tc.sw, tc.sh, 2)
+		var want *v4l2.Frame
+		for _, procs := range []int{1, 2, 3, 8} {
+			runtime.GOMAXPROCS(procs)
+			var c Converter
+			dst := newFrame(t, tc.dst, tc.dw, tc.dh)
+			if err := c.Convert(dst, src, tc.crop); err != nil {
+				t.Fatalf("%s: %v", tc.name, err)
+			}
+			if procs > 1 && len(c.plan.workers) < 2 {
+				t.Errorf("%s: %d bands on %d CPUs", tc.name, len(c.plan.workers), procs)
+			}
+			if want == nil {
+				want = dst
+				continue
+			}
+			if d := maxDiff(dst, want); d != 0 {
+				t.Errorf("%s: %d CPUs off by %d", tc.name, procs, d)
+			}
+
+			// the plan of the last conversion is reused
+			p := c.plan
+			clear(dst.Planes[0])
+			if err := c.Convert(dst, src, tc.crop); err != nil || c.plan != p {
+				t.Errorf("%s: reconverting: %v, plan reused %v", tc.name, err, c.plan == p)
+			}
+			if d := maxDiff(dst, want); d != 0 {
+				t.Errorf("%s: %d CPUs reconverted off by %d", tc.name, procs, d)
+			}
+		}
+	}
+}
+
+func BenchmarkConvert1080p(b *testing.B) {
+	for _, bc := range []struct {
+		src, dst uint32
+	}{
+		{v4l2.V4L2_PIX_FMT_YUYV, v4l2.V4L2_PIX_FMT_RGB24},
+		{v4l2.V4L2_PIX_FMT_NV12, v4l2.V4L2_PIX_FMT_RGB24},
+		{v4l2.V4L2_PIX_FMT_YUYV, v4l2.V4L2_PIX_FMT_NV12},
+		{v4l2.V4L2_PIX_FMT_YUV420, v4l2.V4L2_PIX_FMT_XRGB32},
+	} {
+		name := v4l2.GetNameByFourCC(bc.src) + "-" + v4l2.GetNameByFourCC(bc.dst)
+		b.Run(name, func(b *testing.B) {
+			src := randomFrame(b, bc.src, 1920, 1080, 3)
+			dst := newFrame(b, bc.dst, 1920, 1080)
+			var c Converter
+			b.SetBytes(1920 * 1080)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := c.Convert(dst, src, image.Rectangle{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
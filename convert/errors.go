@@ -0,0 +1,10 @@
+package convert
+
+import (
+	"errors"
+)
+
+var (
+	ErrorCrop = errors.New("Crop rectangle outside of frame")
+	ErrorSize = errors.New("Empty frame")
+)
@@ -0,0 +1,68 @@
+package convert
+
+import (
+	"fmt"
+
+	"github.com/Charleye/v4l2-go"
+)
+
+// kinds of layouts the converter reads and writes
+const (
+	kindPlanar     = iota // Y, Cb, Cr planes, e.g. I420
+	kindSemiPlanar        // Y plane and interleaved CbCr plane, e.g. NV12
+	kindPacked422         // YUYV and its permutations
+	kindRGB               // packed 24- and 32-bit RGB
+	kindGrey              // 8-bit luma only
+)
+
+type layout struct {
+	v4l2.RGBOrder // offsets of the components of kindRGB
+
+	kind       int
+	hsub, vsub int
+	crFirst    bool // Cr plane or sample before Cb
+	y0, y1     int  // offsets of the samples of a pair of kindPacked422 pixels
+	cb, cr     int
+}
+
+func (l *layout) rgb() bool {
+	return l.kind == kindRGB
+}
+
+func (l *layout) alpha() bool {
+	return l.kind == kindRGB && l.A >= 0
+}
+
+var packed422Layouts = map[uint32]layout{
+	v4l2.V4L2_PIX_FMT_YUYV: {kind: kindPacked422, y0: 0, y1: 2, cb: 1, cr: 3},
+	v4l2.V4L2_PIX_FMT_YVYU: {kind: kindPacked422, y0: 0, y1: 2, cb: 3, cr: 1},
+	v4l2.V4L2_PIX_FMT_UYVY: {kind: kindPacked422, y0: 1, y1: 3, cb: 0, cr: 2},
+	v4l2.V4L2_PIX_FMT_VYUY: {kind: kindPacked422, y0: 1, y1: 3, cb: 2, cr: 0},
+}
+
+func layoutOf(pf *v4l2.PixelFormat) (layout, error) {
+	if o, ok := pf.RGBOrder(); ok {
+		return layout{kind: kindRGB, RGBOrder: o}, nil
+	}
+	if l, ok := packed422Layouts[pf.FourCC]; ok {
+		l.hsub, l.vsub = 2, 1
+		return l, nil
+	}
+	l := layout{hsub: pf.HSub, vsub: pf.VSub, crFirst: pf.CrFirst()}
+	p := pf.Planes
+	switch {
+	case pf.FourCC == v4l2.V4L2_PIX_FMT_GREY:
+		l.kind = kindGrey
+		return l, nil
+	case pf.Flags&v4l2.PixFmtTiled != 0 || pf.FourCC == v4l2.V4L2_PIX_FMT_M420:
+	case len(p) == 3 && p[0] == v4l2.PlaneLayout{Bytes: 1, Pixels: 1} &&
+		p[1] == p[0] && p[2] == p[0]:
+		l.kind = kindPlanar
+		return l, nil
+	case len(p) == 2 && p[0] == v4l2.PlaneLayout{Bytes: 1, Pixels: 1} &&
+		p[1] == v4l2.PlaneLayout{Bytes: 2, Pixels: 1}:
+		l.kind = kindSemiPlanar
+		return l, nil
+	}
+	return l, fmt.Errorf("%w: %s", v4l2.ErrorUnsupportedFormat, pf.Name)
+}
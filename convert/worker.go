@@ -0,0 +1,307 @@
+package convert
+
+import (
+	"github.com/Charleye/v4l2-go"
+)
+
+// line is an unpacked source line of the crop rectangle
+type line struct {
+	y int
+	r row
+}
+
+// worker converts the destination lines [y0, y1) with buffers of its own
+type worker struct {
+	p      *plan
+	y0, y1 int
+	lines  [2]line
+	tmp    row
+	out    row
+	acc    [2][]int32 // sums of the chroma samples of subsampled dst lines
+	nacc   int        // lines summed in acc
+	opaque []uint8    // alpha of a line of pixels without alpha
+}
+
+func (w *worker) run(dst, src *v4l2.Frame) {
+	w.lines[0].y, w.lines[1].y = -1, -1
+	w.nacc = 0
+	for y := w.y0; y < w.y1; y++ {
+		w.scale(src, y)
+		w.color()
+		w.pack(dst, y)
+	}
+}
+
+// fetch returns the source line y of the crop rectangle, unpacking it if
+// it is not one of the two last ones
+func (w *worker) fetch(src *v4l2.Frame, y int) row {
+	if w.lines[0].y == y {
+		return w.lines[0].r
+	}
+	if w.lines[1].y != y {
+		// keep the line fetched last, it is the upper one of the next pair
+		w.lines[0], w.lines[1] = w.lines[1], w.lines[0]
+		w.unpack(src, w.p.crop.Min.Y+y, w.lines[1].r)
+		w.lines[1].y = y
+	}
+	return w.lines[1].r
+}
+
+// scale fills out with the destination line y
+func (w *worker) scale(src *v4l2.Frame, y int) {
+	p := w.p
+	cw := p.crop.Dx()
+	if cw == p.dw && p.crop.Dy() == p.dh {
+		w.unpack(src, p.crop.Min.Y+y, w.out)
+		return
+	}
+	r := w.fetch(src, int(p.ys[y]))
+	if fy := p.yw[y]; fy != 0 {
+		r1 := w.fetch(src, int(p.ys[y])+1)
+		r0 := w.fetch(src, int(p.ys[y]))
+		for c := 0; c < p.ncomp; c++ {
+			blend(w.tmp[c][:cw], r0[c][:cw], r1[c][:cw], fy)
+		}
+		r = w.tmp
+	}
+	if cw == p.dw {
+		for c := 0; c < p.ncomp; c++ {
+			copy(w.out[c], r[c][:cw])
+		}
+		return
+	}
+	for c := 0; c < p.ncomp; c++ {
+		resample(w.out[c][:p.dw], r[c][:cw], p.xs, p.xw)
+	}
+}
+
+// blend interpolates the lines a and b with weight f/256 of b
+func blend(dst, a, b []uint8, f int32) {
+	a, b = a[:len(dst)], b[:len(dst)]
+	for x := range dst {
+		dst[x] = uint8((int32(a[x])*(256-f) + int32(b[x])*f + 128) >> 8)
+	}
+}
+
+// resample interpolates the columns of dst from src
+func resample(dst, src []uint8, xs, xw []int32) {
+	xs, xw = xs[:len(dst)], xw[:len(dst)]
+	last := int32(len(src) - 1)
+	for x := range dst {
+		x0, f := xs[x], xw[x]
+		x1 := min(x0+1, last)
+		dst[x] = uint8((int32(src[x0])*(256-f) + int32(src[x1])*f + 128) >> 8)
+	}
+}
+
+// color converts out from the colorimetry of src to dst
+func (w *worker) color() {
+	p := w.p
+	if !p.linear {
+		if p.color != nil {
+			p.color.apply(w.out, p.dw)
+		}
+		return
+	}
+	p.toRGB.apply(w.out, p.dw)
+	c0, c1, c2 := w.out[0][:p.dw], w.out[1][:p.dw], w.out[2][:p.dw]
+	c1, c2 = c1[:len(c0)], c2[:len(c0)]
+	g := &p.gamut
+	for x := range c0 {
+		r, gr, b := int64(p.decode[c0[x]]), int64(p.decode[c1[x]]), int64(p.decode[c2[x]])
+		v0 := (g[0][0]*r + g[0][1]*gr + g[0][2]*b + 1<<13) >> 14
+		v1 := (g[1][0]*r + g[1][1]*gr + g[1][2]*b + 1<<13) >> 14
+		v2 := (g[2][0]*r + g[2][1]*gr + g[2][2]*b + 1<<13) >> 14
+		c0[x], c1[x], c2[x] = p.encode[clamp16(v0)], p.encode[clamp16(v1)], p.encode[clamp16(v2)]
+	}
+	p.fromRGB.apply(w.out, p.dw)
+}
+
+func clamp16(v int64) uint16 {
+	return uint16(min(max(v, 0), 65535))
+}
+
+// unpack converts the source line y of the crop rectangle to r
+func (w *worker) unpack(src *v4l2.Frame, y int, r row) {
+	p := w.p
+	l := &p.src
+	x0, n := p.crop.Min.X, p.crop.Dx()
+	r0, r1, r2 := r[0][:n], r[1][:n], r[2][:n]
+	r1, r2 = r1[:len(r0)], r2[:len(r0)]
+
+	switch l.kind {
+	case kindGrey:
+		copy(r0, src.Planes[0][y*src.Strides[0]+x0:])
+		for x := range r1 {
+			r1[x], r2[x] = 128, 128
+		}
+	case kindPlanar:
+		copy(r0, src.Planes[0][y*src.Strides[0]+x0:])
+		cy := y / l.vsub
+		cb := src.Planes[1][cy*src.Strides[1]:]
+		cr := src.Planes[2][cy*src.Strides[2]:]
+		if l.crFirst {
+			cb, cr = cr, cb
+		}
+		upsample(r1, r2, cb, cr, 1, x0, l.hsub)
+	case kindSemiPlanar:
+		copy(r0, src.Planes[0][y*src.Strides[0]+x0:])
+		c := src.Planes[1][y/l.vsub*src.Strides[1]:]
+		cb, cr := c, c[1:]
+		if l.crFirst {
+			cb, cr = cr, cb
+		}
+		upsample(r1, r2, cb, cr, 2, x0, l.hsub)
+	case kindPacked422:
+		s := src.Planes[0][y*src.Strides[0]:]
+		for x := range r0 {
+			i := (x0 + x) / 2 * 4
+			r0[x] = s[i+l.y0]
+			if (x0+x)&1 != 0 {
+				r0[x] = s[i+l.y1]
+			}
+			r1[x], r2[x] = s[i+l.cb], s[i+l.cr]
+		}
+	case kindRGB:
+		s := src.Planes[0][y*src.Strides[0]+x0*l.Bytes:]
+		for x := range r0 {
+			i := x * l.Bytes
+			r0[x], r1[x], r2[x] = s[i+l.R], s[i+l.G], s[i+l.B]
+		}
+		if p.ncomp == 4 {
+			a := r[3][:n]
+			for x := range a {
+				a[x] = s[x*l.Bytes+l.A]
+			}
+		}
+	}
+}
+
+// upsample repeats the chroma samples of cb and cr, step bytes apart, for
+// the pixels from x0 on, hsub pixels per sample
+func upsample(r1, r2, cb, cr []uint8, step, x0, hsub int) {
+	if hsub != 2 || x0&1 != 0 {
+		for x := range r1 {
+			i := (x0 + x) / hsub * step
+			r1[x], r2[x] = cb[i], cr[i]
+		}
+		return
+	}
+	// the common 4:2:x case, two pixels per sample
+	n := len(r1) &^ 1
+	cb, cr = cb[x0/2*step:], cr[x0/2*step:]
+	for x := 0; x < n; x += 2 {
+		i := x / 2 * step
+		u, v := cb[i], cr[i]
+		r1[x], r1[x+1] = u, u
+		r2[x], r2[x+1] = v, v
+	}
+	if n < len(r1) {
+		i := n / 2 * step
+		r1[n], r2[n] = cb[i], cr[i]
+	}
+}
+
+// pack writes out to the destination line y
+func (w *worker) pack(dst *v4l2.Frame, y int) {
+	p := w.p
+	l := &p.dst
+	n := p.dw
+	r0, r1, r2 := w.out[0][:n], w.out[1][:n], w.out[2][:n]
+	r1, r2 = r1[:len(r0)], r2[:len(r0)]
+
+	switch l.kind {
+	case kindGrey:
+		copy(dst.Planes[0][y*dst.Strides[0]:], r0)
+	case kindPacked422:
+		d := dst.Planes[0][y*dst.Strides[0]:]
+		for x := 0; x < n; x += 2 {
+			i := x / 2 * 4
+			x1 := min(x+1, n-1)
+			d[i+l.y0], d[i+l.y1] = r0[x], r0[x1]
+			d[i+l.cb] = uint8((int32(r1[x]) + int32(r1[x1]) + 1) >> 1)
+			d[i+l.cr] = uint8((int32(r2[x]) + int32(r2[x1]) + 1) >> 1)
+		}
+	case kindRGB:
+		d := dst.Planes[0][y*dst.Strides[0]:]
+		d = d[:n*l.Bytes]
+		if l.Bytes == 3 {
+			for x := range r0 {
+				px := d[x*3 : x*3+3 : x*3+3]
+				px[l.R], px[l.G], px[l.B] = r0[x], r1[x], r2[x]
+			}
+			break
+		}
+		// alpha, or the padding byte of formats without it
+		ai := 6 - l.R - l.G - l.B
+		a := w.out[3][:n]
+		if p.ncomp != 4 {
+			a = w.opaque[:n]
+		}
+		a = a[:len(r0)]
+		for x := range r0 {
+			px := d[x*4 : x*4+4 : x*4+4]
+			px[l.R], px[l.G], px[l.B], px[ai] = r0[x], r1[x], r2[x], a[x]
+		}
+	case kindPlanar, kindSemiPlanar:
+		copy(dst.Planes[0][y*dst.Strides[0]:], r0)
+		w.accumulate(r1, r2)
+		if (y+1)%l.vsub == 0 || y == dst.Height-1 {
+			w.flushChroma(dst, y/l.vsub)
+		}
+	}
+}
+
+// accumulate adds the chroma of a line to the sums of the subsampled
+// columns
+func (w *worker) accumulate(cb, cr []uint8) {
+	hsub := w.p.dst.hsub
+	a1, a2 := w.acc[0], w.acc[1]
+	if w.nacc == 0 {
+		clear(a1)
+		clear(a2)
+	}
+	for x := range cb {
+		a1[x/hsub] += int32(cb[x])
+		a2[x/hsub] += int32(cr[x])
+	}
+	w.nacc++
+}
+
+// flushChroma writes the averages of the summed chroma to the chroma line cy
+func (w *worker) flushChroma(dst *v4l2.Frame, cy int) {
+	p := w.p
+	l := &p.dst
+	cw := (p.dw + l.hsub - 1) / l.hsub
+	a1, a2 := w.acc[0][:cw], w.acc[1][:cw]
+	a2 = a2[:len(a1)]
+	full := int32(l.hsub * w.nacc)
+	avg := func(sum int32, cx int) uint8 {
+		n := full
+		if rest := p.dw - cx*l.hsub; rest < l.hsub {
+			n = int32(rest * w.nacc)
+		}
+		return uint8((sum + n/2) / n)
+	}
+
+	if l.kind == kindPlanar {
+		cb := dst.Planes[1][cy*dst.Strides[1]:]
+		cr := dst.Planes[2][cy*dst.Strides[2]:]
+		if l.crFirst {
+			cb, cr = cr, cb
+		}
+		for cx := range a1 {
+			cb[cx], cr[cx] = avg(a1[cx], cx), avg(a2[cx], cx)
+		}
+	} else {
+		d := dst.Planes[1][cy*dst.Strides[1]:]
+		cb, cr := 0, 1
+		if l.crFirst {
+			cb, cr = 1, 0
+		}
+		for cx := range a1 {
+			d[cx*2+cb], d[cx*2+cr] = avg(a1[cx], cx), avg(a2[cx], cx)
+		}
+	}
+	w.nacc = 0
+}
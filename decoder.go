@@ -0,0 +1,465 @@
+package v4l2
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+	"time"
+)
+
+// Decoder drives a stateful video decoder, e.g. for H.264, VP8 or MPEG-4,
+// as described by the stateful decoder interface of the kernel. Chunks of
+// the bitstream are queued on the source (OUTPUT) queue. The destination
+// (CAPTURE) queue is set up once the decoder has parsed the stream headers
+// and reported the decoded format with V4L2_EVENT_SOURCE_CHANGE, and set
+// up again whenever the resolution of the stream changes.
+type Decoder struct {
+	M2M         *M2MDevice
+	CodedFormat uint32
+
+	// Format of the decoded frames, valid after the first source change
+	Width       uint32
+	Height      uint32
+	PixelFormat uint32
+	Visible     V4L2_Rect // part of the decoded frames holding the picture
+
+	// CAPTURE buffers allocated on top of the minimum the decoder needs
+	ExtraBuffers uint32
+
+	poll      *poller
+	capturing bool // CAPTURE queue set up and streaming
+	resChange bool // resolution changed, waiting for the last buffer
+	draining  bool // V4L2_DEC_CMD_STOP issued
+	stopped   bool // last buffer dequeued after draining
+	eos       bool // V4L2_EVENT_EOS received
+	frames    []*DecodedFrame
+}
+
+// DecodedFrame is a copy of one decoded frame.
+type DecodedFrame struct {
+	Planes      [][]byte
+	Width       uint32
+	Height      uint32
+	PixelFormat uint32
+	Visible     V4L2_Rect
+	Sequence    uint32
+	TimeStamp   time.Duration // of the bitstream chunk the frame was decoded from
+}
+
+// OpenDecoder opens a stateful decoder for codedFormat, e.g.
+// V4L2_PIX_FMT_H264. It allocates count source buffers of bufSize bytes,
+// each of which must hold one chunk of the bitstream.
+func OpenDecoder(name string, codedFormat, bufSize, count uint32) (*Decoder, error) {
+	m, err := OpenM2M(name)
+	if err != nil {
+		return nil, err
+	}
+	d, err := NewDecoder(m, codedFormat, bufSize, count)
+	if err != nil {
+		m.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+// NewDecoder sets up the memory-to-memory device m as a stateful decoder,
+// see OpenDecoder.
+func NewDecoder(m *M2MDevice, codedFormat, bufSize, count uint32) (*Decoder, error) {
+	d := &Decoder{
+		M2M:          m,
+		CodedFormat:  codedFormat,
+		ExtraBuffers: 2,
+	}
+
+	format := V4L2_Format{Type: m.Src.Type}
+	if isMplane(m.Src.Type) {
+		pixmp := V4L2_Pix_Format_Mplane{PixelFormat: codedFormat, NumPlanes: 1}
+		pixmp.PlaneFmt[0].SizeImage = bufSize
+		format.Fmt = &pixmp
+	} else {
+		format.Fmt = &V4L2_Pix_Format{PixelFormat: codedFormat, SizeImage: bufSize}
+	}
+	if err := IoctlSetFmt(m.FD, &format); err != nil {
+		return nil, fmt.Errorf("Failed to set coded format: %w", err)
+	}
+
+	subs := []V4L2_Event_Subscription{
+		{Type: V4L2_EVENT_SOURCE_CHANGE},
+		{Type: V4L2_EVENT_EOS},
+	}
+	for i := range subs {
+		if err := IoctlSubscribeEvent(m.FD, &subs[i]); err != nil {
+			return nil, fmt.Errorf("Failed to subscribe event: %w", err)
+		}
+	}
+
+	if err := m.Src.Alloc(count); err != nil {
+		return nil, err
+	}
+	if err := m.Src.StreamOn(); err != nil {
+		m.Src.Release()
+		return nil, fmt.Errorf("Failed to stream on output queue: %w", err)
+	}
+
+	p, err := newPoller(m.FD, syscall.EPOLLIN|syscall.EPOLLOUT|syscall.EPOLLPRI)
+	if err != nil {
+		m.Src.StreamOff()
+		m.Src.Release()
+		return nil, err
+	}
+	d.poll = p
+	return d, nil
+}
+
+// Close stops decoding and closes the device.
+func (d *Decoder) Close() error {
+	err := d.M2M.Src.StreamOff()
+	if d.capturing {
+		if e := d.M2M.Dst.StreamOff(); e != nil && err == nil {
+			err = e
+		}
+	}
+	d.poll.close()
+	if e := d.M2M.Close(); e != nil && err == nil {
+		err = e
+	}
+	return err
+}
+
+// Decode queues one chunk of the bitstream, waiting for a free source
+// buffer if needed, and returns the frames decoded so far. ts is passed on
+// to the frames decoded from the chunk. Decoding continues after a Drain.
+func (d *Decoder) Decode(ctx context.Context, chunk []byte, ts time.Duration) ([]*DecodedFrame, error) {
+	if d.stopped || d.draining {
+		if err := d.restart(); err != nil {
+			return nil, err
+		}
+	}
+
+	b, err := d.freeSrc(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(chunk) > int(b.Planes[0].Length) {
+		return nil, fmt.Errorf("Chunk of %d bytes exceeds buffer of %d bytes",
+			len(chunk), b.Planes[0].Length)
+	}
+	b.Planes[0].BytesUsed = uint32(copy(b.Planes[0].Data, chunk))
+	b.Flags = 0
+	b.TimeStamp = ts
+	if err := d.M2M.Src.Queue(b); err != nil {
+		return nil, err
+	}
+
+	if err := d.step(ctx, 0); err != nil {
+		return nil, err
+	}
+	return d.takeFrames(), nil
+}
+
+// Drain signals the end of the stream with V4L2_DEC_CMD_STOP and returns
+// all remaining frames once the decoder has marked the last one.
+func (d *Decoder) Drain(ctx context.Context) ([]*DecodedFrame, error) {
+	if !d.draining {
+		cmd := V4L2_Decoder_Cmd{Cmd: V4L2_DEC_CMD_STOP}
+		if err := IoctlDecoderCmd(d.M2M.FD, &cmd); err != nil {
+			return nil, fmt.Errorf("Failed to stop decoder: %w", err)
+		}
+		d.draining = true
+	}
+
+	for !d.stopped {
+		if !d.capturing && !d.srcQueued() {
+			// the stream ended before its headers
+			d.stopped = true
+			break
+		}
+		if err := d.step(ctx, -1); err != nil {
+			return nil, err
+		}
+	}
+	return d.takeFrames(), nil
+}
+
+// Flush discards all queued chunks and all frames not yet returned. To
+// seek, call Flush and continue with the chunk at the new position, which
+// should start with a key frame.
+func (d *Decoder) Flush() error {
+	src := d.M2M.Src
+	if err := src.StreamOff(); err != nil {
+		return err
+	}
+	if err := src.StreamOn(); err != nil {
+		return err
+	}
+
+	d.frames = nil
+	d.draining = false
+	d.stopped = false
+	d.eos = false
+	if !d.capturing {
+		return nil
+	}
+
+	dst := d.M2M.Dst
+	if err := dst.StreamOff(); err != nil {
+		return err
+	}
+	if d.resChange {
+		// no last buffer will be returned once streamed off
+		d.resChange = false
+		return d.resetCapture()
+	}
+	if err := dst.QueueAll(); err != nil {
+		return err
+	}
+	return dst.StreamOn()
+}
+
+// restart resumes decoding after a drain.
+func (d *Decoder) restart() error {
+	cmd := V4L2_Decoder_Cmd{Cmd: V4L2_DEC_CMD_START}
+	err := IoctlDecoderCmd(d.M2M.FD, &cmd)
+	if err != nil && d.capturing {
+		// restarting the CAPTURE queue resumes the decoder as well
+		dst := d.M2M.Dst
+		if err = dst.StreamOff(); err == nil {
+			err = dst.StreamOn()
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to restart decoder: %w", err)
+	}
+
+	d.draining = false
+	d.stopped = false
+	d.eos = false
+	if d.capturing {
+		return d.M2M.Dst.QueueAll()
+	}
+	return nil
+}
+
+func (d *Decoder) srcQueued() bool {
+	for _, b := range d.M2M.Src.Bufs {
+		if b.Queued {
+			return true
+		}
+	}
+	return false
+}
+
+// freeSrc returns a source buffer not owned by the decoder, waiting for
+// the decoder to consume one if needed.
+func (d *Decoder) freeSrc(ctx context.Context) (*Buffer, error) {
+	for {
+		for _, b := range d.M2M.Src.Bufs {
+			if !b.Queued {
+				return b, nil
+			}
+		}
+		if err := d.step(ctx, -1); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (d *Decoder) takeFrames() []*DecodedFrame {
+	frames := d.frames
+	d.frames = nil
+	return frames
+}
+
+// step waits up to timeout milliseconds for the decoder, then handles
+// pending events and dequeues all ready buffers.
+func (d *Decoder) step(ctx context.Context, timeout int) error {
+	if timeout != 0 {
+		stop := context.AfterFunc(ctx, d.poll.wakeup)
+		defer stop()
+	}
+	events, err := d.poll.wait(timeout)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := d.dequeueEvents(); err != nil {
+		return err
+	}
+	if err := d.dequeueSrc(); err != nil {
+		return err
+	}
+	if err := d.dequeueDst(); err != nil {
+		return err
+	}
+	if timeout < 0 && events&syscall.EPOLLERR != 0 {
+		// nothing queued, waiting again would not block
+		return ErrorNoBuffers
+	}
+	return nil
+}
+
+func (d *Decoder) dequeueEvents() error {
+	for {
+		var ev V4L2_Event
+		err := IoctlDQEvent(d.M2M.FD, &ev)
+		if err == syscall.ENOENT {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch ev.Type {
+		case V4L2_EVENT_SOURCE_CHANGE:
+			sc, ok := ev.Union.(*V4L2_Event_Src_Change)
+			if !ok || sc.Changes&V4L2_EVENT_SRC_CH_RESOLUTION == 0 {
+				break
+			}
+			if !d.capturing {
+				if err := d.setupCapture(); err != nil {
+					return err
+				}
+			} else {
+				// the frames decoded before the change come first
+				d.resChange = true
+			}
+		case V4L2_EVENT_EOS:
+			d.eos = true
+		}
+		if ev.Pending == 0 {
+			return nil
+		}
+	}
+}
+
+func (d *Decoder) dequeueSrc() error {
+	for {
+		_, err := d.M2M.Src.Dequeue()
+		if err == syscall.EAGAIN {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (d *Decoder) dequeueDst() error {
+	dst := d.M2M.Dst
+	for d.capturing && !d.stopped {
+		b, err := dst.Dequeue()
+		if err == syscall.EAGAIN {
+			return nil
+		}
+		if err == syscall.EPIPE {
+			// the last buffer was dequeued already
+			return d.lastBuffer()
+		}
+		if err != nil {
+			return err
+		}
+
+		var bytesused uint32
+		for i := range b.Planes {
+			bytesused += b.Planes[i].BytesUsed
+		}
+		if b.Flags&V4L2_BUF_FLAG_ERROR == 0 && bytesused > 0 {
+			d.frames = append(d.frames, &DecodedFrame{
+				Planes:      copyPlanes(b),
+				Width:       d.Width,
+				Height:      d.Height,
+				PixelFormat: d.PixelFormat,
+				Visible:     d.Visible,
+				Sequence:    b.Sequence,
+				TimeStamp:   b.TimeStamp,
+			})
+		}
+
+		// older drivers mark the end of the stream with an empty buffer
+		// and V4L2_EVENT_EOS instead of V4L2_BUF_FLAG_LAST
+		if b.Flags&V4L2_BUF_FLAG_LAST != 0 ||
+			d.draining && d.eos && bytesused == 0 {
+			return d.lastBuffer()
+		}
+		if err := dst.Queue(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lastBuffer handles the last buffer of the CAPTURE queue, which either
+// completes a resolution change or a drain.
+func (d *Decoder) lastBuffer() error {
+	if !d.resChange {
+		d.stopped = true
+		return nil
+	}
+	d.resChange = false
+	if err := d.M2M.Dst.StreamOff(); err != nil {
+		return err
+	}
+	return d.resetCapture()
+}
+
+// resetCapture frees the CAPTURE buffers of the previous resolution and
+// sets up the queue again.
+func (d *Decoder) resetCapture() error {
+	d.capturing = false
+	if err := d.M2M.Dst.Release(); err != nil {
+		return err
+	}
+	return d.setupCapture()
+}
+
+// setupCapture reads the decoded format and sets up the CAPTURE queue.
+func (d *Decoder) setupCapture() error {
+	dst := d.M2M.Dst
+
+	format := V4L2_Format{Type: dst.Type}
+	var pixmp V4L2_Pix_Format_Mplane
+	var pix V4L2_Pix_Format
+	if isMplane(dst.Type) {
+		format.Fmt = &pixmp
+	} else {
+		format.Fmt = &pix
+	}
+	if err := IoctlGetFmt(d.M2M.FD, &format); err != nil {
+		return fmt.Errorf("Failed to get decoded format: %w", err)
+	}
+	if isMplane(dst.Type) {
+		d.Width, d.Height, d.PixelFormat = pixmp.Width, pixmp.Height, pixmp.PixelFormat
+	} else {
+		d.Width, d.Height, d.PixelFormat = pix.Width, pix.Height, pix.PixelFormat
+	}
+
+	visible, err := d.M2M.GetSelection(dst.Type, V4L2_SEL_TGT_COMPOSE)
+	if err != nil {
+		visible = V4L2_Rect{Width: d.Width, Height: d.Height}
+	}
+	d.Visible = visible
+
+	count := d.ExtraBuffers
+	ctrl := V4L2_Control{ID: V4L2_CID_MIN_BUFFERS_FOR_CAPTURE}
+	if err := IoctlGetCtrl(d.M2M.FD, &ctrl); err == nil {
+		count += uint32(ctrl.Value)
+	}
+	if count == 0 {
+		count = 1
+	}
+
+	if err := dst.Alloc(count); err != nil {
+		return err
+	}
+	if err := dst.QueueAll(); err != nil {
+		return fmt.Errorf("Failed to enqueue buffers: %w", err)
+	}
+	if err := dst.StreamOn(); err != nil {
+		return fmt.Errorf("Failed to stream on capture queue: %w", err)
+	}
+	d.capturing = true
+	return nil
+}
@@ -0,0 +1,131 @@
+package v4l2
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// decode decodes the chunks, the i-th one with a timestamp of i ms, then
+// drains the decoder
+func decode(t *testing.T, d *Decoder, chunks ...string) []*DecodedFrame {
+	t.Helper()
+	ctx := context.Background()
+	var frames []*DecodedFrame
+	for i, chunk := range chunks {
+		fr, err := d.Decode(ctx, []byte(chunk), time.Duration(i)*time.Millisecond)
+		if err != nil {
+			t.Fatalf("chunk %q: %v", chunk, err)
+		}
+		frames = append(frames, fr...)
+	}
+	fr, err := d.Drain(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(frames, fr...)
+}
+
+type wantFrame struct {
+	data          string
+	width, height uint32
+	ts            time.Duration
+}
+
+func checkFrames(t *testing.T, frames []*DecodedFrame, want []wantFrame) {
+	t.Helper()
+	if len(frames) != len(want) {
+		t.Fatalf("%d frames, want %d", len(frames), len(want))
+	}
+	for i, w := range want {
+		f := frames[i]
+		if string(f.Planes[0]) != w.data || f.Width != w.width || f.Height != w.height ||
+			f.Visible != (V4L2_Rect{Width: w.width, Height: w.height}) || f.TimeStamp != w.ts {
+			t.Errorf("frame %d is %q of %dx%d, visible %+v at %v, want %+v",
+				i, f.Planes[0], f.Width, f.Height, f.Visible, f.TimeStamp, w)
+		}
+	}
+}
+
+func TestDecoderResolutionChange(t *testing.T) {
+	d, _ := openDecoder(t)
+	frames := decode(t, d, "64x48", "a", "b", "32x24", "c")
+	checkFrames(t, frames, []wantFrame{
+		{"a", 64, 48, 1 * time.Millisecond},
+		{"b", 64, 48, 2 * time.Millisecond},
+		{"c", 32, 24, 4 * time.Millisecond},
+	})
+	if d.Width != 32 || d.Height != 24 || d.PixelFormat != V4L2_PIX_FMT_NV12 {
+		t.Errorf("decoded format %dx%d %s", d.Width, d.Height, GetNameByFourCC(d.PixelFormat))
+	}
+}
+
+func TestDecoderDrain(t *testing.T) {
+	d, _ := openDecoder(t)
+	checkFrames(t, decode(t, d, "64x48", "a"), []wantFrame{
+		{"a", 64, 48, 1 * time.Millisecond},
+	})
+
+	// decoding continues after a drain, which may be repeated
+	checkFrames(t, decode(t, d, "b", "c"), []wantFrame{
+		{"b", 64, 48, 0},
+		{"c", 64, 48, 1 * time.Millisecond},
+	})
+	frames, err := d.Drain(context.Background())
+	if err != nil || len(frames) != 0 {
+		t.Errorf("drained again: %d frames, %v", len(frames), err)
+	}
+
+	// a stream ending before its headers
+	d, _ = openDecoder(t)
+	if frames := decode(t, d); len(frames) != 0 {
+		t.Errorf("%d frames of an empty stream", len(frames))
+	}
+}
+
+func TestDecoderFlush(t *testing.T) {
+	d, c := openDecoder(t)
+	ctx := context.Background()
+	if _, err := d.Decode(ctx, []byte("64x48"), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	c.mu.Lock()
+	c.hold = true
+	c.mu.Unlock()
+	for _, chunk := range []string{"a", "b"} {
+		if _, err := d.Decode(ctx, []byte(chunk), 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := d.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	c.mu.Lock()
+	c.hold = false
+	c.mu.Unlock()
+
+	// the chunks queued before the flush are dropped
+	checkFrames(t, decode(t, d, "c"), []wantFrame{
+		{"c", 64, 48, 0},
+	})
+}
+
+func TestDecoderNoBuffers(t *testing.T) {
+	d, c := openDecoder(t)
+	if _, err := d.Decode(context.Background(), []byte("64x48"), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// a device reporting errors along with other events must not be waited
+	// for until the deadline
+	c.mu.Lock()
+	c.gone = true
+	c.mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := d.Drain(ctx); !errors.Is(err, ErrorNoBuffers) {
+		t.Errorf("drain of a gone device: %v", err)
+	}
+}
@@ -40,14 +40,15 @@ func (d *Device) Open() (err error) {
 }
 
 func (d *Device) Close() {
-	syscall.Close(d.FD)
+	closeFile(d.FD)
 	d.Path = ""
 	d.FD = -1
 }
 
 type Device struct {
-	Path string
-	FD   int
+	Path    string
+	FD      int
+	Backend Backend // serves FD in place of the kernel, see OpenBackend
 }
 
 type Buffers struct {
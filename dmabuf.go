@@ -0,0 +1,213 @@
+package v4l2
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// SharedBuffers passes frames between two devices without copying. The
+// MMAP buffers of the source queue, e.g. a camera CAPTURE queue, are
+// exported as DMABUF file descriptors and imported as the DMABUF buffers
+// of the destination queue, e.g. the OUTPUT queue of a scaler or codec.
+type SharedBuffers struct {
+	Src     *Device
+	SrcType uint32
+	Dst     *Device
+	DstType uint32
+	Count   uint32
+	NPlanes uint32
+	FDs     [][]int    // exported dmabuf fds, per buffer and plane
+	Lengths [][]uint32 // plane lengths, per buffer and plane
+}
+
+// ShareBuffers allocates count MMAP buffers on the source queue, exports
+// them, and sets up the destination queue to import them.
+func ShareBuffers(src *Device, srcType uint32, dst *Device, dstType uint32, count uint32) (*SharedBuffers, error) {
+	s := &SharedBuffers{
+		Src:     src,
+		SrcType: srcType,
+		Dst:     dst,
+		DstType: dstType,
+	}
+
+	reqbufs := V4L2_Requestbuffers{
+		Count:  count,
+		Type:   srcType,
+		Memory: V4L2_MEMORY_MMAP,
+	}
+	if err := IoctlRequestBuffers(src.FD, &reqbufs); err != nil {
+		return nil, fmt.Errorf("Failed to request source buffers: %w", err)
+	}
+	if reqbufs.Count == 0 {
+		return nil, fmt.Errorf("%w: out of memory", ErrorBufferAlloc)
+	}
+	s.Count = reqbufs.Count
+
+	reqbufs = V4L2_Requestbuffers{
+		Count:  s.Count,
+		Type:   dstType,
+		Memory: V4L2_MEMORY_DMABUF,
+	}
+	if err := IoctlRequestBuffers(dst.FD, &reqbufs); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("Failed to request destination buffers: %w", err)
+	}
+	if reqbufs.Count < s.Count {
+		s.Close()
+		return nil, fmt.Errorf("%w: destination accepts %d of %d buffers",
+			ErrorBufferAlloc, reqbufs.Count, s.Count)
+	}
+
+	for i := uint32(0); i < s.Count; i++ {
+		var planes [VIDEO_MAX_PLANES]V4L2_Plane
+		vb := V4L2_Buffer{
+			Index:  i,
+			Type:   srcType,
+			Memory: V4L2_MEMORY_MMAP,
+		}
+		if isMplane(srcType) {
+			vb.Planes = planes[:]
+			vb.Length = VIDEO_MAX_PLANES
+		}
+		if err := IoctlQueryBuf(src.FD, &vb); err != nil {
+			s.Close()
+			return nil, fmt.Errorf("Failed to query buffers: %w", err)
+		}
+
+		var lengths []uint32
+		if isMplane(srcType) {
+			for j := uint32(0); j < vb.Length; j++ {
+				lengths = append(lengths, planes[j].Length)
+			}
+		} else {
+			lengths = append(lengths, vb.Length)
+		}
+		s.NPlanes = uint32(len(lengths))
+		s.Lengths = append(s.Lengths, lengths)
+
+		var fds []int
+		for j := range lengths {
+			eb := V4L2_Exportbuffer{
+				Type:  srcType,
+				Index: i,
+				Plane: uint32(j),
+				Flags: syscall.O_CLOEXEC | syscall.O_RDWR,
+			}
+			if err := IoctlExportBuffer(src.FD, &eb); err != nil {
+				s.FDs = append(s.FDs, fds)
+				s.Close()
+				return nil, fmt.Errorf("Failed to export buffer: %w", err)
+			}
+			fds = append(fds, eb.FD)
+		}
+		s.FDs = append(s.FDs, fds)
+	}
+	return s, nil
+}
+
+// QueueSrc hands buffer index to the source device to be filled.
+func (s *SharedBuffers) QueueSrc(index uint32) error {
+	var planes [VIDEO_MAX_PLANES]V4L2_Plane
+	vb := V4L2_Buffer{
+		Index:  index,
+		Type:   s.SrcType,
+		Memory: V4L2_MEMORY_MMAP,
+	}
+	if isMplane(s.SrcType) {
+		vb.Planes = planes[:]
+		vb.Length = s.NPlanes
+	}
+	return IoctlQBuf(s.Src.FD, &vb)
+}
+
+// Forward dequeues a filled buffer from the source device and queues the
+// same memory on the destination device. It returns the buffer index.
+func (s *SharedBuffers) Forward() (uint32, error) {
+	var planes [VIDEO_MAX_PLANES]V4L2_Plane
+	src := V4L2_Buffer{
+		Type:   s.SrcType,
+		Memory: V4L2_MEMORY_MMAP,
+	}
+	if isMplane(s.SrcType) {
+		src.Planes = planes[:]
+		src.Length = s.NPlanes
+	}
+	if err := IoctlDQBuf(s.Src.FD, &src); err != nil {
+		return 0, err
+	}
+
+	bytesused := []uint32{src.BytesUsed}
+	if isMplane(s.SrcType) {
+		bytesused = bytesused[:0]
+		for j := uint32(0); j < s.NPlanes; j++ {
+			bytesused = append(bytesused, planes[j].BytesUsed)
+		}
+	}
+
+	dst := V4L2_Buffer{
+		Index:     src.Index,
+		Type:      s.DstType,
+		Memory:    V4L2_MEMORY_DMABUF,
+		Field:     src.Field,
+		TimeStamp: src.TimeStamp,
+	}
+	var dstPlanes [VIDEO_MAX_PLANES]V4L2_Plane
+	if isMplane(s.DstType) {
+		for j := uint32(0); j < s.NPlanes; j++ {
+			dstPlanes[j].SetFD(s.FDs[src.Index][j])
+			dstPlanes[j].Length = s.Lengths[src.Index][j]
+			dstPlanes[j].BytesUsed = bytesused[j]
+		}
+		dst.Planes = dstPlanes[:]
+		dst.Length = s.NPlanes
+	} else {
+		// a single-planar queue only imports the first plane
+		dst.SetFD(s.FDs[src.Index][0])
+		dst.Length = s.Lengths[src.Index][0]
+		dst.BytesUsed = bytesused[0]
+	}
+	if err := IoctlQBuf(s.Dst.FD, &dst); err != nil {
+		return 0, err
+	}
+	return src.Index, nil
+}
+
+// Recycle dequeues a consumed buffer from the destination device and
+// hands it back to the source device. It returns the buffer index.
+func (s *SharedBuffers) Recycle() (uint32, error) {
+	var planes [VIDEO_MAX_PLANES]V4L2_Plane
+	vb := V4L2_Buffer{
+		Type:   s.DstType,
+		Memory: V4L2_MEMORY_DMABUF,
+	}
+	if isMplane(s.DstType) {
+		vb.Planes = planes[:]
+		vb.Length = s.NPlanes
+	}
+	if err := IoctlDQBuf(s.Dst.FD, &vb); err != nil {
+		return 0, err
+	}
+	return vb.Index, s.QueueSrc(vb.Index)
+}
+
+// Close releases the exported file descriptors and the buffers of both
+// queues. Both queues must be streamed off first.
+func (s *SharedBuffers) Close() error {
+	for _, fds := range s.FDs {
+		for _, fd := range fds {
+			syscall.Close(fd)
+		}
+	}
+	s.FDs = nil
+
+	var err error
+	dst := V4L2_Requestbuffers{Type: s.DstType, Memory: V4L2_MEMORY_DMABUF}
+	if e := IoctlRequestBuffers(s.Dst.FD, &dst); e != nil {
+		err = e
+	}
+	src := V4L2_Requestbuffers{Type: s.SrcType, Memory: V4L2_MEMORY_MMAP}
+	if e := IoctlRequestBuffers(s.Src.FD, &src); e != nil {
+		err = e
+	}
+	return err
+}
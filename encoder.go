@@ -0,0 +1,400 @@
+package v4l2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"time"
+)
+
+// Encoder drives a stateful video encoder, e.g. the H.264 encoder of the
+// Exynos MFC, as described by the stateful encoder interface of the
+// kernel. Raw frames are queued on the source (OUTPUT) queue, encoded
+// frames are returned from the destination (CAPTURE) queue.
+type Encoder struct {
+	M2M         *M2MDevice
+	CodedFormat uint32 // e.g. V4L2_PIX_FMT_H264
+	PixelFormat uint32 // of the raw frames, e.g. V4L2_PIX_FMT_NV12M
+	Width       uint32
+	Height      uint32
+	FrameRate   uint32 // frames per second, 0 keeps the driver default
+	BufSize     uint32 // size of the buffers for encoded frames, 0 lets the driver choose
+
+	poll     *poller
+	draining bool // V4L2_ENC_CMD_STOP issued
+	stopped  bool // last buffer dequeued after draining
+	frames   []*EncodedFrame
+}
+
+// EncodedFrame is a copy of one encoded frame, e.g. an H.264 access unit.
+type EncodedFrame struct {
+	Data      []byte
+	KeyFrame  bool
+	Flags     uint32 // V4L2_BUF_FLAG_KEYFRAME, V4L2_BUF_FLAG_PFRAME or V4L2_BUF_FLAG_BFRAME
+	Sequence  uint32
+	TimeStamp time.Duration // of the raw frame
+}
+
+// OpenEncoder opens a stateful encoder. Set the format fields and call
+// SetFormat, set up the codec controls, then allocate the buffers with
+// AllocBuffers before encoding.
+func OpenEncoder(name string) (*Encoder, error) {
+	m, err := OpenM2M(name)
+	if err != nil {
+		return nil, err
+	}
+	e, err := NewEncoder(m)
+	if err != nil {
+		m.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+// NewEncoder uses the memory-to-memory device m as a stateful encoder,
+// see OpenEncoder.
+func NewEncoder(m *M2MDevice) (*Encoder, error) {
+	p, err := newPoller(m.FD, syscall.EPOLLIN|syscall.EPOLLOUT)
+	if err != nil {
+		return nil, err
+	}
+	return &Encoder{M2M: m, poll: p}, nil
+}
+
+// Close stops encoding and closes the device.
+func (e *Encoder) Close() error {
+	e.poll.close()
+	return e.M2M.Close()
+}
+
+// SetFormat sets the coded format and then the raw format, as the encoder
+// may restrict the raw formats to the ones it can encode, and the frame
+// rate. Width, Height and PixelFormat are updated with the values the
+// driver applied.
+func (e *Encoder) SetFormat() error {
+	if e.Width == 0 || e.Height == 0 {
+		return errors.New("Not configure width or height in pixel")
+	}
+	if e.CodedFormat == 0 || e.PixelFormat == 0 {
+		return errors.New("Not assign pixel format")
+	}
+
+	dst := e.M2M.Dst
+	format := V4L2_Format{Type: dst.Type}
+	if isMplane(dst.Type) {
+		pixmp := V4L2_Pix_Format_Mplane{
+			Width:       e.Width,
+			Height:      e.Height,
+			PixelFormat: e.CodedFormat,
+			NumPlanes:   1,
+		}
+		pixmp.PlaneFmt[0].SizeImage = e.BufSize
+		format.Fmt = &pixmp
+	} else {
+		format.Fmt = &V4L2_Pix_Format{
+			Width:       e.Width,
+			Height:      e.Height,
+			PixelFormat: e.CodedFormat,
+			SizeImage:   e.BufSize,
+		}
+	}
+	if err := IoctlSetFmt(e.M2M.FD, &format); err != nil {
+		return fmt.Errorf("Failed to set coded format: %w", err)
+	}
+
+	src := e.M2M.Src
+	format = V4L2_Format{Type: src.Type}
+	var pixmp V4L2_Pix_Format_Mplane
+	var pix V4L2_Pix_Format
+	if isMplane(src.Type) {
+		pixmp = V4L2_Pix_Format_Mplane{
+			Width:       e.Width,
+			Height:      e.Height,
+			PixelFormat: e.PixelFormat,
+			Field:       V4L2_FIELD_NONE,
+		}
+		format.Fmt = &pixmp
+	} else {
+		pix = V4L2_Pix_Format{
+			Width:       e.Width,
+			Height:      e.Height,
+			PixelFormat: e.PixelFormat,
+			Field:       V4L2_FIELD_NONE,
+		}
+		format.Fmt = &pix
+	}
+	if err := IoctlSetFmt(e.M2M.FD, &format); err != nil {
+		return fmt.Errorf("Failed to set raw format: %w", err)
+	}
+	if isMplane(src.Type) {
+		e.Width, e.Height, e.PixelFormat = pixmp.Width, pixmp.Height, pixmp.PixelFormat
+	} else {
+		e.Width, e.Height, e.PixelFormat = pix.Width, pix.Height, pix.PixelFormat
+	}
+
+	if e.FrameRate == 0 {
+		return nil
+	}
+	parm := V4L2_Streamparm{
+		Type: src.Type,
+		Parm: &V4L2_Outputparm{
+			TimePerFrame: V4L2_Fract{Numerator: 1, Denominator: e.FrameRate},
+		},
+	}
+	if err := IoctlSetParm(e.M2M.FD, &parm); err != nil {
+		return fmt.Errorf("Failed to set frame rate: %w", err)
+	}
+	return nil
+}
+
+// SetControl sets one codec control, e.g. V4L2_CID_MPEG_VIDEO_B_FRAMES.
+func (e *Encoder) SetControl(id uint32, value int32) error {
+	ctrls := V4L2_Ext_Controls{
+		ClassWhich: ctrlID2Which(id),
+		Count:      1,
+		Controls:   []V4L2_Ext_Control{{ID: id, Union: value}},
+	}
+	return IoctlSetExtCtrls(e.M2M.FD, &ctrls)
+}
+
+// SetBitrate sets the average bitrate in bits per second.
+func (e *Encoder) SetBitrate(bitrate uint32) error {
+	return e.SetControl(V4L2_CID_MPEG_VIDEO_BITRATE, int32(bitrate))
+}
+
+// SetRateControl selects V4L2_MPEG_VIDEO_BITRATE_MODE_VBR, _CBR or _CQ and
+// enables frame level rate control.
+func (e *Encoder) SetRateControl(mode uint32) error {
+	if err := e.SetControl(V4L2_CID_MPEG_VIDEO_FRAME_RC_ENABLE, 1); err != nil {
+		return err
+	}
+	return e.SetControl(V4L2_CID_MPEG_VIDEO_BITRATE_MODE, int32(mode))
+}
+
+// SetGOPSize sets the distance between key frames.
+func (e *Encoder) SetGOPSize(size uint32) error {
+	return e.SetControl(V4L2_CID_MPEG_VIDEO_GOP_SIZE, int32(size))
+}
+
+// SetProfile sets the profile of the coded format, e.g.
+// V4L2_MPEG_VIDEO_H264_PROFILE_MAIN.
+func (e *Encoder) SetProfile(profile uint32) error {
+	var id uint32
+	switch e.CodedFormat {
+	case V4L2_PIX_FMT_H264:
+		id = V4L2_CID_MPEG_VIDEO_H264_PROFILE
+	case V4L2_PIX_FMT_MPEG4:
+		id = V4L2_CID_MPEG_VIDEO_MPEG4_PROFILE
+	case V4L2_PIX_FMT_VP8:
+		id = V4L2_CID_MPEG_VIDEO_VP8_PROFILE
+	default:
+		return fmt.Errorf("%w: profile of %s", ErrorUnsupportedCap, GetNameByFourCC(e.CodedFormat))
+	}
+	return e.SetControl(id, int32(profile))
+}
+
+// SetLevel sets the level of the coded format, e.g.
+// V4L2_MPEG_VIDEO_H264_LEVEL_4_0.
+func (e *Encoder) SetLevel(level uint32) error {
+	var id uint32
+	switch e.CodedFormat {
+	case V4L2_PIX_FMT_H264:
+		id = V4L2_CID_MPEG_VIDEO_H264_LEVEL
+	case V4L2_PIX_FMT_MPEG4:
+		id = V4L2_CID_MPEG_VIDEO_MPEG4_LEVEL
+	default:
+		return fmt.Errorf("%w: level of %s", ErrorUnsupportedCap, GetNameByFourCC(e.CodedFormat))
+	}
+	return e.SetControl(id, int32(level))
+}
+
+// ForceKeyFrame makes the encoder code the next frame as a key frame.
+func (e *Encoder) ForceKeyFrame() error {
+	return e.SetControl(V4L2_CID_MPEG_VIDEO_FORCE_KEY_FRAME, 0)
+}
+
+// AllocBuffers allocates the buffers of both queues.
+func (e *Encoder) AllocBuffers(srcCount, dstCount uint32) error {
+	return e.M2M.Alloc(srcCount, dstCount)
+}
+
+// Encode queues one raw frame, given per plane, waiting for a free source
+// buffer if needed, and returns the frames encoded so far. ts is passed on
+// to the encoded frame. Streaming is started by the first call, and
+// encoding continues after a Drain.
+func (e *Encoder) Encode(ctx context.Context, in [][]byte, ts time.Duration) ([]*EncodedFrame, error) {
+	if e.stopped || e.draining {
+		if err := e.restart(); err != nil {
+			return nil, err
+		}
+	}
+	if err := e.M2M.Start(); err != nil {
+		return nil, err
+	}
+
+	b, err := e.freeSrc(ctx)
+	if err != nil {
+		return nil, err
+	}
+	b.Flags = 0
+	b.TimeStamp = ts
+	if err := e.M2M.queueSrc(b, in); err != nil {
+		return nil, err
+	}
+
+	if err := e.step(ctx, 0); err != nil {
+		return nil, err
+	}
+	return e.takeFrames(), nil
+}
+
+// Drain stops the encoder with V4L2_ENC_CMD_STOP and returns all
+// remaining frames once the encoder has marked the last one.
+func (e *Encoder) Drain(ctx context.Context) ([]*EncodedFrame, error) {
+	if !e.M2M.streaming {
+		return e.takeFrames(), nil
+	}
+
+	if !e.draining {
+		cmd := V4L2_Encoder_Cmd{Cmd: V4L2_ENC_CMD_STOP}
+		err := IoctlEncoderCmd(e.M2M.FD, &cmd)
+		if err == syscall.ENOTTY {
+			// older drivers stop on an empty source buffer
+			err = e.queueEmpty(ctx)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Failed to stop encoder: %w", err)
+		}
+		e.draining = true
+	}
+
+	for !e.stopped {
+		if err := e.step(ctx, -1); err != nil {
+			return nil, err
+		}
+	}
+	return e.takeFrames(), nil
+}
+
+func (e *Encoder) queueEmpty(ctx context.Context) error {
+	b, err := e.freeSrc(ctx)
+	if err != nil {
+		return err
+	}
+	b.Flags = 0
+	return e.M2M.queueSrc(b, nil)
+}
+
+// restart resumes encoding after a drain.
+func (e *Encoder) restart() error {
+	cmd := V4L2_Encoder_Cmd{Cmd: V4L2_ENC_CMD_START}
+	err := IoctlEncoderCmd(e.M2M.FD, &cmd)
+	if err != nil {
+		// restarting streaming resumes the encoder as well
+		err = e.M2M.Stop()
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to restart encoder: %w", err)
+	}
+
+	e.draining = false
+	e.stopped = false
+	return e.M2M.Dst.QueueAll()
+}
+
+// freeSrc returns a source buffer not owned by the encoder, waiting for
+// the encoder to consume one if needed. Encoded frames are dequeued while
+// waiting, so that the encoder does not run out of destination buffers.
+func (e *Encoder) freeSrc(ctx context.Context) (*Buffer, error) {
+	for {
+		for _, b := range e.M2M.Src.Bufs {
+			if !b.Queued {
+				return b, nil
+			}
+		}
+		if err := e.step(ctx, -1); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (e *Encoder) takeFrames() []*EncodedFrame {
+	frames := e.frames
+	e.frames = nil
+	return frames
+}
+
+// step waits up to timeout milliseconds for the encoder, then dequeues all
+// ready buffers.
+func (e *Encoder) step(ctx context.Context, timeout int) error {
+	if timeout != 0 {
+		stop := context.AfterFunc(ctx, e.poll.wakeup)
+		defer stop()
+	}
+	events, err := e.poll.wait(timeout)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	for {
+		_, err := e.M2M.Src.Dequeue()
+		if err == syscall.EAGAIN {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := e.dequeueDst(); err != nil {
+		return err
+	}
+	if timeout < 0 && events&syscall.EPOLLERR != 0 {
+		// nothing queued, waiting again would not block
+		return ErrorNoBuffers
+	}
+	return nil
+}
+
+func (e *Encoder) dequeueDst() error {
+	dst := e.M2M.Dst
+	for !e.stopped {
+		b, err := dst.Dequeue()
+		if err == syscall.EAGAIN {
+			return nil
+		}
+		if err == syscall.EPIPE {
+			// the last buffer was dequeued already
+			e.stopped = true
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		data := b.Planes[0].Bytes()
+		if b.Flags&V4L2_BUF_FLAG_ERROR == 0 && len(data) > 0 {
+			e.frames = append(e.frames, &EncodedFrame{
+				Data:      append([]byte(nil), data...),
+				KeyFrame:  b.Flags&V4L2_BUF_FLAG_KEYFRAME != 0,
+				Flags:     b.Flags & (V4L2_BUF_FLAG_KEYFRAME | V4L2_BUF_FLAG_PFRAME | V4L2_BUF_FLAG_BFRAME),
+				Sequence:  b.Sequence,
+				TimeStamp: b.TimeStamp,
+			})
+		}
+
+		// older drivers mark the end of the stream with an empty buffer
+		// instead of V4L2_BUF_FLAG_LAST
+		if b.Flags&V4L2_BUF_FLAG_LAST != 0 || e.draining && len(data) == 0 {
+			e.stopped = true
+			return nil
+		}
+		if err := dst.Queue(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package v4l2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// openEncoder opens an Encoder of 64x48 NV12 frames to H.264 on a
+// fakeCodec, which is closed when the test ends
+func openEncoder(t *testing.T) (*Encoder, *fakeCodec) {
+	t.Helper()
+	m, c := openFakeCodec(t, true)
+	e, err := NewEncoder(m)
+	if err != nil {
+		m.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { e.Close() })
+
+	e.CodedFormat = V4L2_PIX_FMT_H264
+	e.PixelFormat = V4L2_PIX_FMT_NV12
+	e.Width, e.Height = 64, 48
+	e.FrameRate = 30
+	if err := e.SetFormat(); err != nil {
+		t.Fatal(err)
+	}
+	return e, c
+}
+
+// encode encodes the frames f<first> to f<first+n-1>, forcing a key frame
+// before the ones in forced, then drains the encoder
+func encode(t *testing.T, e *Encoder, first, n int, forced ...int) []*EncodedFrame {
+	t.Helper()
+	ctx := context.Background()
+	var frames []*EncodedFrame
+	for i := first; i < first+n; i++ {
+		for _, f := range forced {
+			if f == i {
+				if err := e.ForceKeyFrame(); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		in := [][]byte{[]byte(fmt.Sprintf("f%d", i))}
+		fr, err := e.Encode(ctx, in, time.Duration(i)*time.Millisecond)
+		if err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		frames = append(frames, fr...)
+	}
+	fr, err := e.Drain(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(frames, fr...)
+}
+
+// checkEncoded checks the frames encoded from f<first> on, of which the
+// ones in keys are key frames
+func checkEncoded(t *testing.T, frames []*EncodedFrame, first, n int, keys ...int) {
+	t.Helper()
+	if len(frames) != n {
+		t.Fatalf("%d frames, want %d", len(frames), n)
+	}
+	for i, f := range frames {
+		key := false
+		for _, k := range keys {
+			key = key || k == first+i
+		}
+		flags := uint32(V4L2_BUF_FLAG_PFRAME)
+		if key {
+			flags = V4L2_BUF_FLAG_KEYFRAME
+		}
+		data := fmt.Sprintf("f%d", first+i)
+		ts := time.Duration(first+i) * time.Millisecond
+		if string(f.Data) != data || f.KeyFrame != key || f.Flags != flags || f.TimeStamp != ts {
+			t.Errorf("frame %d is %q, key frame %v, flags %#x at %v, want %q at %v",
+				first+i, f.Data, f.KeyFrame, f.Flags, f.TimeStamp, data, ts)
+		}
+	}
+}
+
+func TestEncoderCommands(t *testing.T) {
+	e, c := openEncoder(t)
+	if c.frameRate != 30 || c.coded != V4L2_PIX_FMT_H264 {
+		t.Errorf("frame rate %d, coded format %s", c.frameRate, GetNameByFourCC(c.coded))
+	}
+	if err := e.SetGOPSize(3); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.AllocBuffers(2, 2); err != nil {
+		t.Fatal(err)
+	}
+	checkEncoded(t, encode(t, e, 0, 5, 1), 0, 5, 0, 1, 3)
+
+	// encoding continues after a drain
+	checkEncoded(t, encode(t, e, 5, 2), 5, 2, 6)
+	frames, err := e.Drain(context.Background())
+	if err != nil || len(frames) != 0 {
+		t.Errorf("drained again: %d frames, %v", len(frames), err)
+	}
+}
+
+func TestEncoderDrainEmptyBuffer(t *testing.T) {
+	e, c := openEncoder(t)
+	c.noCmd = true
+	if err := e.AllocBuffers(2, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// without V4L2_ENC_CMD_STOP the encoder is stopped by an empty frame
+	// and restarted by streaming on again
+	checkEncoded(t, encode(t, e, 0, 3), 0, 3, 0)
+	checkEncoded(t, encode(t, e, 3, 2), 3, 2)
+}
+
+func TestEncoderNoBuffers(t *testing.T) {
+	e, c := openEncoder(t)
+	if err := e.AllocBuffers(2, 2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Encode(context.Background(), [][]byte{[]byte("f0")}, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// a device reporting errors along with other events must not be waited
+	// for until the deadline
+	c.mu.Lock()
+	c.gone = true
+	c.mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := e.Drain(ctx); !errors.Is(err, ErrorNoBuffers) {
+		t.Errorf("drain of a gone device: %v", err)
+	}
+}
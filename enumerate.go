@@ -0,0 +1,308 @@
+package v4l2
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// roots of sysfs and of the devnodes, and the function opening a devnode,
+// replaced by the tests
+var (
+	sysfsRoot = "/sys"
+	devRoot   = "/dev"
+	openNode  = Open
+)
+
+// DeviceInfo describes a video devnode found by Enumerate.
+type DeviceInfo struct {
+	Path  string // devnode, e.g. /dev/video0
+	Name  string // name of the video device in sysfs
+	Index int    // index of the devnode among those of its device
+
+	// as reported by VIDIOC_QUERYCAP, Driver falls back to the name of
+	// the driver in sysfs if the devnode cannot be opened
+	Driver       string
+	Card         string
+	BusInfo      string
+	Capabilities uint32
+	DeviceCaps   uint32 // Capabilities for drivers without device caps
+
+	CaptureFormats []uint32 // pixel formats of the capture queue
+	OutputFormats  []uint32 // pixel formats of the output queue
+
+	MediaDevice string // media controller devnode, empty if there is none
+	VendorID    uint16 // USB vendor ID, 0 for devices not on USB
+	ProductID   uint16 // USB product ID, 0 for devices not on USB
+
+	Err error // error opening or querying the devnode, if any
+}
+
+// DeviceFilter selects devices in Enumerate and FindDevice.
+type DeviceFilter func(*DeviceInfo) bool
+
+// Enumerate returns the video devnodes of /sys/class/video4linux that pass
+// all filters, in the order of their numbers. Each devnode is opened to
+// query its capabilities and formats, a devnode that cannot be opened is
+// reported with its Err set and only the fields known to sysfs.
+func Enumerate(filters ...DeviceFilter) ([]DeviceInfo, error) {
+	class := filepath.Join(sysfsRoot, "class", "video4linux")
+	entries, err := os.ReadDir(class)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var devs []DeviceInfo
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name(), "video") {
+			continue
+		}
+		info := readSysfsInfo(filepath.Join(class, e.Name()))
+		info.query()
+		if info.match(filters) {
+			devs = append(devs, info)
+		}
+	}
+	sort.Slice(devs, func(i, j int) bool {
+		return nodeNumber(devs[i].Path) < nodeNumber(devs[j].Path)
+	})
+	return devs, nil
+}
+
+// FindDevice returns the first device of Enumerate that passes all
+// filters, or ErrorNoDevice if there is none. Filter by WithBusInfo to
+// find the same device across reboots, as the devnode numbers may change.
+func FindDevice(filters ...DeviceFilter) (*DeviceInfo, error) {
+	devs, err := Enumerate(filters...)
+	if err != nil {
+		return nil, err
+	}
+	if len(devs) == 0 {
+		return nil, ErrorNoDevice
+	}
+	return &devs[0], nil
+}
+
+// Open opens the devnode of the device, and fails with ErrorWrongDevice if
+// the devnode has since been taken by another device.
+func (info *DeviceInfo) Open() (*Device, error) {
+	d, err := openNode(info.Path)
+	if err != nil {
+		return nil, err
+	}
+	var caps V4L2_Capability
+	err = IoctlQueryCap(d.FD, &caps)
+	if err != nil {
+		d.Close()
+		return nil, err
+	}
+	if caps.BusInfo != info.BusInfo || caps.Card != info.Card {
+		d.Close()
+		return nil, fmt.Errorf("%w: %s is now %q at %q", ErrorWrongDevice,
+			info.Path, caps.Card, caps.BusInfo)
+	}
+	return d, nil
+}
+
+func (info *DeviceInfo) match(filters []DeviceFilter) bool {
+	for _, f := range filters {
+		if !f(info) {
+			return false
+		}
+	}
+	return true
+}
+
+// HasCaps selects the devices with all of caps in their device caps, e.g.
+// V4L2_CAP_VIDEO_M2M_MPLANE for mem2mem codecs of multi-planar formats.
+func HasCaps(caps uint32) DeviceFilter {
+	return func(info *DeviceInfo) bool {
+		return info.DeviceCaps&caps == caps
+	}
+}
+
+// CapturesFormat selects the devices whose capture queue supports the
+// pixel format, e.g. V4L2_PIX_FMT_MJPEG for MJPEG cameras.
+func CapturesFormat(pixelformat uint32) DeviceFilter {
+	return func(info *DeviceInfo) bool {
+		return hasFormat(info.CaptureFormats, pixelformat)
+	}
+}
+
+// OutputsFormat selects the devices whose output queue supports the pixel
+// format, e.g. V4L2_PIX_FMT_H264 for H.264 decoders.
+func OutputsFormat(pixelformat uint32) DeviceFilter {
+	return func(info *DeviceInfo) bool {
+		return hasFormat(info.OutputFormats, pixelformat)
+	}
+}
+
+// WithDriver selects the devices of the driver.
+func WithDriver(driver string) DeviceFilter {
+	return func(info *DeviceInfo) bool {
+		return info.Driver == driver
+	}
+}
+
+// WithCard selects the devices of the card name.
+func WithCard(card string) DeviceFilter {
+	return func(info *DeviceInfo) bool {
+		return info.Card == card
+	}
+}
+
+// WithBusInfo selects the devices at the bus location, e.g.
+// "usb-0000:00:14.0-1" for a camera plugged in the first port of a USB
+// controller. Unlike the devnode numbers, the location stays the same
+// across reboots.
+func WithBusInfo(busInfo string) DeviceFilter {
+	return func(info *DeviceInfo) bool {
+		return info.BusInfo == busInfo
+	}
+}
+
+// WithUSBID selects the USB devices of the vendor and product IDs.
+func WithUSBID(vendor, product uint16) DeviceFilter {
+	return func(info *DeviceInfo) bool {
+		return info.VendorID == vendor && info.ProductID == product
+	}
+}
+
+func hasFormat(formats []uint32, pixelformat uint32) bool {
+	for _, f := range formats {
+		if f == pixelformat {
+			return true
+		}
+	}
+	return false
+}
+
+// readSysfsInfo returns what sysfs tells about the video device in dir
+func readSysfsInfo(dir string) DeviceInfo {
+	info := DeviceInfo{
+		Path: filepath.Join(devRoot, filepath.Base(dir)),
+		Name: readSysfsString(dir, "name"),
+	}
+	info.Index, _ = strconv.Atoi(readSysfsString(dir, "index"))
+	if data, err := os.ReadFile(filepath.Join(dir, "uevent")); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if name, ok := strings.CutPrefix(line, "DEVNAME="); ok {
+				info.Path = filepath.Join(devRoot, name)
+			}
+		}
+	}
+
+	device, err := filepath.EvalSymlinks(filepath.Join(dir, "device"))
+	if err != nil {
+		return info
+	}
+	if driver, err := os.Readlink(filepath.Join(device, "driver")); err == nil {
+		info.Driver = filepath.Base(driver)
+	}
+	if media, _ := filepath.Glob(filepath.Join(device, "media[0-9]*")); len(media) > 0 {
+		info.MediaDevice = filepath.Join(devRoot, filepath.Base(media[0]))
+	}
+
+	// the USB device is the closest parent with vendor and product IDs,
+	// the video device hangs off one of its interfaces
+	for d := device; strings.HasPrefix(d, sysfsRoot+"/"); d = filepath.Dir(d) {
+		vendor, err1 := strconv.ParseUint(readSysfsString(d, "idVendor"), 16, 16)
+		product, err2 := strconv.ParseUint(readSysfsString(d, "idProduct"), 16, 16)
+		if err1 == nil && err2 == nil {
+			info.VendorID = uint16(vendor)
+			info.ProductID = uint16(product)
+			break
+		}
+	}
+	return info
+}
+
+func readSysfsString(dir, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// query fills in the capabilities and formats of the devnode
+func (info *DeviceInfo) query() {
+	d, err := openNode(info.Path)
+	if err != nil {
+		info.Err = err
+		return
+	}
+	defer d.Close()
+
+	var caps V4L2_Capability
+	err = IoctlQueryCap(d.FD, &caps)
+	if err != nil {
+		info.Err = fmt.Errorf("Failed to query capability: %w", err)
+		return
+	}
+	info.Driver = caps.Driver
+	info.Card = caps.Card
+	info.BusInfo = caps.BusInfo
+	info.Capabilities = caps.Capabilities
+	info.DeviceCaps = caps.Capabilities
+	if caps.Capabilities&V4L2_CAP_DEVICE_CAPS != 0 {
+		info.DeviceCaps = caps.DeviceCaps
+	}
+
+	var capture, output uint32
+	switch {
+	case info.DeviceCaps&(V4L2_CAP_VIDEO_CAPTURE_MPLANE|V4L2_CAP_VIDEO_M2M_MPLANE) != 0:
+		capture = V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE
+	case info.DeviceCaps&(V4L2_CAP_VIDEO_CAPTURE|V4L2_CAP_VIDEO_M2M) != 0:
+		capture = V4L2_BUF_TYPE_VIDEO_CAPTURE
+	}
+	switch {
+	case info.DeviceCaps&(V4L2_CAP_VIDEO_OUTPUT_MPLANE|V4L2_CAP_VIDEO_M2M_MPLANE) != 0:
+		output = V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE
+	case info.DeviceCaps&(V4L2_CAP_VIDEO_OUTPUT|V4L2_CAP_VIDEO_M2M) != 0:
+		output = V4L2_BUF_TYPE_VIDEO_OUTPUT
+	}
+	if capture != 0 {
+		info.CaptureFormats, err = enumFormats(d.FD, capture)
+	}
+	if err == nil && output != 0 {
+		info.OutputFormats, err = enumFormats(d.FD, output)
+	}
+	if err != nil {
+		info.Err = fmt.Errorf("Failed to enumerate formats: %w", err)
+	}
+}
+
+func enumFormats(fd int, bufType uint32) ([]uint32, error) {
+	var formats []uint32
+
+	for i := uint32(0); ; i++ {
+		fmtdesc := V4L2_Fmtdesc{Index: i, Type: bufType}
+		err := IoctlEnumFmt(fd, &fmtdesc)
+		if err == syscall.EINVAL {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		formats = append(formats, fmtdesc.PixelFormat)
+	}
+	return formats, nil
+}
+
+// nodeNumber returns the number of a devnode, e.g. 11 for /dev/video11
+func nodeNumber(path string) int {
+	name := filepath.Base(path)
+	n, err := strconv.Atoi(strings.TrimLeft(name, "abcdefghijklmnopqrstuvwxyz-"))
+	if err != nil {
+		return -1
+	}
+	return n
+}
@@ -0,0 +1,203 @@
+package v4l2
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// fakeSysfs builds a sysfs with the video devnodes of a USB camera, a
+// metadata node of it and a platform device, whose devnodes are served by
+// fake drivers, except /dev/video1, which cannot be opened
+func fakeSysfs(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	usb := filepath.Join(root, "sys/devices/pci0000:00/0000:00:14.0/usb1/1-1")
+	platform := filepath.Join(root, "sys/devices/platform/codec")
+	files := map[string]string{
+		usb + "/idVendor":                          "046d\n",
+		usb + "/idProduct":                         "0825\n",
+		usb + "/1-1:1.0/media0/dev":                "236:0\n",
+		usb + "/1-1:1.0/video4linux/video0/name":   "UVC Camera (046d:0825)\n",
+		usb + "/1-1:1.0/video4linux/video0/index":  "0\n",
+		usb + "/1-1:1.0/video4linux/video1/name":   "UVC Camera (046d:0825)\n",
+		usb + "/1-1:1.0/video4linux/video1/index":  "1\n",
+		platform + "/video4linux/video11/name":     "codec-dec\n",
+		platform + "/video4linux/video11/index":    "0\n",
+		platform + "/video4linux/video11/uevent":   "MAJOR=81\nMINOR=11\nDEVNAME=video11\n",
+		platform + "/video4linux/v4l-subdev0/name": "sensor\n",
+	}
+	for name, data := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		usb + "/1-1:1.0/driver":                    "../../../../../bus/usb/drivers/uvcvideo",
+		usb + "/1-1:1.0/video4linux/video0/device": "../../../1-1:1.0",
+		usb + "/1-1:1.0/video4linux/video1/device": "../../../1-1:1.0",
+		platform + "/driver":                       "../../../bus/platform/drivers/codec",
+		platform + "/video4linux/video11/device":   "../../../codec",
+	}
+	class := filepath.Join(root, "sys/class/video4linux")
+	if err := os.MkdirAll(class, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, node := range []string{
+		usb + "/1-1:1.0/video4linux/video1",
+		usb + "/1-1:1.0/video4linux/video0",
+		platform + "/video4linux/video11",
+		platform + "/video4linux/v4l-subdev0",
+	} {
+		links[filepath.Join(class, filepath.Base(node))] = node
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sysfs, dev, open := sysfsRoot, devRoot, openNode
+	t.Cleanup(func() { sysfsRoot, devRoot, openNode = sysfs, dev, open })
+	sysfsRoot = filepath.Join(root, "sys")
+	devRoot = "/fakedev"
+	openNode = func(path string) (*Device, error) {
+		if path == "/fakedev/video1" {
+			return nil, syscall.EACCES
+		}
+		return OpenBackend(NewFakeDriver())
+	}
+}
+
+func TestEnumerate(t *testing.T) {
+	fakeSysfs(t)
+	devs, err := Enumerate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devs) != 3 {
+		t.Fatalf("%d devices: %+v", len(devs), devs)
+	}
+
+	cam := devs[0]
+	if cam.Path != "/fakedev/video0" || cam.Name != "UVC Camera (046d:0825)" || cam.Index != 0 {
+		t.Errorf("camera node %+v", cam)
+	}
+	if cam.Err != nil || cam.Driver != "fake" || cam.Card != "Fake Camera" ||
+		cam.BusInfo != "platform:fake" {
+		t.Errorf("camera caps %+v", cam)
+	}
+	if cam.DeviceCaps != V4L2_CAP_VIDEO_CAPTURE|V4L2_CAP_STREAMING ||
+		cam.Capabilities != cam.DeviceCaps|V4L2_CAP_DEVICE_CAPS {
+		t.Errorf("camera caps %#x, device caps %#x", cam.Capabilities, cam.DeviceCaps)
+	}
+	if !hasFormat(cam.CaptureFormats, V4L2_PIX_FMT_YUYV) || len(cam.OutputFormats) != 0 {
+		t.Errorf("camera formats %v, %v", cam.CaptureFormats, cam.OutputFormats)
+	}
+	if cam.MediaDevice != "/fakedev/media0" || cam.VendorID != 0x046d || cam.ProductID != 0x0825 {
+		t.Errorf("camera media %q, USB ID %04x:%04x", cam.MediaDevice, cam.VendorID, cam.ProductID)
+	}
+
+	meta := devs[1]
+	if meta.Path != "/fakedev/video1" || meta.Index != 1 || !errors.Is(meta.Err, syscall.EACCES) {
+		t.Errorf("metadata node %+v", meta)
+	}
+	if meta.Driver != "uvcvideo" || meta.Card != "" || meta.VendorID != 0x046d {
+		t.Errorf("metadata node from sysfs %+v", meta)
+	}
+
+	codec := devs[2]
+	if codec.Path != "/fakedev/video11" || codec.Name != "codec-dec" || codec.Err != nil {
+		t.Errorf("codec node %+v", codec)
+	}
+	if codec.MediaDevice != "" || codec.VendorID != 0 || codec.ProductID != 0 {
+		t.Errorf("codec media %q, USB ID %04x:%04x", codec.MediaDevice, codec.VendorID, codec.ProductID)
+	}
+}
+
+func TestEnumerateFilters(t *testing.T) {
+	fakeSysfs(t)
+	for _, tc := range []struct {
+		name    string
+		filters []DeviceFilter
+		paths   []string
+	}{
+		{"none", nil, []string{"/fakedev/video0", "/fakedev/video1", "/fakedev/video11"}},
+		{"capture", []DeviceFilter{HasCaps(V4L2_CAP_VIDEO_CAPTURE | V4L2_CAP_STREAMING)},
+			[]string{"/fakedev/video0", "/fakedev/video11"}},
+		{"m2m mplane", []DeviceFilter{HasCaps(V4L2_CAP_VIDEO_M2M_MPLANE)}, nil},
+		{"capture NV12", []DeviceFilter{CapturesFormat(V4L2_PIX_FMT_NV12)},
+			[]string{"/fakedev/video0", "/fakedev/video11"}},
+		{"capture MJPEG", []DeviceFilter{CapturesFormat(V4L2_PIX_FMT_MJPEG)}, nil},
+		{"output YUYV", []DeviceFilter{OutputsFormat(V4L2_PIX_FMT_YUYV)}, nil},
+		{"driver", []DeviceFilter{WithDriver("uvcvideo")}, []string{"/fakedev/video1"}},
+		{"card", []DeviceFilter{WithCard("Fake Camera")},
+			[]string{"/fakedev/video0", "/fakedev/video11"}},
+		{"USB ID", []DeviceFilter{WithUSBID(0x046d, 0x0825)},
+			[]string{"/fakedev/video0", "/fakedev/video1"}},
+		{"USB ID and bus", []DeviceFilter{WithUSBID(0x046d, 0x0825), WithBusInfo("platform:fake")},
+			[]string{"/fakedev/video0"}},
+	} {
+		devs, err := Enumerate(tc.filters...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var paths []string
+		for _, d := range devs {
+			paths = append(paths, d.Path)
+		}
+		if len(paths) != len(tc.paths) {
+			t.Errorf("%s: %v, want %v", tc.name, paths, tc.paths)
+			continue
+		}
+		for i := range paths {
+			if paths[i] != tc.paths[i] {
+				t.Errorf("%s: %v, want %v", tc.name, paths, tc.paths)
+				break
+			}
+		}
+	}
+}
+
+func TestFindDevice(t *testing.T) {
+	fakeSysfs(t)
+	info, err := FindDevice(WithBusInfo("platform:fake"), CapturesFormat(V4L2_PIX_FMT_RGB24))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Path != "/fakedev/video0" {
+		t.Errorf("found %s", info.Path)
+	}
+	d, err := info.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Close()
+
+	// the devnode has been taken by another device since
+	info.BusInfo = "usb-0000:00:14.0-1"
+	if _, err := info.Open(); !errors.Is(err, ErrorWrongDevice) {
+		t.Errorf("open renumbered devnode: %v, want ErrorWrongDevice", err)
+	}
+
+	if _, err := FindDevice(WithBusInfo("usb-0000:00:14.0-2")); err != ErrorNoDevice {
+		t.Errorf("find missing device: %v, want ErrorNoDevice", err)
+	}
+}
+
+func TestEnumerateWithoutSysfs(t *testing.T) {
+	sysfs := sysfsRoot
+	t.Cleanup(func() { sysfsRoot = sysfs })
+	sysfsRoot = filepath.Join(t.TempDir(), "sys")
+	devs, err := Enumerate()
+	if err != nil || len(devs) != 0 {
+		t.Errorf("%v, %v", devs, err)
+	}
+}
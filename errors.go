@@ -5,4 +5,17 @@ import "errors"
 var (
 	ErrorWrongDevice  = errors.New("Wrong V4L2 device")
 	ErrorNotSpecified = errors.New("Not specify device")
+	ErrorNoDevice     = errors.New("No matching V4L2 device")
+
+	ErrorUnsupportedCap = errors.New("Unsupported device capability")
+	ErrorUnknownFourCC  = errors.New("Unknown FourCC")
+	ErrorBufferAlloc    = errors.New("Buffer allocation failed")
+	ErrorDisconnected   = errors.New("V4L2 device disconnected")
+	ErrorUnexpectedType = errors.New("Unexpected type")
+	ErrorNoBuffers      = errors.New("No buffers queued")
+	ErrorFormatMismatch = errors.New("Format mismatch")
+	ErrorNoPlaneLayout  = errors.New("No plane layout of pixel format")
+
+	ErrorUnsupportedFormat = errors.New("Unsupported pixel format")
+	ErrorShortFrame        = errors.New("Frame shorter than its format")
 )
@@ -0,0 +1,85 @@
+package v4l2
+
+import (
+	"context"
+	"syscall"
+)
+
+// SubscribeEvents subscribes to every (type, id) pair in subs and starts a
+// goroutine which waits for exceptional conditions on the device and
+// delivers the dequeued events on the returned channel. When ctx is
+// cancelled, or the device fails, the goroutine unsubscribes from all
+// events and closes the channel.
+func (d *Device) SubscribeEvents(ctx context.Context, subs ...V4L2_Event_Subscription) (<-chan V4L2_Event, error) {
+	subs = append([]V4L2_Event_Subscription(nil), subs...)
+	for i := range subs {
+		if err := IoctlSubscribeEvent(d.FD, &subs[i]); err != nil {
+			unsubscribeEvents(d.FD, subs[:i])
+			return nil, err
+		}
+	}
+
+	p, err := newPoller(d.FD, syscall.EPOLLPRI)
+	if err != nil {
+		unsubscribeEvents(d.FD, subs)
+		return nil, err
+	}
+
+	events := make(chan V4L2_Event, 16)
+	done := make(chan struct{})
+	woken := make(chan struct{})
+	go func() {
+		defer close(woken)
+		select {
+		case <-ctx.Done():
+			p.wakeup()
+		case <-done:
+		}
+	}()
+	go func() {
+		dispatchEvents(ctx, d.FD, p, events)
+
+		// the pipe must stay open until the waker has returned
+		close(done)
+		<-woken
+		p.close()
+		unsubscribeEvents(d.FD, subs)
+		close(events)
+	}()
+	return events, nil
+}
+
+func unsubscribeEvents(fd int, subs []V4L2_Event_Subscription) {
+	for i := range subs {
+		IoctlUnsubscribeEvent(fd, &subs[i])
+	}
+}
+
+func dispatchEvents(ctx context.Context, fd int, p *poller, events chan<- V4L2_Event) {
+	for {
+		_, err := p.wait(-1)
+		if err != nil || ctx.Err() != nil {
+			return
+		}
+
+		// drain all pending events
+		for {
+			var ev V4L2_Event
+			err := IoctlDQEvent(fd, &ev)
+			if err == syscall.ENOENT {
+				break
+			}
+			if err != nil {
+				return
+			}
+			select {
+			case events <- ev:
+			case <-ctx.Done():
+				return
+			}
+			if ev.Pending == 0 {
+				break
+			}
+		}
+	}
+}
@@ -22,9 +22,8 @@ var packet = flag.Bool("p", false, "packet many frames, then write into a file")
 
 /* global varibales */
 var num_src_planes, num_dst_planes int
-var data_src_buf, data_dst_buf [][][]byte
+var src_queue, dst_queue *v4l2.BufferQueue
 var src_frame_size, dst_frame_size uint32
-var num_src_bufs, num_dst_bufs uint32
 
 func main() {
 	flag.Parse()
@@ -38,13 +37,21 @@ func main() {
 	var cam v4l2.Camera
 	cam.Device = *dev
 
-	cam.VerifyCaps()
+	if err := cam.VerifyCaps(); err != nil {
+		log.Fatal(err)
+	}
 	cam.Width = uint32(*width)
 	cam.Height = uint32(*height)
 	cam.PixFmtDescription = *fourcc
-	cam.SetFormat()
-	cam.AllocBuffers(4)
-	cam.TurnOn()
+	if err := cam.SetFormat(); err != nil {
+		log.Fatal(err)
+	}
+	if err := cam.AllocBuffers(4); err != nil {
+		log.Fatal(err)
+	}
+	if err := cam.TurnOn(); err != nil {
+		log.Fatal(err)
+	}
 	defer cam.TurnOff()
 
 	video_fd := InitCSCVideoNode()
@@ -63,49 +70,42 @@ func main() {
 }
 
 func process(cam *v4l2.Camera, video_fd int) {
-	var src_planes [v4l2.VIDEO_MAX_PLANES]v4l2.V4L2_Plane
-	var dst_planes [v4l2.VIDEO_MAX_PLANES]v4l2.V4L2_Plane
-	var src_buf, dst_buf v4l2.V4L2_Buffer
-
-	src_buf.Type = v4l2.V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE
-	src_buf.Memory = v4l2.V4L2_MEMORY_MMAP
-	src_buf.Index = 0
-	src_buf.M = v4l2.PointerToBytes(&src_planes[0])
-	src_buf.Length = uint32(num_src_planes)
-
-	dst_buf.Type = v4l2.V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE
-	dst_buf.Memory = v4l2.V4L2_MEMORY_MMAP
-	dst_buf.Index = 0
-	dst_buf.M = v4l2.PointerToBytes(&dst_planes[0])
-	dst_buf.Length = uint32(num_dst_planes)
+	src := src_queue.Bufs[0]
+	var dst *v4l2.Buffer
 
 	/* copy first frame into src buffer */
-	copy(data_src_buf[0][0], cam.Capture())
+	frame, err := cam.Capture()
+	if err != nil {
+		log.Fatal(err)
+	}
+	src.Planes[0].BytesUsed = uint32(copy(src.Planes[0].Data, frame))
 
 	var num_frames int
 	var file *os.File
 	for ; num_frames < *duration; num_frames++ {
 		if num_frames != 0 {
-			copy(data_src_buf[0][0], cam.Capture())
+			frame, err := cam.Capture()
+			if err != nil {
+				log.Fatal(err)
+			}
+			src.Planes[0].BytesUsed = uint32(copy(src.Planes[0].Data, frame))
 		}
 
-		err := v4l2.IoctlQBuf(video_fd, &src_buf)
+		err := src_queue.Queue(src)
 		if err != nil {
 			log.Fatalf("Failed to enqueue input buffer: %v", err)
 		}
-		err = v4l2.IoctlQBuf(video_fd, &dst_buf)
+		err = dst_queue.QueueAll()
 		if err != nil {
 			log.Fatalf("Failed to enqueue output buffer: %v", err)
 		}
 
 		if num_frames == 0 {
-			Type := v4l2.V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE
-			err := v4l2.IoctlStreamOn(video_fd, &Type)
+			err := dst_queue.StreamOn()
 			if err != nil {
 				log.Fatalf("Failed to stream on capture interface: %v", err)
 			}
-			Type = v4l2.V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE
-			err = v4l2.IoctlStreamOn(video_fd, &Type)
+			err = src_queue.StreamOn()
 			if err != nil {
 				log.Fatalf("Failed to stream on output interface: %v", err)
 			}
@@ -120,7 +120,7 @@ func process(cam *v4l2.Camera, video_fd int) {
 			log.Fatalf("select errors: %v\n", err)
 		}
 
-		err = v4l2.IoctlDQBuf(video_fd, &dst_buf)
+		dst, err = dst_queue.Dequeue()
 		if err != nil {
 			log.Fatalf("Failed to dequeue capture interface buffer: %v", err)
 		}
@@ -128,7 +128,7 @@ func process(cam *v4l2.Camera, video_fd int) {
 		/* write data into file */
 		if !*packet {
 			file, _ = os.OpenFile("test_UYVY"+strconv.Itoa(num_frames)+"_800_600.raw", os.O_RDWR|os.O_CREATE, 0644)
-			n, _ := file.Write(data_dst_buf[0][0][:dst_planes[0].BytesUsed])
+			n, _ := file.Write(dst.Planes[0].Bytes())
 			fmt.Printf("Write: %v\n", n)
 		} else if file.Fd() == uintptr(1<<uint(strconv.IntSize)-1) {
 			file, _ = os.OpenFile("video_NM12_"+strconv.Itoa(*duration)+".raw", os.O_RDWR|os.O_CREATE, 0644)
@@ -136,11 +136,11 @@ func process(cam *v4l2.Camera, video_fd int) {
 
 		if *packet {
 			for i := 0; i < num_dst_planes; i++ {
-				n, _ := file.Write(data_dst_buf[0][i][:dst_planes[i].BytesUsed])
+				n, _ := file.Write(dst.Planes[i].Bytes())
 				fmt.Printf("plane[%d]: Write: %v\n", i, n)
 			}
 		}
-		err = v4l2.IoctlDQBuf(video_fd, &src_buf)
+		_, err = src_queue.Dequeue()
 		if err != nil {
 			log.Fatalf("Failed to dequeue output interface buffer: %v", err)
 		}
@@ -186,9 +186,13 @@ func SetInputFormat(video_fd int) {
 	pixmp.Width = uint32(*width)
 	pixmp.Height = uint32(*height)
 	pixmp.Field = v4l2.V4L2_FIELD_ANY
-	pixmp.PixelFormat = v4l2.GetFourCCByName(*fourcc)
+	pixelformat, err := v4l2.GetFourCCByName(*fourcc)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pixmp.PixelFormat = pixelformat
 	format.Fmt = &pixmp
-	err := v4l2.IoctlSetFmt(video_fd, &format)
+	err = v4l2.IoctlSetFmt(video_fd, &format)
 	if err != nil {
 		log.Fatal("Failed to set input format")
 	}
@@ -219,9 +223,13 @@ func SetOutputFormat(video_fd int) {
 	pixmp.Height = uint32(*height)
 	pixmp.Field = v4l2.V4L2_FIELD_ANY
 	pixmp.PlaneFmt[0].BytesPerLine = uint32(*width)
-	pixmp.PixelFormat = v4l2.GetFourCCByName(*out_fourcc)
+	pixelformat, err := v4l2.GetFourCCByName(*out_fourcc)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pixmp.PixelFormat = pixelformat
 	format.Fmt = &pixmp
-	err := v4l2.IoctlSetFmt(video_fd, &format)
+	err = v4l2.IoctlSetFmt(video_fd, &format)
 	if err != nil {
 		log.Fatal("Failed to set output format")
 	}
@@ -237,134 +245,42 @@ func SetOutputFormat(video_fd int) {
 
 func AllocInputBuffers(video_fd int) {
 	/* request input buffer */
-	var reqbuf v4l2.V4L2_Requestbuffers
-	reqbuf.Count = 1
-	reqbuf.Type = v4l2.V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE
-	reqbuf.Memory = v4l2.V4L2_MEMORY_MMAP
-	err := v4l2.IoctlRequestBuffers(video_fd, &reqbuf)
+	src_queue = v4l2.NewBufferQueue(video_fd, v4l2.V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE,
+		v4l2.V4L2_MEMORY_MMAP)
+	err := src_queue.Alloc(1)
 	if err != nil {
-		log.Fatal("Failed to request input buffers")
-	}
-	if reqbuf.Count < 1 {
-		log.Fatal("Out of memory")
-	}
-	num_src_bufs = reqbuf.Count
-
-	data_src_buf = make([][][]byte, 0, num_src_bufs)
-	for index := 0; index < int(num_src_bufs); index++ {
-		/* get buffer parameters */
-		var buf v4l2.V4L2_Buffer
-		var planes [v4l2.VIDEO_MAX_PLANES]v4l2.V4L2_Plane
-
-		buf.Type = v4l2.V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE
-		buf.M = v4l2.PointerToBytes(&planes[0])
-		buf.Length = uint32(num_src_planes)
-		buf.Memory = v4l2.V4L2_MEMORY_MMAP
-		buf.Index = uint32(index)
-
-		err := v4l2.IoctlQueryBuf(video_fd, &buf)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		data_src_planes := make([][]byte, 0, num_src_planes)
-		for i := 0; i < num_src_planes; i++ {
-			var offset uint32
-			v4l2.GetValueFromUnion(planes[i].Union, &offset)
-			fmt.Printf("QUERYBUF: plane [%d]: Length: %v, bytesused: %v, offset: %v\n",
-				i, planes[i].Length, planes[i].BytesUsed, offset)
-
-			/* mmap input buffer */
-			buf, err := syscall.Mmap(video_fd, int64(offset), int(planes[i].Length),
-				syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
-			if err != nil {
-				log.Fatalf("mmap error: %v\n", err)
-			}
-			data_src_planes = append(data_src_planes, buf)
-		}
-		data_src_buf = append(data_src_buf, data_src_planes)
+		log.Fatalf("Failed to request input buffers: %v", err)
 	}
 }
 
 func AllocOutputBuffers(video_fd int) {
 	/* request output buffers */
-	var reqbuf v4l2.V4L2_Requestbuffers
-	reqbuf.Type = v4l2.V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE
-	reqbuf.Memory = v4l2.V4L2_MEMORY_MMAP
-	reqbuf.Count = 1
-	err := v4l2.IoctlRequestBuffers(video_fd, &reqbuf)
+	dst_queue = v4l2.NewBufferQueue(video_fd, v4l2.V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE,
+		v4l2.V4L2_MEMORY_MMAP)
+	err := dst_queue.Alloc(1)
 	if err != nil {
-		log.Fatal("Failed to request output buffer")
-	}
-	if reqbuf.Count < 1 {
-		log.Fatal("request output buffer: Out of memory")
-	}
-	num_dst_bufs = reqbuf.Count
-
-	data_dst_buf = make([][][]byte, 0, num_dst_bufs)
-	for index := 0; index < int(num_dst_bufs); index++ {
-		/* get buffer parameters */
-		var buf v4l2.V4L2_Buffer
-		var planes [v4l2.VIDEO_MAX_PLANES]v4l2.V4L2_Plane
-
-		buf.Type = v4l2.V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE
-		buf.M = v4l2.PointerToBytes(&planes[0])
-		buf.Length = uint32(num_dst_planes)
-		buf.Memory = v4l2.V4L2_MEMORY_MMAP
-		buf.Index = uint32(index)
-
-		err := v4l2.IoctlQueryBuf(video_fd, &buf)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		data_dst_planes := make([][]byte, 0, num_dst_planes)
-		for i := 0; i < num_dst_planes; i++ {
-			var offset uint32
-			v4l2.GetValueFromUnion(planes[i].Union, &offset)
-			fmt.Printf("QUERYBUF: plane [%d]: Length: %v, bytesused: %v, offset: %v\n",
-				i, planes[i].Length, planes[i].BytesUsed, offset)
-
-			/* mmap output buffer */
-			buf, err := syscall.Mmap(video_fd, int64(offset), int(planes[i].Length),
-				syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
-			if err != nil {
-				log.Fatalf("mmap error: %v\n", err)
-			}
-			data_dst_planes = append(data_dst_planes, buf)
-		}
-		data_dst_buf = append(data_dst_buf, data_dst_planes)
+		log.Fatalf("Failed to request output buffer: %v", err)
 	}
 }
 
 func FreeInputBuffers() {
-	for _, v := range data_src_buf {
-		for _, d := range v {
-			if err := syscall.Munmap(d); err != nil {
-				fmt.Printf("SRC: munmap error: %v\n", err)
-			}
-		}
+	if err := src_queue.Release(); err != nil {
+		fmt.Printf("SRC: release error: %v\n", err)
 	}
 }
 
 func FreeOutputBuffers() {
-	for _, v := range data_dst_buf {
-		for _, d := range v {
-			if err := syscall.Munmap(d); err != nil {
-				fmt.Printf("DST: munmap error: %v\n", err)
-			}
-		}
+	if err := dst_queue.Release(); err != nil {
+		fmt.Printf("DST: release error: %v\n", err)
 	}
 }
 
 func streamoff(video_fd int) {
-	Type := v4l2.V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE
-	err := v4l2.IoctlStreamOff(video_fd, &Type)
+	err := src_queue.StreamOff()
 	if err != nil {
 		log.Fatalf("Failed to stream off output interface: %v", err)
 	}
-	Type = v4l2.V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE
-	err = v4l2.IoctlStreamOff(video_fd, &Type)
+	err = dst_queue.StreamOff()
 	if err != nil {
 		log.Fatalf("Failed to stream off capture interface: %v", err)
 	}
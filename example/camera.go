@@ -11,17 +11,19 @@ import (
 	"github.com/Charleye/v4l2-go"
 )
 
-var device = flag.String("d", "/dev/video0", "camera device")
+var device = flag.String("d", "", "camera device, the first camera if empty")
+var businfo = flag.String("b", "", "bus info of the camera, e.g. usb-0000:00:14.0-1")
 var image = flag.Bool("i", false, "store frame into image file")
 var fourcc = flag.String("f", "", "set pixel format")
 var videoname = flag.String("v", "", "video name")
+var userptr = flag.Bool("u", false, "capture into user pointer buffers")
 
 var file *os.File
 
 func main() {
 	flag.Parse()
 
-	d, err := v4l2.Open(*device)
+	d, err := openCamera()
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -30,14 +32,27 @@ func main() {
 	var cam v4l2.Camera
 	cam.Device = *d
 
-	cam.VerifyCaps()
+	if err := cam.VerifyCaps(); err != nil {
+		log.Fatal(err)
+	}
 
 	cam.Width = 800
 	cam.Height = 600
-	cam.PixelFormat = v4l2.GetFourCCByName(*fourcc)
-	cam.SetFormat()
-	cam.AllocBuffers(4)
-	cam.TurnOn()
+	cam.PixFmtDescription = *fourcc
+	if err := cam.SetFormat(); err != nil {
+		log.Fatal(err)
+	}
+	if *userptr {
+		err = cam.AllocUserBuffers(4)
+	} else {
+		err = cam.AllocBuffers(4)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := cam.TurnOn(); err != nil {
+		log.Fatal(err)
+	}
 	defer cam.TurnOff()
 
 	if !*image {
@@ -57,7 +72,10 @@ func main() {
 				imagename := "in" + strconv.Itoa(counter) + "_" + *fourcc + "_800_600.raw"
 				file, _ = os.OpenFile(imagename, os.O_RDWR|os.O_CREATE, 0644)
 			}
-			data := cam.Capture()
+			data, err := cam.Capture()
+			if err != nil {
+				log.Fatal(err)
+			}
 			n, _ := file.Write(data)
 			fmt.Println("Write: ", n)
 			if *image {
@@ -67,3 +85,31 @@ func main() {
 		}
 	}
 }
+
+// openCamera opens the device of -d, or else finds a camera at the bus
+// of -b with the pixel format of -f, which keeps working when the devnodes
+// are renumbered
+func openCamera() (*v4l2.Device, error) {
+	if *device != "" {
+		return v4l2.Open(*device)
+	}
+	filters := []v4l2.DeviceFilter{
+		v4l2.HasCaps(v4l2.V4L2_CAP_VIDEO_CAPTURE | v4l2.V4L2_CAP_STREAMING),
+	}
+	if *businfo != "" {
+		filters = append(filters, v4l2.WithBusInfo(*businfo))
+	}
+	if *fourcc != "" {
+		pixfmt, err := v4l2.GetFourCCByName(*fourcc)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, v4l2.CapturesFormat(pixfmt))
+	}
+	info, err := v4l2.FindDevice(filters...)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("camera %s: %s at %s\n", info.Path, info.Card, info.BusInfo)
+	return info.Open()
+}
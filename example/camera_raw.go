@@ -43,7 +43,7 @@ func main() {
 	var pixfmt v4l2.V4L2_Pix_Format
 	pixfmt.Width = 800
 	pixfmt.Height = 600
-	pixfmt.PixelFormat = v4l2.GetFourCCByName("YUYV")
+	pixfmt.PixelFormat = v4l2.V4L2_PIX_FMT_YUYV
 	pixfmt.Priv = 0
 	format.Type = v4l2.V4L2_BUF_TYPE_VIDEO_CAPTURE
 	format.Fmt = &pixfmt
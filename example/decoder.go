@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	v4l2 "github.com/Charleye/v4l2-go"
+)
+
+var in = flag.String("f", "", "input file: H.264 or MPEG-4 elementary stream, VP8 in IVF")
+var out = flag.String("o", "out.raw", "output file")
+var video_node = flag.String("v", "", "decoder device")
+var codec = flag.String("c", "H264", "coded format: H264, VP8 or MPEG4")
+
+const chunk_size = 1024 * 1024
+
+func main() {
+	flag.Parse()
+
+	data, err := os.ReadFile(*in)
+	if err != nil {
+		log.Fatalf("Failed to read input file: %v", err)
+	}
+
+	var chunks [][]byte
+	var coded uint32
+	switch *codec {
+	case "H264":
+		coded = v4l2.V4L2_PIX_FMT_H264
+		chunks = split(data, []byte{0, 0, 1})
+	case "MPEG4":
+		coded = v4l2.V4L2_PIX_FMT_MPEG4
+		chunks = split(data, []byte{0, 0, 1, 0xb6})
+	case "VP8":
+		coded = v4l2.V4L2_PIX_FMT_VP8
+		chunks = splitIVF(data)
+	default:
+		log.Fatalf("Unsupported coded format: %s", *codec)
+	}
+
+	dec, err := v4l2.OpenDecoder(*video_node, coded, chunk_size, 4)
+	if err != nil {
+		log.Fatalf("Failed to open decoder %s: %v", *video_node, err)
+	}
+	defer dec.Close()
+
+	file, err := os.Create(*out)
+	if err != nil {
+		log.Fatalf("Failed to create output file: %v", err)
+	}
+	defer file.Close()
+
+	ctx := context.Background()
+	var num_frames int
+	write := func(frames []*v4l2.DecodedFrame) {
+		for _, f := range frames {
+			for _, plane := range f.Planes {
+				file.Write(plane)
+			}
+			num_frames++
+			fmt.Printf("frame %d: %dx%d %s\n", num_frames, f.Width, f.Height,
+				v4l2.GetNameByFourCC(f.PixelFormat))
+		}
+	}
+
+	for i, chunk := range chunks {
+		ts := time.Duration(i) * time.Microsecond
+		frames, err := dec.Decode(ctx, chunk, ts)
+		if err != nil {
+			log.Fatalf("Failed to decode chunk %d: %v", i, err)
+		}
+		write(frames)
+	}
+	frames, err := dec.Drain(ctx)
+	if err != nil {
+		log.Fatalf("Failed to drain decoder: %v", err)
+	}
+	write(frames)
+	fmt.Printf("Decoded %d frames into %s\n", num_frames, *out)
+}
+
+// split cuts an elementary stream in front of every start code
+func split(data, start_code []byte) [][]byte {
+	var chunks [][]byte
+	for len(data) > 0 {
+		next := bytes.Index(data[1:], start_code)
+		if next < 0 {
+			chunks = append(chunks, data)
+			break
+		}
+		chunks = append(chunks, data[:next+1])
+		data = data[next+1:]
+	}
+	return chunks
+}
+
+// splitIVF returns the frames of an IVF file
+func splitIVF(data []byte) [][]byte {
+	if len(data) < 32 || string(data[:4]) != "DKIF" {
+		log.Fatal("Not an IVF file")
+	}
+	header_size := int(binary.LittleEndian.Uint16(data[6:]))
+	data = data[header_size:]
+
+	var chunks [][]byte
+	for len(data) >= 12 {
+		size := int(binary.LittleEndian.Uint32(data))
+		data = data[12:]
+		if size > len(data) {
+			break
+		}
+		chunks = append(chunks, data[:size])
+		data = data[size:]
+	}
+	return chunks
+}
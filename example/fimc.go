@@ -20,14 +20,12 @@ var fourcc = flag.String("r", "", "pixel format")
 var data_input_file []byte
 var input_file_sz int64
 var num_src_planes int
-var num_src_bufs uint32
 var src_frame_size uint32
-var data_src_buf [][][]byte
+var src_queue *v4l2.BufferQueue
 
 var num_dst_planes int
-var num_dst_bufs uint32
-var data_dst_buf [][][]byte
 var dst_frame_size uint32
+var dst_queue *v4l2.BufferQueue
 
 func InitInputFile() int {
 	if *in == "" {
@@ -96,9 +94,13 @@ func main() {
 	pixmp.Width = uint32(*width)
 	pixmp.Height = uint32(*height)
 	pixmp.Field = v4l2.V4L2_FIELD_ANY
-	pixmp.PixelFormat = v4l2.GetFourCCByName(*fourcc)
+	pixelformat, err := v4l2.GetFourCCByName(*fourcc)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pixmp.PixelFormat = pixelformat
 	format.Fmt = &pixmp
-	err := v4l2.IoctlSetFmt(video_fd, &format)
+	err = v4l2.IoctlSetFmt(video_fd, &format)
 	if err != nil {
 		log.Fatal("Failed to set input format")
 	}
@@ -119,63 +121,17 @@ func main() {
 	fmt.Printf("width: %v, height: %v\n", *width, *height)
 
 	/* request input buffer */
-	var reqbuf v4l2.V4L2_Requestbuffers
-	reqbuf.Count = 1
-	reqbuf.Type = v4l2.V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE
-	reqbuf.Memory = v4l2.V4L2_MEMORY_MMAP
-	err = v4l2.IoctlRequestBuffers(video_fd, &reqbuf)
+	src_queue = v4l2.NewBufferQueue(video_fd, v4l2.V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE,
+		v4l2.V4L2_MEMORY_MMAP)
+	err = src_queue.Alloc(1)
 	if err != nil {
-		log.Fatal("Failed to request input buffers")
+		log.Fatalf("Failed to request input buffers: %v", err)
 	}
-	if reqbuf.Count < 1 {
-		log.Fatal("Out of memory")
-	}
-	num_src_bufs = reqbuf.Count
-
-	data_src_buf = make([][][]byte, 0, num_src_bufs)
-	for index := 0; index < int(num_src_bufs); index++ {
-		/* get buffer parameters */
-		var buf v4l2.V4L2_Buffer
-		var planes [v4l2.VIDEO_MAX_PLANES]v4l2.V4L2_Plane
-
-		buf.Type = v4l2.V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE
-		buf.M = v4l2.PointerToBytes(&planes[0])
-		buf.Length = uint32(num_src_planes)
-		buf.Memory = v4l2.V4L2_MEMORY_MMAP
-		buf.Index = uint32(index)
-
-		err := v4l2.IoctlQueryBuf(video_fd, &buf)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		data_src_planes := make([][]byte, 0, num_src_planes)
-		for i := 0; i < num_src_planes; i++ {
-			var offset uint32
-			v4l2.GetValueFromUnion(planes[i].Union, &offset)
-			fmt.Printf("QUERYBUF: plane [%d]: Length: %v, bytesused: %v, offset: %v\n",
-				i, planes[i].Length, planes[i].BytesUsed, offset)
-
-			/* mmap input buffer */
-			buf, err := syscall.Mmap(video_fd, int64(offset), int(planes[i].Length),
-				syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
-			if err != nil {
-				log.Fatalf("mmap error: %v\n", err)
-			}
-			data_src_planes = append(data_src_planes, buf)
-		}
-		data_src_buf = append(data_src_buf, data_src_planes)
-	}
-	defer func() {
-		for _, v := range data_src_buf {
-			for _, d := range v {
-				syscall.Munmap(d)
-			}
-		}
-	}()
+	defer src_queue.Release()
 
 	/* copy file data into the buffer */
-	copy(data_src_buf[0][0], data_input_file)
+	src := src_queue.Bufs[0]
+	src.Planes[0].BytesUsed = uint32(copy(src.Planes[0].Data, data_input_file))
 
 	/* set output format */
 	format.Type = v4l2.V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE
@@ -184,7 +140,7 @@ func main() {
 	pixmp.Height = uint32(*height)
 	pixmp.Field = v4l2.V4L2_FIELD_ANY
 	pixmp.PlaneFmt[0].BytesPerLine = uint32(*width)
-	pixmp.PixelFormat = v4l2.GetFourCCByName("UYVY")
+	pixmp.PixelFormat = v4l2.V4L2_PIX_FMT_UYVY
 	format.Fmt = &pixmp
 	err = v4l2.IoctlSetFmt(video_fd, &format)
 	if err != nil {
@@ -201,58 +157,13 @@ func main() {
 	fmt.Printf("DST framesize: %v\n", dst_frame_size)
 
 	/* request output buffers */
-	reqbuf.Type = v4l2.V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE
-	reqbuf.Count = 1
-	err = v4l2.IoctlRequestBuffers(video_fd, &reqbuf)
+	dst_queue = v4l2.NewBufferQueue(video_fd, v4l2.V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE,
+		v4l2.V4L2_MEMORY_MMAP)
+	err = dst_queue.Alloc(1)
 	if err != nil {
-		log.Fatal("Failed to request output buffer")
+		log.Fatalf("Failed to request output buffers: %v", err)
 	}
-	if reqbuf.Count < 1 {
-		log.Fatal("request output buffer: Out of memory")
-	}
-	num_dst_bufs = reqbuf.Count
-
-	data_dst_buf = make([][][]byte, 0, num_dst_bufs)
-	for index := 0; index < int(num_dst_bufs); index++ {
-		/* get buffer parameters */
-		var buf v4l2.V4L2_Buffer
-		var planes [v4l2.VIDEO_MAX_PLANES]v4l2.V4L2_Plane
-
-		buf.Type = v4l2.V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE
-		buf.M = v4l2.PointerToBytes(&planes[0])
-		buf.Length = uint32(num_dst_planes)
-		buf.Memory = v4l2.V4L2_MEMORY_MMAP
-		buf.Index = uint32(index)
-
-		err := v4l2.IoctlQueryBuf(video_fd, &buf)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		data_dst_planes := make([][]byte, 0, num_dst_planes)
-		for i := 0; i < num_dst_planes; i++ {
-			var offset uint32
-			v4l2.GetValueFromUnion(planes[i].Union, &offset)
-			fmt.Printf("QUERYBUF: plane [%d]: Length: %v, bytesused: %v, offset: %v\n",
-				i, planes[i].Length, planes[i].BytesUsed, offset)
-
-			/* mmap output buffer */
-			buf, err := syscall.Mmap(video_fd, int64(offset), int(planes[i].Length),
-				syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
-			if err != nil {
-				log.Fatalf("mmap error: %v\n", err)
-			}
-			data_dst_planes = append(data_dst_planes, buf)
-		}
-		data_dst_buf = append(data_dst_buf, data_dst_planes)
-	}
-	defer func() {
-		for _, v := range data_dst_buf {
-			for _, d := range v {
-				syscall.Munmap(d)
-			}
-		}
-	}()
+	defer dst_queue.Release()
 
 	/* process frames */
 	process(video_fd)
@@ -260,41 +171,25 @@ func main() {
 }
 
 func process(video_fd int) {
-	var src_planes [v4l2.VIDEO_MAX_PLANES]v4l2.V4L2_Plane
-	var dst_planes [v4l2.VIDEO_MAX_PLANES]v4l2.V4L2_Plane
-	var src_buf, dst_buf v4l2.V4L2_Buffer
-
-	src_buf.Type = v4l2.V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE
-	src_buf.Memory = v4l2.V4L2_MEMORY_MMAP
-	src_buf.Index = 0
-	src_buf.M = v4l2.PointerToBytes(&src_planes[0])
-	src_buf.Length = uint32(num_src_planes)
-
-	dst_buf.Type = v4l2.V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE
-	dst_buf.Memory = v4l2.V4L2_MEMORY_MMAP
-	dst_buf.Index = 0
-	dst_buf.M = v4l2.PointerToBytes(&dst_planes[0])
-	dst_buf.Length = uint32(num_dst_planes)
+	var dst *v4l2.Buffer
 
 	var num_frames int
 	for ; num_frames < 1; num_frames++ {
-		err := v4l2.IoctlQBuf(video_fd, &src_buf)
+		err := src_queue.QueueAll()
 		if err != nil {
 			log.Fatalf("Failed to enqueue input buffer: %v", err)
 		}
-		err = v4l2.IoctlQBuf(video_fd, &dst_buf)
+		err = dst_queue.QueueAll()
 		if err != nil {
 			log.Fatalf("Failed to enqueue output buffer: %v", err)
 		}
 
 		if num_frames == 0 {
-			Type := v4l2.V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE
-			err := v4l2.IoctlStreamOn(video_fd, &Type)
+			err := dst_queue.StreamOn()
 			if err != nil {
 				log.Fatalf("Failed to stream on capture interface: %v", err)
 			}
-			Type = v4l2.V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE
-			err = v4l2.IoctlStreamOn(video_fd, &Type)
+			err = src_queue.StreamOn()
 			if err != nil {
 				log.Fatalf("Failed to stream on output interface: %v", err)
 			}
@@ -310,11 +205,11 @@ func process(video_fd int) {
 			log.Fatalf("select errors: %v\n", err)
 		}
 
-		err = v4l2.IoctlDQBuf(video_fd, &dst_buf)
+		dst, err = dst_queue.Dequeue()
 		if err != nil {
 			log.Fatalf("Failed to dequeue capture interface buffer: %v", err)
 		}
-		err = v4l2.IoctlDQBuf(video_fd, &src_buf)
+		_, err = src_queue.Dequeue()
 		if err != nil {
 			log.Fatalf("Failed to dequeue output interface buffer: %v", err)
 		}
@@ -324,20 +219,18 @@ func process(video_fd int) {
 		log.Fatal("Failed to open output file")
 	}
 	fmt.Println("Generating output file...")
-	n, _ := out_file.Write(data_dst_buf[0][0][:dst_planes[0].BytesUsed])
+	n, _ := out_file.Write(dst.Planes[0].Bytes())
 	fmt.Println(n)
 	out_file.Close()
-	fmt.Printf("Output file: %s, size: %v\n", "out422_uyvy_800_600.raw", dst_planes[0].BytesUsed)
+	fmt.Printf("Output file: %s, size: %v\n", "out422_uyvy_800_600.raw", dst.Planes[0].BytesUsed)
 }
 
 func streamoff(video_fd int) {
-	Type := v4l2.V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE
-	err := v4l2.IoctlStreamOff(video_fd, &Type)
+	err := src_queue.StreamOff()
 	if err != nil {
 		log.Fatalf("Failed to stream off output interface: %v", err)
 	}
-	Type = v4l2.V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE
-	err = v4l2.IoctlStreamOff(video_fd, &Type)
+	err = dst_queue.StreamOff()
 	if err != nil {
 		log.Fatalf("Failed to stream off capture interface: %v", err)
 	}
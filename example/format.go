@@ -92,7 +92,7 @@ func main() {
 	vpfm := v4l2.V4L2_Pix_Format_Mplane{
 		Width:       1024,
 		Height:      768,
-		PixelFormat: v4l2.GetFourCCByName("NM12"),
+		PixelFormat: v4l2.V4L2_PIX_FMT_NV12M,
 	}
 	vpfm.NumPlanes = 2
 	vpfm.PlaneFmt[0].SizeImage = 0xC0000
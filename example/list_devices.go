@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Charleye/v4l2-go"
+)
+
+var m2m = flag.Bool("m", false, "list multi-planar mem2mem devices only")
+var capture = flag.String("c", "", "list devices capturing the pixel format only, e.g. MJPG")
+
+func formatNames(formats []uint32) string {
+	names := make([]string, len(formats))
+	for i, f := range formats {
+		names[i] = v4l2.GetNameByFourCC(f)
+	}
+	return strings.Join(names, " ")
+}
+
+func main() {
+	flag.Parse()
+
+	var filters []v4l2.DeviceFilter
+	if *m2m {
+		filters = append(filters, v4l2.HasCaps(v4l2.V4L2_CAP_VIDEO_M2M_MPLANE))
+	}
+	if *capture != "" {
+		pixfmt, err := v4l2.GetFourCCByName(*capture)
+		if err != nil {
+			log.Fatal(err)
+		}
+		filters = append(filters, v4l2.CapturesFormat(pixfmt))
+	}
+
+	devs, err := v4l2.Enumerate(filters...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, d := range devs {
+		fmt.Printf("%s: %s (index %d)\n", d.Path, d.Name, d.Index)
+		if d.Err != nil {
+			fmt.Printf("\tdriver %s: %v\n", d.Driver, d.Err)
+			continue
+		}
+		fmt.Printf("\tdriver %s, card %s, bus %s\n", d.Driver, d.Card, d.BusInfo)
+		fmt.Printf("\tdevice caps %#x\n", d.DeviceCaps)
+		if d.MediaDevice != "" {
+			fmt.Printf("\tmedia device %s\n", d.MediaDevice)
+		}
+		if d.VendorID != 0 || d.ProductID != 0 {
+			fmt.Printf("\tUSB %04x:%04x\n", d.VendorID, d.ProductID)
+		}
+		if len(d.CaptureFormats) > 0 {
+			fmt.Printf("\tcapture %s\n", formatNames(d.CaptureFormats))
+		}
+		if len(d.OutputFormats) > 0 {
+			fmt.Printf("\toutput %s\n", formatNames(d.OutputFormats))
+		}
+	}
+}
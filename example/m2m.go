@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	v4l2 "github.com/Charleye/v4l2-go"
+)
+
+var in = flag.String("f", "", "input file")
+var out = flag.String("o", "out.raw", "output file")
+var video_node = flag.String("v", "", "mem-to-mem device")
+var width = flag.Uint("w", 0, "width  in pixel")
+var height = flag.Uint("h", 0, "height in pixel")
+var in_fourcc = flag.String("r", "YUYV", "pixel format of input")
+var out_fourcc = flag.String("c", "UYVY", "pixel format of output")
+
+func main() {
+	flag.Parse()
+
+	data, err := os.ReadFile(*in)
+	if err != nil {
+		log.Fatalf("Failed to read input file: %v", err)
+	}
+
+	m, err := v4l2.OpenM2M(*video_node)
+	if err != nil {
+		log.Fatalf("Failed to open device %s: %v", *video_node, err)
+	}
+	defer m.Close()
+
+	src_fourcc, err := v4l2.GetFourCCByName(*in_fourcc)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dst_fourcc, err := v4l2.GetFourCCByName(*out_fourcc)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = m.SetSrcFormat(uint32(*width), uint32(*height), src_fourcc)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = m.SetDstFormat(uint32(*width), uint32(*height), dst_fourcc)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = m.Alloc(1, 1)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	frame, err := m.Process(context.Background(), [][]byte{data})
+	if err != nil {
+		log.Fatalf("Failed to process frame: %v", err)
+	}
+
+	file, err := os.Create(*out)
+	if err != nil {
+		log.Fatalf("Failed to create output file: %v", err)
+	}
+	defer file.Close()
+	for i, plane := range frame {
+		n, _ := file.Write(plane)
+		fmt.Printf("plane[%d]: Write: %v\n", i, n)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Charleye/v4l2-go/media"
+)
+
+var media_node = flag.String("d", "/dev/media0", "media device")
+var enable = flag.String("e", "", "enable link, e.g. \"sensor:0->isp:0\"")
+var disable = flag.String("x", "", "disable link, e.g. \"sensor:0->isp:0\"")
+
+func main() {
+	flag.Parse()
+
+	dev, err := media.Open(*media_node)
+	if err != nil {
+		log.Fatalf("Failed to open %s: %v", *media_node, err)
+	}
+	defer dev.Close()
+
+	fmt.Printf("driver: %s, model: %s, bus: %s\n",
+		dev.Info.Driver, dev.Info.Model, dev.Info.BusInfo)
+
+	graph, err := dev.Topology()
+	if err != nil {
+		log.Fatalf("Failed to get topology: %v", err)
+	}
+
+	if *enable != "" {
+		source, sink := parseLink(*enable)
+		if err := dev.EnableLink(graph, source, sink); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *disable != "" {
+		source, sink := parseLink(*disable)
+		if err := dev.DisableLink(graph, source, sink); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	for _, e := range graph.Entities {
+		fmt.Printf("entity %d: %s (function 0x%x, %d pads)\n",
+			e.ID, e.Name, e.Function, len(e.Pads))
+		if path, err := e.DevPath(); err == nil {
+			fmt.Printf("\tdevice node: %s\n", path)
+		}
+		for _, l := range e.Links {
+			if l.Source.Entity != e {
+				continue
+			}
+			state := "disabled"
+			if l.Flags&media.MEDIA_LNK_FL_ENABLED != 0 {
+				state = "enabled"
+			}
+			if l.Flags&media.MEDIA_LNK_FL_IMMUTABLE != 0 {
+				state += ", immutable"
+			}
+			fmt.Printf("\t%s [%s]\n", l, state)
+		}
+	}
+}
+
+func parseLink(s string) (string, string) {
+	source, sink, ok := strings.Cut(s, "->")
+	if !ok {
+		log.Fatalf("Invalid link %q", s)
+	}
+	return source, sink
+}
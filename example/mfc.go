@@ -1,316 +1,110 @@
+/* Tested on odroid-xu4 board */
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
-	"syscall"
+	"os"
+	"time"
 
 	v4l2 "github.com/Charleye/v4l2-go"
 )
 
 var input_file = flag.String("f", "", "input file")
+var output_file = flag.String("o", "out.h264", "output file")
 var mfc_node = flag.String("v", "", "MFC device node")
 var width = flag.Uint("w", 0, "width  in pixel")
 var height = flag.Uint("h", 0, "height in pixel")
 var fourcc = flag.String("r", "NM12", "pixel format for input interface")
-
-/* global varibales */
-var data_input_file []byte
-var input_file_size int64
-var num_src_planes, num_dst_planes int
-var data_src_buf, data_dst_buf [][][]byte
-var src_frame_size, dst_frame_size uint32
-var num_src_bufs, num_dst_bufs uint32
+var bitrate = flag.Uint("b", 2000000, "bitrate in bits per second")
+var gop = flag.Uint("g", 30, "GOP size")
+var fps = flag.Uint("p", 30, "frames per second")
 
 func main() {
 	flag.Parse()
 
-	file_fd := InitInputFile()
-	defer syscall.Close(file_fd)
-
-	video_fd := InitMFCVideoNode()
-	defer syscall.Close(video_fd)
-
-	SetInputFormat(video_fd)
-	AllocInputBuffers(video_fd)
-	defer FreeInputBuffers()
-
-	SetOutputFormat(video_fd)
-	AllocOutputBuffers(video_fd)
-	defer FreeOutputBuffers()
-}
-
-func InitInputFile() int {
 	if *input_file == "" {
 		log.Fatal("Failed to specify input file")
 	}
-
-	var st syscall.Stat_t
-	var fd int
-	fd, err := syscall.Open(*input_file, syscall.O_RDONLY, 0)
+	data, err := os.ReadFile(*input_file)
 	if err != nil {
-		log.Fatalf("Failed to open input file: %s", *input_file)
-	}
-	syscall.Fstat(fd, &st)
-	input_file_size = st.Size
-	fmt.Printf("input file size: %v\n", input_file_size)
-
-	data_input_file, err = syscall.Mmap(fd, 0, int(input_file_size),
-		syscall.PROT_READ, syscall.MAP_SHARED)
-
-	fmt.Println("len cap: ", len(data_input_file), cap(data_input_file))
-	return fd
-}
-
-func process(video_fd int) {
-	var src_planes [v4l2.VIDEO_MAX_PLANES]v4l2.V4L2_Plane
-	var dst_planes [v4l2.VIDEO_MAX_PLANES]v4l2.V4L2_Plane
-	var src_buf, dst_buf v4l2.V4L2_Buffer
-
-	src_buf.Type = v4l2.V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE
-	src_buf.Memory = v4l2.V4L2_MEMORY_MMAP
-	src_buf.M = v4l2.PointerToBytes(&src_planes[0])
-	src_buf.Length = uint32(num_src_planes)
-
-	for i := 0; i < int(num_dst_bufs); i++ {
-		copy(data_src_buf[i][0], data_input_file)
-		copy(data_src_buf[i][1], data_input_file)
-		src_buf.Index = uint32(i)
-		err := v4l2.IoctlQBuf(video_fd, &src_buf)
-		if err != nil {
-			fmt.Printf("Failed to enqueue buffer %d/%d to %d\n", i, num_dst_bufs, video_fd)
-		}
+		log.Fatalf("Failed to read input file: %v", err)
 	}
+	fmt.Printf("input file size: %v\n", len(data))
 
-	dst_buf.Type = v4l2.V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE
-	dst_buf.Memory = v4l2.V4L2_MEMORY_MMAP
-	dst_buf.Index = 0
-	dst_buf.M = v4l2.PointerToBytes(&dst_planes[0])
-	dst_buf.Length = uint32(num_dst_planes)
-
-}
-
-func InitMFCVideoNode() int {
-	vid_fd, err := syscall.Open(*mfc_node, syscall.O_RDWR|syscall.O_NONBLOCK, 0)
+	enc, err := v4l2.OpenEncoder(*mfc_node)
 	if err != nil {
-		log.Fatalf("Failed to open MFC node: %s", *mfc_node)
+		log.Fatalf("Failed to open MFC node %s: %v", *mfc_node, err)
 	}
+	defer enc.Close()
 
-	var caps v4l2.V4L2_Capability
-	err = v4l2.IoctlQueryCap(vid_fd, &caps)
+	pixelformat, err := v4l2.GetFourCCByName(*fourcc)
 	if err != nil {
-		log.Fatal("Failed to query capabilities")
+		log.Fatal(err)
 	}
-	if caps.Capabilities&v4l2.V4L2_CAP_DEVICE_CAPS != 0 {
-		if caps.DeviceCaps&v4l2.V4L2_CAP_VIDEO_M2M_MPLANE == 0 {
-			log.Fatalf("Device %s does not support multi-planes mem-to-mem (%#x)\n",
-				*mfc_node, caps.DeviceCaps)
-		}
-	} else {
-		if caps.Capabilities&v4l2.V4L2_CAP_VIDEO_CAPTURE_MPLANE == 0 {
-			log.Fatalf("Device %s does not support multi-planes capture (%#x)\n",
-				*mfc_node, caps.Capabilities)
-		}
-		if caps.Capabilities&v4l2.V4L2_CAP_VIDEO_OUTPUT_MPLANE == 0 {
-			log.Fatalf("Device %s does not support multi-planes output (%#x)\n",
-				*mfc_node, caps.Capabilities)
-		}
+	enc.CodedFormat = v4l2.V4L2_PIX_FMT_H264
+	enc.PixelFormat = pixelformat
+	enc.Width = uint32(*width)
+	enc.Height = uint32(*height)
+	enc.FrameRate = uint32(*fps)
+	enc.BufSize = 2 * 1024 * 1024
+	if err := enc.SetFormat(); err != nil {
+		log.Fatal(err)
 	}
-	return vid_fd
-}
+	fmt.Printf("width: %v, height: %v\n", enc.Width, enc.Height)
 
-func SetInputFormat(video_fd int) {
-	/* set input format */
-	var format v4l2.V4L2_Format
-	var pixmp v4l2.V4L2_Pix_Format_Mplane
-	format.Type = v4l2.V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE
-	pixmp.Width = uint32(*width)
-	pixmp.Height = uint32(*height)
-	pixmp.Field = v4l2.V4L2_FIELD_ANY
-	pixmp.PixelFormat = v4l2.GetFourCCByName(*fourcc)
-	pixmp.NumPlanes = 2
-	pixmp.PlaneFmt[0].BytesPerLine = 1280
-	pixmp.PlaneFmt[0].SizeImage = 0xE1000
-	pixmp.PlaneFmt[1].BytesPerLine = 1280
-	pixmp.PlaneFmt[1].SizeImage = 0x70800
-	format.Fmt = &pixmp
-	err := v4l2.IoctlSetFmt(video_fd, &format)
-	if err != nil {
-		log.Fatal("Failed to set input format")
+	if err := enc.SetRateControl(v4l2.V4L2_MPEG_VIDEO_BITRATE_MODE_CBR); err != nil {
+		log.Fatalf("Failed to set rate control: %v", err)
 	}
-	fmt.Println(planes)
-	num_src_planes = int(pixmp.NumPlanes)
-	for i := 0; i < num_src_planes; i++ {
-		src_frame_size += pixmp.PlaneFmt[i].SizeImage
-		fmt.Printf("plane[%d]: bytesperline: %d, sizeimage: %d\n",
-			i, pixmp.PlaneFmt[i].BytesPerLine, pixmp.PlaneFmt[i].SizeImage)
+	if err := enc.SetBitrate(uint32(*bitrate)); err != nil {
+		log.Fatalf("Failed to set bitrate: %v", err)
 	}
-	fmt.Printf("SRC frame_size: %v\n", src_frame_size)
-
-	err = v4l2.IoctlGetFmt(video_fd, &format)
-	if err != nil {
-		log.Fatal("Failed to get input format")
+	if err := enc.SetGOPSize(uint32(*gop)); err != nil {
+		log.Fatalf("Failed to set GOP size: %v", err)
 	}
-	*width = uint(pixmp.Width)
-	*height = uint(pixmp.Height)
-	fmt.Printf("width: %v, height: %v\n", *width, *height)
-}
-
-func SetOutputFormat(video_fd int) {
-	/* set output format */
-	var format v4l2.V4L2_Format
-	var pixmp v4l2.V4L2_Pix_Format_Mplane
-	format.Type = v4l2.V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE
-	pixmp.PixelFormat = v4l2.GetFourCCByName("H264")
-	pixmp.PlaneFmt[0].SizeImage = 2 * 1024 * 1024
-	pixmp.NumPlanes = 1
-	format.Fmt = &pixmp
-	err := v4l2.IoctlSetFmt(video_fd, &format)
-	if err != nil {
-		log.Fatal("Failed to set output format")
+	if err := enc.SetProfile(v4l2.V4L2_MPEG_VIDEO_H264_PROFILE_MAIN); err != nil {
+		log.Fatalf("Failed to set profile: %v", err)
 	}
-	num_dst_planes = int(pixmp.NumPlanes)
-	fmt.Printf("out_width: %v, out_height: %v\n", pixmp.Width, pixmp.Height)
-}
-
-func AllocInputBuffers(video_fd int) {
-	/* request input buffer */
-	var reqbuf v4l2.V4L2_Requestbuffers
-	reqbuf.Count = 16
-	reqbuf.Type = v4l2.V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE
-	reqbuf.Memory = v4l2.V4L2_MEMORY_MMAP
-	err := v4l2.IoctlRequestBuffers(video_fd, &reqbuf)
-	if err != nil {
-		log.Fatal("Failed to request input buffers")
+	if err := enc.SetLevel(v4l2.V4L2_MPEG_VIDEO_H264_LEVEL_4_0); err != nil {
+		log.Fatalf("Failed to set level: %v", err)
 	}
-	if reqbuf.Count < 1 {
-		log.Fatal("Out of memory")
+	if err := enc.AllocBuffers(4, 4); err != nil {
+		log.Fatal(err)
 	}
-	num_src_bufs = reqbuf.Count
-
-	data_src_buf = make([][][]byte, 0, num_src_bufs)
-	for index := 0; index < int(num_src_bufs); index++ {
-		/* get buffer parameters */
-		var buf v4l2.V4L2_Buffer
-		var planes [v4l2.VIDEO_MAX_PLANES]v4l2.V4L2_Plane
-
-		buf.Type = v4l2.V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE
-		buf.M = v4l2.PointerToBytes(&planes[0])
-		buf.Length = uint32(num_src_planes)
-		buf.Memory = v4l2.V4L2_MEMORY_MMAP
-		buf.Index = uint32(index)
-
-		err := v4l2.IoctlQueryBuf(video_fd, &buf)
-		if err != nil {
-			log.Fatal(err)
-		}
 
-		data_src_planes := make([][]byte, 0, num_src_planes)
-		for i := 0; i < num_src_planes; i++ {
-			var offset uint32
-			v4l2.GetValueFromUnion(planes[i].Union, &offset)
-			fmt.Printf("QUERYBUF: plane [%d]: Length: %v, bytesused: %v, offset: %v\n",
-				i, planes[i].Length, planes[i].BytesUsed, offset)
-
-			/* mmap input buffer */
-			buf, err := syscall.Mmap(video_fd, int64(offset), int(planes[i].Length),
-				syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
-			if err != nil {
-				log.Fatalf("mmap error: %v\n", err)
-			}
-			data_src_planes = append(data_src_planes, buf)
-		}
-		data_src_buf = append(data_src_buf, data_src_planes)
-	}
-}
-
-func AllocOutputBuffers(video_fd int) {
-	/* request output buffers */
-	var reqbuf v4l2.V4L2_Requestbuffers
-	reqbuf.Type = v4l2.V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE
-	reqbuf.Memory = v4l2.V4L2_MEMORY_MMAP
-	reqbuf.Count = 4
-	err := v4l2.IoctlRequestBuffers(video_fd, &reqbuf)
+	out_file, err := os.Create(*output_file)
 	if err != nil {
-		log.Fatalf("Failed to request output buffer: %v", err)
-	}
-	if reqbuf.Count < 1 {
-		log.Fatal("request output buffer: Out of memory")
-	}
-	num_dst_bufs = reqbuf.Count
-
-	data_dst_buf = make([][][]byte, 0, num_dst_bufs)
-	for index := 0; index < int(num_dst_bufs); index++ {
-		/* get buffer parameters */
-		var buf v4l2.V4L2_Buffer
-		var planes [v4l2.VIDEO_MAX_PLANES]v4l2.V4L2_Plane
-
-		buf.Type = v4l2.V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE
-		buf.M = v4l2.PointerToBytes(&planes[0])
-		buf.Length = v4l2.VIDEO_MAX_PLANES
-		buf.Memory = v4l2.V4L2_MEMORY_MMAP
-		buf.Index = uint32(index)
-
-		err := v4l2.IoctlQueryBuf(video_fd, &buf)
-		if err != nil {
-			log.Fatal(err)
-		}
-		data_dst_planes := make([][]byte, 0, num_dst_planes)
-		for i := 0; i < num_dst_planes; i++ {
-			var offset uint32
-			v4l2.GetValueFromUnion(planes[i].Union, &offset)
-			fmt.Printf("QUERYBUF: plane [%d]: Length: %v, bytesused: %v, offset: %v\n",
-				i, planes[i].Length, planes[i].BytesUsed, offset)
-
-			// mmap output buffer
-			buf, err := syscall.Mmap(video_fd, int64(offset), int(planes[i].Length),
-				syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
-			if err != nil {
-				log.Fatalf("mmap error: %v\n", err)
-			}
-			data_dst_planes = append(data_dst_planes, buf)
-		}
-		data_dst_buf = append(data_dst_buf, data_dst_planes)
-
-		err = v4l2.IoctlQBuf(video_fd, &buf)
-		if err != nil {
-			log.Fatalf("Failed to enqueue output buffer: %v", err)
-		} else {
-			fmt.Printf("Enqueued buffer %d/%d to %d\n", index, num_dst_bufs, video_fd)
-		}
+		log.Fatal("Failed to open output file")
 	}
-}
+	defer out_file.Close()
 
-func FreeInputBuffers() {
-	for _, v := range data_src_buf {
-		for _, d := range v {
-			if err := syscall.Munmap(d); err != nil {
-				fmt.Printf("SRC: munmap error: %v\n", err)
-			}
+	write := func(frames []*v4l2.EncodedFrame) {
+		for _, f := range frames {
+			n, _ := out_file.Write(f.Data)
+			fmt.Printf("frame %d: %d bytes, key frame: %v\n", f.Sequence, n, f.KeyFrame)
 		}
 	}
-}
 
-func FreeOutputBuffers() {
-	for _, v := range data_dst_buf {
-		for _, d := range v {
-			if err := syscall.Munmap(d); err != nil {
-				fmt.Printf("DST: munmap error: %v\n", err)
-			}
+	/* NV12M: Y plane followed by CbCr plane */
+	luma_size := int(enc.Width * enc.Height)
+	frame_size := luma_size * 3 / 2
+	ctx := context.Background()
+	for i := 0; (i+1)*frame_size <= len(data); i++ {
+		frame := data[i*frame_size : (i+1)*frame_size]
+		planes := [][]byte{frame[:luma_size], frame[luma_size:]}
+		ts := time.Duration(i) * time.Second / time.Duration(*fps)
+		frames, err := enc.Encode(ctx, planes, ts)
+		if err != nil {
+			log.Fatalf("Failed to encode frame %d: %v", i, err)
 		}
+		write(frames)
 	}
-}
-
-func streamoff(video_fd int) {
-	Type := v4l2.V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE
-	err := v4l2.IoctlStreamOff(video_fd, &Type)
-	if err != nil {
-		log.Fatalf("Failed to stream off output interface: %v", err)
-	}
-	Type = v4l2.V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE
-	err = v4l2.IoctlStreamOff(video_fd, &Type)
+	frames, err := enc.Drain(ctx)
 	if err != nil {
-		log.Fatalf("Failed to stream off capture interface: %v", err)
+		log.Fatalf("Failed to drain encoder: %v", err)
 	}
+	write(frames)
+	fmt.Printf("Output file: %s\n", *output_file)
 }
@@ -68,7 +68,7 @@ func main() {
 	vpf := v4l2.V4L2_Pix_Format{
 		Width:       1024,
 		Height:      768,
-		PixelFormat: v4l2.GetFourCCByName("YUYV"),
+		PixelFormat: v4l2.V4L2_PIX_FMT_YUYV,
 	}
 	vfmt.Fmt = &vpf
 	err = v4l2.IoctlSetFmt(fd, &vfmt)
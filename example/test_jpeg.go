@@ -91,7 +91,11 @@ func main() {
 	pixfmt.Height = uint32(*height)
 	pixfmt.SizeImage = uint32(input_file_sz)
 	if *mode == ENCODE {
-		pixfmt.PixelFormat = v4l2.GetFourCCByName(*fourcc)
+		pixelformat, err := v4l2.GetFourCCByName(*fourcc)
+		if err != nil {
+			log.Fatal(err)
+		}
+		pixfmt.PixelFormat = pixelformat
 	} else {
 		pixfmt.PixelFormat = v4l2.V4L2_PIX_FMT_JPEG
 	}
@@ -175,7 +179,11 @@ func main() {
 	pixfmt.Height = uint32(*height)
 	pixfmt.SizeImage = uint32(*width * (*height) * 4)
 	if *mode == DECODE {
-		pixfmt.PixelFormat = v4l2.GetFourCCByName(*fourcc)
+		pixelformat, err := v4l2.GetFourCCByName(*fourcc)
+		if err != nil {
+			log.Fatal(err)
+		}
+		pixfmt.PixelFormat = pixelformat
 	} else {
 		pixfmt.PixelFormat = v4l2.V4L2_PIX_FMT_JPEG
 	}
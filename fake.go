@@ -0,0 +1,854 @@
+package v4l2
+
+import (
+	"image"
+	"image/color"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// pixel formats, frame sizes and frame intervals of FakeDriver
+var (
+	fakeFormats = []uint32{
+		V4L2_PIX_FMT_YUYV,
+		V4L2_PIX_FMT_UYVY,
+		V4L2_PIX_FMT_NV12,
+		V4L2_PIX_FMT_YUV420,
+		V4L2_PIX_FMT_RGB24,
+		V4L2_PIX_FMT_GREY,
+	}
+	fakeSizes     = []V4L2_Frmsize_Discrete{{320, 240}, {640, 480}, {1280, 720}}
+	fakeIntervals = []V4L2_Fract{{1, 30}, {1, 15}}
+)
+
+// items of the V4L2_CID_TEST_PATTERN menu of FakeDriver
+const (
+	FakePatternColorBars = iota
+	FakePatternGradient
+	FakePatternCheckers
+)
+
+var fakePatterns = []string{"Color Bars", "Gradient", "Checkers"}
+
+const (
+	fakeVersion    = 6<<16 | 1<<8
+	fakeMaxBuffers = 32
+	fakeMaxEvents  = 32
+	fakePageSize   = 4096
+)
+
+// FakeDriver is a Backend emulating a webcam in memory, for testing without
+// hardware. It captures a test pattern in the single-planar formats YUYV,
+// UYVY, NV12, YUV420, RGB24 and GREY at 320x240, 640x480 and 1280x720 into
+// V4L2_MEMORY_MMAP or V4L2_MEMORY_USERPTR buffers, and has brightness,
+// contrast, horizontal flip and test pattern controls. Subscribers get
+// V4L2_EVENT_CTRL events on changes of the controls and
+// V4L2_EVENT_FRAME_SYNC events on every frame.
+//
+// Frames are captured on demand: VIDIOC_DQBUF fills the oldest queued
+// buffer right away, and fails with EAGAIN, as on a non-blocking file, when
+// no buffer is queued. The device polls readable while streaming with a
+// buffer queued.
+type FakeDriver struct {
+	mu        sync.Mutex
+	format    fakeFormat
+	memory    uint32
+	bufs      []*fakeBuffer
+	queued    []*fakeBuffer
+	streaming bool
+	sequence  uint32
+	ctrls     []*fakeControl
+	subs      map[fakeSub]bool
+	events    []v4l2_event
+	eventSeq  uint32
+	frame     []byte // the test pattern in format, nil when outdated
+}
+
+type fakeFormat struct {
+	fourcc        uint32
+	width, height uint32
+	bytesperline  uint32
+	sizeimage     uint32
+}
+
+type fakeBuffer struct {
+	index     uint32
+	offset    uint32 // of V4L2_MEMORY_MMAP memory
+	length    uint32
+	data      []byte
+	mapped    int
+	queued    bool
+	bytesused uint32
+	sequence  uint32
+	timestamp time.Duration
+}
+
+type fakeControl struct {
+	id, typ  uint32
+	name     string
+	min, max int32
+	step     int32
+	def      int32
+	flags    uint32
+	menu     []string
+	value    int32
+}
+
+type fakeSub struct {
+	typ, id uint32
+}
+
+// NewFakeDriver returns a FakeDriver capturing color bars in YUYV at
+// 640x480. It is opened as a Device with OpenBackend.
+func NewFakeDriver() *FakeDriver {
+	d := &FakeDriver{
+		ctrls: []*fakeControl{
+			{id: V4L2_CID_BRIGHTNESS, typ: V4L2_CTRL_TYPE_INTEGER, name: "Brightness",
+				min: 0, max: 255, step: 1, def: 128, flags: V4L2_CTRL_FLAG_SLIDER},
+			{id: V4L2_CID_CONTRAST, typ: V4L2_CTRL_TYPE_INTEGER, name: "Contrast",
+				min: 0, max: 255, step: 1, def: 128, flags: V4L2_CTRL_FLAG_SLIDER},
+			{id: V4L2_CID_HFLIP, typ: V4L2_CTRL_TYPE_BOOLEAN, name: "Horizontal Flip",
+				min: 0, max: 1, step: 1},
+			{id: V4L2_CID_TEST_PATTERN, typ: V4L2_CTRL_TYPE_MENU, name: "Test Pattern",
+				min: 0, max: int32(len(fakePatterns) - 1), step: 1, menu: fakePatterns},
+		},
+		subs: make(map[fakeSub]bool),
+	}
+	for _, c := range d.ctrls {
+		c.value = c.def
+	}
+	d.format = tryFakeFormat(V4L2_PIX_FMT_YUYV, 640, 480)
+	return d
+}
+
+// Ioctl implements Backend
+func (d *FakeDriver) Ioctl(request uint, argp unsafe.Pointer) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	switch request {
+	case VIDIOC_QUERYCAP:
+		d.queryCap((*v4l2_capability)(argp))
+		return nil
+	case VIDIOC_ENUM_FMT:
+		return d.enumFmt((*v4l2_fmtdesc)(argp))
+	case VIDIOC_ENUM_FRAMESIZES:
+		return d.enumFrameSizes((*v4l2_frmsizeenum)(argp))
+	case VIDIOC_ENUM_FRAMEINTERVALS:
+		return d.enumFrameIntervals((*v4l2_frmivalenum)(argp))
+	case VIDIOC_G_FMT, VIDIOC_S_FMT, VIDIOC_TRY_FMT:
+		return d.fmt(request, (*v4l2_format)(argp))
+	case VIDIOC_ENUMINPUT:
+		return d.enumInput((*v4l2_input)(argp))
+	case VIDIOC_G_INPUT:
+		*(*int32)(argp) = 0
+		return nil
+	case VIDIOC_S_INPUT:
+		if *(*int32)(argp) != 0 {
+			return syscall.EINVAL
+		}
+		return nil
+	case VIDIOC_REQBUFS:
+		return d.reqBufs((*v4l2_requestbuffers)(argp))
+	case VIDIOC_QUERYBUF:
+		return d.queryBuf((*v4l2_buffer)(argp))
+	case VIDIOC_QBUF:
+		return d.qBuf((*v4l2_buffer)(argp))
+	case VIDIOC_DQBUF:
+		return d.dqBuf((*v4l2_buffer)(argp))
+	case VIDIOC_STREAMON, VIDIOC_STREAMOFF:
+		return d.stream(request == VIDIOC_STREAMON, *(*int32)(argp))
+	case VIDIOC_QUERYCTRL:
+		return d.queryCtrl((*v4l2_queryctrl)(argp))
+	case VIDIOC_QUERY_EXT_CTRL:
+		return d.queryExtCtrl((*v4l2_query_ext_ctrl)(argp))
+	case VIDIOC_QUERYMENU:
+		return d.queryMenu((*v4l2_querymenu)(argp))
+	case VIDIOC_G_CTRL, VIDIOC_S_CTRL:
+		return d.ctrl(request, (*v4l2_control)(argp))
+	case VIDIOC_G_EXT_CTRLS, VIDIOC_S_EXT_CTRLS, VIDIOC_TRY_EXT_CTRLS:
+		return d.extCtrls(request, (*v4l2_ext_controls)(argp))
+	case VIDIOC_SUBSCRIBE_EVENT:
+		return d.subscribe((*v4l2_event_subscription)(argp))
+	case VIDIOC_UNSUBSCRIBE_EVENT:
+		d.unsubscribe((*v4l2_event_subscription)(argp))
+		return nil
+	case VIDIOC_DQEVENT:
+		return d.dqEvent((*v4l2_event)(argp))
+	}
+	return syscall.ENOTTY
+}
+
+// Mmap implements Backend
+func (d *FakeDriver) Mmap(offset int64, length int) ([]byte, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.memory != V4L2_MEMORY_MMAP {
+		return nil, syscall.EINVAL
+	}
+	for _, b := range d.bufs {
+		if int64(b.offset) == offset {
+			if length <= 0 || length > int(b.length) {
+				return nil, syscall.EINVAL
+			}
+			b.mapped++
+			return b.data[:length:length], nil
+		}
+	}
+	return nil, syscall.EINVAL
+}
+
+// Munmap implements Backend. Buffers freed while mapped stay valid until
+// they are unmapped.
+func (d *FakeDriver) Munmap(data []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, b := range d.bufs {
+		if b.mapped > 0 && unsafe.SliceData(b.data) == unsafe.SliceData(data) {
+			b.mapped--
+		}
+	}
+	return nil
+}
+
+// Poll implements Backend
+func (d *FakeDriver) Poll() uint32 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var events uint32
+	if d.streaming && len(d.queued) > 0 {
+		events |= syscall.EPOLLIN
+	}
+	if len(d.events) > 0 {
+		events |= syscall.EPOLLPRI
+	}
+	return events
+}
+
+// Close implements Backend, the driver returns to the state of
+// NewFakeDriver but for the format and the values of the controls
+func (d *FakeDriver) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.streaming = false
+	d.sequence = 0
+	d.bufs, d.queued = nil, nil
+	d.subs = make(map[fakeSub]bool)
+	d.events = nil
+	return nil
+}
+
+func (d *FakeDriver) queryCap(p *v4l2_capability) {
+	*p = v4l2_capability{}
+	setCString(p.driver[:], "fake")
+	setCString(p.card[:], "Fake Camera")
+	setCString(p.bus_info[:], "platform:fake")
+	p.version = fakeVersion
+	p.device_caps = V4L2_CAP_VIDEO_CAPTURE | V4L2_CAP_STREAMING
+	p.capabilities = p.device_caps | V4L2_CAP_DEVICE_CAPS
+}
+
+func (d *FakeDriver) enumFmt(p *v4l2_fmtdesc) error {
+	index := uint32(p.index)
+	if p._type != V4L2_BUF_TYPE_VIDEO_CAPTURE || index >= uint32(len(fakeFormats)) {
+		return syscall.EINVAL
+	}
+	pf, err := LookupPixelFormat(fakeFormats[index])
+	if err != nil {
+		return syscall.EINVAL
+	}
+	*p = v4l2_fmtdesc{}
+	p.index = __u32(index)
+	p._type = V4L2_BUF_TYPE_VIDEO_CAPTURE
+	setCString(p.description[:], pf.Description)
+	p.pixelformat = __u32(pf.FourCC)
+	return nil
+}
+
+func isFakeFormat(fourcc uint32) bool {
+	for _, f := range fakeFormats {
+		if f == fourcc {
+			return true
+		}
+	}
+	return false
+}
+
+func isFakeSize(width, height uint32) bool {
+	for _, s := range fakeSizes {
+		if s.Width == width && s.Height == height {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *FakeDriver) enumFrameSizes(p *v4l2_frmsizeenum) error {
+	index := uint32(p.index)
+	if !isFakeFormat(uint32(p.pixel_format)) || index >= uint32(len(fakeSizes)) {
+		return syscall.EINVAL
+	}
+	p._type = V4L2_FRMSIZE_TYPE_DISCRETE
+	s := (*v4l2_frmsize_discrete)(unsafe.Pointer(&p.anon0))
+	s.width = __u32(fakeSizes[index].Width)
+	s.height = __u32(fakeSizes[index].Height)
+	return nil
+}
+
+func (d *FakeDriver) enumFrameIntervals(p *v4l2_frmivalenum) error {
+	index := uint32(p.index)
+	if !isFakeFormat(uint32(p.pixel_format)) ||
+		!isFakeSize(uint32(p.width), uint32(p.height)) ||
+		index >= uint32(len(fakeIntervals)) {
+		return syscall.EINVAL
+	}
+	p._type = V4L2_FRMIVAL_TYPE_DISCRETE
+	f := (*v4l2_fract)(unsafe.Pointer(&p.anon0))
+	f.numerator = __u32(fakeIntervals[index].Numerator)
+	f.denominator = __u32(fakeIntervals[index].Denominator)
+	return nil
+}
+
+// tryFakeFormat returns the format closest to fourcc, width x height: the
+// first format if fourcc is unsupported and the nearest frame size
+func tryFakeFormat(fourcc, width, height uint32) fakeFormat {
+	if !isFakeFormat(fourcc) {
+		fourcc = fakeFormats[0]
+	}
+	dist := func(s V4L2_Frmsize_Discrete) int64 {
+		dw, dh := int64(s.Width)-int64(width), int64(s.Height)-int64(height)
+		return max(dw, -dw) + max(dh, -dh)
+	}
+	size := fakeSizes[0]
+	for _, s := range fakeSizes[1:] {
+		if dist(s) < dist(size) {
+			size = s
+		}
+	}
+	f := fakeFormat{fourcc: fourcc, width: size.Width, height: size.Height}
+	pf, _ := LookupPixelFormat(fourcc)
+	f.bytesperline, f.sizeimage, _ = pf.SizeImage(f.width, f.height, 1)
+	return f
+}
+
+func (d *FakeDriver) fmt(request uint, p *v4l2_format) error {
+	if p._type != V4L2_BUF_TYPE_VIDEO_CAPTURE {
+		return syscall.EINVAL
+	}
+	pix := (*v4l2_pix_format)(unsafe.Pointer(&p.fmt))
+	f := d.format
+	if request != VIDIOC_G_FMT {
+		f = tryFakeFormat(uint32(pix.pixelformat), uint32(pix.width), uint32(pix.height))
+	}
+	if request == VIDIOC_S_FMT && f != d.format {
+		if len(d.bufs) > 0 {
+			return syscall.EBUSY
+		}
+		d.format, d.frame = f, nil
+	}
+	*pix = v4l2_pix_format{}
+	pix.width = __u32(f.width)
+	pix.height = __u32(f.height)
+	pix.pixelformat = __u32(f.fourcc)
+	pix.field = V4L2_FIELD_NONE
+	pix.bytesperline = __u32(f.bytesperline)
+	pix.sizeimage = __u32(f.sizeimage)
+	pix.colorspace = V4L2_COLORSPACE_SRGB
+	return nil
+}
+
+func (d *FakeDriver) enumInput(p *v4l2_input) error {
+	if p.index != 0 {
+		return syscall.EINVAL
+	}
+	*p = v4l2_input{}
+	setCString(p.name[:], "Test Pattern")
+	p._type = V4L2_INPUT_TYPE_CAMERA
+	return nil
+}
+
+func (d *FakeDriver) reqBufs(p *v4l2_requestbuffers) error {
+	if p._type != V4L2_BUF_TYPE_VIDEO_CAPTURE ||
+		p.memory != V4L2_MEMORY_MMAP && p.memory != V4L2_MEMORY_USERPTR {
+		return syscall.EINVAL
+	}
+	if d.streaming {
+		return syscall.EBUSY
+	}
+
+	// mapped buffers are orphaned, their memory stays valid
+	d.bufs, d.queued = nil, nil
+	count := min(uint32(p.count), fakeMaxBuffers)
+	d.memory = uint32(p.memory)
+	var offset uint32
+	for i := uint32(0); i < count; i++ {
+		b := &fakeBuffer{index: i, length: d.format.sizeimage}
+		if d.memory == V4L2_MEMORY_MMAP {
+			b.offset = offset
+			b.data = make([]byte, b.length)
+			offset += roundUp(b.length, fakePageSize)
+		}
+		d.bufs = append(d.bufs, b)
+	}
+
+	p.count = __u32(count)
+	p.capabilities = V4L2_BUF_CAP_SUPPORTS_MMAP | V4L2_BUF_CAP_SUPPORTS_USERPTR |
+		V4L2_BUF_CAP_SUPPORTS_ORPHANED_BUFS
+	p.flags = 0
+	return nil
+}
+
+// buffer returns the buffer p refers to
+func (d *FakeDriver) buffer(p *v4l2_buffer) (*fakeBuffer, error) {
+	if p._type != V4L2_BUF_TYPE_VIDEO_CAPTURE || uint32(p.index) >= uint32(len(d.bufs)) {
+		return nil, syscall.EINVAL
+	}
+	return d.bufs[p.index], nil
+}
+
+// fillBuffer reports the state of b in p
+func (d *FakeDriver) fillBuffer(p *v4l2_buffer, b *fakeBuffer, flags uint32) {
+	flags |= V4L2_BUF_FLAG_TIMESTAMP_MONOTONIC | V4L2_BUF_FLAG_TSTAMP_SRC_EOF
+	if b.queued {
+		flags |= V4L2_BUF_FLAG_QUEUED
+	}
+	if b.mapped > 0 {
+		flags |= V4L2_BUF_FLAG_MAPPED
+	}
+
+	p.index = __u32(b.index)
+	p._type = V4L2_BUF_TYPE_VIDEO_CAPTURE
+	p.bytesused = __u32(b.bytesused)
+	p.flags = __u32(flags)
+	p.field = V4L2_FIELD_NONE
+	setTimeval(&p.timestamp.tv_sec, &p.timestamp.tv_usec, b.timestamp)
+	p.timecode = v4l2_timecode{}
+	p.sequence = __u32(b.sequence)
+	p.memory = __u32(d.memory)
+	clear(p.m[:])
+	switch {
+	case d.memory == V4L2_MEMORY_MMAP:
+		*(*__u32)(unsafe.Pointer(&p.m)) = __u32(b.offset)
+	case b.data != nil:
+		*(*unsafe.Pointer)(unsafe.Pointer(&p.m)) = unsafe.Pointer(unsafe.SliceData(b.data))
+	}
+	p.length = __u32(b.length)
+}
+
+func (d *FakeDriver) queryBuf(p *v4l2_buffer) error {
+	b, err := d.buffer(p)
+	if err != nil {
+		return err
+	}
+	d.fillBuffer(p, b, 0)
+	return nil
+}
+
+func (d *FakeDriver) qBuf(p *v4l2_buffer) error {
+	b, err := d.buffer(p)
+	if err != nil {
+		return err
+	}
+	if uint32(p.memory) != d.memory || b.queued {
+		return syscall.EINVAL
+	}
+	if d.memory == V4L2_MEMORY_USERPTR {
+		ptr := *(*unsafe.Pointer)(unsafe.Pointer(&p.m))
+		length := uint32(p.length)
+		if ptr == nil || length < d.format.sizeimage {
+			return syscall.EINVAL
+		}
+		b.data = unsafe.Slice((*byte)(ptr), length)
+		b.length = length
+	}
+	b.queued = true
+	d.queued = append(d.queued, b)
+	d.fillBuffer(p, b, 0)
+	return nil
+}
+
+func (d *FakeDriver) dqBuf(p *v4l2_buffer) error {
+	if p._type != V4L2_BUF_TYPE_VIDEO_CAPTURE || uint32(p.memory) != d.memory ||
+		!d.streaming {
+		return syscall.EINVAL
+	}
+	if len(d.queued) == 0 {
+		return syscall.EAGAIN
+	}
+	b := d.queued[0]
+	d.queued = d.queued[1:]
+	d.capture(b)
+	d.fillBuffer(p, b, V4L2_BUF_FLAG_DONE)
+	return nil
+}
+
+// capture fills b with the next frame
+func (d *FakeDriver) capture(b *fakeBuffer) {
+	b.queued = false
+	b.bytesused = uint32(copy(b.data, d.pattern()))
+	b.sequence = d.sequence
+	b.timestamp = monotonicNow()
+	d.sequence++
+
+	d.queueEvent(V4L2_EVENT_FRAME_SYNC, 0, func(u unsafe.Pointer) {
+		(*v4l2_event_frame_sync)(u).frame_sequence = __u32(b.sequence)
+	})
+}
+
+func (d *FakeDriver) stream(on bool, bufType int32) error {
+	if bufType != V4L2_BUF_TYPE_VIDEO_CAPTURE {
+		return syscall.EINVAL
+	}
+	if on {
+		if len(d.bufs) == 0 {
+			return syscall.EINVAL
+		}
+		d.streaming = true
+		return nil
+	}
+
+	// all buffers return to userspace
+	d.streaming = false
+	d.sequence = 0
+	for _, b := range d.bufs {
+		b.queued = false
+	}
+	d.queued = nil
+	return nil
+}
+
+// control returns the control id, or the first control after it with
+// V4L2_CTRL_FLAG_NEXT_CTRL
+func (d *FakeDriver) control(id uint32) *fakeControl {
+	next := id&(V4L2_CTRL_FLAG_NEXT_CTRL|V4L2_CTRL_FLAG_NEXT_COMPOUND) != 0
+	id &^= V4L2_CTRL_FLAG_NEXT_CTRL | V4L2_CTRL_FLAG_NEXT_COMPOUND
+	for _, c := range d.ctrls {
+		if c.id == id && !next || c.id > id && next {
+			return c
+		}
+	}
+	return nil
+}
+
+func (d *FakeDriver) queryCtrl(p *v4l2_queryctrl) error {
+	c := d.control(uint32(p.id))
+	if c == nil {
+		return syscall.EINVAL
+	}
+	*p = v4l2_queryctrl{}
+	p.id = __u32(c.id)
+	p._type = __u32(c.typ)
+	setCString(p.name[:], c.name)
+	p.minimum = __s32(c.min)
+	p.maximum = __s32(c.max)
+	p.step = __s32(c.step)
+	p.default_value = __s32(c.def)
+	p.flags = __u32(c.flags)
+	return nil
+}
+
+func (d *FakeDriver) queryExtCtrl(p *v4l2_query_ext_ctrl) error {
+	c := d.control(uint32(p.id))
+	if c == nil {
+		return syscall.EINVAL
+	}
+	*p = v4l2_query_ext_ctrl{}
+	p.id = __u32(c.id)
+	p._type = __u32(c.typ)
+	setCString(p.name[:], c.name)
+	p.minimum = __s64(c.min)
+	p.maximum = __s64(c.max)
+	p.step = __u64(c.step)
+	p.default_value = __s64(c.def)
+	p.flags = __u32(c.flags)
+	p.elem_size = 4
+	p.elems = 1
+	return nil
+}
+
+func (d *FakeDriver) queryMenu(p *v4l2_querymenu) error {
+	c := d.control(uint32(p.id))
+	index := uint32(p.index)
+	if c == nil || c.typ != V4L2_CTRL_TYPE_MENU || index >= uint32(len(c.menu)) {
+		return syscall.EINVAL
+	}
+	name := unsafe.Slice((*__u8)(unsafe.Pointer(&p.anon0)), 32)
+	setCString(name, c.menu[index])
+	return nil
+}
+
+// validate returns value adjusted to the control the way the kernel does
+func (c *fakeControl) validate(value int32) (int32, error) {
+	switch c.typ {
+	case V4L2_CTRL_TYPE_BOOLEAN:
+		if value != 0 {
+			return 1, nil
+		}
+		return 0, nil
+	case V4L2_CTRL_TYPE_MENU:
+		if value < c.min || value > c.max {
+			return 0, syscall.ERANGE
+		}
+		return value, nil
+	}
+	value = min(max(value, c.min), c.max)
+	return c.min + (value-c.min+c.step/2)/c.step*c.step, nil
+}
+
+func (d *FakeDriver) setControl(c *fakeControl, value int32) {
+	if c.value == value {
+		return
+	}
+	c.value = value
+	d.frame = nil
+	d.queueCtrlEvent(c, V4L2_EVENT_CTRL_CH_VALUE)
+}
+
+func (d *FakeDriver) ctrl(request uint, p *v4l2_control) error {
+	c := d.control(uint32(p.id))
+	if c == nil {
+		return syscall.EINVAL
+	}
+	if request == VIDIOC_S_CTRL {
+		value, err := c.validate(int32(p.value))
+		if err != nil {
+			return err
+		}
+		d.setControl(c, value)
+	}
+	p.value = __s32(c.value)
+	return nil
+}
+
+func (d *FakeDriver) extCtrls(request uint, p *v4l2_ext_controls) error {
+	which := uint32(*(*__u32)(unsafe.Pointer(&p.anon0)))
+	if which == V4L2_CTRL_WHICH_DEF_VAL && request != VIDIOC_G_EXT_CTRLS {
+		return syscall.EINVAL
+	}
+	if p.count == 0 {
+		return nil
+	}
+	if p.controls == nil {
+		return syscall.EFAULT
+	}
+
+	ctrls := unsafe.Slice(p.controls, p.count)
+	found := make([]*fakeControl, len(ctrls))
+	values := make([]int32, len(ctrls))
+	for i := range ctrls {
+		id := uint32(ctrls[i].id)
+		c := d.control(id)
+		if c == nil || which != 0 && which != V4L2_CTRL_WHICH_DEF_VAL && ctrlID2Which(id) != which {
+			p.error_idx = __u32(i)
+			return syscall.EINVAL
+		}
+		value := (*__s32)(unsafe.Pointer(&ctrls[i].anon0))
+		switch {
+		case request == VIDIOC_G_EXT_CTRLS && which == V4L2_CTRL_WHICH_DEF_VAL:
+			*value = __s32(c.def)
+		case request == VIDIOC_G_EXT_CTRLS:
+			*value = __s32(c.value)
+		default:
+			v, err := c.validate(int32(*value))
+			if err != nil {
+				p.error_idx = __u32(i)
+				return err
+			}
+			*value = __s32(v)
+		}
+		found[i], values[i] = c, int32(*value)
+	}
+	if request == VIDIOC_S_EXT_CTRLS {
+		for i, c := range found {
+			d.setControl(c, values[i])
+		}
+	}
+	return nil
+}
+
+func (d *FakeDriver) subscribe(p *v4l2_event_subscription) error {
+	sub := fakeSub{uint32(p._type), uint32(p.id)}
+	var c *fakeControl
+	switch sub.typ {
+	case V4L2_EVENT_CTRL:
+		if c = d.control(sub.id); c == nil || c.id != sub.id {
+			return syscall.EINVAL
+		}
+	case V4L2_EVENT_FRAME_SYNC:
+	default:
+		return syscall.EINVAL
+	}
+	if d.subs[sub] {
+		return nil
+	}
+	d.subs[sub] = true
+	if c != nil && p.flags&V4L2_EVENT_SUB_FL_SEND_INITIAL != 0 {
+		d.queueCtrlEvent(c, V4L2_EVENT_CTRL_CH_VALUE|V4L2_EVENT_CTRL_CH_FLAGS|
+			V4L2_EVENT_CTRL_CH_RANGE)
+	}
+	return nil
+}
+
+func (d *FakeDriver) unsubscribe(p *v4l2_event_subscription) {
+	sub := fakeSub{uint32(p._type), uint32(p.id)}
+	if sub.typ == V4L2_EVENT_ALL {
+		d.subs = make(map[fakeSub]bool)
+		d.events = nil
+		return
+	}
+	delete(d.subs, sub)
+
+	// pending events of the subscription are dropped with it
+	events := d.events[:0]
+	for _, e := range d.events {
+		if uint32(e._type) != sub.typ || uint32(e.id) != sub.id {
+			events = append(events, e)
+		}
+	}
+	d.events = events
+}
+
+// queueEvent queues the event typ, id for its subscriber, fill sets its
+// payload. The oldest event is dropped when the queue is full.
+func (d *FakeDriver) queueEvent(typ, id uint32, fill func(u unsafe.Pointer)) {
+	if !d.subs[fakeSub{typ, id}] {
+		return
+	}
+	var e v4l2_event
+	e._type = __u32(typ)
+	e.id = __u32(id)
+	fill(unsafe.Pointer(&e.u))
+	e.sequence = __u32(d.eventSeq)
+	setTimespec(&e.timestamp.tv_sec, &e.timestamp.tv_nsec, monotonicNow())
+	d.eventSeq++
+	if len(d.events) == fakeMaxEvents {
+		d.events = d.events[1:]
+	}
+	d.events = append(d.events, e)
+}
+
+func (d *FakeDriver) queueCtrlEvent(c *fakeControl, changes uint32) {
+	d.queueEvent(V4L2_EVENT_CTRL, c.id, func(u unsafe.Pointer) {
+		p := (*v4l2_event_ctrl)(u)
+		p.changes = __u32(changes)
+		p._type = __u32(c.typ)
+		*(*__s32)(unsafe.Pointer(&p.anon0)) = __s32(c.value)
+		p.flags = __u32(c.flags)
+		p.minimum = __s32(c.min)
+		p.maximum = __s32(c.max)
+		p.step = __s32(c.step)
+		p.default_value = __s32(c.def)
+	})
+}
+
+func (d *FakeDriver) dqEvent(p *v4l2_event) error {
+	if len(d.events) == 0 {
+		return syscall.ENOENT
+	}
+	*p = d.events[0]
+	d.events = d.events[1:]
+	p.pending = __u32(len(d.events))
+	return nil
+}
+
+// pattern returns the test pattern as a frame of the current format
+func (d *FakeDriver) pattern() []byte {
+	if d.frame != nil {
+		return d.frame
+	}
+	f := d.format
+	format := V4L2_Format{
+		Type: V4L2_BUF_TYPE_VIDEO_CAPTURE,
+		Fmt: &V4L2_Pix_Format{
+			Width:        f.width,
+			Height:       f.height,
+			PixelFormat:  f.fourcc,
+			Field:        V4L2_FIELD_NONE,
+			BytesPerLine: f.bytesperline,
+			SizeImage:    f.sizeimage,
+			ColorSpace:   V4L2_COLORSPACE_SRGB,
+		},
+	}
+	img := &fakePattern{w: int(f.width), h: int(f.height)}
+	for _, c := range d.ctrls {
+		switch c.id {
+		case V4L2_CID_BRIGHTNESS:
+			img.brightness = int(c.value)
+		case V4L2_CID_CONTRAST:
+			img.contrast = int(c.value)
+		case V4L2_CID_HFLIP:
+			img.hflip = c.value != 0
+		case V4L2_CID_TEST_PATTERN:
+			img.kind = int(c.value)
+		}
+	}
+	d.frame = make([]byte, f.sizeimage)
+	WriteImage(&format, img, d.frame)
+	return d.frame
+}
+
+// fakeBars are the colors of FakePatternColorBars from left to right
+var fakeBars = []color.RGBA{
+	{0xff, 0xff, 0xff, 0xff}, // white
+	{0xff, 0xff, 0x00, 0xff}, // yellow
+	{0x00, 0xff, 0xff, 0xff}, // cyan
+	{0x00, 0xff, 0x00, 0xff}, // green
+	{0xff, 0x00, 0xff, 0xff}, // magenta
+	{0xff, 0x00, 0x00, 0xff}, // red
+	{0x00, 0x00, 0xff, 0xff}, // blue
+	{0x00, 0x00, 0x00, 0xff}, // black
+}
+
+// fakePattern is a test pattern adjusted by the controls of FakeDriver
+type fakePattern struct {
+	kind                 int
+	w, h                 int
+	hflip                bool
+	brightness, contrast int // 128 leaves the pattern as it is
+}
+
+func (p *fakePattern) ColorModel() color.Model {
+	return color.RGBAModel
+}
+
+func (p *fakePattern) Bounds() image.Rectangle {
+	return image.Rect(0, 0, p.w, p.h)
+}
+
+func (p *fakePattern) At(x, y int) color.Color {
+	if p.hflip {
+		x = p.w - 1 - x
+	}
+	var c color.RGBA
+	switch p.kind {
+	case FakePatternGradient:
+		v := uint8(x * 255 / max(p.w-1, 1))
+		c = color.RGBA{v, v, v, 0xff}
+	case FakePatternCheckers:
+		c = fakeBars[7]
+		if (x/16+y/16)%2 == 0 {
+			c = fakeBars[0]
+		}
+	default:
+		c = fakeBars[x*len(fakeBars)/p.w]
+	}
+	c.R, c.G, c.B = p.adjust(c.R), p.adjust(c.G), p.adjust(c.B)
+	return c
+}
+
+func (p *fakePattern) adjust(v uint8) uint8 {
+	a := (int(v)-128)*p.contrast/128 + p.brightness
+	return uint8(min(max(a, 0), 255))
+}
+
+// setCString stores s in dst NUL terminated, truncated to fit
+func setCString[T ~int8 | ~uint8](dst []T, s string) {
+	for i := range dst {
+		dst[i] = 0
+		if i < len(dst)-1 && i < len(s) {
+			dst[i] = T(s[i])
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package v4l2
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// openFake opens a FakeDriver, which is closed when the test ends
+func openFake(t *testing.T) (*Device, *FakeDriver) {
+	t.Helper()
+	drv := NewFakeDriver()
+	d, err := OpenBackend(drv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(d.Close)
+	return d, drv
+}
+
+// setFakeFormat sets the capture format of d and returns what the driver
+// made of it
+func setFakeFormat(t *testing.T, d *Device, fourcc, width, height uint32) *V4L2_Format {
+	t.Helper()
+	format := &V4L2_Format{
+		Type: V4L2_BUF_TYPE_VIDEO_CAPTURE,
+		Fmt: &V4L2_Pix_Format{
+			Width:       width,
+			Height:      height,
+			PixelFormat: fourcc,
+		},
+	}
+	if err := IoctlSetFmt(d.FD, format); err != nil {
+		t.Fatal(err)
+	}
+	return format
+}
+
+func TestFakeQueryCap(t *testing.T) {
+	d, _ := openFake(t)
+	var caps V4L2_Capability
+	if err := IoctlQueryCap(d.FD, &caps); err != nil {
+		t.Fatal(err)
+	}
+	// bus info is matched exactly by WithBusInfo, without the NUL padding
+	if caps.Driver != "fake" || caps.Card != "Fake Camera" || caps.BusInfo != "platform:fake" {
+		t.Errorf("driver %q card %q bus %q", caps.Driver, caps.Card, caps.BusInfo)
+	}
+	want := uint32(V4L2_CAP_VIDEO_CAPTURE | V4L2_CAP_STREAMING)
+	if caps.DeviceCaps != want || caps.Capabilities != want|V4L2_CAP_DEVICE_CAPS {
+		t.Errorf("capabilities %#x device caps %#x", caps.Capabilities, caps.DeviceCaps)
+	}
+}
+
+func TestFakeUnknownIoctl(t *testing.T) {
+	d, _ := openFake(t)
+	var parm V4L2_Streamparm
+	parm.Type = V4L2_BUF_TYPE_VIDEO_CAPTURE
+	parm.Parm = &V4L2_Captureparm{}
+	if err := IoctlGetParm(d.FD, &parm); err != syscall.ENOTTY {
+		t.Errorf("VIDIOC_G_PARM: %v, want ENOTTY", err)
+	}
+}
+
+func TestFakeFrameModes(t *testing.T) {
+	d, _ := openFake(t)
+	modes, err := d.EnumFrameModes(V4L2_BUF_TYPE_VIDEO_CAPTURE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(fakeFormats) * len(fakeSizes) * len(fakeIntervals); len(modes) != n {
+		t.Fatalf("%d frame modes, want %d", len(modes), n)
+	}
+	m := modes[0]
+	if m.PixelFormat != V4L2_PIX_FMT_YUYV || m.Width != 320 || m.Height != 240 ||
+		m.Interval != (V4L2_Fract{1, 30}) || m.Description == "" {
+		t.Errorf("first mode %+v", m)
+	}
+}
+
+func TestFakeTryFormat(t *testing.T) {
+	d, _ := openFake(t)
+	tests := []struct {
+		fourcc, width, height uint32
+		want                  V4L2_Pix_Format
+	}{
+		{V4L2_PIX_FMT_RGB24, 640, 480,
+			V4L2_Pix_Format{Width: 640, Height: 480, PixelFormat: V4L2_PIX_FMT_RGB24,
+				BytesPerLine: 1920, SizeImage: 921600}},
+		{V4L2_PIX_FMT_NV12, 1000, 700,
+			V4L2_Pix_Format{Width: 1280, Height: 720, PixelFormat: V4L2_PIX_FMT_NV12,
+				BytesPerLine: 1280, SizeImage: 1382400}},
+		{V4L2_PIX_FMT_MJPEG, 0, 0,
+			V4L2_Pix_Format{Width: 320, Height: 240, PixelFormat: V4L2_PIX_FMT_YUYV,
+				BytesPerLine: 640, SizeImage: 153600}},
+	}
+	for _, tt := range tests {
+		pix := V4L2_Pix_Format{Width: tt.width, Height: tt.height, PixelFormat: tt.fourcc}
+		format := V4L2_Format{Type: V4L2_BUF_TYPE_VIDEO_CAPTURE, Fmt: &pix}
+		if err := IoctlTryFmt(d.FD, &format); err != nil {
+			t.Fatal(err)
+		}
+		tt.want.Field = V4L2_FIELD_NONE
+		tt.want.ColorSpace = V4L2_COLORSPACE_SRGB
+		if got := *format.Fmt.(*V4L2_Pix_Format); got != tt.want {
+			t.Errorf("try %#x %dx%d: %+v, want %+v", tt.fourcc, tt.width, tt.height, got, tt.want)
+		}
+	}
+
+	// TRY_FMT leaves the format alone
+	format := V4L2_Format{Type: V4L2_BUF_TYPE_VIDEO_CAPTURE, Fmt: &V4L2_Pix_Format{}}
+	if err := IoctlGetFmt(d.FD, &format); err != nil {
+		t.Fatal(err)
+	}
+	if pix := format.Fmt.(*V4L2_Pix_Format); pix.PixelFormat != V4L2_PIX_FMT_YUYV || pix.Width != 640 {
+		t.Errorf("format changed to %+v", pix)
+	}
+}
+
+func TestFakeSetFormatBusy(t *testing.T) {
+	d, _ := openFake(t)
+	q := NewBufferQueue(d.FD, V4L2_BUF_TYPE_VIDEO_CAPTURE, V4L2_MEMORY_MMAP)
+	if err := q.Alloc(2); err != nil {
+		t.Fatal(err)
+	}
+	format := V4L2_Format{
+		Type: V4L2_BUF_TYPE_VIDEO_CAPTURE,
+		Fmt:  &V4L2_Pix_Format{Width: 320, Height: 240, PixelFormat: V4L2_PIX_FMT_GREY},
+	}
+	if err := IoctlSetFmt(d.FD, &format); err != syscall.EBUSY {
+		t.Errorf("S_FMT with buffers: %v, want EBUSY", err)
+	}
+	if err := q.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if err := IoctlSetFmt(d.FD, &format); err != nil {
+		t.Errorf("S_FMT after release: %v", err)
+	}
+}
+
+func TestFakeControls(t *testing.T) {
+	d, _ := openFake(t)
+	pattern, err := d.QueryControl(V4L2_CID_TEST_PATTERN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pattern.Menu) != len(fakePatterns) || pattern.Menu[1].Name != "Gradient" {
+		t.Errorf("test pattern menu %+v", pattern.Menu)
+	}
+
+	brightness, err := d.QueryControl(V4L2_CID_BRIGHTNESS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := brightness.Set(300); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := brightness.Get(); err != nil || v != 255 {
+		t.Errorf("brightness set to 300 is %d, %v, want clamped to 255", v, err)
+	}
+	if err := brightness.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := brightness.Get(); v != 128 {
+		t.Errorf("brightness reset to %d", v)
+	}
+	if err := pattern.Set(7); err != syscall.ERANGE {
+		t.Errorf("test pattern set to 7: %v, want ERANGE", err)
+	}
+	if _, err := d.QueryControl(V4L2_CID_HUE); err != syscall.EINVAL {
+		t.Errorf("query unknown control: %v, want EINVAL", err)
+	}
+}
+
+func TestFakeExtControls(t *testing.T) {
+	d, _ := openFake(t)
+	ctrls := V4L2_Ext_Controls{
+		ClassWhich: V4L2_CTRL_CLASS_USER,
+		Count:      2,
+		Controls: []V4L2_Ext_Control{
+			{ID: V4L2_CID_CONTRAST, Union: int32(64)},
+			{ID: V4L2_CID_HFLIP, Union: int32(5)},
+		},
+	}
+	if err := IoctlTryExtCtrls(d.FD, &ctrls); err != nil {
+		t.Fatal(err)
+	}
+	if v := ctrls.Controls[1].Union; v != int32(1) {
+		t.Errorf("tried hflip 5 as %v, want 1", v)
+	}
+	if err := IoctlSetExtCtrls(d.FD, &ctrls); err != nil {
+		t.Fatal(err)
+	}
+	contrast := V4L2_Control{ID: V4L2_CID_CONTRAST}
+	if err := IoctlGetCtrl(d.FD, &contrast); err != nil || contrast.Value != 64 {
+		t.Errorf("contrast %d, %v, want 64", contrast.Value, err)
+	}
+
+	// the defaults are not affected
+	ctrls.ClassWhich = V4L2_CTRL_WHICH_DEF_VAL
+	if err := IoctlGetExtCtrls(d.FD, &ctrls); err != nil {
+		t.Fatal(err)
+	}
+	if ctrls.Controls[0].Union != int32(128) || ctrls.Controls[1].Union != int32(0) {
+		t.Errorf("defaults %v %v", ctrls.Controls[0].Union, ctrls.Controls[1].Union)
+	}
+
+	// controls of another class are rejected as a whole
+	ctrls.ClassWhich = V4L2_CTRL_CLASS_USER
+	ctrls.Controls[1] = V4L2_Ext_Control{ID: V4L2_CID_TEST_PATTERN, Union: int32(1)}
+	if err := IoctlSetExtCtrls(d.FD, &ctrls); err != syscall.EINVAL || ctrls.ErrorIdx != 1 {
+		t.Errorf("S_EXT_CTRLS of two classes: %v at %d, want EINVAL at 1", err, ctrls.ErrorIdx)
+	}
+}
+
+func TestFakeEvents(t *testing.T) {
+	d, _ := openFake(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := d.SubscribeEvents(ctx,
+		V4L2_Event_Subscription{
+			Type:  V4L2_EVENT_CTRL,
+			ID:    V4L2_CID_BRIGHTNESS,
+			Flags: V4L2_EVENT_SUB_FL_SEND_INITIAL,
+		},
+		V4L2_Event_Subscription{Type: V4L2_EVENT_FRAME_SYNC})
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := func() V4L2_Event {
+		t.Helper()
+		select {
+		case ev := <-events:
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event")
+		}
+		return V4L2_Event{}
+	}
+
+	ev := next()
+	c, ok := ev.Union.(*V4L2_Event_Ctrl)
+	if !ok || ev.ID != V4L2_CID_BRIGHTNESS || c.Value != 128 ||
+		c.Changes&V4L2_EVENT_CTRL_CH_RANGE == 0 || c.Maximum != 255 {
+		t.Fatalf("initial event %+v %+v", ev, ev.Union)
+	}
+
+	ctrl := V4L2_Control{ID: V4L2_CID_BRIGHTNESS, Value: 10}
+	if err := IoctlSetCtrl(d.FD, &ctrl); err != nil {
+		t.Fatal(err)
+	}
+	ev = next()
+	c, ok = ev.Union.(*V4L2_Event_Ctrl)
+	if !ok || c.Value != 10 || c.Changes != V4L2_EVENT_CTRL_CH_VALUE || ev.Sequence != 1 {
+		t.Fatalf("change event %+v %+v", ev, ev.Union)
+	}
+
+	q := NewBufferQueue(d.FD, V4L2_BUF_TYPE_VIDEO_CAPTURE, V4L2_MEMORY_MMAP)
+	if err := q.Alloc(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.QueueAll(); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.StreamOn(); err != nil {
+		t.Fatal(err)
+	}
+	for i := uint32(0); i < 2; i++ {
+		if _, err := q.Dequeue(); err != nil {
+			t.Fatal(err)
+		}
+		ev = next()
+		f, ok := ev.Union.(*V4L2_Event_Frame_Sync)
+		if !ok || f.FrameSequence != i {
+			t.Fatalf("frame sync event %+v %+v, want frame %d", ev, ev.Union, i)
+		}
+	}
+	if ts := MonotonicTime(ev.TimeStamp); time.Since(ts) > time.Minute || time.Until(ts) > time.Minute {
+		t.Errorf("event time %v", ts)
+	}
+
+	cancel()
+	for range events {
+	}
+	var left V4L2_Event
+	if err := IoctlDQEvent(d.FD, &left); err != syscall.ENOENT {
+		t.Errorf("DQEVENT after unsubscribing: %v, want ENOENT", err)
+	}
+}
+
+func TestFakePoll(t *testing.T) {
+	d, _ := openFake(t)
+	p, err := newPoller(d.FD, syscall.EPOLLIN|syscall.EPOLLPRI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.close()
+	poll := func(want uint32) {
+		t.Helper()
+		if events, err := p.wait(0); err != nil || events != want {
+			t.Errorf("poll %#x, %v, want %#x", events, err, want)
+		}
+	}
+
+	q := NewBufferQueue(d.FD, V4L2_BUF_TYPE_VIDEO_CAPTURE, V4L2_MEMORY_MMAP)
+	if err := q.Alloc(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.QueueAll(); err != nil {
+		t.Fatal(err)
+	}
+	poll(0)
+	if err := q.StreamOn(); err != nil {
+		t.Fatal(err)
+	}
+	poll(syscall.EPOLLIN)
+
+	sub := V4L2_Event_Subscription{Type: V4L2_EVENT_FRAME_SYNC}
+	if err := IoctlSubscribeEvent(d.FD, &sub); err != nil {
+		t.Fatal(err)
+	}
+	for range q.Bufs {
+		if _, err := q.Dequeue(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	poll(syscall.EPOLLPRI)
+	if _, err := q.Dequeue(); err != syscall.EAGAIN {
+		t.Errorf("dequeue without queued buffers: %v, want EAGAIN", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		var ev V4L2_Event
+		if err := IoctlDQEvent(d.FD, &ev); err != nil || ev.Pending != uint32(1-i) {
+			t.Fatalf("DQEVENT: pending %d, %v", ev.Pending, err)
+		}
+	}
+	poll(0)
+}
+
+func TestFakeClose(t *testing.T) {
+	drv := NewFakeDriver()
+	d, err := OpenBackend(drv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := NewBufferQueue(d.FD, V4L2_BUF_TYPE_VIDEO_CAPTURE, V4L2_MEMORY_MMAP)
+	mapped := backends.mapped.Load()
+	if err := q.Alloc(2); err != nil {
+		t.Fatal(err)
+	}
+	fd := d.FD
+	d.Close()
+	if lookupBackend(fd) != nil {
+		t.Error("closed device is still served by the driver")
+	}
+	if len(drv.bufs) != 0 {
+		t.Errorf("closing left %d buffers", len(drv.bufs))
+	}
+
+	// mappings outlive the device
+	if err := q.Release(); err == nil {
+		t.Error("release on a closed device succeeded")
+	}
+	if n := backends.mapped.Load() - mapped; n != 0 {
+		t.Errorf("%d mappings left", n)
+	}
+}
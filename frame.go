@@ -0,0 +1,127 @@
+package v4l2
+
+import (
+	"syscall"
+)
+
+// FrameMode is one (pixel format, frame size, frame interval) combination
+// supported by a device.
+type FrameMode struct {
+	PixelFormat uint32
+	Description string
+	Width       uint32
+	Height      uint32
+	Interval    V4L2_Fract // zero if the driver does not enumerate intervals
+
+	// SizeRange is the range of a stepwise or continuous frame size, whose
+	// maximum is Width x Height, nil for a discrete size
+	SizeRange *V4L2_Frmsize_Stepwise
+	// IntervalRange is the range of a stepwise or continuous frame
+	// interval, whose minimum is Interval, nil for a discrete interval
+	IntervalRange *V4L2_Frmival_Stepwise
+}
+
+// EnumFrameModes returns every frame mode the device supports for the
+// given buffer type. Stepwise and continuous frame sizes and intervals
+// contribute a single mode with SizeRange or IntervalRange set. The
+// intervals of a range of sizes are those of its maximum size.
+func (d *Device) EnumFrameModes(bufType uint32) ([]FrameMode, error) {
+	var modes []FrameMode
+
+	for i := uint32(0); ; i++ {
+		fmtdesc := V4L2_Fmtdesc{Index: i, Type: bufType}
+		err := IoctlEnumFmt(d.FD, &fmtdesc)
+		if err == syscall.EINVAL {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		sizes, sizeRange, err := enumFrameSizes(d.FD, fmtdesc.PixelFormat)
+		if err != nil {
+			return nil, err
+		}
+		for _, size := range sizes {
+			intervals, ivalRange, err := enumFrameIntervals(d.FD,
+				fmtdesc.PixelFormat, size.Width, size.Height)
+			if err != nil {
+				return nil, err
+			}
+			if len(intervals) == 0 {
+				intervals = append(intervals, V4L2_Fract{})
+			}
+			for _, ival := range intervals {
+				modes = append(modes, FrameMode{
+					PixelFormat:   fmtdesc.PixelFormat,
+					Description:   fmtdesc.Description,
+					Width:         size.Width,
+					Height:        size.Height,
+					Interval:      ival,
+					SizeRange:     sizeRange,
+					IntervalRange: ivalRange,
+				})
+			}
+		}
+	}
+	return modes, nil
+}
+
+// enumFrameSizes returns the discrete frame sizes of pixfmt, or the maximum
+// size and the range of a stepwise or continuous one
+func enumFrameSizes(fd int, pixfmt uint32) ([]V4L2_Frmsize_Discrete, *V4L2_Frmsize_Stepwise, error) {
+	var sizes []V4L2_Frmsize_Discrete
+
+	for i := uint32(0); ; i++ {
+		fs := V4L2_Frmsizeenum{Index: i, PixelFormat: pixfmt}
+		err := IoctlEnumFrameSizes(fd, &fs)
+		if err == syscall.EINVAL || err == syscall.ENOTTY {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch u := fs.Union.(type) {
+		case *V4L2_Frmsize_Discrete:
+			sizes = append(sizes, *u)
+		case *V4L2_Frmsize_Stepwise:
+			// only index 0 is valid for stepwise and continuous sizes
+			largest := V4L2_Frmsize_Discrete{Width: u.MaxWidth, Height: u.MaxHeight}
+			return []V4L2_Frmsize_Discrete{largest}, u, nil
+		}
+	}
+	return sizes, nil, nil
+}
+
+// enumFrameIntervals returns the discrete frame intervals of pixfmt at
+// width x height, or the minimum interval and the range of a stepwise or
+// continuous one
+func enumFrameIntervals(fd int, pixfmt, width, height uint32) ([]V4L2_Fract, *V4L2_Frmival_Stepwise, error) {
+	var intervals []V4L2_Fract
+
+	for i := uint32(0); ; i++ {
+		fi := V4L2_Frmivalenum{
+			Index:       i,
+			PixelFormat: pixfmt,
+			Width:       width,
+			Height:      height,
+		}
+		err := IoctlEnumFrameIntervals(fd, &fi)
+		if err == syscall.EINVAL || err == syscall.ENOTTY {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch u := fi.Union.(type) {
+		case *V4L2_Fract:
+			intervals = append(intervals, *u)
+		case *V4L2_Frmival_Stepwise:
+			// only index 0 is valid for stepwise and continuous intervals
+			return []V4L2_Fract{u.Min}, u, nil
+		}
+	}
+	return intervals, nil, nil
+}
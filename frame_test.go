@@ -0,0 +1,91 @@
+package v4l2
+
+import (
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+// stepwiseDriver is a FakeDriver enumerating a stepwise range of frame
+// sizes for all formats and a continuous range of frame intervals for GREY
+type stepwiseDriver struct {
+	*FakeDriver
+}
+
+var (
+	stepwiseSizes = V4L2_Frmsize_Stepwise{
+		MinWidth: 160, MaxWidth: 1280, StepWidth: 16,
+		MinHeight: 120, MaxHeight: 720, StepHeight: 8,
+	}
+	continuousIntervals = V4L2_Frmival_Stepwise{
+		Min: V4L2_Fract{1, 60}, Max: V4L2_Fract{1, 1}, Step: V4L2_Fract{1, 1},
+	}
+)
+
+func (d *stepwiseDriver) Ioctl(request uint, argp unsafe.Pointer) error {
+	switch request {
+	case VIDIOC_ENUM_FRAMESIZES:
+		p := (*v4l2_frmsizeenum)(argp)
+		if p.index != 0 || !isFakeFormat(uint32(p.pixel_format)) {
+			return syscall.EINVAL
+		}
+		p._type = V4L2_FRMSIZE_TYPE_STEPWISE
+		s := (*v4l2_frmsize_stepwise)(unsafe.Pointer(&p.anon0))
+		s.min_width, s.max_width = __u32(stepwiseSizes.MinWidth), __u32(stepwiseSizes.MaxWidth)
+		s.step_width = __u32(stepwiseSizes.StepWidth)
+		s.min_height, s.max_height = __u32(stepwiseSizes.MinHeight), __u32(stepwiseSizes.MaxHeight)
+		s.step_height = __u32(stepwiseSizes.StepHeight)
+		return nil
+	case VIDIOC_ENUM_FRAMEINTERVALS:
+		p := (*v4l2_frmivalenum)(argp)
+		if uint32(p.pixel_format) != V4L2_PIX_FMT_GREY {
+			break
+		}
+		if p.index != 0 {
+			return syscall.EINVAL
+		}
+		p._type = V4L2_FRMIVAL_TYPE_CONTINUOUS
+		f := (*v4l2_frmival_stepwise)(unsafe.Pointer(&p.anon0))
+		continuousIntervals.Min.set(unsafe.Pointer(&f.min))
+		continuousIntervals.Max.set(unsafe.Pointer(&f.max))
+		continuousIntervals.Step.set(unsafe.Pointer(&f.step))
+		return nil
+	}
+	return d.FakeDriver.Ioctl(request, argp)
+}
+
+func TestFrameModesStepwise(t *testing.T) {
+	d, err := OpenBackend(&stepwiseDriver{NewFakeDriver()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(d.Close)
+	modes, err := d.EnumFrameModes(V4L2_BUF_TYPE_VIDEO_CAPTURE)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a single size per format, with the discrete intervals of the maximum
+	// size, but a single interval for GREY
+	if n := (len(fakeFormats)-1)*len(fakeIntervals) + 1; len(modes) != n {
+		t.Fatalf("%d frame modes, want %d: %+v", len(modes), n, modes)
+	}
+	for _, m := range modes {
+		if m.Width != 1280 || m.Height != 720 || m.SizeRange == nil ||
+			*m.SizeRange != stepwiseSizes {
+			t.Errorf("%s: size %dx%d, range %+v", GetNameByFourCC(m.PixelFormat),
+				m.Width, m.Height, m.SizeRange)
+		}
+		if m.PixelFormat != V4L2_PIX_FMT_GREY {
+			if m.IntervalRange != nil {
+				t.Errorf("%s: interval range %+v of discrete intervals",
+					GetNameByFourCC(m.PixelFormat), m.IntervalRange)
+			}
+			continue
+		}
+		if m.Interval != continuousIntervals.Min || m.IntervalRange == nil ||
+			*m.IntervalRange != continuousIntervals {
+			t.Errorf("GREY: interval %v, range %+v", m.Interval, m.IntervalRange)
+		}
+	}
+}
@@ -1,4 +1,4 @@
-// +build arm i386
+// +build 386 arm mips mipsle
 
 package v4l2
 
@@ -10,48 +10,52 @@ import (
 )
 
 // get value from v4l2_buffer union field
-func GetValueFromUnion(union []byte, value interface{}) {
+func GetValueFromUnion(union []byte, value interface{}) error {
+	var err error
 	tmp := bytes.NewReader(union)
 	switch x := value.(type) {
 	case *uint32: // offset
-		err := binary.Read(tmp, binary.LittleEndian, x)
-		if err != nil {
-			goto BinaryError
-		}
+		err = binary.Read(tmp, binary.NativeEndian, x)
 	case *int: // fd
-		var m uint32
-		err := binary.Read(tmp, binary.LittleEndian, &m)
-		if err != nil {
-			goto BinaryError
-		}
+		var m int32
+		err = binary.Read(tmp, binary.NativeEndian, &m)
 		*x = int(m)
 	case *uintptr:
 		var m uint32
-		err := binary.Read(tmp, binary.LittleEndian, &m)
-		if err != nil {
-			goto BinaryError
-		}
+		err = binary.Read(tmp, binary.NativeEndian, &m)
 		*x = uintptr(m)
 	}
-	return
-BinaryError:
-	fmt.Printf("Read for package binary failed\n")
+	if err != nil {
+		return fmt.Errorf("Read for package binary failed: %w", err)
+	}
+	return nil
 }
 
 func UintptrToBytes(n uintptr) []byte {
 	tmp := uint32(n)
 	buffer := bytes.NewBuffer([]byte{})
-	binary.Write(buffer, binary.LittleEndian, tmp)
+	binary.Write(buffer, binary.NativeEndian, tmp)
 	return buffer.Bytes()
 }
 
 func BytesToUintptr(b []byte) uintptr {
 	buffer := bytes.NewBuffer(b)
 	var tmp uint32
-	binary.Read(buffer, binary.LittleEndian, &tmp)
+	binary.Read(buffer, binary.NativeEndian, &tmp)
 	return uintptr(tmp)
 }
 
+// fd occupies the first 4 bytes of the union
+func fdToBytes(fd int) []byte {
+	buffer := bytes.NewBuffer([]byte{})
+	binary.Write(buffer, binary.NativeEndian, int32(fd))
+	return buffer.Bytes()
+}
+
+// PointerToBytes returns the address of a plane array as bytes.
+//
+// Deprecated: the address is not seen by the garbage collector. Set
+// V4L2_Buffer.Planes instead, M is ignored for multi-planar buffers.
 func PointerToBytes(p interface{}) []byte {
 	switch x := p.(type) {
 	case *V4L2_Plane:
@@ -0,0 +1,517 @@
+package v4l2
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// NV12 is an in-memory image of a semi-planar YUV format: a Y plane and a
+// plane of interleaved Cb and Cr samples, Cr first if CrFirst is set, e.g.
+// NV21. NV16 and NV24 are the 4:2:2 and 4:4:4 variants.
+type NV12 struct {
+	Y, CbCr        []byte
+	YStride        int
+	CStride        int
+	SubsampleRatio image.YCbCrSubsampleRatio
+	CrFirst        bool
+	Rect           image.Rectangle
+}
+
+func (p *NV12) ColorModel() color.Model {
+	return color.YCbCrModel
+}
+
+func (p *NV12) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+func (p *NV12) Opaque() bool {
+	return true
+}
+
+func (p *NV12) At(x, y int) color.Color {
+	return p.YCbCrAt(x, y)
+}
+
+func (p *NV12) YCbCrAt(x, y int) color.YCbCr {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return color.YCbCr{}
+	}
+	yi := p.YOffset(x, y)
+	ci := p.COffset(x, y)
+	c := color.YCbCr{Y: p.Y[yi], Cb: p.CbCr[ci], Cr: p.CbCr[ci+1]}
+	if p.CrFirst {
+		c.Cb, c.Cr = c.Cr, c.Cb
+	}
+	return c
+}
+
+// YOffset returns the index of the Y sample of the pixel at (x, y)
+func (p *NV12) YOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.YStride + (x - p.Rect.Min.X)
+}
+
+// COffset returns the index of the first chroma sample of the pixel at
+// (x, y), the second one follows it
+func (p *NV12) COffset(x, y int) int {
+	x, y = x-p.Rect.Min.X, y-p.Rect.Min.Y
+	switch p.SubsampleRatio {
+	case image.YCbCrSubsampleRatio422:
+		return y*p.CStride + x/2*2
+	case image.YCbCrSubsampleRatio444:
+		return y*p.CStride + x*2
+	}
+	return y/2*p.CStride + x/2*2
+}
+
+// YUYV is an in-memory image of a packed YUV 4:2:2 format, two pixels in
+// four bytes. FourCC tells the order of the samples: YUYV, YVYU, UYVY or
+// VYUY.
+type YUYV struct {
+	Pix    []byte
+	Stride int
+	Rect   image.Rectangle
+	FourCC uint32
+}
+
+func (p *YUYV) ColorModel() color.Model {
+	return color.YCbCrModel
+}
+
+func (p *YUYV) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+func (p *YUYV) Opaque() bool {
+	return true
+}
+
+func (p *YUYV) At(x, y int) color.Color {
+	return p.YCbCrAt(x, y)
+}
+
+func (p *YUYV) YCbCrAt(x, y int) color.YCbCr {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return color.YCbCr{}
+	}
+	y0, y1, cb, cr := yuyvOrder(p.FourCC)
+	x, y = x-p.Rect.Min.X, y-p.Rect.Min.Y
+	i := y*p.Stride + x/2*4
+	c := color.YCbCr{Y: p.Pix[i+y0], Cb: p.Pix[i+cb], Cr: p.Pix[i+cr]}
+	if x&1 != 0 {
+		c.Y = p.Pix[i+y1]
+	}
+	return c
+}
+
+// yuyvOrder returns the offsets of the two Y, the Cb and the Cr sample in
+// the four bytes of a pair of pixels
+func yuyvOrder(fourcc uint32) (y0, y1, cb, cr int) {
+	switch fourcc {
+	case V4L2_PIX_FMT_YVYU:
+		return 0, 2, 3, 1
+	case V4L2_PIX_FMT_UYVY:
+		return 1, 3, 0, 2
+	case V4L2_PIX_FMT_VYUY:
+		return 1, 3, 2, 0
+	}
+	return 0, 2, 1, 3
+}
+
+// bits to shift the samples of little endian grey formats to 16 bits
+var greyShift = map[uint32]uint{
+	V4L2_PIX_FMT_Y10: 6,
+	V4L2_PIX_FMT_Y12: 4,
+	V4L2_PIX_FMT_Y14: 2,
+	V4L2_PIX_FMT_Y16: 0,
+}
+
+// Frame is a frame of a pixel format split into its component planes: Y,
+// Cb, Cr or Y, CbCr or a single packed plane. The colorimetry fields are
+// those of the format, V4L2_COLORSPACE_DEFAULT etc. if the driver left them
+// so.
+type Frame struct {
+	Format  *PixelFormat
+	Width   int
+	Height  int
+	Planes  [][]byte
+	Strides []int
+	Used    []uint32 // bytesused of each memory plane
+
+	ColorSpace   uint32
+	Encoding     uint32
+	Quantization uint32
+	XferFunc     uint32
+}
+
+// NewFrame splits mem, the memory planes of a buffer, into the component
+// planes of format. Tiled formats are not supported.
+func NewFrame(format *V4L2_Format, mem ...[]byte) (*Frame, error) {
+	var width, height, pixfmt uint32
+	var bpl []uint32
+	fr := &Frame{}
+	switch f := format.Fmt.(type) {
+	case *V4L2_Pix_Format:
+		width, height, pixfmt = f.Width, f.Height, f.PixelFormat
+		bpl = []uint32{f.BytesPerLine}
+		fr.ColorSpace, fr.Encoding = f.ColorSpace, f.Encoding
+		fr.Quantization, fr.XferFunc = f.Quantization, f.XferFunc
+	case *V4L2_Pix_Format_Mplane:
+		width, height, pixfmt = f.Width, f.Height, f.PixelFormat
+		for i := 0; i < int(f.NumPlanes) && i < VIDEO_MAX_PLANES; i++ {
+			bpl = append(bpl, f.PlaneFmt[i].BytesPerLine)
+		}
+		fr.ColorSpace, fr.Encoding = f.ColorSpace, uint32(f.Encoding)
+		fr.Quantization, fr.XferFunc = uint32(f.Quantization), uint32(f.XferFunc)
+	default:
+		return nil, fmt.Errorf("%w: format %T", ErrorUnexpectedType, format.Fmt)
+	}
+
+	pf, err := LookupPixelFormat(pixfmt)
+	if err != nil {
+		return nil, err
+	}
+	if len(pf.Planes) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrorNoPlaneLayout, pf.Name)
+	}
+	if pf.Flags&PixFmtTiled != 0 {
+		return nil, fmt.Errorf("%w: %s", ErrorUnsupportedFormat, pf.Name)
+	}
+	if len(mem) < pf.MemPlanes || len(bpl) < pf.MemPlanes {
+		return nil, fmt.Errorf("%w: %s has %d memory planes", ErrorShortFrame,
+			pf.Name, pf.MemPlanes)
+	}
+
+	fr.Format = pf
+	fr.Width, fr.Height = int(width), int(height)
+	fr.Used = make([]uint32, pf.MemPlanes)
+	var offset int
+	for i, p := range pf.Planes {
+		w, h := fr.Width, fr.Height
+		if i > 0 {
+			w = (w + pf.HSub - 1) / pf.HSub
+			h = (h + pf.VSub - 1) / pf.VSub
+		}
+		line := (w + p.Pixels - 1) / p.Pixels * p.Bytes
+
+		m := min(i, pf.MemPlanes-1)
+		var stride int
+		if i == m {
+			stride = int(bpl[i])
+			if stride == 0 {
+				stride = line
+			}
+			offset = 0
+		} else {
+			stride = int(pf.chromaStride(i, width, uint32(fr.Strides[0])))
+		}
+		if stride < line || offset+stride*(h-1)+line > len(mem[m]) {
+			return nil, fmt.Errorf("%w: plane %d of %s %dx%d", ErrorShortFrame,
+				i, pf.Name, width, height)
+		}
+		fr.Planes = append(fr.Planes, mem[m][offset:])
+		fr.Strides = append(fr.Strides, stride)
+		offset += stride * h
+		fr.Used[m] = uint32(offset)
+	}
+	return fr, nil
+}
+
+// ycbcrRatio returns the image.YCbCrSubsampleRatio of the chroma planes
+func (fr *Frame) ycbcrRatio() (image.YCbCrSubsampleRatio, bool) {
+	switch [2]int{fr.Format.HSub, fr.Format.VSub} {
+	case [2]int{1, 1}:
+		return image.YCbCrSubsampleRatio444, true
+	case [2]int{2, 1}:
+		return image.YCbCrSubsampleRatio422, true
+	case [2]int{2, 2}:
+		return image.YCbCrSubsampleRatio420, true
+	case [2]int{1, 2}:
+		return image.YCbCrSubsampleRatio440, true
+	case [2]int{4, 1}:
+		return image.YCbCrSubsampleRatio411, true
+	}
+	return 0, false
+}
+
+// isYUVPlanar reports whether the frame is 8-bit Y, Cb, Cr planes
+func (fr *Frame) isYUVPlanar() bool {
+	pl := fr.Format.Planes
+	return len(pl) == 3 && pl[0] == PlaneLayout{1, 1} &&
+		pl[1] == PlaneLayout{1, 1} && pl[2] == PlaneLayout{1, 1}
+}
+
+// isNV12 reports whether the frame is an 8-bit Y plane and a CbCr plane
+func (fr *Frame) isNV12() bool {
+	pl := fr.Format.Planes
+	return len(pl) == 2 && pl[0] == PlaneLayout{1, 1} && pl[1] == PlaneLayout{2, 1}
+}
+
+// NewImage returns the frame in mem, the memory planes of a buffer, of
+// format as an image.Image. YUV frames, GREY, Y16_BE and RGBA32 share the
+// memory of the buffer and are valid until it is queued again, other RGB
+// and grey formats are copied.
+func NewImage(format *V4L2_Format, mem ...[]byte) (image.Image, error) {
+	fr, err := NewFrame(format, mem...)
+	if err != nil {
+		return nil, err
+	}
+	fourcc := fr.Format.FourCC
+	rect := image.Rect(0, 0, fr.Width, fr.Height)
+
+	switch {
+	case fr.isYUVPlanar():
+		ratio, ok := fr.ycbcrRatio()
+		if !ok {
+			break
+		}
+		cb, cr := fr.Planes[1], fr.Planes[2]
+		if fr.Format.CrFirst() {
+			cb, cr = cr, cb
+		}
+		return &image.YCbCr{
+			Y:              fr.Planes[0],
+			Cb:             cb,
+			Cr:             cr,
+			YStride:        fr.Strides[0],
+			CStride:        fr.Strides[1],
+			SubsampleRatio: ratio,
+			Rect:           rect,
+		}, nil
+	case fr.isNV12():
+		ratio, ok := fr.ycbcrRatio()
+		if !ok || fourcc == V4L2_PIX_FMT_M420 {
+			break
+		}
+		return &NV12{
+			Y:              fr.Planes[0],
+			CbCr:           fr.Planes[1],
+			YStride:        fr.Strides[0],
+			CStride:        fr.Strides[1],
+			SubsampleRatio: ratio,
+			CrFirst:        fr.Format.CrFirst(),
+			Rect:           rect,
+		}, nil
+	}
+
+	switch fourcc {
+	case V4L2_PIX_FMT_YUYV, V4L2_PIX_FMT_YVYU, V4L2_PIX_FMT_UYVY, V4L2_PIX_FMT_VYUY:
+		return &YUYV{
+			Pix:    fr.Planes[0],
+			Stride: fr.Strides[0],
+			Rect:   rect,
+			FourCC: fourcc,
+		}, nil
+	case V4L2_PIX_FMT_GREY:
+		return &image.Gray{Pix: fr.Planes[0], Stride: fr.Strides[0], Rect: rect}, nil
+	case V4L2_PIX_FMT_Y16_BE:
+		return &image.Gray16{Pix: fr.Planes[0], Stride: fr.Strides[0], Rect: rect}, nil
+	case V4L2_PIX_FMT_RGBA32:
+		return &image.NRGBA{Pix: fr.Planes[0], Stride: fr.Strides[0], Rect: rect}, nil
+	case V4L2_PIX_FMT_RGB565:
+		img := image.NewRGBA(rect)
+		for y := 0; y < fr.Height; y++ {
+			src := fr.Planes[0][y*fr.Strides[0]:]
+			dst := img.Pix[y*img.Stride:]
+			for x := 0; x < fr.Width; x++ {
+				v := binary.LittleEndian.Uint16(src[x*2:])
+				r, g, b := byte(v>>11), byte(v>>5&0x3f), byte(v&0x1f)
+				dst[x*4+0] = r<<3 | r>>2
+				dst[x*4+1] = g<<2 | g>>4
+				dst[x*4+2] = b<<3 | b>>2
+				dst[x*4+3] = 0xff
+			}
+		}
+		return img, nil
+	}
+
+	if shift, ok := greyShift[fourcc]; ok {
+		img := image.NewGray16(rect)
+		for y := 0; y < fr.Height; y++ {
+			src := fr.Planes[0][y*fr.Strides[0]:]
+			dst := img.Pix[y*img.Stride:]
+			for x := 0; x < fr.Width; x++ {
+				binary.BigEndian.PutUint16(dst[x*2:],
+					binary.LittleEndian.Uint16(src[x*2:])<<shift)
+			}
+		}
+		return img, nil
+	}
+
+	if o, ok := fr.Format.RGBOrder(); ok {
+		var pix []byte
+		var img image.Image
+		if o.A < 0 {
+			rgba := image.NewRGBA(rect)
+			pix, img = rgba.Pix, rgba
+		} else {
+			nrgba := image.NewNRGBA(rect)
+			pix, img = nrgba.Pix, nrgba
+		}
+		for y := 0; y < fr.Height; y++ {
+			src := fr.Planes[0][y*fr.Strides[0]:]
+			dst := pix[y*fr.Width*4:]
+			for x := 0; x < fr.Width; x++ {
+				s, d := src[x*o.Bytes:], dst[x*4:]
+				d[0], d[1], d[2], d[3] = s[o.R], s[o.G], s[o.B], 0xff
+				if o.A >= 0 {
+					d[3] = s[o.A]
+				}
+			}
+		}
+		return img, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrorUnsupportedFormat, fr.Format.Name)
+}
+
+// Image returns the frame of the dequeued buffer b of format, see NewImage
+func (b *Buffer) Image(format *V4L2_Format) (image.Image, error) {
+	mem := make([][]byte, len(b.Planes))
+	for i := range b.Planes {
+		mem[i] = b.Planes[i].Bytes()
+	}
+	return NewImage(format, mem...)
+}
+
+// WriteImage converts img to format into mem, the memory planes of a buffer
+// of an OUTPUT queue, and returns the bytesused of each memory plane. img
+// is scaled neither up nor down, its top left part of the size of format is
+// written and uncovered pixels are left black.
+func WriteImage(format *V4L2_Format, img image.Image, mem ...[]byte) ([]uint32, error) {
+	fr, err := NewFrame(format, mem...)
+	if err != nil {
+		return nil, err
+	}
+	fourcc := fr.Format.FourCC
+	switch {
+	case fr.isYUVPlanar() || fr.isNV12():
+		if _, ok := fr.ycbcrRatio(); !ok || fourcc == V4L2_PIX_FMT_M420 {
+			break
+		}
+		fr.writeYUV(img)
+		return fr.Used, nil
+	case fourcc == V4L2_PIX_FMT_YUYV || fourcc == V4L2_PIX_FMT_YVYU ||
+		fourcc == V4L2_PIX_FMT_UYVY || fourcc == V4L2_PIX_FMT_VYUY:
+		fr.writeYUV(img)
+		return fr.Used, nil
+	case fourcc == V4L2_PIX_FMT_GREY:
+		b := img.Bounds()
+		for y := 0; y < fr.Height; y++ {
+			dst := fr.Planes[0][y*fr.Strides[0] : y*fr.Strides[0]+fr.Width]
+			for x := range dst {
+				dst[x] = color.GrayModel.Convert(pixelAt(img, b, x, y)).(color.Gray).Y
+			}
+		}
+		return fr.Used, nil
+	}
+
+	if o, ok := fr.Format.RGBOrder(); ok {
+		b := img.Bounds()
+		for y := 0; y < fr.Height; y++ {
+			dst := fr.Planes[0][y*fr.Strides[0]:]
+			for x := 0; x < fr.Width; x++ {
+				c := color.NRGBAModel.Convert(pixelAt(img, b, x, y)).(color.NRGBA)
+				d := dst[x*o.Bytes:]
+				d[o.R], d[o.G], d[o.B] = c.R, c.G, c.B
+				if o.Bytes == 4 {
+					// fill the padding byte of RGBX formats as well
+					d[6-o.R-o.G-o.B] = c.A
+				}
+			}
+		}
+		return fr.Used, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrorUnsupportedFormat, fr.Format.Name)
+}
+
+// SetImage writes img into the buffer b of an OUTPUT queue of format and
+// sets the bytesused of its planes, see WriteImage
+func (b *Buffer) SetImage(format *V4L2_Format, img image.Image) error {
+	mem := make([][]byte, len(b.Planes))
+	for i := range b.Planes {
+		p := &b.Planes[i]
+		if p.Data == nil || int(p.DataOffset) > len(p.Data) {
+			return fmt.Errorf("%w: plane %d has no memory", ErrorShortFrame, i)
+		}
+		mem[i] = p.Data[p.DataOffset:]
+	}
+	used, err := WriteImage(format, img, mem...)
+	if err != nil {
+		return err
+	}
+	for i := range used {
+		b.Planes[i].BytesUsed = b.Planes[i].DataOffset + used[i]
+	}
+	return nil
+}
+
+// pixelAt returns the pixel (x, y) of the frame from img, black outside of
+// its bounds b
+func pixelAt(img image.Image, b image.Rectangle, x, y int) color.Color {
+	p := image.Point{b.Min.X + x, b.Min.Y + y}
+	if !p.In(b) {
+		return color.Black
+	}
+	return img.At(p.X, p.Y)
+}
+
+func ycbcrAt(img image.Image, b image.Rectangle, x, y int) color.YCbCr {
+	if c, ok := img.(interface{ YCbCrAt(x, y int) color.YCbCr }); ok {
+		p := image.Point{b.Min.X + x, b.Min.Y + y}
+		if p.In(b) {
+			return c.YCbCrAt(p.X, p.Y)
+		}
+		return color.YCbCr{Y: 0, Cb: 128, Cr: 128}
+	}
+	return color.YCbCrModel.Convert(pixelAt(img, b, x, y)).(color.YCbCr)
+}
+
+// writeYUV writes img into a planar, semi-planar or packed YUV frame. The
+// chroma samples are the average of the pixels they cover.
+func (fr *Frame) writeYUV(img image.Image) {
+	b := img.Bounds()
+	hsub, vsub := fr.Format.HSub, fr.Format.VSub
+	packed := len(fr.Planes) == 1
+	y0, y1, cbo, cro := yuyvOrder(fr.Format.FourCC)
+
+	for cy := 0; cy*vsub < fr.Height; cy++ {
+		for cx := 0; cx*hsub < fr.Width; cx++ {
+			var cb, cr, n int
+			for y := cy * vsub; y < (cy+1)*vsub && y < fr.Height; y++ {
+				for x := cx * hsub; x < (cx+1)*hsub && x < fr.Width; x++ {
+					c := ycbcrAt(img, b, x, y)
+					cb += int(c.Cb)
+					cr += int(c.Cr)
+					n++
+					if packed {
+						i := y*fr.Strides[0] + cx*4
+						if x&1 == 0 {
+							fr.Planes[0][i+y0] = c.Y
+						} else {
+							fr.Planes[0][i+y1] = c.Y
+						}
+					} else {
+						fr.Planes[0][y*fr.Strides[0]+x] = c.Y
+					}
+				}
+			}
+			ucb, ucr := byte((cb+n/2)/n), byte((cr+n/2)/n)
+			if fr.Format.CrFirst() {
+				ucb, ucr = ucr, ucb
+			}
+			switch len(fr.Planes) {
+			case 1:
+				i := cy*fr.Strides[0] + cx*4
+				fr.Planes[0][i+cbo], fr.Planes[0][i+cro] = ucb, ucr
+			case 2:
+				i := cy*fr.Strides[1] + cx*2
+				fr.Planes[1][i], fr.Planes[1][i+1] = ucb, ucr
+			case 3:
+				fr.Planes[1][cy*fr.Strides[1]+cx] = ucb
+				fr.Planes[2][cy*fr.Strides[2]+cx] = ucr
+			}
+		}
+	}
+}
@@ -0,0 +1,145 @@
+//go:build cgo
+
+package cabi
+
+/*
+#cgo CFLAGS: -D_TIME_BITS=64 -D_FILE_OFFSET_BITS=64
+
+#include <linux/videodev2.h>
+#include <linux/v4l2-subdev.h>
+#include <linux/media.h>
+*/
+import "C"
+
+import "reflect"
+
+// Types are the structs of the headers by tag
+var Types = map[string]reflect.Type{
+	"media_device_info":             reflect.TypeOf(C.struct_media_device_info{}),
+	"media_link_desc":               reflect.TypeOf(C.struct_media_link_desc{}),
+	"media_links_enum":              reflect.TypeOf(C.struct_media_links_enum{}),
+	"media_pad_desc":                reflect.TypeOf(C.struct_media_pad_desc{}),
+	"media_v2_entity":               reflect.TypeOf(C.struct_media_v2_entity{}),
+	"media_v2_interface":            reflect.TypeOf(C.struct_media_v2_interface{}),
+	"media_v2_intf_devnode":         reflect.TypeOf(C.struct_media_v2_intf_devnode{}),
+	"media_v2_link":                 reflect.TypeOf(C.struct_media_v2_link{}),
+	"media_v2_pad":                  reflect.TypeOf(C.struct_media_v2_pad{}),
+	"media_v2_topology":             reflect.TypeOf(C.struct_media_v2_topology{}),
+	"v4l2_buffer":                   reflect.TypeOf(C.struct_v4l2_buffer{}),
+	"v4l2_capability":               reflect.TypeOf(C.struct_v4l2_capability{}),
+	"v4l2_captureparm":              reflect.TypeOf(C.struct_v4l2_captureparm{}),
+	"v4l2_control":                  reflect.TypeOf(C.struct_v4l2_control{}),
+	"v4l2_crop":                     reflect.TypeOf(C.struct_v4l2_crop{}),
+	"v4l2_cropcap":                  reflect.TypeOf(C.struct_v4l2_cropcap{}),
+	"v4l2_ctrl_h264_decode_params":  reflect.TypeOf(C.struct_v4l2_ctrl_h264_decode_params{}),
+	"v4l2_ctrl_h264_pps":            reflect.TypeOf(C.struct_v4l2_ctrl_h264_pps{}),
+	"v4l2_ctrl_h264_pred_weights":   reflect.TypeOf(C.struct_v4l2_ctrl_h264_pred_weights{}),
+	"v4l2_ctrl_h264_scaling_matrix": reflect.TypeOf(C.struct_v4l2_ctrl_h264_scaling_matrix{}),
+	"v4l2_ctrl_h264_slice_params":   reflect.TypeOf(C.struct_v4l2_ctrl_h264_slice_params{}),
+	"v4l2_ctrl_h264_sps":            reflect.TypeOf(C.struct_v4l2_ctrl_h264_sps{}),
+	"v4l2_ctrl_vp8_frame":           reflect.TypeOf(C.struct_v4l2_ctrl_vp8_frame{}),
+	"v4l2_decoder_cmd":              reflect.TypeOf(C.struct_v4l2_decoder_cmd{}),
+	"v4l2_encoder_cmd":              reflect.TypeOf(C.struct_v4l2_encoder_cmd{}),
+	"v4l2_event":                    reflect.TypeOf(C.struct_v4l2_event{}),
+	"v4l2_event_ctrl":               reflect.TypeOf(C.struct_v4l2_event_ctrl{}),
+	"v4l2_event_frame_sync":         reflect.TypeOf(C.struct_v4l2_event_frame_sync{}),
+	"v4l2_event_motion_det":         reflect.TypeOf(C.struct_v4l2_event_motion_det{}),
+	"v4l2_event_src_change":         reflect.TypeOf(C.struct_v4l2_event_src_change{}),
+	"v4l2_event_subscription":       reflect.TypeOf(C.struct_v4l2_event_subscription{}),
+	"v4l2_event_vsync":              reflect.TypeOf(C.struct_v4l2_event_vsync{}),
+	"v4l2_exportbuffer":             reflect.TypeOf(C.struct_v4l2_exportbuffer{}),
+	"v4l2_ext_control":              reflect.TypeOf(C.struct_v4l2_ext_control{}),
+	"v4l2_ext_controls":             reflect.TypeOf(C.struct_v4l2_ext_controls{}),
+	"v4l2_fmtdesc":                  reflect.TypeOf(C.struct_v4l2_fmtdesc{}),
+	"v4l2_format":                   reflect.TypeOf(C.struct_v4l2_format{}),
+	"v4l2_fract":                    reflect.TypeOf(C.struct_v4l2_fract{}),
+	"v4l2_frmival_stepwise":         reflect.TypeOf(C.struct_v4l2_frmival_stepwise{}),
+	"v4l2_frmivalenum":              reflect.TypeOf(C.struct_v4l2_frmivalenum{}),
+	"v4l2_frmsize_discrete":         reflect.TypeOf(C.struct_v4l2_frmsize_discrete{}),
+	"v4l2_frmsize_stepwise":         reflect.TypeOf(C.struct_v4l2_frmsize_stepwise{}),
+	"v4l2_frmsizeenum":              reflect.TypeOf(C.struct_v4l2_frmsizeenum{}),
+	"v4l2_h264_dpb_entry":           reflect.TypeOf(C.struct_v4l2_h264_dpb_entry{}),
+	"v4l2_h264_weight_factors":      reflect.TypeOf(C.struct_v4l2_h264_weight_factors{}),
+	"v4l2_input":                    reflect.TypeOf(C.struct_v4l2_input{}),
+	"v4l2_mbus_framefmt":            reflect.TypeOf(C.struct_v4l2_mbus_framefmt{}),
+	"v4l2_output":                   reflect.TypeOf(C.struct_v4l2_output{}),
+	"v4l2_outputparm":               reflect.TypeOf(C.struct_v4l2_outputparm{}),
+	"v4l2_pix_format":               reflect.TypeOf(C.struct_v4l2_pix_format{}),
+	"v4l2_pix_format_mplane":        reflect.TypeOf(C.struct_v4l2_pix_format_mplane{}),
+	"v4l2_plane":                    reflect.TypeOf(C.struct_v4l2_plane{}),
+	"v4l2_plane_pix_format":         reflect.TypeOf(C.struct_v4l2_plane_pix_format{}),
+	"v4l2_query_ext_ctrl":           reflect.TypeOf(C.struct_v4l2_query_ext_ctrl{}),
+	"v4l2_queryctrl":                reflect.TypeOf(C.struct_v4l2_queryctrl{}),
+	"v4l2_querymenu":                reflect.TypeOf(C.struct_v4l2_querymenu{}),
+	"v4l2_rect":                     reflect.TypeOf(C.struct_v4l2_rect{}),
+	"v4l2_requestbuffers":           reflect.TypeOf(C.struct_v4l2_requestbuffers{}),
+	"v4l2_selection":                reflect.TypeOf(C.struct_v4l2_selection{}),
+	"v4l2_streamparm":               reflect.TypeOf(C.struct_v4l2_streamparm{}),
+	"v4l2_subdev_format":            reflect.TypeOf(C.struct_v4l2_subdev_format{}),
+	"v4l2_subdev_frame_interval":    reflect.TypeOf(C.struct_v4l2_subdev_frame_interval{}),
+	"v4l2_subdev_frame_size_enum":   reflect.TypeOf(C.struct_v4l2_subdev_frame_size_enum{}),
+	"v4l2_subdev_mbus_code_enum":    reflect.TypeOf(C.struct_v4l2_subdev_mbus_code_enum{}),
+	"v4l2_subdev_selection":         reflect.TypeOf(C.struct_v4l2_subdev_selection{}),
+	"v4l2_timecode":                 reflect.TypeOf(C.struct_v4l2_timecode{}),
+}
+
+// Ioctls are the ioctl request codes of the headers by name
+var Ioctls = map[string]uint64{
+	"MEDIA_IOC_DEVICE_INFO":          C.MEDIA_IOC_DEVICE_INFO,
+	"MEDIA_IOC_ENUM_LINKS":           C.MEDIA_IOC_ENUM_LINKS,
+	"MEDIA_IOC_G_TOPOLOGY":           C.MEDIA_IOC_G_TOPOLOGY,
+	"MEDIA_IOC_REQUEST_ALLOC":        C.MEDIA_IOC_REQUEST_ALLOC,
+	"MEDIA_IOC_SETUP_LINK":           C.MEDIA_IOC_SETUP_LINK,
+	"MEDIA_REQUEST_IOC_QUEUE":        C.MEDIA_REQUEST_IOC_QUEUE,
+	"MEDIA_REQUEST_IOC_REINIT":       C.MEDIA_REQUEST_IOC_REINIT,
+	"VIDIOC_CROPCAP":                 C.VIDIOC_CROPCAP,
+	"VIDIOC_DECODER_CMD":             C.VIDIOC_DECODER_CMD,
+	"VIDIOC_DQBUF":                   C.VIDIOC_DQBUF,
+	"VIDIOC_DQEVENT":                 C.VIDIOC_DQEVENT,
+	"VIDIOC_ENCODER_CMD":             C.VIDIOC_ENCODER_CMD,
+	"VIDIOC_ENUMINPUT":               C.VIDIOC_ENUMINPUT,
+	"VIDIOC_ENUMOUTPUT":              C.VIDIOC_ENUMOUTPUT,
+	"VIDIOC_ENUM_FMT":                C.VIDIOC_ENUM_FMT,
+	"VIDIOC_ENUM_FRAMEINTERVALS":     C.VIDIOC_ENUM_FRAMEINTERVALS,
+	"VIDIOC_ENUM_FRAMESIZES":         C.VIDIOC_ENUM_FRAMESIZES,
+	"VIDIOC_EXPBUF":                  C.VIDIOC_EXPBUF,
+	"VIDIOC_G_CROP":                  C.VIDIOC_G_CROP,
+	"VIDIOC_G_CTRL":                  C.VIDIOC_G_CTRL,
+	"VIDIOC_G_EXT_CTRLS":             C.VIDIOC_G_EXT_CTRLS,
+	"VIDIOC_G_FMT":                   C.VIDIOC_G_FMT,
+	"VIDIOC_G_INPUT":                 C.VIDIOC_G_INPUT,
+	"VIDIOC_G_OUTPUT":                C.VIDIOC_G_OUTPUT,
+	"VIDIOC_G_PARM":                  C.VIDIOC_G_PARM,
+	"VIDIOC_G_SELECTION":             C.VIDIOC_G_SELECTION,
+	"VIDIOC_QBUF":                    C.VIDIOC_QBUF,
+	"VIDIOC_QUERYBUF":                C.VIDIOC_QUERYBUF,
+	"VIDIOC_QUERYCAP":                C.VIDIOC_QUERYCAP,
+	"VIDIOC_QUERYCTRL":               C.VIDIOC_QUERYCTRL,
+	"VIDIOC_QUERYMENU":               C.VIDIOC_QUERYMENU,
+	"VIDIOC_QUERY_EXT_CTRL":          C.VIDIOC_QUERY_EXT_CTRL,
+	"VIDIOC_REQBUFS":                 C.VIDIOC_REQBUFS,
+	"VIDIOC_STREAMOFF":               C.VIDIOC_STREAMOFF,
+	"VIDIOC_STREAMON":                C.VIDIOC_STREAMON,
+	"VIDIOC_SUBDEV_ENUM_FRAME_SIZE":  C.VIDIOC_SUBDEV_ENUM_FRAME_SIZE,
+	"VIDIOC_SUBDEV_ENUM_MBUS_CODE":   C.VIDIOC_SUBDEV_ENUM_MBUS_CODE,
+	"VIDIOC_SUBDEV_G_FMT":            C.VIDIOC_SUBDEV_G_FMT,
+	"VIDIOC_SUBDEV_G_FRAME_INTERVAL": C.VIDIOC_SUBDEV_G_FRAME_INTERVAL,
+	"VIDIOC_SUBDEV_G_SELECTION":      C.VIDIOC_SUBDEV_G_SELECTION,
+	"VIDIOC_SUBDEV_S_FMT":            C.VIDIOC_SUBDEV_S_FMT,
+	"VIDIOC_SUBDEV_S_FRAME_INTERVAL": C.VIDIOC_SUBDEV_S_FRAME_INTERVAL,
+	"VIDIOC_SUBDEV_S_SELECTION":      C.VIDIOC_SUBDEV_S_SELECTION,
+	"VIDIOC_SUBSCRIBE_EVENT":         C.VIDIOC_SUBSCRIBE_EVENT,
+	"VIDIOC_S_CROP":                  C.VIDIOC_S_CROP,
+	"VIDIOC_S_CTRL":                  C.VIDIOC_S_CTRL,
+	"VIDIOC_S_EXT_CTRLS":             C.VIDIOC_S_EXT_CTRLS,
+	"VIDIOC_S_FMT":                   C.VIDIOC_S_FMT,
+	"VIDIOC_S_INPUT":                 C.VIDIOC_S_INPUT,
+	"VIDIOC_S_OUTPUT":                C.VIDIOC_S_OUTPUT,
+	"VIDIOC_S_PARM":                  C.VIDIOC_S_PARM,
+	"VIDIOC_S_SELECTION":             C.VIDIOC_S_SELECTION,
+	"VIDIOC_TRY_DECODER_CMD":         C.VIDIOC_TRY_DECODER_CMD,
+	"VIDIOC_TRY_ENCODER_CMD":         C.VIDIOC_TRY_ENCODER_CMD,
+	"VIDIOC_TRY_EXT_CTRLS":           C.VIDIOC_TRY_EXT_CTRLS,
+	"VIDIOC_TRY_FMT":                 C.VIDIOC_TRY_FMT,
+	"VIDIOC_UNSUBSCRIBE_EVENT":       C.VIDIOC_UNSUBSCRIBE_EVENT,
+}
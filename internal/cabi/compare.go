@@ -0,0 +1,65 @@
+package cabi
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Compare returns the differences between the layout of the Go type t and
+// the C type c: size, alignment, and the name, offset and type of every
+// field, down into nested structs and arrays. Padding fields are left out,
+// cgo only declares the ones Go alignment would not insert.
+func Compare(t, c reflect.Type) []string {
+	var diffs []string
+	compare(&diffs, t.Name(), t, c)
+	return diffs
+}
+
+func compare(diffs *[]string, path string, t, c reflect.Type) {
+	if t.Size() != c.Size() {
+		*diffs = append(*diffs, fmt.Sprintf("%s: size %d, C %d", path, t.Size(), c.Size()))
+		return
+	}
+	if t.Align() != c.Align() {
+		*diffs = append(*diffs, fmt.Sprintf("%s: align %d, C %d", path, t.Align(), c.Align()))
+	}
+	if t.Kind() != c.Kind() {
+		*diffs = append(*diffs, fmt.Sprintf("%s: %v, C %v", path, t.Kind(), c.Kind()))
+		return
+	}
+	switch t.Kind() {
+	case reflect.Array:
+		if t.Len() != c.Len() {
+			*diffs = append(*diffs, fmt.Sprintf("%s: %d elements, C %d", path, t.Len(), c.Len()))
+			return
+		}
+		compare(diffs, path+"[]", t.Elem(), c.Elem())
+	case reflect.Struct:
+		fields, cfields := namedFields(t), namedFields(c)
+		if len(fields) != len(cfields) {
+			*diffs = append(*diffs, fmt.Sprintf("%s: %d fields, C %d", path, len(fields), len(cfields)))
+			return
+		}
+		for i, f := range fields {
+			cf := cfields[i]
+			p := path + "." + f.Name
+			if f.Name != cf.Name {
+				*diffs = append(*diffs, fmt.Sprintf("%s: C field %s", p, cf.Name))
+			}
+			if f.Offset != cf.Offset {
+				*diffs = append(*diffs, fmt.Sprintf("%s: offset %d, C %d", p, f.Offset, cf.Offset))
+			}
+			compare(diffs, p, f.Type, cf.Type)
+		}
+	}
+}
+
+func namedFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.Name != "_" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
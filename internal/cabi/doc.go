@@ -0,0 +1,5 @@
+// Package cabi exposes the layouts of the structs and the ioctl request
+// codes of the kernel headers as the C compiler sees them, for the tests of
+// the pure Go definitions of v4l2 and media to compare against. It is empty
+// without cgo.
+package cabi
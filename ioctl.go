@@ -1,14 +1,9 @@
 package v4l2
 
-/*
-#include <linux/videodev2.h>
-*/
-import "C"
-
 import (
 	"fmt"
-	"log"
 	"syscall"
+	"time"
 	"unsafe"
 )
 
@@ -22,17 +17,17 @@ type V4L2_Capability struct {
 }
 
 func (c *V4L2_Capability) get(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_capability)(ptr)
-	c.Driver = C.GoStringN((*C.char)(unsafe.Pointer(&p.driver[0])), 16)
-	c.Card = C.GoStringN((*C.char)(unsafe.Pointer(&p.card[0])), 32)
-	c.BusInfo = C.GoStringN((*C.char)(unsafe.Pointer(&p.bus_info[0])), 32)
+	p := (*v4l2_capability)(ptr)
+	c.Driver = goString(unsafe.Pointer(&p.driver[0]))
+	c.Card = goString(unsafe.Pointer(&p.card[0]))
+	c.BusInfo = goString(unsafe.Pointer(&p.bus_info[0]))
 	c.Version = uint32(p.version)
 	c.Capabilities = uint32(p.capabilities)
 	c.DeviceCaps = uint32(p.device_caps)
 }
 
 func IoctlQueryCap(fd int, argp *V4L2_Capability) error {
-	var caps C.struct_v4l2_capability
+	var caps v4l2_capability
 	p := unsafe.Pointer(&caps)
 	err := ioctl(fd, VIDIOC_QUERYCAP, p)
 	if err != nil {
@@ -51,24 +46,24 @@ type V4L2_Fmtdesc struct {
 }
 
 func (f *V4L2_Fmtdesc) set(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_fmtdesc)(ptr)
-	p.index = C.__u32(f.Index)
+	p := (*v4l2_fmtdesc)(ptr)
+	p.index = __u32(f.Index)
 
 	// due to type field, it is keyword in golang
-	tmp := (*C.__u32)(unsafe.Pointer(
+	tmp := (*__u32)(unsafe.Pointer(
 		uintptr(ptr) + offset_fmtdesc_type))
-	*tmp = C.__u32(f.Type)
+	*tmp = __u32(f.Type)
 }
 
 func (f *V4L2_Fmtdesc) get(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_fmtdesc)(ptr)
+	p := (*v4l2_fmtdesc)(ptr)
 	f.Flags = uint32(p.flags)
-	f.Description = C.GoString((*C.char)(unsafe.Pointer(&p.description[0])))
+	f.Description = goString(unsafe.Pointer(&p.description[0]))
 	f.PixelFormat = uint32(p.pixelformat)
 }
 
 func IoctlEnumFmt(fd int, argp *V4L2_Fmtdesc) error {
-	var f C.struct_v4l2_fmtdesc
+	var f v4l2_fmtdesc
 	p := unsafe.Pointer(&f)
 	argp.set(p)
 	err := ioctl(fd, VIDIOC_ENUM_FMT, p)
@@ -79,6 +74,296 @@ func IoctlEnumFmt(fd int, argp *V4L2_Fmtdesc) error {
 	return nil
 }
 
+type V4L2_Frmsizeenum struct {
+	Index       uint32
+	PixelFormat uint32
+	Type        uint32
+	Union       interface{} // *V4L2_Frmsize_Discrete or *V4L2_Frmsize_Stepwise
+}
+
+type V4L2_Frmsize_Discrete struct {
+	Width  uint32
+	Height uint32
+}
+
+type V4L2_Frmsize_Stepwise struct {
+	MinWidth   uint32
+	MaxWidth   uint32
+	StepWidth  uint32
+	MinHeight  uint32
+	MaxHeight  uint32
+	StepHeight uint32
+}
+
+func (f *V4L2_Frmsize_Discrete) get(ptr unsafe.Pointer) {
+	p := (*v4l2_frmsize_discrete)(ptr)
+	f.Width = uint32(p.width)
+	f.Height = uint32(p.height)
+}
+
+func (f *V4L2_Frmsize_Stepwise) get(ptr unsafe.Pointer) {
+	p := (*v4l2_frmsize_stepwise)(ptr)
+	f.MinWidth = uint32(p.min_width)
+	f.MaxWidth = uint32(p.max_width)
+	f.StepWidth = uint32(p.step_width)
+	f.MinHeight = uint32(p.min_height)
+	f.MaxHeight = uint32(p.max_height)
+	f.StepHeight = uint32(p.step_height)
+}
+
+func (f *V4L2_Frmsizeenum) set(ptr unsafe.Pointer) {
+	p := (*v4l2_frmsizeenum)(ptr)
+	p.index = __u32(f.Index)
+	p.pixel_format = __u32(f.PixelFormat)
+}
+
+func (f *V4L2_Frmsizeenum) get(ptr unsafe.Pointer) {
+	// due to type field, it is keyword in golang
+	tmp := (*__u32)(unsafe.Pointer(
+		uintptr(ptr) + offset_frmsizeenum_type))
+	f.Type = uint32(*tmp)
+
+	// due to anonymous union, cannot get it's field pointer
+	u := unsafe.Pointer(uintptr(ptr) + offset_frmsizeenum_union)
+	switch f.Type {
+	case V4L2_FRMSIZE_TYPE_DISCRETE:
+		d := V4L2_Frmsize_Discrete{}
+		d.get(u)
+		f.Union = &d
+	case V4L2_FRMSIZE_TYPE_CONTINUOUS,
+		V4L2_FRMSIZE_TYPE_STEPWISE:
+		s := V4L2_Frmsize_Stepwise{}
+		s.get(u)
+		f.Union = &s
+	default:
+		f.Union = nil
+	}
+}
+
+func IoctlEnumFrameSizes(fd int, argp *V4L2_Frmsizeenum) error {
+	var fs v4l2_frmsizeenum
+	p := unsafe.Pointer(&fs)
+	argp.set(p)
+	err := ioctl(fd, VIDIOC_ENUM_FRAMESIZES, p)
+	if err != nil {
+		return err
+	}
+	argp.get(p)
+	return nil
+}
+
+type V4L2_Frmivalenum struct {
+	Index       uint32
+	PixelFormat uint32
+	Width       uint32
+	Height      uint32
+	Type        uint32
+	Union       interface{} // *V4L2_Fract or *V4L2_Frmival_Stepwise
+}
+
+type V4L2_Frmival_Stepwise struct {
+	Min  V4L2_Fract
+	Max  V4L2_Fract
+	Step V4L2_Fract
+}
+
+func (f *V4L2_Frmival_Stepwise) get(ptr unsafe.Pointer) {
+	p := (*v4l2_frmival_stepwise)(ptr)
+	f.Min.get(unsafe.Pointer(&p.min))
+	f.Max.get(unsafe.Pointer(&p.max))
+	f.Step.get(unsafe.Pointer(&p.step))
+}
+
+func (f *V4L2_Frmivalenum) set(ptr unsafe.Pointer) {
+	p := (*v4l2_frmivalenum)(ptr)
+	p.index = __u32(f.Index)
+	p.pixel_format = __u32(f.PixelFormat)
+	p.width = __u32(f.Width)
+	p.height = __u32(f.Height)
+}
+
+func (f *V4L2_Frmivalenum) get(ptr unsafe.Pointer) {
+	// due to type field, it is keyword in golang
+	tmp := (*__u32)(unsafe.Pointer(
+		uintptr(ptr) + offset_frmivalenum_type))
+	f.Type = uint32(*tmp)
+
+	// due to anonymous union, cannot get it's field pointer
+	u := unsafe.Pointer(uintptr(ptr) + offset_frmivalenum_union)
+	switch f.Type {
+	case V4L2_FRMIVAL_TYPE_DISCRETE:
+		d := V4L2_Fract{}
+		d.get(u)
+		f.Union = &d
+	case V4L2_FRMIVAL_TYPE_CONTINUOUS,
+		V4L2_FRMIVAL_TYPE_STEPWISE:
+		s := V4L2_Frmival_Stepwise{}
+		s.get(u)
+		f.Union = &s
+	default:
+		f.Union = nil
+	}
+}
+
+func IoctlEnumFrameIntervals(fd int, argp *V4L2_Frmivalenum) error {
+	var fi v4l2_frmivalenum
+	p := unsafe.Pointer(&fi)
+	argp.set(p)
+	err := ioctl(fd, VIDIOC_ENUM_FRAMEINTERVALS, p)
+	if err != nil {
+		return err
+	}
+	argp.get(p)
+	return nil
+}
+
+type V4L2_Input struct {
+	Index        uint32
+	Name         string
+	Type         uint32
+	AudioSet     uint32
+	Tuner        uint32
+	Std          uint64
+	Status       uint32
+	Capabilities uint32
+}
+
+func (i *V4L2_Input) set(ptr unsafe.Pointer) {
+	p := (*v4l2_input)(ptr)
+	p.index = __u32(i.Index)
+}
+
+func (i *V4L2_Input) get(ptr unsafe.Pointer) {
+	p := (*v4l2_input)(ptr)
+	i.Index = uint32(p.index)
+	i.Name = goString(unsafe.Pointer(&p.name[0]))
+
+	// due to type field, it is keyword in golang
+	tmp := (*__u32)(unsafe.Pointer(
+		uintptr(ptr) + offset_input_type))
+	i.Type = uint32(*tmp)
+
+	i.AudioSet = uint32(p.audioset)
+	i.Tuner = uint32(p.tuner)
+	i.Std = uint64(p.std)
+	i.Status = uint32(p.status)
+	i.Capabilities = uint32(p.capabilities)
+}
+
+// NoPower reports whether the attached device is off
+func (i *V4L2_Input) NoPower() bool {
+	return i.Status&V4L2_IN_ST_NO_POWER != 0
+}
+
+// NoSignal reports whether no signal is present on the input
+func (i *V4L2_Input) NoSignal() bool {
+	return i.Status&V4L2_IN_ST_NO_SIGNAL != 0
+}
+
+// NoColor reports whether the input signal has no color
+func (i *V4L2_Input) NoColor() bool {
+	return i.Status&V4L2_IN_ST_NO_COLOR != 0
+}
+
+func IoctlEnumInput(fd int, argp *V4L2_Input) error {
+	var vi v4l2_input
+	p := unsafe.Pointer(&vi)
+	argp.set(p)
+	err := ioctl(fd, VIDIOC_ENUMINPUT, p)
+	if err != nil {
+		return err
+	}
+	argp.get(p)
+	return nil
+}
+
+func IoctlGetInput(fd int, argp *int) error {
+	var i int32
+	p := unsafe.Pointer(&i)
+	err := ioctl(fd, VIDIOC_G_INPUT, p)
+	if err != nil {
+		return err
+	}
+	*argp = int(i)
+	return nil
+}
+
+func IoctlSetInput(fd int, argp *int) error {
+	var i int32
+	i = int32(*argp)
+	p := unsafe.Pointer(&i)
+	err := ioctl(fd, VIDIOC_S_INPUT, p)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+type V4L2_Output struct {
+	Index        uint32
+	Name         string
+	Type         uint32
+	AudioSet     uint32
+	Modulator    uint32
+	Std          uint64
+	Capabilities uint32
+}
+
+func (o *V4L2_Output) set(ptr unsafe.Pointer) {
+	p := (*v4l2_output)(ptr)
+	p.index = __u32(o.Index)
+}
+
+func (o *V4L2_Output) get(ptr unsafe.Pointer) {
+	p := (*v4l2_output)(ptr)
+	o.Index = uint32(p.index)
+	o.Name = goString(unsafe.Pointer(&p.name[0]))
+
+	// due to type field, it is keyword in golang
+	tmp := (*__u32)(unsafe.Pointer(
+		uintptr(ptr) + offset_output_type))
+	o.Type = uint32(*tmp)
+
+	o.AudioSet = uint32(p.audioset)
+	o.Modulator = uint32(p.modulator)
+	o.Std = uint64(p.std)
+	o.Capabilities = uint32(p.capabilities)
+}
+
+func IoctlEnumOutput(fd int, argp *V4L2_Output) error {
+	var vo v4l2_output
+	p := unsafe.Pointer(&vo)
+	argp.set(p)
+	err := ioctl(fd, VIDIOC_ENUMOUTPUT, p)
+	if err != nil {
+		return err
+	}
+	argp.get(p)
+	return nil
+}
+
+func IoctlGetOutput(fd int, argp *int) error {
+	var i int32
+	p := unsafe.Pointer(&i)
+	err := ioctl(fd, VIDIOC_G_OUTPUT, p)
+	if err != nil {
+		return err
+	}
+	*argp = int(i)
+	return nil
+}
+
+func IoctlSetOutput(fd int, argp *int) error {
+	var i int32
+	i = int32(*argp)
+	p := unsafe.Pointer(&i)
+	err := ioctl(fd, VIDIOC_S_OUTPUT, p)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 type V4L2_Format struct {
 	Type uint32
 	Fmt  interface{}
@@ -119,41 +404,41 @@ type V4L2_Plane_Pix_Format struct {
 }
 
 func (f *V4L2_Plane_Pix_Format) set(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_plane_pix_format)(ptr)
-	p.sizeimage = C.__u32(f.SizeImage)
-	p.bytesperline = C.__u32(f.BytesPerLine)
+	p := (*v4l2_plane_pix_format)(ptr)
+	p.sizeimage = __u32(f.SizeImage)
+	p.bytesperline = __u32(f.BytesPerLine)
 }
 
 func (f *V4L2_Plane_Pix_Format) get(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_plane_pix_format)(ptr)
+	p := (*v4l2_plane_pix_format)(ptr)
 	f.SizeImage = uint32(p.sizeimage)
 	f.BytesPerLine = uint32(p.bytesperline)
 }
 
 func (f *V4L2_Pix_Format_Mplane) set(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_pix_format_mplane)(ptr)
-	p.width = C.__u32(f.Width)
-	p.height = C.__u32(f.Height)
-	p.pixelformat = C.__u32(f.PixelFormat)
-	p.field = C.__u32(f.Field)
-	p.colorspace = C.__u32(f.ColorSpace)
-	p.num_planes = C.__u8(f.NumPlanes)
+	p := (*v4l2_pix_format_mplane)(ptr)
+	p.width = __u32(f.Width)
+	p.height = __u32(f.Height)
+	p.pixelformat = __u32(f.PixelFormat)
+	p.field = __u32(f.Field)
+	p.colorspace = __u32(f.ColorSpace)
+	p.num_planes = __u8(f.NumPlanes)
 	for i := 0; i < VIDEO_MAX_PLANES; i++ {
 		f.PlaneFmt[i].set(unsafe.Pointer(&p.plane_fmt[i]))
 	}
-	p.flags = C.__u8(f.Flags)
+	p.flags = __u8(f.Flags)
 
 	// v4l2_pix_format_mplane in videodev2.h is a little difference between linux-4.4.0 and linux-4.14.0
-	tmp := (*C.__u8)(unsafe.Pointer(
+	tmp := (*__u8)(unsafe.Pointer(
 		uintptr(ptr) + offset_pix_format_mplane_encoding))
-	*tmp = C.__u8(f.Encoding)
+	*tmp = __u8(f.Encoding)
 
-	p.quantization = C.__u8(f.Quantization)
-	p.xfer_func = C.__u8(f.XferFunc)
+	p.quantization = __u8(f.Quantization)
+	p.xfer_func = __u8(f.XferFunc)
 }
 
 func (f *V4L2_Pix_Format_Mplane) get(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_pix_format_mplane)(ptr)
+	p := (*v4l2_pix_format_mplane)(ptr)
 	f.Width = uint32(p.width)
 	f.Height = uint32(p.height)
 	f.PixelFormat = uint32(p.pixelformat)
@@ -166,7 +451,7 @@ func (f *V4L2_Pix_Format_Mplane) get(ptr unsafe.Pointer) {
 	f.Flags = uint8(p.flags)
 
 	// v4l2_pix_format_mplane in videodev2.h is a little difference between linux-4.4.0 and linux-4.14.0
-	tmp := (*C.__u8)(unsafe.Pointer(
+	tmp := (*__u8)(unsafe.Pointer(
 		uintptr(ptr) + offset_pix_format_mplane_encoding))
 	f.Encoding = uint8(*tmp)
 
@@ -175,28 +460,28 @@ func (f *V4L2_Pix_Format_Mplane) get(ptr unsafe.Pointer) {
 }
 
 func (f *V4L2_Pix_Format) set(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_pix_format)(ptr)
-	p.width = C.__u32(f.Width)
-	p.height = C.__u32(f.Height)
-	p.pixelformat = C.__u32(f.PixelFormat)
-	p.field = C.__u32(f.Field)
-	p.bytesperline = C.__u32(f.BytesPerLine)
-	p.sizeimage = C.__u32(f.SizeImage)
-	p.colorspace = C.__u32(f.ColorSpace)
-	p.priv = C.__u32(f.Priv)
-	p.flags = C.__u32(f.Flags)
+	p := (*v4l2_pix_format)(ptr)
+	p.width = __u32(f.Width)
+	p.height = __u32(f.Height)
+	p.pixelformat = __u32(f.PixelFormat)
+	p.field = __u32(f.Field)
+	p.bytesperline = __u32(f.BytesPerLine)
+	p.sizeimage = __u32(f.SizeImage)
+	p.colorspace = __u32(f.ColorSpace)
+	p.priv = __u32(f.Priv)
+	p.flags = __u32(f.Flags)
 
 	// v4l2_pix_format in videodev2.h is a little difference between linux-4.4.0 and linux-4.14.0
-	tmp := (*C.__u32)(unsafe.Pointer(
+	tmp := (*__u32)(unsafe.Pointer(
 		uintptr(ptr) + offset_pix_format_encoding))
-	*tmp = C.__u32(f.Encoding)
+	*tmp = __u32(f.Encoding)
 
-	p.quantization = C.__u32(f.Quantization)
-	p.xfer_func = C.__u32(f.XferFunc)
+	p.quantization = __u32(f.Quantization)
+	p.xfer_func = __u32(f.XferFunc)
 }
 
 func (f *V4L2_Pix_Format) get(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_pix_format)(ptr)
+	p := (*v4l2_pix_format)(ptr)
 	f.Width = uint32(p.width)
 	f.Height = uint32(p.height)
 	f.PixelFormat = uint32(p.pixelformat)
@@ -208,7 +493,7 @@ func (f *V4L2_Pix_Format) get(ptr unsafe.Pointer) {
 	f.Flags = uint32(p.flags)
 
 	// v4l2_pix_format in videodev2.h is a little difference between linux-4.4.0 and linux-4.14.0
-	tmp := (*C.__u32)(unsafe.Pointer(
+	tmp := (*__u32)(unsafe.Pointer(
 		uintptr(ptr) + offset_pix_format_encoding))
 	f.Encoding = uint32(*tmp)
 
@@ -218,13 +503,13 @@ func (f *V4L2_Pix_Format) get(ptr unsafe.Pointer) {
 
 func (f *V4L2_Format) set(ptr unsafe.Pointer) {
 	// due to type field, it is keyword in golang
-	tmp := (*C.__u32)(unsafe.Pointer(
+	tmp := (*__u32)(unsafe.Pointer(
 		uintptr(ptr) + offset_format_type))
-	*tmp = C.__u32(f.Type)
+	*tmp = __u32(f.Type)
 }
 
-func (f *V4L2_Format) get(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_format)(ptr)
+func (f *V4L2_Format) get(ptr unsafe.Pointer) error {
+	p := (*v4l2_format)(ptr)
 
 	switch pf := f.Fmt.(type) {
 	case *V4L2_Pix_Format:
@@ -232,24 +517,24 @@ func (f *V4L2_Format) get(ptr unsafe.Pointer) {
 	case *V4L2_Pix_Format_Mplane:
 		pf.get(unsafe.Pointer(&p.fmt))
 	default:
-		log.Fatalf("Unexpected type %T\n", pf)
+		return fmt.Errorf("%w %T", ErrorUnexpectedType, pf)
 	}
+	return nil
 }
 
 func IoctlGetFmt(fd int, argp *V4L2_Format) error {
-	var vf C.struct_v4l2_format
+	var vf v4l2_format
 	p := unsafe.Pointer(&vf)
 	argp.set(p)
 	err := ioctl(fd, VIDIOC_G_FMT, p)
 	if err != nil {
 		return err
 	}
-	argp.get(p)
-	return nil
+	return argp.get(p)
 }
 
 func IoctlSetFmt(fd int, argp *V4L2_Format) error {
-	var vf C.struct_v4l2_format
+	var vf v4l2_format
 	p := unsafe.Pointer(&vf)
 	argp.set(p)
 	switch pf := argp.Fmt.(type) {
@@ -258,18 +543,17 @@ func IoctlSetFmt(fd int, argp *V4L2_Format) error {
 	case *V4L2_Pix_Format_Mplane:
 		pf.set(unsafe.Pointer(&vf.fmt))
 	default:
-		log.Fatalf("Unexpected type %T\n", pf)
+		return fmt.Errorf("%w %T", ErrorUnexpectedType, pf)
 	}
 	err := ioctl(fd, VIDIOC_S_FMT, p)
 	if err != nil {
 		return err
 	}
-	argp.get(p)
-	return nil
+	return argp.get(p)
 }
 
 func IoctlTryFmt(fd int, argp *V4L2_Format) error {
-	var vf C.struct_v4l2_format
+	var vf v4l2_format
 	p := unsafe.Pointer(&vf)
 	argp.set(p)
 	switch pf := argp.Fmt.(type) {
@@ -278,14 +562,13 @@ func IoctlTryFmt(fd int, argp *V4L2_Format) error {
 	case *V4L2_Pix_Format_Mplane:
 		pf.set(unsafe.Pointer(&vf.fmt))
 	default:
-		log.Fatalf("Unexpected type %T", pf)
+		return fmt.Errorf("%w %T", ErrorUnexpectedType, pf)
 	}
 	err := ioctl(fd, VIDIOC_TRY_FMT, p)
 	if err != nil {
 		return err
 	}
-	argp.get(p)
-	return nil
+	return argp.get(p)
 }
 
 type V4L2_Control struct {
@@ -294,19 +577,19 @@ type V4L2_Control struct {
 }
 
 func (c *V4L2_Control) set(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_control)(ptr)
-	p.id = C.__u32(c.ID)
-	p.value = C.__s32(c.Value)
+	p := (*v4l2_control)(ptr)
+	p.id = __u32(c.ID)
+	p.value = __s32(c.Value)
 }
 
 func (c *V4L2_Control) get(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_control)(ptr)
+	p := (*v4l2_control)(ptr)
 	c.ID = uint32(p.id)
 	c.Value = int32(p.value)
 }
 
 func IoctlGetCtrl(fd int, argp *V4L2_Control) error {
-	var vc C.struct_v4l2_control
+	var vc v4l2_control
 	p := unsafe.Pointer(&vc)
 	argp.set(p)
 	err := ioctl(fd, VIDIOC_G_CTRL, p)
@@ -318,7 +601,7 @@ func IoctlGetCtrl(fd int, argp *V4L2_Control) error {
 }
 
 func IoctlSetCtrl(fd int, argp *V4L2_Control) error {
-	var vc C.struct_v4l2_control
+	var vc v4l2_control
 	p := unsafe.Pointer(&vc)
 	argp.set(p)
 	err := ioctl(fd, VIDIOC_S_CTRL, p)
@@ -340,20 +623,20 @@ type V4L2_Queryctrl struct {
 }
 
 func (c *V4L2_Queryctrl) set(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_queryctrl)(ptr)
-	p.id = C.__u32(c.ID)
+	p := (*v4l2_queryctrl)(ptr)
+	p.id = __u32(c.ID)
 }
 
 func (c *V4L2_Queryctrl) get(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_queryctrl)(ptr)
+	p := (*v4l2_queryctrl)(ptr)
 	c.ID = uint32(p.id)
 
 	// due to type field, it is keyword in golang
-	tmp := (*C.__u32)(unsafe.Pointer(
+	tmp := (*__u32)(unsafe.Pointer(
 		uintptr(ptr) + offset_queryctrl_type))
 	c.Type = uint32(*tmp)
 
-	c.Name = C.GoString((*C.char)(unsafe.Pointer(&p.name[0])))
+	c.Name = goString(unsafe.Pointer(&p.name[0]))
 	c.Minimum = int32(p.minimum)
 	c.Maximum = int32(p.maximum)
 	c.Step = int32(p.step)
@@ -362,7 +645,7 @@ func (c *V4L2_Queryctrl) get(ptr unsafe.Pointer) {
 }
 
 func IoctlQueryCtrl(fd int, argp *V4L2_Queryctrl) error {
-	var qc C.struct_v4l2_queryctrl
+	var qc v4l2_queryctrl
 	p := unsafe.Pointer(&qc)
 	argp.set(p)
 	err := ioctl(fd, VIDIOC_QUERYCTRL, p)
@@ -377,22 +660,26 @@ type V4L2_Querymenu struct {
 	ID    uint32
 	Index uint32
 	Union []byte
+	Name  string // item name of V4L2_CTRL_TYPE_MENU controls
+	Value int64  // item value of V4L2_CTRL_TYPE_INTEGER_MENU controls
 }
 
 func (m *V4L2_Querymenu) set(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_querymenu)(ptr)
-	p.id = C.__u32(m.ID)
-	p.index = C.__u32(m.Index)
+	p := (*v4l2_querymenu)(ptr)
+	p.id = __u32(m.ID)
+	p.index = __u32(m.Index)
 }
 
 func (m *V4L2_Querymenu) get(ptr unsafe.Pointer) {
 	// due to anonymous union, cannot get it's field pointer
 	p := unsafe.Pointer(uintptr(ptr) + offset_querymenu_union)
-	m.Union = C.GoBytes(p, 32)
+	m.Union = goBytes(p, 32)
+	m.Name = goString(p)
+	m.Value = int64(*(*__s64)(p))
 }
 
 func IoctlQueryMenu(fd int, argp *V4L2_Querymenu) error {
-	var vm C.struct_v4l2_querymenu
+	var vm v4l2_querymenu
 	p := unsafe.Pointer(&vm)
 	argp.set(p)
 	err := ioctl(fd, VIDIOC_QUERYMENU, p)
@@ -419,17 +706,18 @@ type V4L2_Query_Ext_Ctrl struct {
 }
 
 func (c *V4L2_Query_Ext_Ctrl) set(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_query_ext_ctrl)(ptr)
-	p.id = C.__u32(c.ID)
+	p := (*v4l2_query_ext_ctrl)(ptr)
+	p.id = __u32(c.ID)
 }
 
 func (c *V4L2_Query_Ext_Ctrl) get(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_query_ext_ctrl)(ptr)
+	p := (*v4l2_query_ext_ctrl)(ptr)
+	c.ID = uint32(p.id)
 
-	tmp := (*C.__u32)(unsafe.Pointer(uintptr(ptr) + offset_query_ext_ctrl_type))
+	tmp := (*__u32)(unsafe.Pointer(uintptr(ptr) + offset_query_ext_ctrl_type))
 	c.Type = uint32(*tmp)
 
-	c.Name = C.GoString((*C.char)(&p.name[0]))
+	c.Name = goString(unsafe.Pointer(&p.name[0]))
 	c.Minimum = int64(p.minimum)
 	c.Maximum = int64(p.maximum)
 	c.Step = uint64(p.step)
@@ -443,7 +731,7 @@ func (c *V4L2_Query_Ext_Ctrl) get(ptr unsafe.Pointer) {
 }
 
 func IoctlQueryExtCtrl(fd int, argp *V4L2_Query_Ext_Ctrl) error {
-	var ctrl C.struct_v4l2_query_ext_ctrl
+	var ctrl v4l2_query_ext_ctrl
 	p := unsafe.Pointer(&ctrl)
 	argp.set(p)
 	err := ioctl(fd, VIDIOC_QUERY_EXT_CTRL, p)
@@ -467,15 +755,15 @@ type V4L2_Rect struct {
 }
 
 func (r *V4L2_Rect) set(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_rect)(ptr)
-	p.left = C.__s32(r.Left)
-	p.top = C.__s32(r.Top)
-	p.width = C.__u32(r.Width)
-	p.height = C.__u32(r.Height)
+	p := (*v4l2_rect)(ptr)
+	p.left = __s32(r.Left)
+	p.top = __s32(r.Top)
+	p.width = __u32(r.Width)
+	p.height = __u32(r.Height)
 }
 
 func (r *V4L2_Rect) get(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_rect)(ptr)
+	p := (*v4l2_rect)(ptr)
 	r.Left = int32(p.left)
 	r.Top = int32(p.top)
 	r.Width = uint32(p.width)
@@ -484,13 +772,13 @@ func (r *V4L2_Rect) get(ptr unsafe.Pointer) {
 
 func (c *V4L2_Crop) set(ptr unsafe.Pointer) {
 	// due to type field, it is keyword in golang
-	tmp := (*C.__u32)(unsafe.Pointer(
+	tmp := (*__u32)(unsafe.Pointer(
 		uintptr(ptr) + offset_crop_type))
-	*tmp = C.__u32(c.Type)
+	*tmp = __u32(c.Type)
 }
 
 func IoctlGetCrop(fd int, argp *V4L2_Crop) error {
-	var vc C.struct_v4l2_crop
+	var vc v4l2_crop
 	p := unsafe.Pointer(&vc)
 	argp.set(p)
 	err := ioctl(fd, VIDIOC_G_CROP, p)
@@ -502,7 +790,7 @@ func IoctlGetCrop(fd int, argp *V4L2_Crop) error {
 }
 
 func IoctlSetCrop(fd int, argp *V4L2_Crop) error {
-	var vc C.struct_v4l2_crop
+	var vc v4l2_crop
 	p := unsafe.Pointer(&vc)
 	argp.set(p)
 	argp.C.set(unsafe.Pointer(&vc.c))
@@ -526,26 +814,26 @@ type V4L2_Fract struct {
 }
 
 func (f *V4L2_Fract) set(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_fract)(ptr)
-	p.numerator = C.__u32(f.Numerator)
-	p.denominator = C.__u32(f.Denominator)
+	p := (*v4l2_fract)(ptr)
+	p.numerator = __u32(f.Numerator)
+	p.denominator = __u32(f.Denominator)
 }
 
 func (f *V4L2_Fract) get(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_fract)(ptr)
+	p := (*v4l2_fract)(ptr)
 	f.Numerator = uint32(p.numerator)
 	f.Denominator = uint32(p.denominator)
 }
 
 func (c *V4L2_Cropcap) set(ptr unsafe.Pointer) {
 	// due to type field, it is keyword in golang
-	tmp := (*C.__u32)(unsafe.Pointer(
+	tmp := (*__u32)(unsafe.Pointer(
 		uintptr(ptr) + offset_crop_type))
-	*tmp = C.__u32(c.Type)
+	*tmp = __u32(c.Type)
 }
 
 func IoctlCropCap(fd int, argp *V4L2_Cropcap) error {
-	var cc C.struct_v4l2_cropcap
+	var cc v4l2_cropcap
 	p := unsafe.Pointer(&cc)
 	argp.set(p)
 	err := ioctl(fd, VIDIOC_CROPCAP, p)
@@ -558,18 +846,99 @@ func IoctlCropCap(fd int, argp *V4L2_Cropcap) error {
 	return nil
 }
 
+type V4L2_Selection struct {
+	Type   uint32
+	Target uint32
+	Flags  uint32
+	R      V4L2_Rect
+}
+
+func (s *V4L2_Selection) set(ptr unsafe.Pointer) {
+	p := (*v4l2_selection)(ptr)
+
+	// due to type field, it is keyword in golang
+	tmp := (*__u32)(unsafe.Pointer(
+		uintptr(ptr) + offset_selection_type))
+	*tmp = __u32(s.Type)
+
+	p.target = __u32(s.Target)
+	p.flags = __u32(s.Flags)
+	s.R.set(unsafe.Pointer(&p.r))
+}
+
+func (s *V4L2_Selection) get(ptr unsafe.Pointer) {
+	p := (*v4l2_selection)(ptr)
+	s.Flags = uint32(p.flags)
+	s.R.get(unsafe.Pointer(&p.r))
+}
+
+func IoctlGetSelection(fd int, argp *V4L2_Selection) error {
+	var vs v4l2_selection
+	p := unsafe.Pointer(&vs)
+	argp.set(p)
+	err := ioctl(fd, VIDIOC_G_SELECTION, p)
+	if err != nil {
+		return err
+	}
+	argp.get(p)
+	return nil
+}
+
+func IoctlSetSelection(fd int, argp *V4L2_Selection) error {
+	var vs v4l2_selection
+	p := unsafe.Pointer(&vs)
+	argp.set(p)
+	err := ioctl(fd, VIDIOC_S_SELECTION, p)
+	if err != nil {
+		return err
+	}
+	argp.get(p)
+	return nil
+}
+
 type V4L2_Buffer struct {
 	Index     uint32
 	Type      uint32
 	BytesUsed uint32
 	Flags     uint32
 	Field     uint32
-	TimeStamp syscall.Timeval
+	TimeStamp time.Duration // timeval of the buffer
 	TimeCode  V4L2_Timecode
 	Sequence  uint32
 	Memory    uint32
-	M         []byte
+	M         []byte       // m of single-planar buffers
+	Planes    []V4L2_Plane // m.planes of multi-planar buffers
 	Length    uint32
+	RequestFD int32 // valid with V4L2_BUF_FLAG_REQUEST_FD
+}
+
+// Offset returns m.offset of a V4L2_MEMORY_MMAP buffer
+func (b *V4L2_Buffer) Offset() uint32 {
+	var offset uint32
+	GetValueFromUnion(b.M, &offset)
+	return offset
+}
+
+// FD returns m.fd of a V4L2_MEMORY_DMABUF buffer
+func (b *V4L2_Buffer) FD() int {
+	var fd int
+	GetValueFromUnion(b.M, &fd)
+	return fd
+}
+
+// SetFD stores the dmabuf fd to be queued into m.fd
+func (b *V4L2_Buffer) SetFD(fd int) {
+	b.M = fdToBytes(fd)
+}
+
+// UserPtr returns m.userptr of a V4L2_MEMORY_USERPTR buffer
+func (b *V4L2_Buffer) UserPtr() uintptr {
+	return BytesToUintptr(b.M)
+}
+
+// SetUserPtr stores the address to be queued into m.userptr
+func (b *V4L2_Buffer) SetUserPtr(ptr uintptr) {
+	b.M = UintptrToBytes(ptr)
 }
 
 type V4L2_Timecode struct {
@@ -589,19 +958,57 @@ type V4L2_Plane struct {
 	DataOffset uint32
 }
 
+// Offset returns m.mem_offset of a V4L2_MEMORY_MMAP plane
+func (v *V4L2_Plane) Offset() uint32 {
+	var offset uint32
+	GetValueFromUnion(v.Union, &offset)
+	return offset
+}
+
+// FD returns m.fd of a V4L2_MEMORY_DMABUF plane
+func (v *V4L2_Plane) FD() int {
+	var fd int
+	GetValueFromUnion(v.Union, &fd)
+	return fd
+}
+
+// SetFD stores the dmabuf fd to be queued into m.fd
+func (v *V4L2_Plane) SetFD(fd int) {
+	v.Union = fdToBytes(fd)
+}
+
+// UserPtr returns m.userptr of a V4L2_MEMORY_USERPTR plane
+func (v *V4L2_Plane) UserPtr() uintptr {
+	return BytesToUintptr(v.Union)
+}
+
+// SetUserPtr stores the address to be queued into m.userptr
+func (v *V4L2_Plane) SetUserPtr(ptr uintptr) {
+	v.Union = UintptrToBytes(ptr)
+}
+
+func (v *V4L2_Plane) set(ptr unsafe.Pointer) {
+	p := (*v4l2_plane)(ptr)
+	p.bytesused = __u32(v.BytesUsed)
+	p.length = __u32(v.Length)
+	m := (*[__SIZEOF_POINTER__]byte)(unsafe.Pointer(&p.m))
+	copy(m[:], v.Union)
+	p.data_offset = __u32(v.DataOffset)
+}
+
 func (v *V4L2_Plane) get(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_plane)(ptr)
+	p := (*v4l2_plane)(ptr)
 	v.BytesUsed = uint32(p.bytesused)
 	v.Length = uint32(p.length)
-	v.Union = C.GoBytes(unsafe.Pointer(&p.m), __SIZEOF_POINTER__)
+	v.Union = goBytes(unsafe.Pointer(&p.m), __SIZEOF_POINTER__)
 	v.DataOffset = uint32(p.data_offset)
 }
 
 func (t *V4L2_Timecode) get(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_timecode)(ptr)
+	p := (*v4l2_timecode)(ptr)
 
 	// due to type field, it is keyword in golang
-	tmp := (*C.__u32)(unsafe.Pointer(
+	tmp := (*__u32)(unsafe.Pointer(
 		uintptr(ptr) + offset_timecode_type))
 	t.Type = uint32(*tmp)
 
@@ -615,27 +1022,40 @@ func (t *V4L2_Timecode) get(ptr unsafe.Pointer) {
 }
 
 func (b *V4L2_Buffer) set(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_buffer)(ptr)
-	p.index = C.__u32(b.Index)
+	p := (*v4l2_buffer)(ptr)
+	p.index = __u32(b.Index)
 
 	// due to type field, it is keyword in golang
-	tmp := (*C.__u32)(unsafe.Pointer(
+	tmp := (*__u32)(unsafe.Pointer(
 		uintptr(ptr) + offset_buffer_type))
-	*tmp = C.__u32(b.Type)
+	*tmp = __u32(b.Type)
+
+	p.bytesused = __u32(b.BytesUsed)
+	p.flags = __u32(b.Flags)
+	p.field = __u32(b.Field)
+
+	// timestamps of OUTPUT buffers are passed on to CAPTURE buffers
+	setTimeval(&p.timestamp.tv_sec, &p.timestamp.tv_usec, b.TimeStamp)
 
-	p.bytesused = C.__u32(b.BytesUsed)
-	p.flags = C.__u32(b.Flags)
-	p.field = C.__u32(b.Field)
-	p.memory = C.__u32(b.Memory)
-	p.length = C.__u32(b.Length)
+	p.memory = __u32(b.Memory)
+	if !isMplane(b.Type) {
+		m := (*[__SIZEOF_POINTER__]byte)(unsafe.Pointer(&p.m))
+		copy(m[:], b.M)
+	}
+	p.length = __u32(b.Length)
+
+	// due to anonymous union, cannot get it's field pointer
+	fd := (*__s32)(unsafe.Pointer(
+		uintptr(ptr) + offset_buffer_request_fd))
+	*fd = __s32(b.RequestFD)
 }
 
 func (b *V4L2_Buffer) get(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_buffer)(ptr)
+	p := (*v4l2_buffer)(ptr)
 	b.Index = uint32(p.index)
 
 	// due to type field, it is keyword in golang
-	tmp := (*C.__u32)(unsafe.Pointer(
+	tmp := (*__u32)(unsafe.Pointer(
 		uintptr(ptr) + offset_buffer_type))
 	b.Type = uint32(*tmp)
 
@@ -643,101 +1063,100 @@ func (b *V4L2_Buffer) get(ptr unsafe.Pointer) {
 	b.Flags = uint32(p.flags)
 	b.Field = uint32(p.field)
 
-	t := (*syscall.Timeval)(unsafe.Pointer(&p.timestamp))
-	b.TimeStamp = *t
+	b.TimeStamp = timevalDuration(p.timestamp.tv_sec, p.timestamp.tv_usec)
 	b.TimeCode.get(unsafe.Pointer(&p.timecode))
 	b.Sequence = uint32(p.sequence)
 	b.Memory = uint32(p.memory)
-	b.M = C.GoBytes(unsafe.Pointer(&p.m), __SIZEOF_POINTER__)
+	b.M = goBytes(unsafe.Pointer(&p.m), __SIZEOF_POINTER__)
 	b.Length = uint32(p.length)
+
+	fd := (*__s32)(unsafe.Pointer(
+		uintptr(ptr) + offset_buffer_request_fd))
+	b.RequestFD = int32(*fd)
 }
 
-func IoctlQueryBuf(fd int, argp *V4L2_Buffer) error {
-	var vb C.struct_v4l2_buffer
-	var planes [VIDEO_MAX_PLANES]C.struct_v4l2_plane
-
-	if argp.Type == V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE ||
-		argp.Type == V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE {
-		b := UintptrToBytes(uintptr(unsafe.Pointer(&planes[0])))
-		for i := 0; i < __SIZEOF_POINTER__; i++ {
-			vb.m[i] = b[i]
-		}
-	}
+func isMplane(bufType uint32) bool {
+	return bufType == V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE ||
+		bufType == V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE
+}
+
+// for multi-planar buffers, the first argp.Length of argp.Planes are
+// passed as m.planes and filled in from the driver
+func ioctlBuffer(fd int, request uint, argp *V4L2_Buffer) error {
+	var vb v4l2_buffer
+	var planes [VIDEO_MAX_PLANES]v4l2_plane
+
+	nplanes := min(int(argp.Length), len(argp.Planes), VIDEO_MAX_PLANES)
 	p := unsafe.Pointer(&vb)
 	argp.set(p)
-	err := ioctl(fd, VIDIOC_QUERYBUF, p)
+	if isMplane(argp.Type) {
+		*(*unsafe.Pointer)(unsafe.Pointer(&vb.m)) = unsafe.Pointer(&planes[0])
+		vb.length = __u32(nplanes)
+		for i := 0; i < nplanes; i++ {
+			argp.Planes[i].set(unsafe.Pointer(&planes[i]))
+		}
+	}
+	err := bufferIoctl(fd, request, &vb)
 	if err != nil {
 		return err
 	}
 
-	if argp.Type == V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE ||
-		argp.Type == V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE {
-		tmp := unsafe.Pointer(BytesToUintptr(argp.M))
-		p := (*[VIDEO_MAX_PLANES]V4L2_Plane)(tmp)
-		for i := 0; i < int(vb.length); i++ {
-			(*p)[i].get(unsafe.Pointer(&planes[i]))
+	argp.get(p)
+	if isMplane(argp.Type) {
+		for i := 0; i < int(vb.length) && i < nplanes; i++ {
+			argp.Planes[i].get(unsafe.Pointer(&planes[i]))
 		}
+		argp.M = nil
 	}
-	argp.get(p)
 	return nil
 }
 
-func IoctlQBuf(fd int, argp *V4L2_Buffer) error {
-	var vb C.struct_v4l2_buffer
-	var planes [VIDEO_MAX_PLANES]C.struct_v4l2_plane
-
-	if argp.Type == V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE ||
-		argp.Type == V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE {
-		b := UintptrToBytes(uintptr(unsafe.Pointer(&planes[0])))
-		for i := 0; i < __SIZEOF_POINTER__; i++ {
-			vb.m[i] = b[i]
-		}
-	}
-	p := unsafe.Pointer(&vb)
-	argp.set(p)
-	err := ioctl(fd, VIDIOC_QBUF, p)
-	if err != nil {
-		return err
-	}
+func IoctlQueryBuf(fd int, argp *V4L2_Buffer) error {
+	return ioctlBuffer(fd, VIDIOC_QUERYBUF, argp)
+}
 
-	if argp.Type == V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE ||
-		argp.Type == V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE {
-		tmp := unsafe.Pointer(BytesToUintptr(argp.M))
-		p := (*[VIDEO_MAX_PLANES]V4L2_Plane)(tmp)
-		for i := 0; i < int(vb.length); i++ {
-			(*p)[i].get(unsafe.Pointer(&planes[i]))
-		}
-	}
-	argp.get(p)
-	return nil
+func IoctlQBuf(fd int, argp *V4L2_Buffer) error {
+	return ioctlBuffer(fd, VIDIOC_QBUF, argp)
 }
 
 func IoctlDQBuf(fd int, argp *V4L2_Buffer) error {
-	var vb C.struct_v4l2_buffer
-	var planes [VIDEO_MAX_PLANES]C.struct_v4l2_plane
-
-	if argp.Type == V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE ||
-		argp.Type == V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE {
-		b := UintptrToBytes(uintptr(unsafe.Pointer(&planes[0])))
-		for i := 0; i < __SIZEOF_POINTER__; i++ {
-			vb.m[i] = b[i]
-		}
-	}
-	p := unsafe.Pointer(&vb)
+	return ioctlBuffer(fd, VIDIOC_DQBUF, argp)
+}
+
+type V4L2_Exportbuffer struct {
+	Type  uint32
+	Index uint32
+	Plane uint32
+	Flags uint32
+	FD    int
+}
+
+func (e *V4L2_Exportbuffer) set(ptr unsafe.Pointer) {
+	p := (*v4l2_exportbuffer)(ptr)
+
+	// due to type field, it is keyword in golang
+	tmp := (*__u32)(unsafe.Pointer(
+		uintptr(ptr) + offset_exportbuffer_type))
+	*tmp = __u32(e.Type)
+
+	p.index = __u32(e.Index)
+	p.plane = __u32(e.Plane)
+	p.flags = __u32(e.Flags)
+}
+
+func (e *V4L2_Exportbuffer) get(ptr unsafe.Pointer) {
+	p := (*v4l2_exportbuffer)(ptr)
+	e.FD = int(p.fd)
+}
+
+func IoctlExportBuffer(fd int, argp *V4L2_Exportbuffer) error {
+	var eb v4l2_exportbuffer
+	p := unsafe.Pointer(&eb)
 	argp.set(p)
-	err := ioctl(fd, VIDIOC_DQBUF, p)
+	err := ioctl(fd, VIDIOC_EXPBUF, p)
 	if err != nil {
 		return err
 	}
-
-	if argp.Type == V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE ||
-		argp.Type == V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE {
-		tmp := unsafe.Pointer(BytesToUintptr(argp.M))
-		p := (*[VIDEO_MAX_PLANES]V4L2_Plane)(tmp)
-		for i := 0; i < int(vb.length); i++ {
-			(*p)[i].get(unsafe.Pointer(&planes[i]))
-		}
-	}
 	argp.get(p)
 	return nil
 }
@@ -749,22 +1168,22 @@ type V4L2_Requestbuffers struct {
 }
 
 func (b *V4L2_Requestbuffers) set(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_requestbuffers)(ptr)
-	p.count = C.__u32(b.Count)
+	p := (*v4l2_requestbuffers)(ptr)
+	p.count = __u32(b.Count)
 	// due to type field, it is keyword in golang
-	tmp := (*C.__u32)(unsafe.Pointer(
+	tmp := (*__u32)(unsafe.Pointer(
 		uintptr(ptr) + offset_requestbuffers_type))
-	*tmp = C.__u32(b.Type)
-	p.memory = C.__u32(b.Memory)
+	*tmp = __u32(b.Type)
+	p.memory = __u32(b.Memory)
 }
 
 func (b *V4L2_Requestbuffers) get(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_requestbuffers)(ptr)
+	p := (*v4l2_requestbuffers)(ptr)
 	b.Count = uint32(p.count)
 }
 
 func IoctlRequestBuffers(fd int, argp *V4L2_Requestbuffers) error {
-	var rb C.struct_v4l2_requestbuffers
+	var rb v4l2_requestbuffers
 	p := unsafe.Pointer(&rb)
 	argp.set(p)
 	err := ioctl(fd, VIDIOC_REQBUFS, p)
@@ -797,14 +1216,14 @@ type V4L2_Outputparm struct {
 }
 
 func (o *V4L2_Outputparm) set(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_outputparm)(ptr)
-	p.outputmode = C.__u32(o.OutputMode)
+	p := (*v4l2_outputparm)(ptr)
+	p.outputmode = __u32(o.OutputMode)
 	o.TimePerFrame.set(unsafe.Pointer(&p.timeperframe))
-	p.writebuffers = C.__u32(o.WriteBuffers)
+	p.writebuffers = __u32(o.WriteBuffers)
 }
 
 func (o *V4L2_Outputparm) get(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_outputparm)(ptr)
+	p := (*v4l2_outputparm)(ptr)
 	o.Capability = uint32(p.capability)
 	o.OutputMode = uint32(p.outputmode)
 	o.TimePerFrame.get(unsafe.Pointer(&p.timeperframe))
@@ -813,14 +1232,14 @@ func (o *V4L2_Outputparm) get(ptr unsafe.Pointer) {
 }
 
 func (c *V4L2_Captureparm) set(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_captureparm)(ptr)
-	p.capturemode = C.__u32(c.CaptureMode)
+	p := (*v4l2_captureparm)(ptr)
+	p.capturemode = __u32(c.CaptureMode)
 	c.TimePerFrame.set(unsafe.Pointer(&p.timeperframe))
-	p.readbuffers = C.__u32(c.ReadBuffers)
+	p.readbuffers = __u32(c.ReadBuffers)
 }
 
 func (c *V4L2_Captureparm) get(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_captureparm)(ptr)
+	p := (*v4l2_captureparm)(ptr)
 	c.Capability = uint32(p.capability)
 	c.CaptureMode = uint32(p.capturemode)
 	c.TimePerFrame.get(unsafe.Pointer(&p.timeperframe))
@@ -830,13 +1249,13 @@ func (c *V4L2_Captureparm) get(ptr unsafe.Pointer) {
 
 func (s *V4L2_Streamparm) set(ptr unsafe.Pointer) {
 	// due to type field, it is keyword in golang
-	tmp := (*C.__u32)(unsafe.Pointer(
+	tmp := (*__u32)(unsafe.Pointer(
 		uintptr(ptr) + offset_streamparm_type))
-	*tmp = C.__u32(s.Type)
+	*tmp = __u32(s.Type)
 }
 
-func (s *V4L2_Streamparm) get(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_streamparm)(ptr)
+func (s *V4L2_Streamparm) get(ptr unsafe.Pointer) error {
+	p := (*v4l2_streamparm)(ptr)
 
 	switch s.Type {
 	case V4L2_BUF_TYPE_VIDEO_CAPTURE,
@@ -850,24 +1269,24 @@ func (s *V4L2_Streamparm) get(ptr unsafe.Pointer) {
 		op.get(unsafe.Pointer(&p.parm))
 		s.Parm = &op
 	default:
-		log.Fatalf("Unexpected buffer type %v\n", s.Type)
+		return fmt.Errorf("%w: buffer type %v", ErrorUnexpectedType, s.Type)
 	}
+	return nil
 }
 
 func IoctlGetParm(fd int, argp *V4L2_Streamparm) error {
-	var sp C.struct_v4l2_streamparm
+	var sp v4l2_streamparm
 	p := unsafe.Pointer(&sp)
 	argp.set(p)
 	err := ioctl(fd, VIDIOC_G_PARM, p)
 	if err != nil {
 		return err
 	}
-	argp.get(p)
-	return nil
+	return argp.get(p)
 }
 
 func IoctlSetParm(fd int, argp *V4L2_Streamparm) error {
-	var sp C.struct_v4l2_streamparm
+	var sp v4l2_streamparm
 	p := unsafe.Pointer(&sp)
 	argp.set(p)
 	switch parm := argp.Parm.(type) {
@@ -876,7 +1295,7 @@ func IoctlSetParm(fd int, argp *V4L2_Streamparm) error {
 	case *V4L2_Outputparm:
 		parm.set(unsafe.Pointer(&sp.parm))
 	default:
-		panic(fmt.Sprintf("Unexpected type %T\n", parm))
+		return fmt.Errorf("%w %T", ErrorUnexpectedType, parm)
 	}
 	err := ioctl(fd, VIDIOC_S_PARM, p)
 	if err != nil {
@@ -892,18 +1311,18 @@ type V4L2_Event_Subscription struct {
 }
 
 func (e *V4L2_Event_Subscription) set(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_event_subscription)(ptr)
+	p := (*v4l2_event_subscription)(ptr)
 
-	tmp := (*C.__u32)(unsafe.Pointer(
+	tmp := (*__u32)(unsafe.Pointer(
 		uintptr(ptr) + offset_event_subscription_type))
-	*tmp = C.__u32(e.Type)
+	*tmp = __u32(e.Type)
 
-	p.id = C.__u32(e.ID)
-	p.flags = C.__u32(e.Flags)
+	p.id = __u32(e.ID)
+	p.flags = __u32(e.Flags)
 }
 
 func IoctlSubscribeEvent(fd int, argp *V4L2_Event_Subscription) error {
-	var se C.struct_v4l2_event_subscription
+	var se v4l2_event_subscription
 	p := unsafe.Pointer(&se)
 	argp.set(p)
 	err := ioctl(fd, VIDIOC_SUBSCRIBE_EVENT, p)
@@ -914,7 +1333,7 @@ func IoctlSubscribeEvent(fd int, argp *V4L2_Event_Subscription) error {
 }
 
 func IoctlUnsubscribeEvent(fd int, argp *V4L2_Event_Subscription) error {
-	var ue C.struct_v4l2_event_subscription
+	var ue v4l2_event_subscription
 	p := unsafe.Pointer(&ue)
 	argp.set(p)
 	err := ioctl(fd, VIDIOC_UNSUBSCRIBE_EVENT, p)
@@ -926,33 +1345,143 @@ func IoctlUnsubscribeEvent(fd int, argp *V4L2_Event_Subscription) error {
 
 type V4L2_Event struct {
 	Type      uint32
-	Union     interface{}
+	Union     interface{} // payload decoded according to Type, raw bytes for private events
 	Pending   uint32
 	Sequence  uint32
-	TimeStamp syscall.Timespec
+	TimeStamp time.Duration // CLOCK_MONOTONIC, see MonotonicTime
 	ID        uint32
 }
 
+// Payload for V4L2_EVENT_VSYNC
+type V4L2_Event_Vsync struct {
+	Field uint8
+}
+
+// Payload for V4L2_EVENT_EOS, which carries no data
+type V4L2_Event_Eos struct{}
+
+// Payload for V4L2_EVENT_CTRL
+type V4L2_Event_Ctrl struct {
+	Changes      uint32
+	Type         uint32
+	Value        int64 // value64 for V4L2_CTRL_TYPE_INTEGER64, value otherwise
+	Flags        uint32
+	Minimum      int32
+	Maximum      int32
+	Step         int32
+	DefaultValue int32
+}
+
+// Payload for V4L2_EVENT_FRAME_SYNC
+type V4L2_Event_Frame_Sync struct {
+	FrameSequence uint32
+}
+
+// Payload for V4L2_EVENT_SOURCE_CHANGE
+type V4L2_Event_Src_Change struct {
+	Changes uint32
+}
+
+// Payload for V4L2_EVENT_MOTION_DET
+type V4L2_Event_Motion_Det struct {
+	Flags         uint32
+	FrameSequence uint32
+	RegionMask    uint32
+}
+
+func (v *V4L2_Event_Vsync) get(ptr unsafe.Pointer) {
+	p := (*v4l2_event_vsync)(ptr)
+	v.Field = uint8(p.field)
+}
+
+func (c *V4L2_Event_Ctrl) get(ptr unsafe.Pointer) {
+	p := (*v4l2_event_ctrl)(ptr)
+	c.Changes = uint32(p.changes)
+
+	// due to type field, it is keyword in golang
+	tmp := (*__u32)(unsafe.Pointer(
+		uintptr(ptr) + offset_event_ctrl_type))
+	c.Type = uint32(*tmp)
+
+	// due to anonymous union, cannot get it's field pointer
+	value := unsafe.Pointer(uintptr(ptr) + offset_event_ctrl_value)
+	if c.Type == V4L2_CTRL_TYPE_INTEGER64 {
+		c.Value = int64(*(*__s64)(value))
+	} else {
+		c.Value = int64(*(*__s32)(value))
+	}
+
+	c.Flags = uint32(p.flags)
+	c.Minimum = int32(p.minimum)
+	c.Maximum = int32(p.maximum)
+	c.Step = int32(p.step)
+	c.DefaultValue = int32(p.default_value)
+}
+
+func (f *V4L2_Event_Frame_Sync) get(ptr unsafe.Pointer) {
+	p := (*v4l2_event_frame_sync)(ptr)
+	f.FrameSequence = uint32(p.frame_sequence)
+}
+
+func (s *V4L2_Event_Src_Change) get(ptr unsafe.Pointer) {
+	p := (*v4l2_event_src_change)(ptr)
+	s.Changes = uint32(p.changes)
+}
+
+func (m *V4L2_Event_Motion_Det) get(ptr unsafe.Pointer) {
+	p := (*v4l2_event_motion_det)(ptr)
+	m.Flags = uint32(p.flags)
+	m.FrameSequence = uint32(p.frame_sequence)
+	m.RegionMask = uint32(p.region_mask)
+}
+
 func (e *V4L2_Event) get(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_event)(ptr)
+	p := (*v4l2_event)(ptr)
 
-	tmp := (*C.__u32)(unsafe.Pointer(
+	tmp := (*__u32)(unsafe.Pointer(
 		uintptr(ptr) + offset_event_type))
 	e.Type = uint32(*tmp)
 
+	u := unsafe.Pointer(&p.u)
+	switch e.Type {
+	case V4L2_EVENT_VSYNC:
+		v := V4L2_Event_Vsync{}
+		v.get(u)
+		e.Union = &v
+	case V4L2_EVENT_EOS:
+		e.Union = &V4L2_Event_Eos{}
+	case V4L2_EVENT_CTRL:
+		c := V4L2_Event_Ctrl{}
+		c.get(u)
+		e.Union = &c
+	case V4L2_EVENT_FRAME_SYNC:
+		f := V4L2_Event_Frame_Sync{}
+		f.get(u)
+		e.Union = &f
+	case V4L2_EVENT_SOURCE_CHANGE:
+		s := V4L2_Event_Src_Change{}
+		s.get(u)
+		e.Union = &s
+	case V4L2_EVENT_MOTION_DET:
+		m := V4L2_Event_Motion_Det{}
+		m.get(u)
+		e.Union = &m
+	default:
+		e.Union = goBytes(u, len(p.u))
+	}
+
 	e.Pending = uint32(p.pending)
 	e.Sequence = uint32(p.sequence)
 
-	t := (*syscall.Timespec)(unsafe.Pointer(&p.timestamp))
-	e.TimeStamp = *t
+	e.TimeStamp = timespecDuration(p.timestamp.tv_sec, p.timestamp.tv_nsec)
 
 	e.ID = uint32(p.id)
 }
 
 func IoctlDQEvent(fd int, argp *V4L2_Event) error {
-	var ve C.struct_v4l2_event
+	var ve v4l2_event
 	p := unsafe.Pointer(&ve)
-	err := ioctl(fd, VIDIOC_DQEVENT, p)
+	err := eventIoctl(fd, VIDIOC_DQEVENT, &ve)
 	if err != nil {
 		return err
 	}
@@ -960,82 +1489,309 @@ func IoctlDQEvent(fd int, argp *V4L2_Event) error {
 	return nil
 }
 
+// Union holds int32 (or nil) and int64 values, string or *string for
+// string controls, and []uint8, []uint16 or []uint32 for array and compound
+// controls, which are read back in place. The raw *uint8, *uint16, *uint32
+// and unsafe.Pointer payloads require the caller to set Size. When a
+// payload is too small, the ioctl fails with ENOSPC and Size is set to the
+// size the driver needs.
 type V4L2_Ext_Control struct {
 	ID    uint32
 	Size  uint32
 	Union interface{}
+
+	buf []byte // backing store of string and compound payloads
 }
 
 type V4L2_Ext_Controls struct {
 	ClassWhich uint32
 	Count      uint32
 	ErrorIdx   uint32
+	RequestFD  int32 // valid with V4L2_CTRL_WHICH_REQUEST_VAL
 	Controls   []V4L2_Ext_Control
 }
 
-func (c *V4L2_Ext_Control) set(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_ext_control)(ptr)
-	p.id = C.__u32(c.ID)
-	p.size = C.__u32(c.Size)
+func (c *V4L2_Ext_Control) set(ptr unsafe.Pointer) error {
+	p := (*v4l2_ext_control)(ptr)
+	p.id = __u32(c.ID)
 
 	tmp := unsafe.Pointer(uintptr(ptr) + offset_ext_control_union)
 	switch v := c.Union.(type) {
+	case nil:
+		*(*__s32)(tmp) = 0
 	case int32:
-		*(*C.__s32)(tmp) = C.__s32(v)
+		*(*__s32)(tmp) = __s32(v)
 	case int64:
-		*(*C.__s64)(tmp) = C.__s64(v)
+		*(*__s64)(tmp) = __s64(v)
+	case string:
+		c.setString(p, v)
 	case *string:
-		*(**C.char)(tmp) = (*C.char)(unsafe.Pointer(v))
+		c.setString(p, *v)
+	case []uint8:
+		if len(v) == 0 {
+			return fmt.Errorf("%w: empty array", ErrorUnexpectedType)
+		}
+		if c.Size == 0 {
+			c.Size = uint32(len(v))
+		}
+		setPayload(p, unsafe.Pointer(&v[0]))
+	case []uint16:
+		if len(v) == 0 {
+			return fmt.Errorf("%w: empty array", ErrorUnexpectedType)
+		}
+		if c.Size == 0 {
+			c.Size = uint32(len(v) * 2)
+		}
+		setPayload(p, unsafe.Pointer(&v[0]))
+	case []uint32:
+		if len(v) == 0 {
+			return fmt.Errorf("%w: empty array", ErrorUnexpectedType)
+		}
+		if c.Size == 0 {
+			c.Size = uint32(len(v) * 4)
+		}
+		setPayload(p, unsafe.Pointer(&v[0]))
 	case *uint8:
-		*(**C.__u8)(tmp) = (*C.__u8)(unsafe.Pointer(v))
+		setPayload(p, unsafe.Pointer(v))
 	case *uint16:
-		*(**C.__u16)(tmp) = (*C.__u16)(unsafe.Pointer(v))
+		setPayload(p, unsafe.Pointer(v))
 	case *uint32:
-		*(**C.__u32)(tmp) = (*C.__u32)(unsafe.Pointer(v))
+		setPayload(p, unsafe.Pointer(v))
 	case unsafe.Pointer:
-		*(**C.void)(tmp) = (*C.void)(v)
+		setPayload(p, v)
+	case compoundControl:
+		c.Size = v.size()
+		c.buf = make([]byte, c.Size)
+		v.set(unsafe.Pointer(&c.buf[0]))
+		setPayload(p, unsafe.Pointer(&c.buf[0]))
 	default:
-		panic(fmt.Sprintf("Unexpected type %T", v))
+		return fmt.Errorf("%w %T", ErrorUnexpectedType, v)
+	}
+	p.size = __u32(c.Size)
+	return nil
+}
+
+// string payloads are copied into a NUL terminated buffer of Size bytes,
+// or of the string length if Size is not set
+func (c *V4L2_Ext_Control) setString(p *v4l2_ext_control, v string) {
+	if c.Size == 0 {
+		c.Size = uint32(len(v) + 1)
 	}
+	c.buf = make([]byte, c.Size)
+	copy(c.buf[:len(c.buf)-1], v)
+	setPayload(p, unsafe.Pointer(&c.buf[0]))
+}
+
+// setPayload stores the address of a payload into the union byte-wise, as
+// the union of the packed v4l2_ext_control is not aligned for a pointer.
+// The payload is kept alive by c.Union or c.buf, which are read back after
+// the ioctl.
+func setPayload(p *v4l2_ext_control, ptr unsafe.Pointer) {
+	copy(p.anon0[:], UintptrToBytes(uintptr(ptr)))
 }
 
 func (c *V4L2_Ext_Control) get(ptr unsafe.Pointer) {
+	p := (*v4l2_ext_control)(ptr)
+	c.Size = uint32(p.size)
+
+	tmp := unsafe.Pointer(uintptr(ptr) + offset_ext_control_union)
+	switch v := c.Union.(type) {
+	case nil, int32:
+		c.Union = int32(*(*__s32)(tmp))
+	case int64:
+		c.Union = int64(*(*__s64)(tmp))
+	case string:
+		c.Union = goString(unsafe.Pointer(&c.buf[0]))
+	case *string:
+		*v = goString(unsafe.Pointer(&c.buf[0]))
+	case compoundControl:
+		v.get(unsafe.Pointer(&c.buf[0]))
+	}
+	// arrays and pointers are filled in place by the driver
 }
 
 func (c *V4L2_Ext_Controls) set(ptr unsafe.Pointer) {
-	p := (*C.struct_v4l2_ext_controls)(ptr)
+	p := (*v4l2_ext_controls)(ptr)
 
-	tmp := (*C.__u32)(unsafe.Pointer(
+	tmp := (*__u32)(unsafe.Pointer(
 		uintptr(ptr) + offset_ext_controls_ctrl_class))
-	*tmp = C.__u32(c.ClassWhich)
+	*tmp = __u32(c.ClassWhich)
 
-	p.count = C.__u32(c.Count)
+	p.count = __u32(c.Count)
+	p.request_fd = __s32(c.RequestFD)
 }
 
 func (c *V4L2_Ext_Controls) get(ptr unsafe.Pointer) {
+	p := (*v4l2_ext_controls)(ptr)
+	c.ErrorIdx = uint32(p.error_idx)
 }
 
-func IoctlSetExtCtrls(fd int, argp *V4L2_Ext_Controls) error {
-	var ctrls C.struct_v4l2_ext_controls
+func ioctlExtCtrls(fd int, request uint, argp *V4L2_Ext_Controls) error {
+	var ctrls v4l2_ext_controls
 	p := unsafe.Pointer(&ctrls)
-	var ctrl []C.struct_v4l2_ext_control
-	for i := 0; i < int(argp.Count); i++ {
-		var c C.struct_v4l2_ext_control
-		argp.Controls[i].set(unsafe.Pointer(&c))
-		ctrl = append(ctrl, c)
+	if int(argp.Count) > len(argp.Controls) {
+		return fmt.Errorf("Count %d exceeds %d controls", argp.Count, len(argp.Controls))
+	}
+	ctrl := make([]v4l2_ext_control, argp.Count)
+	for i := range ctrl {
+		if err := argp.Controls[i].set(unsafe.Pointer(&ctrl[i])); err != nil {
+			return err
+		}
 	}
 	argp.set(p)
-	ctrls.controls = (*C.struct_v4l2_ext_control)(unsafe.Pointer(&ctrl[0]))
-	err := ioctl(fd, VIDIOC_S_EXT_CTRLS, p)
+	if len(ctrl) > 0 {
+		ctrls.controls = (*v4l2_ext_control)(unsafe.Pointer(&ctrl[0]))
+	}
+	err := ioctl(fd, request, p)
+
+	// error_idx is also valid when the ioctl fails
+	argp.get(p)
+	if err == syscall.ENOSPC {
+		// the driver reports the payload size it needs
+		for i := range ctrl {
+			argp.Controls[i].Size = uint32(ctrl[i].size)
+		}
+	}
 	if err != nil {
 		return err
 	}
+	for i := range ctrl {
+		argp.Controls[i].get(unsafe.Pointer(&ctrl[i]))
+	}
+	return nil
+}
+
+func IoctlGetExtCtrls(fd int, argp *V4L2_Ext_Controls) error {
+	return ioctlExtCtrls(fd, VIDIOC_G_EXT_CTRLS, argp)
+}
+
+func IoctlSetExtCtrls(fd int, argp *V4L2_Ext_Controls) error {
+	return ioctlExtCtrls(fd, VIDIOC_S_EXT_CTRLS, argp)
+}
+
+func IoctlTryExtCtrls(fd int, argp *V4L2_Ext_Controls) error {
+	return ioctlExtCtrls(fd, VIDIOC_TRY_EXT_CTRLS, argp)
+}
+
+type V4L2_Encoder_Cmd struct {
+	Cmd   uint32
+	Flags uint32
+}
+
+func (e *V4L2_Encoder_Cmd) set(ptr unsafe.Pointer) {
+	p := (*v4l2_encoder_cmd)(ptr)
+	p.cmd = __u32(e.Cmd)
+	p.flags = __u32(e.Flags)
+}
+
+func (e *V4L2_Encoder_Cmd) get(ptr unsafe.Pointer) {
+	p := (*v4l2_encoder_cmd)(ptr)
+	e.Flags = uint32(p.flags)
+}
+
+func IoctlEncoderCmd(fd int, argp *V4L2_Encoder_Cmd) error {
+	var ec v4l2_encoder_cmd
+	p := unsafe.Pointer(&ec)
+	argp.set(p)
+	err := ioctl(fd, VIDIOC_ENCODER_CMD, p)
+	if err != nil {
+		return err
+	}
+	argp.get(p)
+	return nil
+}
+
+func IoctlTryEncoderCmd(fd int, argp *V4L2_Encoder_Cmd) error {
+	var ec v4l2_encoder_cmd
+	p := unsafe.Pointer(&ec)
+	argp.set(p)
+	err := ioctl(fd, VIDIOC_TRY_ENCODER_CMD, p)
+	if err != nil {
+		return err
+	}
+	argp.get(p)
+	return nil
+}
+
+type V4L2_Decoder_Cmd struct {
+	Cmd   uint32
+	Flags uint32
+	Union interface{} // *V4L2_Decoder_Cmd_Stop, *V4L2_Decoder_Cmd_Start or nil
+}
+
+// Parameters of V4L2_DEC_CMD_STOP
+type V4L2_Decoder_Cmd_Stop struct {
+	Pts uint64
+}
+
+// Parameters of V4L2_DEC_CMD_START
+type V4L2_Decoder_Cmd_Start struct {
+	Speed  int32
+	Format uint32
+}
+
+func (d *V4L2_Decoder_Cmd) set(ptr unsafe.Pointer) {
+	p := (*v4l2_decoder_cmd)(ptr)
+	p.cmd = __u32(d.Cmd)
+	p.flags = __u32(d.Flags)
+
+	// due to anonymous union, cannot get it's field pointer
+	u := unsafe.Pointer(uintptr(ptr) + offset_decoder_cmd_union)
+	switch v := d.Union.(type) {
+	case *V4L2_Decoder_Cmd_Stop:
+		*(*__u64)(u) = __u64(v.Pts)
+	case *V4L2_Decoder_Cmd_Start:
+		*(*__s32)(u) = __s32(v.Speed)
+		*(*__u32)(unsafe.Pointer(uintptr(u) + 4)) = __u32(v.Format)
+	}
+}
+
+func (d *V4L2_Decoder_Cmd) get(ptr unsafe.Pointer) {
+	p := (*v4l2_decoder_cmd)(ptr)
+	d.Flags = uint32(p.flags)
+
+	// due to anonymous union, cannot get it's field pointer
+	u := unsafe.Pointer(uintptr(ptr) + offset_decoder_cmd_union)
+	switch d.Cmd {
+	case V4L2_DEC_CMD_STOP:
+		d.Union = &V4L2_Decoder_Cmd_Stop{Pts: uint64(*(*__u64)(u))}
+	case V4L2_DEC_CMD_START:
+		d.Union = &V4L2_Decoder_Cmd_Start{
+			Speed:  int32(*(*__s32)(u)),
+			Format: uint32(*(*__u32)(unsafe.Pointer(uintptr(u) + 4))),
+		}
+	default:
+		d.Union = nil
+	}
+}
+
+func IoctlDecoderCmd(fd int, argp *V4L2_Decoder_Cmd) error {
+	var dc v4l2_decoder_cmd
+	p := unsafe.Pointer(&dc)
+	argp.set(p)
+	err := ioctl(fd, VIDIOC_DECODER_CMD, p)
+	if err != nil {
+		return err
+	}
+	argp.get(p)
+	return nil
+}
+
+func IoctlTryDecoderCmd(fd int, argp *V4L2_Decoder_Cmd) error {
+	var dc v4l2_decoder_cmd
+	p := unsafe.Pointer(&dc)
+	argp.set(p)
+	err := ioctl(fd, VIDIOC_TRY_DECODER_CMD, p)
+	if err != nil {
+		return err
+	}
+	argp.get(p)
 	return nil
 }
 
 func IoctlStreamOn(fd int, argp *int) error {
-	var i C.int
-	i = C.int(*argp)
+	var i int32
+	i = int32(*argp)
 	p := unsafe.Pointer(&i)
 	err := ioctl(fd, VIDIOC_STREAMON, p)
 	if err != nil {
@@ -1045,8 +1801,8 @@ func IoctlStreamOn(fd int, argp *int) error {
 }
 
 func IoctlStreamOff(fd int, argp *int) error {
-	var i C.int
-	i = C.int(*argp)
+	var i int32
+	i = int32(*argp)
 	p := unsafe.Pointer(&i)
 	err := ioctl(fd, VIDIOC_STREAMOFF, p)
 	if err != nil {
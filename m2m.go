@@ -0,0 +1,393 @@
+package v4l2
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"syscall"
+)
+
+// M2MDevice is a memory-to-memory device, e.g. a scaler, a color space
+// converter or a codec. Frames queued on the source (OUTPUT) queue are
+// processed by the device into buffers of the destination (CAPTURE)
+// queue. The device is used in non-blocking mode, waiting for buffers is
+// done internally.
+type M2MDevice struct {
+	Device
+	Src *BufferQueue // frames to process
+	Dst *BufferQueue // processed frames
+
+	streaming bool
+	srcPoll   *poller
+	dstPoll   *poller
+	err       error
+}
+
+// OpenM2M opens a memory-to-memory device. Its queues use MMAP buffers.
+func OpenM2M(name string) (*M2MDevice, error) {
+	d, err := Open(name)
+	if err != nil {
+		return nil, err
+	}
+	m, err := NewM2MDevice(d)
+	if err != nil {
+		d.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+// NewM2MDevice sets up the queues of an opened memory-to-memory device.
+func NewM2MDevice(d *Device) (*M2MDevice, error) {
+	var caps V4L2_Capability
+	if err := IoctlQueryCap(d.FD, &caps); err != nil {
+		return nil, fmt.Errorf("Failed to query capability: %w", err)
+	}
+	c := caps.Capabilities
+	if c&V4L2_CAP_DEVICE_CAPS != 0 {
+		c = caps.DeviceCaps
+	}
+
+	var src, dst uint32
+	switch {
+	case c&V4L2_CAP_VIDEO_M2M_MPLANE != 0,
+		c&V4L2_CAP_VIDEO_CAPTURE_MPLANE != 0 && c&V4L2_CAP_VIDEO_OUTPUT_MPLANE != 0:
+		src = V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE
+		dst = V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE
+	case c&V4L2_CAP_VIDEO_M2M != 0,
+		c&V4L2_CAP_VIDEO_CAPTURE != 0 && c&V4L2_CAP_VIDEO_OUTPUT != 0:
+		src = V4L2_BUF_TYPE_VIDEO_OUTPUT
+		dst = V4L2_BUF_TYPE_VIDEO_CAPTURE
+	default:
+		return nil, fmt.Errorf("%w: memory-to-memory", ErrorUnsupportedCap)
+	}
+
+	if err := syscall.SetNonblock(d.FD, true); err != nil {
+		return nil, err
+	}
+
+	m := &M2MDevice{
+		Device: *d,
+		Src:    NewBufferQueue(d.FD, src, V4L2_MEMORY_MMAP),
+		Dst:    NewBufferQueue(d.FD, dst, V4L2_MEMORY_MMAP),
+	}
+	var err error
+	if m.srcPoll, err = newPoller(d.FD, syscall.EPOLLOUT); err != nil {
+		return nil, err
+	}
+	if m.dstPoll, err = newPoller(d.FD, syscall.EPOLLIN); err != nil {
+		m.srcPoll.close()
+		return nil, err
+	}
+	return m, nil
+}
+
+// SetSrcFormat sets the format of the frames to process.
+func (m *M2MDevice) SetSrcFormat(width, height, pixelformat uint32) error {
+	return m.setFormat(m.Src.Type, width, height, pixelformat)
+}
+
+// SetDstFormat sets the format of the processed frames.
+func (m *M2MDevice) SetDstFormat(width, height, pixelformat uint32) error {
+	return m.setFormat(m.Dst.Type, width, height, pixelformat)
+}
+
+func (m *M2MDevice) setFormat(bufType, width, height, pixelformat uint32) error {
+	format := V4L2_Format{Type: bufType}
+	if isMplane(bufType) {
+		format.Fmt = &V4L2_Pix_Format_Mplane{
+			Width:       width,
+			Height:      height,
+			PixelFormat: pixelformat,
+			Field:       V4L2_FIELD_ANY,
+		}
+	} else {
+		format.Fmt = &V4L2_Pix_Format{
+			Width:       width,
+			Height:      height,
+			PixelFormat: pixelformat,
+			Field:       V4L2_FIELD_ANY,
+		}
+	}
+	if err := IoctlSetFmt(m.FD, &format); err != nil {
+		return fmt.Errorf("Failed to set format: %w", err)
+	}
+	return nil
+}
+
+// Alloc allocates the buffers of both queues. The formats must be set
+// first.
+func (m *M2MDevice) Alloc(srcCount, dstCount uint32) error {
+	if err := m.Src.Alloc(srcCount); err != nil {
+		return err
+	}
+	if err := m.Dst.Alloc(dstCount); err != nil {
+		m.Src.Release()
+		return err
+	}
+	return nil
+}
+
+// Start queues all destination buffers and starts streaming on both
+// queues.
+func (m *M2MDevice) Start() error {
+	if m.streaming {
+		return nil
+	}
+	if err := m.Dst.QueueAll(); err != nil {
+		return fmt.Errorf("Failed to enqueue buffers: %w", err)
+	}
+	if err := m.Dst.StreamOn(); err != nil {
+		return fmt.Errorf("Failed to stream on capture queue: %w", err)
+	}
+	if err := m.Src.StreamOn(); err != nil {
+		m.Dst.StreamOff()
+		return fmt.Errorf("Failed to stream on output queue: %w", err)
+	}
+	m.streaming = true
+	return nil
+}
+
+// Stop stops streaming. The source queue is stopped first so that the
+// device does not start processing into a stopped destination queue.
+func (m *M2MDevice) Stop() error {
+	if !m.streaming {
+		return nil
+	}
+	m.streaming = false
+	err := m.Src.StreamOff()
+	if e := m.Dst.StreamOff(); e != nil && err == nil {
+		err = e
+	}
+	return err
+}
+
+// Close stops streaming, frees all buffers and closes the device.
+func (m *M2MDevice) Close() error {
+	err := m.Stop()
+	if e := m.Src.Release(); e != nil && err == nil {
+		err = e
+	}
+	if e := m.Dst.Release(); e != nil && err == nil {
+		err = e
+	}
+	m.srcPoll.close()
+	m.dstPoll.close()
+	m.Device.Close()
+	return err
+}
+
+// Process passes one frame, given per plane, through the device and
+// returns the processed frame per plane. It starts streaming if needed
+// and is meant for devices which produce one destination buffer for
+// every source buffer, like scalers and converters.
+func (m *M2MDevice) Process(ctx context.Context, in [][]byte) ([][]byte, error) {
+	if err := m.Start(); err != nil {
+		return nil, err
+	}
+
+	src, err := m.freeSrc(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.queueSrc(src, in); err != nil {
+		return nil, err
+	}
+
+	dst, err := m.dequeue(ctx, m.Dst, m.dstPoll)
+	if err != nil {
+		return nil, err
+	}
+	out := copyPlanes(dst)
+	if err := m.Dst.Queue(dst); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Stream starts streaming and passes every frame received from in through
+// the device. Processed frames are delivered on the returned channel,
+// which is closed when in is closed and all frames have been processed,
+// when ctx is cancelled, or on failure. Err reports the reason after
+// the channel is closed. Streaming is stopped before the channel is
+// closed. Like Process, Stream expects one destination buffer for every
+// source buffer.
+func (m *M2MDevice) Stream(ctx context.Context, in <-chan [][]byte) (<-chan [][]byte, error) {
+	if err := m.Start(); err != nil {
+		return nil, err
+	}
+	m.err = nil
+
+	out := make(chan [][]byte, len(m.Dst.Bufs))
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(ctx, func() {
+		m.srcPoll.wakeup()
+		m.dstPoll.wakeup()
+	})
+
+	// number of queued source frames without a processed frame
+	var pending int32
+	fed := make(chan error, 1)
+	go func() {
+		defer m.dstPoll.wakeup()
+		for {
+			var frame [][]byte
+			var ok bool
+			select {
+			case frame, ok = <-in:
+			case <-ctx.Done():
+				fed <- ctx.Err()
+				return
+			}
+			if !ok {
+				fed <- nil
+				return
+			}
+
+			src, err := m.freeSrc(ctx)
+			if err == nil {
+				atomic.AddInt32(&pending, 1)
+				err = m.queueSrc(src, frame)
+			}
+			if err != nil {
+				fed <- err
+				return
+			}
+		}
+	}()
+
+	go func() {
+		done, err := m.receive(ctx, out, fed, &pending)
+		cancel()
+		stop()
+		if !done {
+			// the feeder must not touch the queues after streaming stopped
+			<-fed
+		}
+		if e := m.Stop(); e != nil && err == nil {
+			err = e
+		}
+		m.err = err
+		close(out)
+	}()
+	return out, nil
+}
+
+// Err returns the error which ended the last Stream.
+func (m *M2MDevice) Err() error {
+	return m.err
+}
+
+// receive delivers processed frames until the feeder is done and all
+// frames it queued have been processed. It reports whether the feeder
+// has returned.
+func (m *M2MDevice) receive(ctx context.Context, out chan<- [][]byte, fed <-chan error, pending *int32) (bool, error) {
+	done := false
+	for {
+		if !done {
+			select {
+			case err := <-fed:
+				done = true
+				if err != nil {
+					return done, err
+				}
+			default:
+			}
+		}
+		if done && atomic.LoadInt32(pending) == 0 {
+			return done, nil
+		}
+
+		dst, err := m.Dst.Dequeue()
+		if err == syscall.EAGAIN {
+			if err := m.wait(ctx, m.dstPoll); err != nil {
+				return done, err
+			}
+			continue
+		}
+		if err != nil {
+			return done, err
+		}
+
+		frame := copyPlanes(dst)
+		if err := m.Dst.Queue(dst); err != nil {
+			return done, err
+		}
+		atomic.AddInt32(pending, -1)
+		select {
+		case out <- frame:
+		case <-ctx.Done():
+			return done, ctx.Err()
+		}
+	}
+}
+
+// freeSrc returns a source buffer not owned by the device, waiting for
+// the device to consume one if needed.
+func (m *M2MDevice) freeSrc(ctx context.Context) (*Buffer, error) {
+	for _, b := range m.Src.Bufs {
+		if !b.Queued {
+			return b, nil
+		}
+	}
+	return m.dequeue(ctx, m.Src, m.srcPoll)
+}
+
+func (m *M2MDevice) queueSrc(b *Buffer, in [][]byte) error {
+	if len(in) > len(b.Planes) {
+		return fmt.Errorf("%d planes given, buffers have %d", len(in), len(b.Planes))
+	}
+	for i := range in {
+		if len(in[i]) > len(b.Planes[i].Data) {
+			return fmt.Errorf("Plane %d of %d bytes exceeds buffer of %d bytes",
+				i, len(in[i]), len(b.Planes[i].Data))
+		}
+	}
+	for i := range b.Planes {
+		b.Planes[i].BytesUsed = 0
+		if i < len(in) {
+			b.Planes[i].BytesUsed = uint32(copy(b.Planes[i].Data, in[i]))
+		}
+	}
+	return m.Src.Queue(b)
+}
+
+// dequeue dequeues a buffer from q, waiting until one is ready.
+func (m *M2MDevice) dequeue(ctx context.Context, q *BufferQueue, p *poller) (*Buffer, error) {
+	for {
+		b, err := q.Dequeue()
+		if err != syscall.EAGAIN {
+			return b, err
+		}
+		if err := m.wait(ctx, p); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// wait blocks until p reports the device ready, p is woken up, or ctx is
+// cancelled.
+func (m *M2MDevice) wait(ctx context.Context, p *poller) error {
+	stop := context.AfterFunc(ctx, p.wakeup)
+	defer stop()
+
+	events, err := p.wait(-1)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if events&syscall.EPOLLERR != 0 {
+		return ErrorNoBuffers
+	}
+	return nil
+}
+
+func copyPlanes(b *Buffer) [][]byte {
+	planes := make([][]byte, len(b.Planes))
+	for i := range b.Planes {
+		planes[i] = append([]byte(nil), b.Planes[i].Bytes()...)
+	}
+	return planes
+}
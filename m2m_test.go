@@ -0,0 +1,22 @@
+package v4l2
+
+import "testing"
+
+func TestM2MQueueSrcOverflow(t *testing.T) {
+	var m M2MDevice
+	b := &Buffer{Planes: []Plane{
+		{Data: make([]byte, 8), Length: 8, BytesUsed: 3},
+		{Data: make([]byte, 4), Length: 4, BytesUsed: 3},
+	}}
+	in := [][]byte{make([]byte, 8), make([]byte, 5)}
+	if err := m.queueSrc(b, in); err == nil {
+		t.Fatal("plane larger than the buffer queued")
+	}
+	if b.Planes[0].BytesUsed != 3 || b.Planes[1].BytesUsed != 3 {
+		t.Errorf("rejected buffer changed to %d and %d bytes used",
+			b.Planes[0].BytesUsed, b.Planes[1].BytesUsed)
+	}
+	if err := m.queueSrc(b, [][]byte{nil, nil, nil}); err == nil {
+		t.Error("3 planes queued into a buffer of 2")
+	}
+}
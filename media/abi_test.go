@@ -0,0 +1,53 @@
+//go:build cgo && !v4l2cgo
+
+package media
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Charleye/v4l2-go/internal/cabi"
+)
+
+// abiTypes are the structs of ztypes_linux_$GOARCH.go
+var abiTypes = []any{
+	media_device_info{},
+	media_link_desc{},
+	media_links_enum{},
+	media_pad_desc{},
+	media_v2_entity{},
+	media_v2_interface{},
+	media_v2_intf_devnode{},
+	media_v2_link{},
+	media_v2_pad{},
+	media_v2_topology{},
+}
+
+var abiIoctls = map[string]uint64{
+	"MEDIA_IOC_DEVICE_INFO": MEDIA_IOC_DEVICE_INFO,
+	"MEDIA_IOC_ENUM_LINKS":  MEDIA_IOC_ENUM_LINKS,
+	"MEDIA_IOC_SETUP_LINK":  MEDIA_IOC_SETUP_LINK,
+	"MEDIA_IOC_G_TOPOLOGY":  MEDIA_IOC_G_TOPOLOGY,
+}
+
+func TestABILayout(t *testing.T) {
+	for _, v := range abiTypes {
+		typ := reflect.TypeOf(v)
+		c, ok := cabi.Types[typ.Name()]
+		if !ok {
+			t.Errorf("%s: not in the C headers", typ.Name())
+			continue
+		}
+		for _, d := range cabi.Compare(typ, c) {
+			t.Error(d)
+		}
+	}
+}
+
+func TestABIIoctls(t *testing.T) {
+	for name, v := range abiIoctls {
+		if c := cabi.Ioctls[name]; v != c {
+			t.Errorf("%s = %#x, C %#x", name, v, c)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package media
+
+import (
+	"errors"
+)
+
+var (
+	ErrorWrongDevice = errors.New("Not a media device")
+	ErrorNoEntity    = errors.New("No such entity")
+	ErrorNoLink      = errors.New("No such link")
+)
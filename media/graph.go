@@ -0,0 +1,361 @@
+package media
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Device is an opened media controller device, e.g. /dev/media0
+type Device struct {
+	Path string
+	FD   int
+	Info Media_Device_Info
+}
+
+// Graph is the topology of a media device. Objects reference each other,
+// e.g. a Link points to its source and sink Pad, and a Pad to its Entity.
+type Graph struct {
+	Version    uint64 // topology version
+	Entities   []*Entity
+	Interfaces []*Interface
+	Pads       []*Pad
+	Links      []*Link
+}
+
+type Entity struct {
+	ID         uint32
+	Name       string
+	Function   uint32
+	Flags      uint32
+	Pads       []*Pad
+	Links      []*Link      // data links from or to the pads of the entity
+	Interfaces []*Interface // devnodes controlling the entity
+}
+
+type Pad struct {
+	ID     uint32
+	Entity *Entity
+	Index  uint16
+	Flags  uint32
+	Links  []*Link
+}
+
+type Link struct {
+	ID     uint32
+	Source *Pad
+	Sink   *Pad
+	Flags  uint32
+}
+
+// Interface is a devnode of the media device, e.g. a video node or a
+// sub-device node.
+type Interface struct {
+	ID       uint32
+	Type     uint32
+	Flags    uint32
+	Major    uint32
+	Minor    uint32
+	Entities []*Entity
+}
+
+// the pad index of G_TOPOLOGY is valid since media version 4.19
+const padIndexVersion = 4<<16 | 19<<8
+
+func Open(name string) (*Device, error) {
+	fd, err := syscall.Open(name, syscall.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	d := &Device{
+		Path: name,
+		FD:   fd,
+	}
+	if err := IoctlDeviceInfo(fd, &d.Info); err != nil {
+		syscall.Close(fd)
+		if err == syscall.ENOTTY {
+			return nil, fmt.Errorf("%w: %s", ErrorWrongDevice, name)
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *Device) Close() {
+	syscall.Close(d.FD)
+	d.Path = ""
+	d.FD = -1
+}
+
+// Topology gets the graph of entities, pads, links and interfaces of the
+// media device.
+func (d *Device) Topology() (*Graph, error) {
+	var topo Media_V2_Topology
+	if err := IoctlGetTopology(d.FD, &topo); err != nil {
+		return nil, err
+	}
+
+	g := &Graph{Version: topo.TopologyVersion}
+	entities := make(map[uint32]*Entity)
+	for _, e := range topo.Entities {
+		entity := &Entity{
+			ID:       e.ID,
+			Name:     e.Name,
+			Function: e.Function,
+			Flags:    e.Flags,
+		}
+		entities[e.ID] = entity
+		g.Entities = append(g.Entities, entity)
+	}
+	interfaces := make(map[uint32]*Interface)
+	for _, i := range topo.Interfaces {
+		intf := &Interface{
+			ID:    i.ID,
+			Type:  i.IntfType,
+			Flags: i.Flags,
+			Major: i.Major,
+			Minor: i.Minor,
+		}
+		interfaces[i.ID] = intf
+		g.Interfaces = append(g.Interfaces, intf)
+	}
+	pads := make(map[uint32]*Pad)
+	for _, p := range topo.Pads {
+		entity, ok := entities[p.EntityID]
+		if !ok {
+			continue
+		}
+		pad := &Pad{
+			ID:     p.ID,
+			Entity: entity,
+			Index:  uint16(len(entity.Pads)),
+			Flags:  p.Flags,
+		}
+		if d.Info.MediaVersion >= padIndexVersion {
+			pad.Index = uint16(p.Index)
+		}
+		pads[p.ID] = pad
+		entity.Pads = append(entity.Pads, pad)
+		g.Pads = append(g.Pads, pad)
+	}
+	for _, l := range topo.Links {
+		switch l.Flags & MEDIA_LNK_FL_LINK_TYPE {
+		case MEDIA_LNK_FL_DATA_LINK:
+			source, ok1 := pads[l.SourceID]
+			sink, ok2 := pads[l.SinkID]
+			if !ok1 || !ok2 {
+				continue
+			}
+			link := &Link{
+				ID:     l.ID,
+				Source: source,
+				Sink:   sink,
+				Flags:  l.Flags,
+			}
+			source.Links = append(source.Links, link)
+			sink.Links = append(sink.Links, link)
+			source.Entity.Links = append(source.Entity.Links, link)
+			if sink.Entity != source.Entity {
+				sink.Entity.Links = append(sink.Entity.Links, link)
+			}
+			g.Links = append(g.Links, link)
+		case MEDIA_LNK_FL_INTERFACE_LINK:
+			intf, ok1 := interfaces[l.SourceID]
+			entity, ok2 := entities[l.SinkID]
+			if !ok1 || !ok2 {
+				continue
+			}
+			intf.Entities = append(intf.Entities, entity)
+			entity.Interfaces = append(entity.Interfaces, intf)
+		}
+	}
+
+	if d.Info.MediaVersion < padIndexVersion {
+		if err := d.enumPadIndexes(g); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// enumPadIndexes gets the pad indexes with ENUM_LINKS on kernels whose
+// G_TOPOLOGY does not report them. Both list the pads of an entity in the
+// order the driver created them.
+func (d *Device) enumPadIndexes(g *Graph) error {
+	for _, e := range g.Entities {
+		var outbound int
+		for _, l := range e.Links {
+			if l.Source.Entity == e {
+				outbound++
+			}
+		}
+		le := Media_Links_Enum{
+			Entity: e.ID,
+			Pads:   make([]Media_Pad_Desc, len(e.Pads)),
+			Links:  make([]Media_Link_Desc, outbound),
+		}
+		if err := IoctlEnumLinks(d.FD, &le); err != nil {
+			return err
+		}
+		for i, pad := range e.Pads {
+			pad.Index = le.Pads[i].Index
+		}
+	}
+	return nil
+}
+
+// SetupLink enables or disables the data link l. Immutable links cannot
+// be changed.
+func (d *Device) SetupLink(l *Link, enable bool) error {
+	desc := Media_Link_Desc{
+		Source: Media_Pad_Desc{
+			Entity: l.Source.Entity.ID,
+			Index:  l.Source.Index,
+		},
+		Sink: Media_Pad_Desc{
+			Entity: l.Sink.Entity.ID,
+			Index:  l.Sink.Index,
+		},
+		Flags: l.Flags &^ MEDIA_LNK_FL_ENABLED,
+	}
+	if enable {
+		desc.Flags |= MEDIA_LNK_FL_ENABLED
+	}
+	if err := IoctlSetupLink(d.FD, &desc); err != nil {
+		return fmt.Errorf("Failed to setup link %s: %w", l, err)
+	}
+	l.Flags = desc.Flags
+	return nil
+}
+
+// EnableLink enables the link from the entity named source to the entity
+// named sink, see Graph.FindLink for the format of the names.
+func (d *Device) EnableLink(g *Graph, source, sink string) error {
+	l, err := g.FindLink(source, sink)
+	if err != nil {
+		return err
+	}
+	return d.SetupLink(l, true)
+}
+
+// DisableLink disables the link from the entity named source to the
+// entity named sink.
+func (d *Device) DisableLink(g *Graph, source, sink string) error {
+	l, err := g.FindLink(source, sink)
+	if err != nil {
+		return err
+	}
+	return d.SetupLink(l, false)
+}
+
+func (g *Graph) EntityByName(name string) (*Entity, error) {
+	for _, e := range g.Entities {
+		if e.Name == name {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrorNoEntity, name)
+}
+
+func (g *Graph) EntityByID(id uint32) (*Entity, error) {
+	for _, e := range g.Entities {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: id %d", ErrorNoEntity, id)
+}
+
+// EntityByDevnode returns the entity controlled by the devnode path, e.g.
+// the entity of a video node /dev/video0 or a sub-device /dev/v4l-subdev0.
+func (g *Graph) EntityByDevnode(path string) (*Entity, error) {
+	var st syscall.Stat_t
+	if err := syscall.Stat(path, &st); err != nil {
+		return nil, err
+	}
+	if st.Mode&syscall.S_IFMT != syscall.S_IFCHR {
+		return nil, fmt.Errorf("%w: %s", ErrorNoEntity, path)
+	}
+	major, minor := devMajor(uint64(st.Rdev)), devMinor(uint64(st.Rdev))
+	for _, intf := range g.Interfaces {
+		if intf.Major == major && intf.Minor == minor && len(intf.Entities) > 0 {
+			return intf.Entities[0], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrorNoEntity, path)
+}
+
+// FindLink returns the data link from the entity named source to the
+// entity named sink. A name may be suffixed with ":index" to select a pad
+// if the entities are linked through more than one pair of pads.
+func (g *Graph) FindLink(source, sink string) (*Link, error) {
+	srcName, srcPad := splitPad(source)
+	sinkName, sinkPad := splitPad(sink)
+	for _, l := range g.Links {
+		if l.Source.Entity.Name != srcName || l.Sink.Entity.Name != sinkName {
+			continue
+		}
+		if srcPad >= 0 && int(l.Source.Index) != srcPad {
+			continue
+		}
+		if sinkPad >= 0 && int(l.Sink.Index) != sinkPad {
+			continue
+		}
+		return l, nil
+	}
+	return nil, fmt.Errorf("%w: %q -> %q", ErrorNoLink, source, sink)
+}
+
+// splitPad splits "name:index" into its name and pad index, which is -1
+// if name has no pad suffix.
+func splitPad(name string) (string, int) {
+	i := strings.LastIndexByte(name, ':')
+	if i < 0 {
+		return name, -1
+	}
+	index, err := strconv.Atoi(name[i+1:])
+	if err != nil || index < 0 {
+		return name, -1
+	}
+	return name[:i], index
+}
+
+// DevPath returns the path of the devnode of the interface, e.g.
+// /dev/video0, as named by the kernel.
+func (i *Interface) DevPath() (string, error) {
+	uevent := fmt.Sprintf("/sys/dev/char/%d:%d/uevent", i.Major, i.Minor)
+	data, err := os.ReadFile(uevent)
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if name, ok := strings.CutPrefix(line, "DEVNAME="); ok {
+			return "/dev/" + name, nil
+		}
+	}
+	return "", fmt.Errorf("%w: no DEVNAME in %s", ErrorNoEntity, uevent)
+}
+
+// DevPath returns the devnode of the first interface of the entity.
+func (e *Entity) DevPath() (string, error) {
+	if len(e.Interfaces) == 0 {
+		return "", fmt.Errorf("%w: %q has no devnode", ErrorNoEntity, e.Name)
+	}
+	return e.Interfaces[0].DevPath()
+}
+
+func (l *Link) String() string {
+	return fmt.Sprintf("%q:%d -> %q:%d", l.Source.Entity.Name, l.Source.Index,
+		l.Sink.Entity.Name, l.Sink.Index)
+}
+
+// same as major() and minor() of glibc
+func devMajor(dev uint64) uint32 {
+	return uint32((dev>>8)&0xfff | (dev>>32)&^0xfff)
+}
+
+func devMinor(dev uint64) uint32 {
+	return uint32(dev&0xff | (dev>>12)&^0xff)
+}
@@ -0,0 +1,134 @@
+package media
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSplitPad(t *testing.T) {
+	tests := []struct {
+		name  string
+		want  string
+		index int
+	}{
+		{"ov5640 1-003c", "ov5640 1-003c", -1},
+		{"ov5640 1-003c:0", "ov5640 1-003c", 0},
+		{"rkisp1_isp:2", "rkisp1_isp", 2},
+		{"csi:main", "csi:main", -1},
+		{"csi:main:1", "csi:main", 1},
+		{"csi:", "csi:", -1},
+		{"csi:-1", "csi:-1", -1},
+		{":3", "", 3},
+	}
+	for _, tt := range tests {
+		name, index := splitPad(tt.name)
+		if name != tt.want || index != tt.index {
+			t.Errorf("splitPad(%q) = %q, %d, want %q, %d", tt.name, name, index, tt.want, tt.index)
+		}
+	}
+}
+
+// testGraph links the two source pads of a sensor to the two sink pads of
+// a CSI receiver, whose source pad is linked to a video node
+func testGraph() *Graph {
+	g := &Graph{}
+	entity := func(name string, pads int) *Entity {
+		e := &Entity{ID: uint32(len(g.Entities) + 1), Name: name}
+		for i := 0; i < pads; i++ {
+			p := &Pad{ID: uint32(100 + len(g.Pads)), Entity: e, Index: uint16(i)}
+			e.Pads = append(e.Pads, p)
+			g.Pads = append(g.Pads, p)
+		}
+		g.Entities = append(g.Entities, e)
+		return e
+	}
+	link := func(source, sink *Pad) {
+		l := &Link{ID: uint32(200 + len(g.Links)), Source: source, Sink: sink}
+		source.Links = append(source.Links, l)
+		sink.Links = append(sink.Links, l)
+		source.Entity.Links = append(source.Entity.Links, l)
+		sink.Entity.Links = append(sink.Entity.Links, l)
+		g.Links = append(g.Links, l)
+	}
+	sensor := entity("imx219 10-0010", 2)
+	csi := entity("csi:main", 3)
+	video := entity("csi:main capture", 1)
+	link(sensor.Pads[0], csi.Pads[0])
+	link(sensor.Pads[1], csi.Pads[1])
+	link(csi.Pads[2], video.Pads[0])
+	return g
+}
+
+func TestFindLink(t *testing.T) {
+	g := testGraph()
+	tests := []struct {
+		source, sink string
+		id           uint32 // of the link, 0 if not found
+	}{
+		{"imx219 10-0010", "csi:main", 200},
+		{"imx219 10-0010:1", "csi:main", 201},
+		{"imx219 10-0010", "csi:main:1", 201},
+		{"imx219 10-0010:0", "csi:main:1", 0},
+		{"imx219 10-0010:1", "csi:main:1", 201},
+		{"csi:main", "csi:main capture", 202},
+		{"csi:main:2", "csi:main capture:0", 202},
+		{"csi:main:0", "csi:main capture", 0},
+		{"csi", "csi:main capture", 0},
+		{"csi:main capture", "csi:main", 0},
+	}
+	for _, tt := range tests {
+		l, err := g.FindLink(tt.source, tt.sink)
+		if tt.id == 0 {
+			if !errors.Is(err, ErrorNoLink) {
+				t.Errorf("%q -> %q: link %v, error %v", tt.source, tt.sink, l, err)
+			}
+			continue
+		}
+		if err != nil || l.ID != tt.id {
+			t.Errorf("%q -> %q: link %v, error %v, want link %d", tt.source, tt.sink, l, err, tt.id)
+		}
+	}
+}
+
+func TestDevMajorMinor(t *testing.T) {
+	tests := []struct {
+		dev          uint64
+		major, minor uint32
+	}{
+		{0x0103, 1, 3},
+		{0x5100, 81, 0},
+		{0xe2ff, 226, 255},
+		{0xfff00, 0xfff, 0},
+		{0x100100, 1, 0x100},
+		{0xfff00000, 0, 0xfff00},
+		{0x100000000000, 0x1000, 0},
+		{0x100056723489, 0x1234, 0x56789},
+		{0xffffffffffffffff, 0xffffffff, 0xffffffff},
+	}
+	for _, tt := range tests {
+		if major, minor := devMajor(tt.dev), devMinor(tt.dev); major != tt.major || minor != tt.minor {
+			t.Errorf("dev %#x is %d:%d, want %d:%d", tt.dev, major, minor, tt.major, tt.minor)
+		}
+	}
+}
+
+func TestEntityByDevnode(t *testing.T) {
+	// /dev/null is the character device 1:3
+	g := testGraph()
+	e := g.Entities[2]
+	intf := &Interface{ID: 300, Major: 1, Minor: 3, Entities: []*Entity{e}}
+	e.Interfaces = append(e.Interfaces, intf)
+	g.Interfaces = append(g.Interfaces, intf)
+
+	got, err := g.EntityByDevnode("/dev/null")
+	if err != nil {
+		t.Skip(err)
+	}
+	if got != e {
+		t.Errorf("entity %q, want %q", got.Name, e.Name)
+	}
+	intf.Minor = 5
+	if _, err := g.EntityByDevnode("/dev/null"); !errors.Is(err, ErrorNoEntity) {
+		t.Errorf("entity of another devnode: %v", err)
+	}
+}
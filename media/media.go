@@ -0,0 +1,337 @@
+// Package media wraps the media controller API of /dev/mediaX devices,
+// which describes the entities, pads and links of a camera or codec
+// pipeline and lets userspace route it.
+package media
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// entity functions
+const (
+	MEDIA_ENT_F_UNKNOWN                    = 0
+	MEDIA_ENT_F_V4L2_SUBDEV_UNKNOWN        = 0x20000
+	MEDIA_ENT_F_IO_V4L                     = 0x10001
+	MEDIA_ENT_F_CAM_SENSOR                 = 0x20001
+	MEDIA_ENT_F_FLASH                      = 0x20002
+	MEDIA_ENT_F_LENS                       = 0x20003
+	MEDIA_ENT_F_ATV_DECODER                = 0x20004
+	MEDIA_ENT_F_TUNER                      = 0x20005
+	MEDIA_ENT_F_PROC_VIDEO_COMPOSER        = 0x4001
+	MEDIA_ENT_F_PROC_VIDEO_PIXEL_FORMATTER = 0x4002
+	MEDIA_ENT_F_PROC_VIDEO_PIXEL_ENC_CONV  = 0x4003
+	MEDIA_ENT_F_PROC_VIDEO_LUT             = 0x4004
+	MEDIA_ENT_F_PROC_VIDEO_SCALER          = 0x4005
+	MEDIA_ENT_F_PROC_VIDEO_STATISTICS      = 0x4006
+	MEDIA_ENT_F_PROC_VIDEO_ENCODER         = 0x4007
+	MEDIA_ENT_F_PROC_VIDEO_DECODER         = 0x4008
+	MEDIA_ENT_F_PROC_VIDEO_ISP             = 0x4009
+	MEDIA_ENT_F_VID_MUX                    = 0x5001
+	MEDIA_ENT_F_VID_IF_BRIDGE              = 0x5002
+)
+
+// entity flags
+const (
+	MEDIA_ENT_FL_DEFAULT   = 1
+	MEDIA_ENT_FL_CONNECTOR = 2
+)
+
+// pad flags
+const (
+	MEDIA_PAD_FL_SINK         = 1
+	MEDIA_PAD_FL_SOURCE       = 2
+	MEDIA_PAD_FL_MUST_CONNECT = 4
+)
+
+// link flags
+const (
+	MEDIA_LNK_FL_ENABLED        = 1
+	MEDIA_LNK_FL_IMMUTABLE      = 2
+	MEDIA_LNK_FL_DYNAMIC        = 4
+	MEDIA_LNK_FL_LINK_TYPE      = 0xf << 28 // negative int in C
+	MEDIA_LNK_FL_DATA_LINK      = 0
+	MEDIA_LNK_FL_INTERFACE_LINK = 0x10000000
+	MEDIA_LNK_FL_ANCILLARY_LINK = 0x20000000
+)
+
+// interface types
+const (
+	MEDIA_INTF_T_V4L_VIDEO   = 0x200
+	MEDIA_INTF_T_V4L_VBI     = 0x201
+	MEDIA_INTF_T_V4L_RADIO   = 0x202
+	MEDIA_INTF_T_V4L_SUBDEV  = 0x203
+	MEDIA_INTF_T_V4L_SWRADIO = 0x204
+	MEDIA_INTF_T_V4L_TOUCH   = 0x205
+)
+
+func ioctl(fd int, request uint, argp unsafe.Pointer) error {
+	_, _, err := syscall.Syscall(syscall.SYS_IOCTL,
+		uintptr(fd), uintptr(request), uintptr(argp))
+	if err != 0 {
+		return err
+	}
+	return nil
+}
+
+// goString returns the NUL terminated string at p
+func goString(p unsafe.Pointer) string {
+	n := 0
+	for *(*byte)(unsafe.Add(p, n)) != 0 {
+		n++
+	}
+	return string(unsafe.Slice((*byte)(p), n))
+}
+
+type Media_Device_Info struct {
+	Driver        string
+	Model         string
+	Serial        string
+	BusInfo       string
+	MediaVersion  uint32
+	HWRevision    uint32
+	DriverVersion uint32
+}
+
+func (i *Media_Device_Info) get(ptr unsafe.Pointer) {
+	p := (*media_device_info)(ptr)
+	i.Driver = goString(unsafe.Pointer(&p.driver[0]))
+	i.Model = goString(unsafe.Pointer(&p.model[0]))
+	i.Serial = goString(unsafe.Pointer(&p.serial[0]))
+	i.BusInfo = goString(unsafe.Pointer(&p.bus_info[0]))
+	i.MediaVersion = uint32(p.media_version)
+	i.HWRevision = uint32(p.hw_revision)
+	i.DriverVersion = uint32(p.driver_version)
+}
+
+func IoctlDeviceInfo(fd int, argp *Media_Device_Info) error {
+	var info media_device_info
+	p := unsafe.Pointer(&info)
+	err := ioctl(fd, MEDIA_IOC_DEVICE_INFO, p)
+	if err != nil {
+		return err
+	}
+	argp.get(p)
+	return nil
+}
+
+type Media_V2_Entity struct {
+	ID       uint32
+	Name     string
+	Function uint32
+	Flags    uint32
+}
+
+func (e *Media_V2_Entity) get(ptr unsafe.Pointer) {
+	p := (*media_v2_entity)(ptr)
+	e.ID = uint32(p.id)
+	e.Name = goString(unsafe.Pointer(&p.name[0]))
+	e.Function = uint32(p.function)
+	e.Flags = uint32(p.flags)
+}
+
+type Media_V2_Interface struct {
+	ID       uint32
+	IntfType uint32
+	Flags    uint32
+	Major    uint32 // devnode of the interface
+	Minor    uint32
+}
+
+func (i *Media_V2_Interface) get(ptr unsafe.Pointer) {
+	p := (*media_v2_interface)(ptr)
+	i.ID = uint32(p.id)
+	i.IntfType = uint32(p.intf_type)
+	i.Flags = uint32(p.flags)
+
+	// due to anonymous union, cannot get it's field pointer
+	devnode := (*media_v2_intf_devnode)(unsafe.Pointer(
+		uintptr(ptr) + unsafe.Offsetof(p.reserved) + unsafe.Sizeof(p.reserved)))
+	i.Major = uint32(devnode.major)
+	i.Minor = uint32(devnode.minor)
+}
+
+type Media_V2_Pad struct {
+	ID       uint32
+	EntityID uint32
+	Flags    uint32
+	Index    uint32 // valid since media version 4.19
+}
+
+func (d *Media_V2_Pad) get(ptr unsafe.Pointer) {
+	p := (*media_v2_pad)(ptr)
+	d.ID = uint32(p.id)
+	d.EntityID = uint32(p.entity_id)
+	d.Flags = uint32(p.flags)
+	d.Index = uint32(p.index)
+}
+
+// Media_V2_Link links two pads, or an interface to an entity if Flags
+// has MEDIA_LNK_FL_INTERFACE_LINK.
+type Media_V2_Link struct {
+	ID       uint32
+	SourceID uint32
+	SinkID   uint32
+	Flags    uint32
+}
+
+func (l *Media_V2_Link) get(ptr unsafe.Pointer) {
+	p := (*media_v2_link)(ptr)
+	l.ID = uint32(p.id)
+	l.SourceID = uint32(p.source_id)
+	l.SinkID = uint32(p.sink_id)
+	l.Flags = uint32(p.flags)
+}
+
+type Media_V2_Topology struct {
+	TopologyVersion uint64
+	Entities        []Media_V2_Entity
+	Interfaces      []Media_V2_Interface
+	Pads            []Media_V2_Pad
+	Links           []Media_V2_Link
+}
+
+// IoctlGetTopology gets the whole topology of the media device. The number
+// of objects is queried first, and the query is repeated if the topology
+// changed in between.
+func IoctlGetTopology(fd int, argp *Media_V2_Topology) error {
+	for {
+		var topo media_v2_topology
+		err := ioctl(fd, MEDIA_IOC_G_TOPOLOGY, unsafe.Pointer(&topo))
+		if err != nil {
+			return err
+		}
+		version := topo.topology_version
+
+		entities := make([]media_v2_entity, topo.num_entities)
+		interfaces := make([]media_v2_interface, topo.num_interfaces)
+		pads := make([]media_v2_pad, topo.num_pads)
+		links := make([]media_v2_link, topo.num_links)
+		if len(entities) > 0 {
+			topo.ptr_entities = __u64(uintptr(unsafe.Pointer(&entities[0])))
+		}
+		if len(interfaces) > 0 {
+			topo.ptr_interfaces = __u64(uintptr(unsafe.Pointer(&interfaces[0])))
+		}
+		if len(pads) > 0 {
+			topo.ptr_pads = __u64(uintptr(unsafe.Pointer(&pads[0])))
+		}
+		if len(links) > 0 {
+			topo.ptr_links = __u64(uintptr(unsafe.Pointer(&links[0])))
+		}
+		err = ioctl(fd, MEDIA_IOC_G_TOPOLOGY, unsafe.Pointer(&topo))
+		if err == syscall.ENOSPC {
+			continue // objects were added in between
+		}
+		if err != nil {
+			return err
+		}
+		if topo.topology_version != version {
+			continue
+		}
+
+		argp.TopologyVersion = uint64(version)
+		argp.Entities = make([]Media_V2_Entity, topo.num_entities)
+		for i := range argp.Entities {
+			argp.Entities[i].get(unsafe.Pointer(&entities[i]))
+		}
+		argp.Interfaces = make([]Media_V2_Interface, topo.num_interfaces)
+		for i := range argp.Interfaces {
+			argp.Interfaces[i].get(unsafe.Pointer(&interfaces[i]))
+		}
+		argp.Pads = make([]Media_V2_Pad, topo.num_pads)
+		for i := range argp.Pads {
+			argp.Pads[i].get(unsafe.Pointer(&pads[i]))
+		}
+		argp.Links = make([]Media_V2_Link, topo.num_links)
+		for i := range argp.Links {
+			argp.Links[i].get(unsafe.Pointer(&links[i]))
+		}
+		return nil
+	}
+}
+
+type Media_Pad_Desc struct {
+	Entity uint32
+	Index  uint16
+	Flags  uint32
+}
+
+func (d *Media_Pad_Desc) set(ptr unsafe.Pointer) {
+	p := (*media_pad_desc)(ptr)
+	p.entity = __u32(d.Entity)
+	p.index = __u16(d.Index)
+	p.flags = __u32(d.Flags)
+}
+
+func (d *Media_Pad_Desc) get(ptr unsafe.Pointer) {
+	p := (*media_pad_desc)(ptr)
+	d.Entity = uint32(p.entity)
+	d.Index = uint16(p.index)
+	d.Flags = uint32(p.flags)
+}
+
+type Media_Link_Desc struct {
+	Source Media_Pad_Desc
+	Sink   Media_Pad_Desc
+	Flags  uint32
+}
+
+func (d *Media_Link_Desc) set(ptr unsafe.Pointer) {
+	p := (*media_link_desc)(ptr)
+	d.Source.set(unsafe.Pointer(&p.source))
+	d.Sink.set(unsafe.Pointer(&p.sink))
+	p.flags = __u32(d.Flags)
+}
+
+func (d *Media_Link_Desc) get(ptr unsafe.Pointer) {
+	p := (*media_link_desc)(ptr)
+	d.Source.get(unsafe.Pointer(&p.source))
+	d.Sink.get(unsafe.Pointer(&p.sink))
+	d.Flags = uint32(p.flags)
+}
+
+// Media_Links_Enum gets the pads and the outbound links of an entity. Pads
+// and Links must be sized to the number of pads and outbound links of it.
+type Media_Links_Enum struct {
+	Entity uint32
+	Pads   []Media_Pad_Desc
+	Links  []Media_Link_Desc
+}
+
+func IoctlEnumLinks(fd int, argp *Media_Links_Enum) error {
+	var le media_links_enum
+	pads := make([]media_pad_desc, len(argp.Pads))
+	links := make([]media_link_desc, len(argp.Links))
+
+	le.entity = __u32(argp.Entity)
+	if len(pads) > 0 {
+		le.pads = &pads[0]
+	}
+	if len(links) > 0 {
+		le.links = &links[0]
+	}
+	err := ioctl(fd, MEDIA_IOC_ENUM_LINKS, unsafe.Pointer(&le))
+	if err != nil {
+		return err
+	}
+	for i := range pads {
+		argp.Pads[i].get(unsafe.Pointer(&pads[i]))
+	}
+	for i := range links {
+		argp.Links[i].get(unsafe.Pointer(&links[i]))
+	}
+	return nil
+}
+
+func IoctlSetupLink(fd int, argp *Media_Link_Desc) error {
+	var ld media_link_desc
+	p := unsafe.Pointer(&ld)
+	argp.set(p)
+	err := ioctl(fd, MEDIA_IOC_SETUP_LINK, p)
+	if err != nil {
+		return err
+	}
+	argp.get(p)
+	return nil
+}
@@ -0,0 +1,52 @@
+//go:build v4l2cgo
+
+package media
+
+/*
+#include <linux/media.h>
+*/
+import "C"
+
+// Built with the v4l2cgo tag, the package takes the structs of the kernel
+// ABI from the C headers instead of ztypes_linux_$GOARCH.go, which is
+// generated from this file.
+
+type (
+	__u16 = C.__u16
+	__u32 = C.__u32
+	__u64 = C.__u64
+)
+
+type (
+	media_device_info     = C.struct_media_device_info
+	media_link_desc       = C.struct_media_link_desc
+	media_links_enum      = C.struct_media_links_enum
+	media_pad_desc        = C.struct_media_pad_desc
+	media_v2_entity       = C.struct_media_v2_entity
+	media_v2_interface    = C.struct_media_v2_interface
+	media_v2_intf_devnode = C.struct_media_v2_intf_devnode
+	media_v2_link         = C.struct_media_v2_link
+	media_v2_pad          = C.struct_media_v2_pad
+	media_v2_topology     = C.struct_media_v2_topology
+)
+
+const (
+	sizeof_media_device_info     = C.sizeof_struct_media_device_info
+	sizeof_media_link_desc       = C.sizeof_struct_media_link_desc
+	sizeof_media_links_enum      = C.sizeof_struct_media_links_enum
+	sizeof_media_pad_desc        = C.sizeof_struct_media_pad_desc
+	sizeof_media_v2_entity       = C.sizeof_struct_media_v2_entity
+	sizeof_media_v2_interface    = C.sizeof_struct_media_v2_interface
+	sizeof_media_v2_intf_devnode = C.sizeof_struct_media_v2_intf_devnode
+	sizeof_media_v2_link         = C.sizeof_struct_media_v2_link
+	sizeof_media_v2_pad          = C.sizeof_struct_media_v2_pad
+	sizeof_media_v2_topology     = C.sizeof_struct_media_v2_topology
+)
+
+// Media controller ioctls
+const (
+	MEDIA_IOC_DEVICE_INFO = C.MEDIA_IOC_DEVICE_INFO
+	MEDIA_IOC_ENUM_LINKS  = C.MEDIA_IOC_ENUM_LINKS
+	MEDIA_IOC_SETUP_LINK  = C.MEDIA_IOC_SETUP_LINK
+	MEDIA_IOC_G_TOPOLOGY  = C.MEDIA_IOC_G_TOPOLOGY
+)
@@ -0,0 +1,389 @@
+package v4l2
+
+import (
+	"fmt"
+	"strings"
+)
+
+//go:generate go run ./tools/pixfmt -o pixfmt_table.go
+
+// flags of PixelFormat
+const (
+	PixFmtCompressed = 1 << iota // bitstream without a plane layout, e.g. MJPEG, H264
+	PixFmtPacked                 // components interleaved in a single plane, e.g. YUYV, RGB24
+	PixFmtBayer                  // raw Bayer pattern of an image sensor
+	PixFmtTiled                  // pixels stored in tiles instead of lines
+)
+
+// PlaneLayout describes a component plane of a pixel format. Bytes bytes
+// store Pixels horizontally adjacent pixels of the plane, e.g. 4 bytes store
+// 2 pixels of YUYV and 5 bytes store 4 pixels of SBGGR10P.
+type PlaneLayout struct {
+	Bytes  int
+	Pixels int
+}
+
+// PixelFormat describes the memory layout of a V4L2_PIX_FMT_* format.
+// Compressed formats and vendor formats of unknown layout have no Planes.
+type PixelFormat struct {
+	FourCC      uint32
+	Name        string // name of the V4L2_PIX_FMT_* constant, e.g. "NV12"
+	Description string
+	Flags       uint32
+
+	MemPlanes int           // buffers per frame with the multi-planar API
+	Planes    []PlaneLayout // Y, Cb, Cr or Y, CbCr or a single packed plane
+	HSub      int           // horizontal chroma subsampling, e.g. 2 for 4:2:2
+	VSub      int           // vertical chroma subsampling, e.g. 2 for 4:2:0
+
+	TileWidth  int // width and height of a tile of tiled formats, in pixels
+	TileHeight int
+}
+
+// PlaneSize is the bytesperline and sizeimage of a memory plane
+type PlaneSize struct {
+	BytesPerLine uint32
+	SizeImage    uint32
+}
+
+type pixelFormatEntry struct {
+	fourcc      uint32
+	name        string
+	description string
+	depth       int
+	flags       uint32
+}
+
+// pixelLayout completes the entries of pixelFormatTable whose layout cannot
+// be derived from the depth listed in videodev2.h
+type pixelLayout struct {
+	flags      uint32
+	memPlanes  int
+	planes     []PlaneLayout
+	hsub, vsub int
+	tile       [2]int
+}
+
+var (
+	yuvPlanes  = []PlaneLayout{{1, 1}, {1, 1}, {1, 1}}
+	nv12Planes = []PlaneLayout{{1, 1}, {2, 1}}
+	p010Planes = []PlaneLayout{{2, 1}, {4, 1}}
+)
+
+var pixelLayouts = map[uint32]pixelLayout{
+	V4L2_PIX_FMT_BGR666: {planes: []PlaneLayout{{4, 1}}},
+
+	V4L2_PIX_FMT_Y10BPACK: {planes: []PlaneLayout{{5, 4}}},
+	V4L2_PIX_FMT_Y10P:     {planes: []PlaneLayout{{5, 4}}},
+	V4L2_PIX_FMT_IPU3_Y10: {planes: []PlaneLayout{{32, 25}}},
+
+	V4L2_PIX_FMT_UV8:  {planes: []PlaneLayout{{2, 1}}},
+	V4L2_PIX_FMT_YUYV: {planes: []PlaneLayout{{4, 2}}, hsub: 2},
+	V4L2_PIX_FMT_YYUV: {planes: []PlaneLayout{{4, 2}}, hsub: 2},
+	V4L2_PIX_FMT_YVYU: {planes: []PlaneLayout{{4, 2}}, hsub: 2},
+	V4L2_PIX_FMT_UYVY: {planes: []PlaneLayout{{4, 2}}, hsub: 2},
+	V4L2_PIX_FMT_VYUY: {planes: []PlaneLayout{{4, 2}}, hsub: 2},
+	V4L2_PIX_FMT_Y41P: {planes: []PlaneLayout{{12, 8}}, hsub: 4},
+	V4L2_PIX_FMT_M420: {planes: nv12Planes, hsub: 2, vsub: 2},
+
+	V4L2_PIX_FMT_NV12: {planes: nv12Planes, hsub: 2, vsub: 2},
+	V4L2_PIX_FMT_NV21: {planes: nv12Planes, hsub: 2, vsub: 2},
+	V4L2_PIX_FMT_NV16: {planes: nv12Planes, hsub: 2, vsub: 1},
+	V4L2_PIX_FMT_NV61: {planes: nv12Planes, hsub: 2, vsub: 1},
+	V4L2_PIX_FMT_NV24: {planes: nv12Planes},
+	V4L2_PIX_FMT_NV42: {planes: nv12Planes},
+	V4L2_PIX_FMT_P010: {planes: p010Planes, hsub: 2, vsub: 2},
+
+	V4L2_PIX_FMT_NV12M: {memPlanes: 2, planes: nv12Planes, hsub: 2, vsub: 2},
+	V4L2_PIX_FMT_NV21M: {memPlanes: 2, planes: nv12Planes, hsub: 2, vsub: 2},
+	V4L2_PIX_FMT_NV16M: {memPlanes: 2, planes: nv12Planes, hsub: 2, vsub: 1},
+	V4L2_PIX_FMT_NV61M: {memPlanes: 2, planes: nv12Planes, hsub: 2, vsub: 1},
+
+	V4L2_PIX_FMT_YUV410:  {planes: yuvPlanes, hsub: 4, vsub: 4},
+	V4L2_PIX_FMT_YVU410:  {planes: yuvPlanes, hsub: 4, vsub: 4},
+	V4L2_PIX_FMT_YUV411P: {planes: yuvPlanes, hsub: 4, vsub: 1},
+	V4L2_PIX_FMT_YUV420:  {planes: yuvPlanes, hsub: 2, vsub: 2},
+	V4L2_PIX_FMT_YVU420:  {planes: yuvPlanes, hsub: 2, vsub: 2},
+	V4L2_PIX_FMT_YUV422P: {planes: yuvPlanes, hsub: 2, vsub: 1},
+
+	V4L2_PIX_FMT_YUV420M: {memPlanes: 3, planes: yuvPlanes, hsub: 2, vsub: 2},
+	V4L2_PIX_FMT_YVU420M: {memPlanes: 3, planes: yuvPlanes, hsub: 2, vsub: 2},
+	V4L2_PIX_FMT_YUV422M: {memPlanes: 3, planes: yuvPlanes, hsub: 2, vsub: 1},
+	V4L2_PIX_FMT_YVU422M: {memPlanes: 3, planes: yuvPlanes, hsub: 2, vsub: 1},
+	V4L2_PIX_FMT_YUV444M: {memPlanes: 3, planes: yuvPlanes},
+	V4L2_PIX_FMT_YVU444M: {memPlanes: 3, planes: yuvPlanes},
+
+	V4L2_PIX_FMT_NV12_4L4:     {planes: nv12Planes, hsub: 2, vsub: 2, tile: [2]int{4, 4}},
+	V4L2_PIX_FMT_NV12_16L16:   {planes: nv12Planes, hsub: 2, vsub: 2, tile: [2]int{16, 16}},
+	V4L2_PIX_FMT_NV12_32L32:   {planes: nv12Planes, hsub: 2, vsub: 2, tile: [2]int{32, 32}},
+	V4L2_PIX_FMT_P010_4L4:     {planes: p010Planes, hsub: 2, vsub: 2, tile: [2]int{4, 4}},
+	V4L2_PIX_FMT_NV12MT:       {memPlanes: 2, planes: nv12Planes, hsub: 2, vsub: 2, tile: [2]int{64, 32}},
+	V4L2_PIX_FMT_NV12MT_16X16: {memPlanes: 2, planes: nv12Planes, hsub: 2, vsub: 2, tile: [2]int{16, 16}},
+	V4L2_PIX_FMT_NV12M_8L128:  {memPlanes: 2, planes: nv12Planes, hsub: 2, vsub: 2, tile: [2]int{8, 128}},
+
+	V4L2_PIX_FMT_SBGGR10P:     {planes: []PlaneLayout{{5, 4}}},
+	V4L2_PIX_FMT_SGBRG10P:     {planes: []PlaneLayout{{5, 4}}},
+	V4L2_PIX_FMT_SGRBG10P:     {planes: []PlaneLayout{{5, 4}}},
+	V4L2_PIX_FMT_SRGGB10P:     {planes: []PlaneLayout{{5, 4}}},
+	V4L2_PIX_FMT_SBGGR10ALAW8: {planes: []PlaneLayout{{1, 1}}},
+	V4L2_PIX_FMT_SGBRG10ALAW8: {planes: []PlaneLayout{{1, 1}}},
+	V4L2_PIX_FMT_SGRBG10ALAW8: {planes: []PlaneLayout{{1, 1}}},
+	V4L2_PIX_FMT_SRGGB10ALAW8: {planes: []PlaneLayout{{1, 1}}},
+	V4L2_PIX_FMT_SBGGR10DPCM8: {planes: []PlaneLayout{{1, 1}}},
+	V4L2_PIX_FMT_SGBRG10DPCM8: {planes: []PlaneLayout{{1, 1}}},
+	V4L2_PIX_FMT_SGRBG10DPCM8: {planes: []PlaneLayout{{1, 1}}},
+	V4L2_PIX_FMT_SRGGB10DPCM8: {planes: []PlaneLayout{{1, 1}}},
+	V4L2_PIX_FMT_SBGGR12P:     {planes: []PlaneLayout{{3, 2}}},
+	V4L2_PIX_FMT_SGBRG12P:     {planes: []PlaneLayout{{3, 2}}},
+	V4L2_PIX_FMT_SGRBG12P:     {planes: []PlaneLayout{{3, 2}}},
+	V4L2_PIX_FMT_SRGGB12P:     {planes: []PlaneLayout{{3, 2}}},
+	V4L2_PIX_FMT_SBGGR14P:     {planes: []PlaneLayout{{7, 4}}},
+	V4L2_PIX_FMT_SGBRG14P:     {planes: []PlaneLayout{{7, 4}}},
+	V4L2_PIX_FMT_SGRBG14P:     {planes: []PlaneLayout{{7, 4}}},
+	V4L2_PIX_FMT_SRGGB14P:     {planes: []PlaneLayout{{7, 4}}},
+	V4L2_PIX_FMT_IPU3_SBGGR10: {flags: PixFmtBayer, planes: []PlaneLayout{{32, 25}}},
+	V4L2_PIX_FMT_IPU3_SGBRG10: {flags: PixFmtBayer, planes: []PlaneLayout{{32, 25}}},
+	V4L2_PIX_FMT_IPU3_SGRBG10: {flags: PixFmtBayer, planes: []PlaneLayout{{32, 25}}},
+	V4L2_PIX_FMT_IPU3_SRGGB10: {flags: PixFmtBayer, planes: []PlaneLayout{{32, 25}}},
+
+	V4L2_PIX_FMT_HSV24: {planes: []PlaneLayout{{3, 1}}},
+	V4L2_PIX_FMT_HSV32: {planes: []PlaneLayout{{4, 1}}},
+
+	/* vendor-specific formats */
+	V4L2_PIX_FMT_HI240:        {planes: []PlaneLayout{{1, 1}}},
+	V4L2_PIX_FMT_SN9C20X_I420: {planes: yuvPlanes, hsub: 2, vsub: 2},
+	V4L2_PIX_FMT_Y8I:          {planes: []PlaneLayout{{2, 1}}},
+	V4L2_PIX_FMT_Y12I:         {planes: []PlaneLayout{{3, 1}}},
+	V4L2_PIX_FMT_Z16:          {planes: []PlaneLayout{{2, 1}}},
+	V4L2_PIX_FMT_CNF4:         {planes: []PlaneLayout{{1, 2}}},
+	V4L2_PIX_FMT_MM21:         {flags: PixFmtTiled, memPlanes: 2, planes: nv12Planes, hsub: 2, vsub: 2, tile: [2]int{16, 32}},
+	V4L2_PIX_FMT_CPIA1:        {flags: PixFmtCompressed},
+	V4L2_PIX_FMT_WNVA:         {flags: PixFmtCompressed},
+	V4L2_PIX_FMT_SN9C10X:      {flags: PixFmtCompressed},
+	V4L2_PIX_FMT_PWC1:         {flags: PixFmtCompressed},
+	V4L2_PIX_FMT_PWC2:         {flags: PixFmtCompressed},
+	V4L2_PIX_FMT_ET61X251:     {flags: PixFmtCompressed},
+	V4L2_PIX_FMT_SPCA561:      {flags: PixFmtCompressed | PixFmtBayer},
+	V4L2_PIX_FMT_PAC207:       {flags: PixFmtCompressed | PixFmtBayer},
+	V4L2_PIX_FMT_MR97310A:     {flags: PixFmtCompressed | PixFmtBayer},
+	V4L2_PIX_FMT_JL2005BCD:    {flags: PixFmtCompressed | PixFmtBayer},
+	V4L2_PIX_FMT_SN9C2028:     {flags: PixFmtCompressed | PixFmtBayer},
+	V4L2_PIX_FMT_SQ905C:       {flags: PixFmtCompressed | PixFmtBayer},
+	V4L2_PIX_FMT_PJPG:         {flags: PixFmtCompressed},
+	V4L2_PIX_FMT_OV511:        {flags: PixFmtCompressed},
+	V4L2_PIX_FMT_OV518:        {flags: PixFmtCompressed},
+	V4L2_PIX_FMT_JPGL:         {flags: PixFmtCompressed},
+	V4L2_PIX_FMT_SE401:        {flags: PixFmtCompressed},
+	V4L2_PIX_FMT_S5C_UYVY_JPG: {flags: PixFmtCompressed},
+	V4L2_PIX_FMT_MT21C:        {flags: PixFmtCompressed},
+	V4L2_PIX_FMT_QC08C:        {flags: PixFmtCompressed},
+	V4L2_PIX_FMT_QC10C:        {flags: PixFmtCompressed},
+}
+
+var pixelFormats = make(map[uint32]*PixelFormat, len(pixelFormatTable))
+
+func init() {
+	for _, e := range pixelFormatTable {
+		f := &PixelFormat{
+			FourCC:      e.fourcc,
+			Name:        e.name,
+			Description: e.description,
+			Flags:       e.flags,
+			HSub:        1,
+			VSub:        1,
+		}
+		if l, ok := pixelLayouts[e.fourcc]; ok {
+			f.Flags |= l.flags
+			f.Planes = l.planes
+			f.MemPlanes = l.memPlanes
+			if l.hsub != 0 {
+				f.HSub = l.hsub
+			}
+			if l.vsub != 0 {
+				f.VSub = l.vsub
+			}
+			f.TileWidth, f.TileHeight = l.tile[0], l.tile[1]
+		} else if e.depth > 0 && f.Flags&PixFmtCompressed == 0 {
+			// unpacked formats store each pixel in whole bytes, e.g. Y10
+			f.Planes = []PlaneLayout{{(e.depth + 7) / 8, 1}}
+		}
+		if len(f.Planes) > 1 {
+			f.Flags &^= PixFmtPacked
+		}
+		if f.MemPlanes == 0 {
+			f.MemPlanes = 1
+		}
+		pixelFormats[f.FourCC] = f
+	}
+}
+
+// LookupPixelFormat returns the description of the pixel format fourcc
+func LookupPixelFormat(fourcc uint32) (*PixelFormat, error) {
+	f, ok := pixelFormats[fourcc]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrorUnknownFourCC, GetNameByFourCC(fourcc))
+	}
+	return f, nil
+}
+
+// LookupPixelFormatByName returns the pixel format by the name of its
+// constant, e.g. "SBGGR10" or its FourCC code, e.g. "BG10"
+func LookupPixelFormatByName(name string) (*PixelFormat, error) {
+	for _, e := range pixelFormatTable {
+		if e.name == name {
+			return pixelFormats[e.fourcc], nil
+		}
+	}
+	for _, e := range pixelFormatTable {
+		if strings.TrimRight(GetNameByFourCC(e.fourcc&^(1<<31)), " ") == name {
+			return pixelFormats[e.fourcc], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrorUnknownFourCC, name)
+}
+
+// PixelFormats returns all known pixel formats in the order of videodev2.h
+func PixelFormats() []*PixelFormat {
+	formats := make([]*PixelFormat, 0, len(pixelFormatTable))
+	for _, e := range pixelFormatTable {
+		formats = append(formats, pixelFormats[e.fourcc])
+	}
+	return formats
+}
+
+// BitsPerPixel returns the bits of the component plane per pixel of the
+// image, e.g. 8 and 4 for the Y and CbCr plane of NV12
+func (f *PixelFormat) BitsPerPixel(plane int) float64 {
+	if plane < 0 || plane >= len(f.Planes) {
+		return 0
+	}
+	p := f.Planes[plane]
+	bpp := float64(p.Bytes*8) / float64(p.Pixels)
+	if plane > 0 {
+		bpp /= float64(f.HSub * f.VSub)
+	}
+	return bpp
+}
+
+// PlaneSizes calculates bytesperline and sizeimage of each memory plane of
+// an image of width x height whose lines are aligned to align bytes. The
+// chroma lines of a contiguous planar format follow the luma stride, as
+// drivers lay them out.
+func (f *PixelFormat) PlaneSizes(width, height, align uint32) ([]PlaneSize, error) {
+	if len(f.Planes) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrorNoPlaneLayout, f.Name)
+	}
+	if align == 0 {
+		align = 1
+	}
+	if f.TileWidth > 0 {
+		width = roundUp(width, uint32(f.TileWidth))
+		height = roundUp(height, uint32(f.TileHeight))
+	}
+
+	sizes := make([]PlaneSize, f.MemPlanes)
+	var stride0 uint32
+	for i, p := range f.Planes {
+		w, h := width, height
+		if i > 0 {
+			w = divRoundUp(width, uint32(f.HSub))
+			h = divRoundUp(height, uint32(f.VSub))
+		}
+		var stride uint32
+		if i == 0 || i < f.MemPlanes {
+			stride = roundUp(divRoundUp(w, uint32(p.Pixels))*uint32(p.Bytes), align)
+		} else {
+			stride = f.chromaStride(i, width, stride0)
+		}
+		if i == 0 {
+			stride0 = stride
+		}
+
+		m := min(i, f.MemPlanes-1)
+		if i == m {
+			sizes[m].BytesPerLine = stride
+		}
+		sizes[m].SizeImage += stride * h
+	}
+	return sizes, nil
+}
+
+// SizeImage returns bytesperline of the first plane and the total size of
+// an image of width x height, as single-planar formats report them
+func (f *PixelFormat) SizeImage(width, height, align uint32) (uint32, uint32, error) {
+	sizes, err := f.PlaneSizes(width, height, align)
+	if err != nil {
+		return 0, 0, err
+	}
+	var total uint32
+	for _, s := range sizes {
+		total += s.SizeImage
+	}
+	return sizes[0].BytesPerLine, total, nil
+}
+
+// chromaStride returns the stride of component plane i following the luma
+// stride in the same memory plane of an image width pixels wide. That is
+// the chroma line of width, as v4l2_fill_pixfmt computes it, or the luma
+// stride scaled to the chroma plane if the luma lines are padded.
+func (f *PixelFormat) chromaStride(i int, width, stride0 uint32) uint32 {
+	p0, p := f.Planes[0], f.Planes[i]
+	line := divRoundUp(divRoundUp(width, uint32(f.HSub)), uint32(p.Pixels)) * uint32(p.Bytes)
+	return max(line, divRoundUp(stride0*uint32(p.Bytes*p0.Pixels), uint32(p.Pixels*p0.Bytes*f.HSub)))
+}
+
+// RGBOrder gives the byte offsets of the components of a pixel of a packed
+// RGB format
+type RGBOrder struct {
+	Bytes      int // bytes per pixel
+	R, G, B, A int // A is -1 for formats without alpha
+}
+
+var rgbOrders = map[uint32]RGBOrder{
+	V4L2_PIX_FMT_RGB24:  {3, 0, 1, 2, -1},
+	V4L2_PIX_FMT_BGR24:  {3, 2, 1, 0, -1},
+	V4L2_PIX_FMT_BGR32:  {4, 2, 1, 0, -1},
+	V4L2_PIX_FMT_ABGR32: {4, 2, 1, 0, 3},
+	V4L2_PIX_FMT_XBGR32: {4, 2, 1, 0, -1},
+	V4L2_PIX_FMT_BGRA32: {4, 3, 2, 1, 0},
+	V4L2_PIX_FMT_BGRX32: {4, 3, 2, 1, -1},
+	V4L2_PIX_FMT_RGB32:  {4, 1, 2, 3, -1},
+	V4L2_PIX_FMT_RGBA32: {4, 0, 1, 2, 3},
+	V4L2_PIX_FMT_RGBX32: {4, 0, 1, 2, -1},
+	V4L2_PIX_FMT_ARGB32: {4, 1, 2, 3, 0},
+	V4L2_PIX_FMT_XRGB32: {4, 1, 2, 3, -1},
+}
+
+// formats whose chroma planes or samples are in Cr, Cb order
+var crFirst = map[uint32]bool{
+	V4L2_PIX_FMT_YVU420:  true,
+	V4L2_PIX_FMT_YVU420M: true,
+	V4L2_PIX_FMT_YVU422M: true,
+	V4L2_PIX_FMT_YVU444M: true,
+	V4L2_PIX_FMT_NV21:    true,
+	V4L2_PIX_FMT_NV21M:   true,
+	V4L2_PIX_FMT_NV61:    true,
+	V4L2_PIX_FMT_NV61M:   true,
+	V4L2_PIX_FMT_NV42:    true,
+}
+
+// RGBOrder returns the order of the components of a packed RGB format, ok
+// is false for other formats.
+func (f *PixelFormat) RGBOrder() (o RGBOrder, ok bool) {
+	o, ok = rgbOrders[f.FourCC]
+	return o, ok
+}
+
+// CrFirst reports whether the chroma planes or samples of a YUV format are
+// in Cr, Cb order, e.g. for YVU420 and NV21.
+func (f *PixelFormat) CrFirst() bool {
+	return crFirst[f.FourCC]
+}
+
+func divRoundUp(n, d uint32) uint32 {
+	return (n + d - 1) / d
+}
+
+func roundUp(n, align uint32) uint32 {
+	return divRoundUp(n, align) * align
+}
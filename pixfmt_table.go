@@ -0,0 +1,459 @@
+// Code generated by "go run ./tools/pixfmt" from linux/videodev2.h; DO NOT EDIT.
+
+package v4l2
+
+// Pixel format FOURCC depth Description
+const (
+	/* RGB formats (1 or 2 bytes per pixel) */
+	V4L2_PIX_FMT_RGB332   = 0x31424752 // "RGB1"
+	V4L2_PIX_FMT_RGB444   = 0x34343452 // "R444"
+	V4L2_PIX_FMT_ARGB444  = 0x32315241 // "AR12"
+	V4L2_PIX_FMT_XRGB444  = 0x32315258 // "XR12"
+	V4L2_PIX_FMT_RGBA444  = 0x32314152 // "RA12"
+	V4L2_PIX_FMT_RGBX444  = 0x32315852 // "RX12"
+	V4L2_PIX_FMT_ABGR444  = 0x32314241 // "AB12"
+	V4L2_PIX_FMT_XBGR444  = 0x32314258 // "XB12"
+	V4L2_PIX_FMT_BGRA444  = 0x32314147 // "GA12"
+	V4L2_PIX_FMT_BGRX444  = 0x32315842 // "BX12"
+	V4L2_PIX_FMT_RGB555   = 0x4f424752 // "RGBO"
+	V4L2_PIX_FMT_ARGB555  = 0x35315241 // "AR15"
+	V4L2_PIX_FMT_XRGB555  = 0x35315258 // "XR15"
+	V4L2_PIX_FMT_RGBA555  = 0x35314152 // "RA15"
+	V4L2_PIX_FMT_RGBX555  = 0x35315852 // "RX15"
+	V4L2_PIX_FMT_ABGR555  = 0x35314241 // "AB15"
+	V4L2_PIX_FMT_XBGR555  = 0x35314258 // "XB15"
+	V4L2_PIX_FMT_BGRA555  = 0x35314142 // "BA15"
+	V4L2_PIX_FMT_BGRX555  = 0x35315842 // "BX15"
+	V4L2_PIX_FMT_RGB565   = 0x50424752 // "RGBP"
+	V4L2_PIX_FMT_RGB555X  = 0x51424752 // "RGBQ"
+	V4L2_PIX_FMT_ARGB555X = 0xb5315241 // "AR15"
+	V4L2_PIX_FMT_XRGB555X = 0xb5315258 // "XR15"
+	V4L2_PIX_FMT_RGB565X  = 0x52424752 // "RGBR"
+
+	/* RGB formats (3 or 4 bytes per pixel) */
+	V4L2_PIX_FMT_BGR666 = 0x48524742 // "BGRH"
+	V4L2_PIX_FMT_BGR24  = 0x33524742 // "BGR3"
+	V4L2_PIX_FMT_RGB24  = 0x33424752 // "RGB3"
+	V4L2_PIX_FMT_BGR32  = 0x34524742 // "BGR4"
+	V4L2_PIX_FMT_ABGR32 = 0x34325241 // "AR24"
+	V4L2_PIX_FMT_XBGR32 = 0x34325258 // "XR24"
+	V4L2_PIX_FMT_BGRA32 = 0x34324152 // "RA24"
+	V4L2_PIX_FMT_BGRX32 = 0x34325852 // "RX24"
+	V4L2_PIX_FMT_RGB32  = 0x34424752 // "RGB4"
+	V4L2_PIX_FMT_RGBA32 = 0x34324241 // "AB24"
+	V4L2_PIX_FMT_RGBX32 = 0x34324258 // "XB24"
+	V4L2_PIX_FMT_ARGB32 = 0x34324142 // "BA24"
+	V4L2_PIX_FMT_XRGB32 = 0x34325842 // "BX24"
+
+	/* Grey formats */
+	V4L2_PIX_FMT_GREY   = 0x59455247 // "GREY"
+	V4L2_PIX_FMT_Y4     = 0x20343059 // "Y04 "
+	V4L2_PIX_FMT_Y6     = 0x20363059 // "Y06 "
+	V4L2_PIX_FMT_Y10    = 0x20303159 // "Y10 "
+	V4L2_PIX_FMT_Y12    = 0x20323159 // "Y12 "
+	V4L2_PIX_FMT_Y14    = 0x20343159 // "Y14 "
+	V4L2_PIX_FMT_Y16    = 0x20363159 // "Y16 "
+	V4L2_PIX_FMT_Y16_BE = 0xa0363159 // "Y16 "
+
+	/* Grey bit-packed formats */
+	V4L2_PIX_FMT_Y10BPACK = 0x42303159 // "Y10B"
+	V4L2_PIX_FMT_Y10P     = 0x50303159 // "Y10P"
+	V4L2_PIX_FMT_IPU3_Y10 = 0x79337069 // "ip3y"
+
+	/* Palette formats */
+	V4L2_PIX_FMT_PAL8 = 0x384c4150 // "PAL8"
+
+	/* Chrominance formats */
+	V4L2_PIX_FMT_UV8 = 0x20385655 // "UV8 "
+
+	/* Luminance+Chrominance formats */
+	V4L2_PIX_FMT_YUYV   = 0x56595559 // "YUYV"
+	V4L2_PIX_FMT_YYUV   = 0x56555959 // "YYUV"
+	V4L2_PIX_FMT_YVYU   = 0x55595659 // "YVYU"
+	V4L2_PIX_FMT_UYVY   = 0x59565955 // "UYVY"
+	V4L2_PIX_FMT_VYUY   = 0x59555956 // "VYUY"
+	V4L2_PIX_FMT_Y41P   = 0x50313459 // "Y41P"
+	V4L2_PIX_FMT_YUV444 = 0x34343459 // "Y444"
+	V4L2_PIX_FMT_YUV555 = 0x4f565559 // "YUVO"
+	V4L2_PIX_FMT_YUV565 = 0x50565559 // "YUVP"
+	V4L2_PIX_FMT_YUV24  = 0x33565559 // "YUV3"
+	V4L2_PIX_FMT_YUV32  = 0x34565559 // "YUV4"
+	V4L2_PIX_FMT_AYUV32 = 0x56555941 // "AYUV"
+	V4L2_PIX_FMT_XYUV32 = 0x56555958 // "XYUV"
+	V4L2_PIX_FMT_VUYA32 = 0x41595556 // "VUYA"
+	V4L2_PIX_FMT_VUYX32 = 0x58595556 // "VUYX"
+	V4L2_PIX_FMT_YUVA32 = 0x41565559 // "YUVA"
+	V4L2_PIX_FMT_YUVX32 = 0x58565559 // "YUVX"
+	V4L2_PIX_FMT_M420   = 0x3032344d // "M420"
+
+	/* two planes -- one Y, one Cr + Cb interleaved */
+	V4L2_PIX_FMT_NV12 = 0x3231564e // "NV12"
+	V4L2_PIX_FMT_NV21 = 0x3132564e // "NV21"
+	V4L2_PIX_FMT_NV16 = 0x3631564e // "NV16"
+	V4L2_PIX_FMT_NV61 = 0x3136564e // "NV61"
+	V4L2_PIX_FMT_NV24 = 0x3432564e // "NV24"
+	V4L2_PIX_FMT_NV42 = 0x3234564e // "NV42"
+	V4L2_PIX_FMT_P010 = 0x30313050 // "P010"
+
+	/* two non contiguous planes - one Y, one Cr + Cb interleaved */
+	V4L2_PIX_FMT_NV12M = 0x32314d4e // "NM12"
+	V4L2_PIX_FMT_NV21M = 0x31324d4e // "NM21"
+	V4L2_PIX_FMT_NV16M = 0x36314d4e // "NM16"
+	V4L2_PIX_FMT_NV61M = 0x31364d4e // "NM61"
+
+	/* three planes - Y Cb, Cr */
+	V4L2_PIX_FMT_YUV410  = 0x39565559 // "YUV9"
+	V4L2_PIX_FMT_YVU410  = 0x39555659 // "YVU9"
+	V4L2_PIX_FMT_YUV411P = 0x50313134 // "411P"
+	V4L2_PIX_FMT_YUV420  = 0x32315559 // "YU12"
+	V4L2_PIX_FMT_YVU420  = 0x32315659 // "YV12"
+	V4L2_PIX_FMT_YUV422P = 0x50323234 // "422P"
+
+	/* three non contiguous planes - Y, Cb, Cr */
+	V4L2_PIX_FMT_YUV420M = 0x32314d59 // "YM12"
+	V4L2_PIX_FMT_YVU420M = 0x31324d59 // "YM21"
+	V4L2_PIX_FMT_YUV422M = 0x36314d59 // "YM16"
+	V4L2_PIX_FMT_YVU422M = 0x31364d59 // "YM61"
+	V4L2_PIX_FMT_YUV444M = 0x34324d59 // "YM24"
+	V4L2_PIX_FMT_YVU444M = 0x32344d59 // "YM42"
+
+	/* Tiled YUV formats */
+	V4L2_PIX_FMT_NV12_4L4   = 0x32315456 // "VT12"
+	V4L2_PIX_FMT_NV12_16L16 = 0x32314d48 // "HM12"
+	V4L2_PIX_FMT_NV12_32L32 = 0x32315453 // "ST12"
+	V4L2_PIX_FMT_P010_4L4   = 0x30313054 // "T010"
+
+	/* Tiled YUV formats, non contiguous planes */
+	V4L2_PIX_FMT_NV12MT           = 0x32314d54 // "TM12"
+	V4L2_PIX_FMT_NV12MT_16X16     = 0x32314d56 // "VM12"
+	V4L2_PIX_FMT_NV12M_8L128      = 0x3231414e // "NA12"
+	V4L2_PIX_FMT_NV12M_10BE_8L128 = 0xb231544e // "NT12"
+
+	/* Bayer formats - see http://www.siliconimaging.com/RGB%20Bayer.htm */
+	V4L2_PIX_FMT_SBGGR8       = 0x31384142 // "BA81"
+	V4L2_PIX_FMT_SGBRG8       = 0x47524247 // "GBRG"
+	V4L2_PIX_FMT_SGRBG8       = 0x47425247 // "GRBG"
+	V4L2_PIX_FMT_SRGGB8       = 0x42474752 // "RGGB"
+	V4L2_PIX_FMT_SBGGR10      = 0x30314742 // "BG10"
+	V4L2_PIX_FMT_SGBRG10      = 0x30314247 // "GB10"
+	V4L2_PIX_FMT_SGRBG10      = 0x30314142 // "BA10"
+	V4L2_PIX_FMT_SRGGB10      = 0x30314752 // "RG10"
+	V4L2_PIX_FMT_SBGGR10P     = 0x41414270 // "pBAA"
+	V4L2_PIX_FMT_SGBRG10P     = 0x41414770 // "pGAA"
+	V4L2_PIX_FMT_SGRBG10P     = 0x41416770 // "pgAA"
+	V4L2_PIX_FMT_SRGGB10P     = 0x41415270 // "pRAA"
+	V4L2_PIX_FMT_SBGGR10ALAW8 = 0x38414261 // "aBA8"
+	V4L2_PIX_FMT_SGBRG10ALAW8 = 0x38414761 // "aGA8"
+	V4L2_PIX_FMT_SGRBG10ALAW8 = 0x38416761 // "agA8"
+	V4L2_PIX_FMT_SRGGB10ALAW8 = 0x38415261 // "aRA8"
+	V4L2_PIX_FMT_SBGGR10DPCM8 = 0x38414262 // "bBA8"
+	V4L2_PIX_FMT_SGBRG10DPCM8 = 0x38414762 // "bGA8"
+	V4L2_PIX_FMT_SGRBG10DPCM8 = 0x30314442 // "BD10"
+	V4L2_PIX_FMT_SRGGB10DPCM8 = 0x38415262 // "bRA8"
+	V4L2_PIX_FMT_SBGGR12      = 0x32314742 // "BG12"
+	V4L2_PIX_FMT_SGBRG12      = 0x32314247 // "GB12"
+	V4L2_PIX_FMT_SGRBG12      = 0x32314142 // "BA12"
+	V4L2_PIX_FMT_SRGGB12      = 0x32314752 // "RG12"
+	V4L2_PIX_FMT_SBGGR12P     = 0x43434270 // "pBCC"
+	V4L2_PIX_FMT_SGBRG12P     = 0x43434770 // "pGCC"
+	V4L2_PIX_FMT_SGRBG12P     = 0x43436770 // "pgCC"
+	V4L2_PIX_FMT_SRGGB12P     = 0x43435270 // "pRCC"
+	V4L2_PIX_FMT_SBGGR14      = 0x34314742 // "BG14"
+	V4L2_PIX_FMT_SGBRG14      = 0x34314247 // "GB14"
+	V4L2_PIX_FMT_SGRBG14      = 0x34315247 // "GR14"
+	V4L2_PIX_FMT_SRGGB14      = 0x34314752 // "RG14"
+	V4L2_PIX_FMT_SBGGR14P     = 0x45454270 // "pBEE"
+	V4L2_PIX_FMT_SGBRG14P     = 0x45454770 // "pGEE"
+	V4L2_PIX_FMT_SGRBG14P     = 0x45456770 // "pgEE"
+	V4L2_PIX_FMT_SRGGB14P     = 0x45455270 // "pREE"
+	V4L2_PIX_FMT_SBGGR16      = 0x32525942 // "BYR2"
+	V4L2_PIX_FMT_SGBRG16      = 0x36314247 // "GB16"
+	V4L2_PIX_FMT_SGRBG16      = 0x36315247 // "GR16"
+	V4L2_PIX_FMT_SRGGB16      = 0x36314752 // "RG16"
+
+	/* HSV formats */
+	V4L2_PIX_FMT_HSV24 = 0x33565348 // "HSV3"
+	V4L2_PIX_FMT_HSV32 = 0x34565348 // "HSV4"
+
+	/* compressed formats */
+	V4L2_PIX_FMT_MJPEG          = 0x47504a4d // "MJPG"
+	V4L2_PIX_FMT_JPEG           = 0x4745504a // "JPEG"
+	V4L2_PIX_FMT_DV             = 0x64737664 // "dvsd"
+	V4L2_PIX_FMT_MPEG           = 0x4745504d // "MPEG"
+	V4L2_PIX_FMT_H264           = 0x34363248 // "H264"
+	V4L2_PIX_FMT_H264_NO_SC     = 0x31435641 // "AVC1"
+	V4L2_PIX_FMT_H264_MVC       = 0x3436324d // "M264"
+	V4L2_PIX_FMT_H263           = 0x33363248 // "H263"
+	V4L2_PIX_FMT_MPEG1          = 0x3147504d // "MPG1"
+	V4L2_PIX_FMT_MPEG2          = 0x3247504d // "MPG2"
+	V4L2_PIX_FMT_MPEG2_SLICE    = 0x5332474d // "MG2S"
+	V4L2_PIX_FMT_MPEG4          = 0x3447504d // "MPG4"
+	V4L2_PIX_FMT_XVID           = 0x44495658 // "XVID"
+	V4L2_PIX_FMT_VC1_ANNEX_G    = 0x47314356 // "VC1G"
+	V4L2_PIX_FMT_VC1_ANNEX_L    = 0x4c314356 // "VC1L"
+	V4L2_PIX_FMT_VP8            = 0x30385056 // "VP80"
+	V4L2_PIX_FMT_VP8_FRAME      = 0x46385056 // "VP8F"
+	V4L2_PIX_FMT_VP9            = 0x30395056 // "VP90"
+	V4L2_PIX_FMT_VP9_FRAME      = 0x46395056 // "VP9F"
+	V4L2_PIX_FMT_HEVC           = 0x43564548 // "HEVC"
+	V4L2_PIX_FMT_FWHT           = 0x54485746 // "FWHT"
+	V4L2_PIX_FMT_FWHT_STATELESS = 0x48574653 // "SFWH"
+	V4L2_PIX_FMT_H264_SLICE     = 0x34363253 // "S264"
+	V4L2_PIX_FMT_HEVC_SLICE     = 0x35363253 // "S265"
+
+	/* Vendor-specific formats */
+	V4L2_PIX_FMT_CPIA1        = 0x41495043 // "CPIA"
+	V4L2_PIX_FMT_WNVA         = 0x41564e57 // "WNVA"
+	V4L2_PIX_FMT_SN9C10X      = 0x30313953 // "S910"
+	V4L2_PIX_FMT_SN9C20X_I420 = 0x30323953 // "S920"
+	V4L2_PIX_FMT_PWC1         = 0x31435750 // "PWC1"
+	V4L2_PIX_FMT_PWC2         = 0x32435750 // "PWC2"
+	V4L2_PIX_FMT_ET61X251     = 0x35323645 // "E625"
+	V4L2_PIX_FMT_SPCA501      = 0x31303553 // "S501"
+	V4L2_PIX_FMT_SPCA505      = 0x35303553 // "S505"
+	V4L2_PIX_FMT_SPCA508      = 0x38303553 // "S508"
+	V4L2_PIX_FMT_SPCA561      = 0x31363553 // "S561"
+	V4L2_PIX_FMT_PAC207       = 0x37303250 // "P207"
+	V4L2_PIX_FMT_MR97310A     = 0x3031334d // "M310"
+	V4L2_PIX_FMT_JL2005BCD    = 0x30324c4a // "JL20"
+	V4L2_PIX_FMT_SN9C2028     = 0x584e4f53 // "SONX"
+	V4L2_PIX_FMT_SQ905C       = 0x43353039 // "905C"
+	V4L2_PIX_FMT_PJPG         = 0x47504a50 // "PJPG"
+	V4L2_PIX_FMT_OV511        = 0x3131354f // "O511"
+	V4L2_PIX_FMT_OV518        = 0x3831354f // "O518"
+	V4L2_PIX_FMT_STV0680      = 0x30383653 // "S680"
+	V4L2_PIX_FMT_TM6000       = 0x30364d54 // "TM60"
+	V4L2_PIX_FMT_CIT_YYVYUY   = 0x56544943 // "CITV"
+	V4L2_PIX_FMT_KONICA420    = 0x494e4f4b // "KONI"
+	V4L2_PIX_FMT_JPGL         = 0x4c47504a // "JPGL"
+	V4L2_PIX_FMT_SE401        = 0x31303453 // "S401"
+	V4L2_PIX_FMT_S5C_UYVY_JPG = 0x49433553 // "S5CI"
+	V4L2_PIX_FMT_Y8I          = 0x20493859 // "Y8I "
+	V4L2_PIX_FMT_Y12I         = 0x49323159 // "Y12I"
+	V4L2_PIX_FMT_Z16          = 0x2036315a // "Z16 "
+	V4L2_PIX_FMT_MT21C        = 0x3132544d // "MT21"
+	V4L2_PIX_FMT_MM21         = 0x31324d4d // "MM21"
+	V4L2_PIX_FMT_INZI         = 0x495a4e49 // "INZI"
+	V4L2_PIX_FMT_CNF4         = 0x34464e43 // "CNF4"
+	V4L2_PIX_FMT_HI240        = 0x34324948 // "HI24"
+	V4L2_PIX_FMT_QC08C        = 0x43383051 // "Q08C"
+	V4L2_PIX_FMT_QC10C        = 0x43303151 // "Q10C"
+
+	/* 10bit raw packed, 32 bytes for every 25 pixels, last LSB 6 bits unused */
+	V4L2_PIX_FMT_IPU3_SBGGR10 = 0x62337069 // "ip3b"
+	V4L2_PIX_FMT_IPU3_SGBRG10 = 0x67337069 // "ip3g"
+	V4L2_PIX_FMT_IPU3_SGRBG10 = 0x47337069 // "ip3G"
+	V4L2_PIX_FMT_IPU3_SRGGB10 = 0x72337069 // "ip3r"
+
+	/* deprecated names */
+	V4L2_PIX_FMT_HM12             = V4L2_PIX_FMT_NV12_16L16
+	V4L2_PIX_FMT_SUNXI_TILED_NV12 = V4L2_PIX_FMT_NV12_32L32
+)
+
+var pixelFormatTable = []pixelFormatEntry{
+	{V4L2_PIX_FMT_RGB332, "RGB332", "RGB-3-3-2", 8, PixFmtPacked},
+	{V4L2_PIX_FMT_RGB444, "RGB444", "xxxxrrrr ggggbbbb", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_ARGB444, "ARGB444", "aaaarrrr ggggbbbb", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_XRGB444, "XRGB444", "xxxxrrrr ggggbbbb", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_RGBA444, "RGBA444", "rrrrgggg bbbbaaaa", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_RGBX444, "RGBX444", "rrrrgggg bbbbxxxx", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_ABGR444, "ABGR444", "aaaabbbb ggggrrrr", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_XBGR444, "XBGR444", "xxxxbbbb ggggrrrr", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_BGRA444, "BGRA444", "bbbbgggg rrrraaaa", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_BGRX444, "BGRX444", "bbbbgggg rrrrxxxx", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_RGB555, "RGB555", "RGB-5-5-5", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_ARGB555, "ARGB555", "ARGB-1-5-5-5", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_XRGB555, "XRGB555", "XRGB-1-5-5-5", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_RGBA555, "RGBA555", "RGBA-5-5-5-1", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_RGBX555, "RGBX555", "RGBX-5-5-5-1", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_ABGR555, "ABGR555", "ABGR-1-5-5-5", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_XBGR555, "XBGR555", "XBGR-1-5-5-5", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_BGRA555, "BGRA555", "BGRA-5-5-5-1", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_BGRX555, "BGRX555", "BGRX-5-5-5-1", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_RGB565, "RGB565", "RGB-5-6-5", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_RGB555X, "RGB555X", "RGB-5-5-5 BE", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_ARGB555X, "ARGB555X", "ARGB-5-5-5 BE", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_XRGB555X, "XRGB555X", "XRGB-5-5-5 BE", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_RGB565X, "RGB565X", "RGB-5-6-5 BE", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_BGR666, "BGR666", "BGR-6-6-6", 18, PixFmtPacked},
+	{V4L2_PIX_FMT_BGR24, "BGR24", "BGR-8-8-8", 24, PixFmtPacked},
+	{V4L2_PIX_FMT_RGB24, "RGB24", "RGB-8-8-8", 24, PixFmtPacked},
+	{V4L2_PIX_FMT_BGR32, "BGR32", "BGR-8-8-8-8", 32, PixFmtPacked},
+	{V4L2_PIX_FMT_ABGR32, "ABGR32", "BGRA-8-8-8-8", 32, PixFmtPacked},
+	{V4L2_PIX_FMT_XBGR32, "XBGR32", "BGRX-8-8-8-8", 32, PixFmtPacked},
+	{V4L2_PIX_FMT_BGRA32, "BGRA32", "ABGR-8-8-8-8", 32, PixFmtPacked},
+	{V4L2_PIX_FMT_BGRX32, "BGRX32", "XBGR-8-8-8-8", 32, PixFmtPacked},
+	{V4L2_PIX_FMT_RGB32, "RGB32", "RGB-8-8-8-8", 32, PixFmtPacked},
+	{V4L2_PIX_FMT_RGBA32, "RGBA32", "RGBA-8-8-8-8", 32, PixFmtPacked},
+	{V4L2_PIX_FMT_RGBX32, "RGBX32", "RGBX-8-8-8-8", 32, PixFmtPacked},
+	{V4L2_PIX_FMT_ARGB32, "ARGB32", "ARGB-8-8-8-8", 32, PixFmtPacked},
+	{V4L2_PIX_FMT_XRGB32, "XRGB32", "XRGB-8-8-8-8", 32, PixFmtPacked},
+	{V4L2_PIX_FMT_GREY, "GREY", "Greyscale", 8, 0},
+	{V4L2_PIX_FMT_Y4, "Y4", "Greyscale", 4, 0},
+	{V4L2_PIX_FMT_Y6, "Y6", "Greyscale", 6, 0},
+	{V4L2_PIX_FMT_Y10, "Y10", "Greyscale", 10, 0},
+	{V4L2_PIX_FMT_Y12, "Y12", "Greyscale", 12, 0},
+	{V4L2_PIX_FMT_Y14, "Y14", "Greyscale", 14, 0},
+	{V4L2_PIX_FMT_Y16, "Y16", "Greyscale", 16, 0},
+	{V4L2_PIX_FMT_Y16_BE, "Y16_BE", "Greyscale BE", 16, 0},
+	{V4L2_PIX_FMT_Y10BPACK, "Y10BPACK", "Greyscale bit-packed", 10, 0},
+	{V4L2_PIX_FMT_Y10P, "Y10P", "Greyscale, MIPI RAW10 packed", 10, 0},
+	{V4L2_PIX_FMT_IPU3_Y10, "IPU3_Y10", "IPU3 packed 10-bit greyscale", 0, 0},
+	{V4L2_PIX_FMT_PAL8, "PAL8", "8-bit palette", 8, 0},
+	{V4L2_PIX_FMT_UV8, "UV8", "UV 4:4", 8, PixFmtPacked},
+	{V4L2_PIX_FMT_YUYV, "YUYV", "YUV 4:2:2", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_YYUV, "YYUV", "YUV 4:2:2", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_YVYU, "YVYU", "YVU 4:2:2", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_UYVY, "UYVY", "YUV 4:2:2", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_VYUY, "VYUY", "YUV 4:2:2", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_Y41P, "Y41P", "YUV 4:1:1", 12, PixFmtPacked},
+	{V4L2_PIX_FMT_YUV444, "YUV444", "xxxxyyyy uuuuvvvv", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_YUV555, "YUV555", "YUV-5-5-5", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_YUV565, "YUV565", "YUV-5-6-5", 16, PixFmtPacked},
+	{V4L2_PIX_FMT_YUV24, "YUV24", "YUV-8-8-8", 24, PixFmtPacked},
+	{V4L2_PIX_FMT_YUV32, "YUV32", "YUV-8-8-8-8", 32, PixFmtPacked},
+	{V4L2_PIX_FMT_AYUV32, "AYUV32", "AYUV-8-8-8-8", 32, PixFmtPacked},
+	{V4L2_PIX_FMT_XYUV32, "XYUV32", "XYUV-8-8-8-8", 32, PixFmtPacked},
+	{V4L2_PIX_FMT_VUYA32, "VUYA32", "VUYA-8-8-8-8", 32, PixFmtPacked},
+	{V4L2_PIX_FMT_VUYX32, "VUYX32", "VUYX-8-8-8-8", 32, PixFmtPacked},
+	{V4L2_PIX_FMT_YUVA32, "YUVA32", "YUVA-8-8-8-8", 32, PixFmtPacked},
+	{V4L2_PIX_FMT_YUVX32, "YUVX32", "YUVX-8-8-8-8", 32, PixFmtPacked},
+	{V4L2_PIX_FMT_M420, "M420", "YUV 4:2:0 2 lines y, 1 line uv interleaved", 12, PixFmtPacked},
+	{V4L2_PIX_FMT_NV12, "NV12", "Y/CbCr 4:2:0", 12, 0},
+	{V4L2_PIX_FMT_NV21, "NV21", "Y/CrCb 4:2:0", 12, 0},
+	{V4L2_PIX_FMT_NV16, "NV16", "Y/CbCr 4:2:2", 16, 0},
+	{V4L2_PIX_FMT_NV61, "NV61", "Y/CrCb 4:2:2", 16, 0},
+	{V4L2_PIX_FMT_NV24, "NV24", "Y/CbCr 4:4:4", 24, 0},
+	{V4L2_PIX_FMT_NV42, "NV42", "Y/CrCb 4:4:4", 24, 0},
+	{V4L2_PIX_FMT_P010, "P010", "Y/CbCr 4:2:0 10-bit per component", 24, 0},
+	{V4L2_PIX_FMT_NV12M, "NV12M", "Y/CbCr 4:2:0", 12, 0},
+	{V4L2_PIX_FMT_NV21M, "NV21M", "Y/CrCb 4:2:0", 21, 0},
+	{V4L2_PIX_FMT_NV16M, "NV16M", "Y/CbCr 4:2:2", 16, 0},
+	{V4L2_PIX_FMT_NV61M, "NV61M", "Y/CrCb 4:2:2", 16, 0},
+	{V4L2_PIX_FMT_YUV410, "YUV410", "YUV 4:1:0", 9, 0},
+	{V4L2_PIX_FMT_YVU410, "YVU410", "YVU 4:1:0", 9, 0},
+	{V4L2_PIX_FMT_YUV411P, "YUV411P", "YVU411 planar", 12, 0},
+	{V4L2_PIX_FMT_YUV420, "YUV420", "YUV 4:2:0", 12, 0},
+	{V4L2_PIX_FMT_YVU420, "YVU420", "YVU 4:2:0", 12, 0},
+	{V4L2_PIX_FMT_YUV422P, "YUV422P", "YVU422 planar", 16, 0},
+	{V4L2_PIX_FMT_YUV420M, "YUV420M", "YUV420 planar", 12, 0},
+	{V4L2_PIX_FMT_YVU420M, "YVU420M", "YVU420 planar", 12, 0},
+	{V4L2_PIX_FMT_YUV422M, "YUV422M", "YUV422 planar", 16, 0},
+	{V4L2_PIX_FMT_YVU422M, "YVU422M", "YVU422 planar", 16, 0},
+	{V4L2_PIX_FMT_YUV444M, "YUV444M", "YUV444 planar", 24, 0},
+	{V4L2_PIX_FMT_YVU444M, "YVU444M", "YVU444 planar", 24, 0},
+	{V4L2_PIX_FMT_NV12_4L4, "NV12_4L4", "Y/CbCr 4:2:0 4x4 tiles", 12, PixFmtTiled},
+	{V4L2_PIX_FMT_NV12_16L16, "NV12_16L16", "Y/CbCr 4:2:0 16x16 tiles", 12, PixFmtTiled},
+	{V4L2_PIX_FMT_NV12_32L32, "NV12_32L32", "Y/CbCr 4:2:0 32x32 tiles", 12, PixFmtTiled},
+	{V4L2_PIX_FMT_P010_4L4, "P010_4L4", "Y/CbCr 4:2:0 10-bit 4x4 macroblocks", 12, PixFmtTiled},
+	{V4L2_PIX_FMT_NV12MT, "NV12MT", "Y/CbCr 4:2:0 64x32 tiles", 12, PixFmtTiled},
+	{V4L2_PIX_FMT_NV12MT_16X16, "NV12MT_16X16", "Y/CbCr 4:2:0 16x16 tiles", 12, PixFmtTiled},
+	{V4L2_PIX_FMT_NV12M_8L128, "NV12M_8L128", "Y/CbCr 4:2:0 8x128 tiles", 0, PixFmtTiled},
+	{V4L2_PIX_FMT_NV12M_10BE_8L128, "NV12M_10BE_8L128", "Y/CbCr 4:2:0 10-bit 8x128 tiles", 0, PixFmtTiled},
+	{V4L2_PIX_FMT_SBGGR8, "SBGGR8", "BGBG.. GRGR..", 8, PixFmtBayer},
+	{V4L2_PIX_FMT_SGBRG8, "SGBRG8", "GBGB.. RGRG..", 8, PixFmtBayer},
+	{V4L2_PIX_FMT_SGRBG8, "SGRBG8", "GRGR.. BGBG..", 8, PixFmtBayer},
+	{V4L2_PIX_FMT_SRGGB8, "SRGGB8", "RGRG.. GBGB..", 8, PixFmtBayer},
+	{V4L2_PIX_FMT_SBGGR10, "SBGGR10", "BGBG.. GRGR..", 10, PixFmtBayer},
+	{V4L2_PIX_FMT_SGBRG10, "SGBRG10", "GBGB.. RGRG..", 10, PixFmtBayer},
+	{V4L2_PIX_FMT_SGRBG10, "SGRBG10", "GRGR.. BGBG..", 10, PixFmtBayer},
+	{V4L2_PIX_FMT_SRGGB10, "SRGGB10", "RGRG.. GBGB..", 10, PixFmtBayer},
+	{V4L2_PIX_FMT_SBGGR10P, "SBGGR10P", "10bit raw bayer packed, 5 bytes for every 4 pixels", 0, PixFmtBayer},
+	{V4L2_PIX_FMT_SGBRG10P, "SGBRG10P", "10bit raw bayer packed, 5 bytes for every 4 pixels", 0, PixFmtBayer},
+	{V4L2_PIX_FMT_SGRBG10P, "SGRBG10P", "10bit raw bayer packed, 5 bytes for every 4 pixels", 0, PixFmtBayer},
+	{V4L2_PIX_FMT_SRGGB10P, "SRGGB10P", "10bit raw bayer packed, 5 bytes for every 4 pixels", 0, PixFmtBayer},
+	{V4L2_PIX_FMT_SBGGR10ALAW8, "SBGGR10ALAW8", "10bit raw bayer a-law compressed to 8 bits", 0, PixFmtBayer},
+	{V4L2_PIX_FMT_SGBRG10ALAW8, "SGBRG10ALAW8", "10bit raw bayer a-law compressed to 8 bits", 0, PixFmtBayer},
+	{V4L2_PIX_FMT_SGRBG10ALAW8, "SGRBG10ALAW8", "10bit raw bayer a-law compressed to 8 bits", 0, PixFmtBayer},
+	{V4L2_PIX_FMT_SRGGB10ALAW8, "SRGGB10ALAW8", "10bit raw bayer a-law compressed to 8 bits", 0, PixFmtBayer},
+	{V4L2_PIX_FMT_SBGGR10DPCM8, "SBGGR10DPCM8", "10bit raw bayer DPCM compressed to 8 bits", 0, PixFmtBayer},
+	{V4L2_PIX_FMT_SGBRG10DPCM8, "SGBRG10DPCM8", "10bit raw bayer DPCM compressed to 8 bits", 0, PixFmtBayer},
+	{V4L2_PIX_FMT_SGRBG10DPCM8, "SGRBG10DPCM8", "10bit raw bayer DPCM compressed to 8 bits", 0, PixFmtBayer},
+	{V4L2_PIX_FMT_SRGGB10DPCM8, "SRGGB10DPCM8", "10bit raw bayer DPCM compressed to 8 bits", 0, PixFmtBayer},
+	{V4L2_PIX_FMT_SBGGR12, "SBGGR12", "BGBG.. GRGR..", 12, PixFmtBayer},
+	{V4L2_PIX_FMT_SGBRG12, "SGBRG12", "GBGB.. RGRG..", 12, PixFmtBayer},
+	{V4L2_PIX_FMT_SGRBG12, "SGRBG12", "GRGR.. BGBG..", 12, PixFmtBayer},
+	{V4L2_PIX_FMT_SRGGB12, "SRGGB12", "RGRG.. GBGB..", 12, PixFmtBayer},
+	{V4L2_PIX_FMT_SBGGR12P, "SBGGR12P", "12bit raw bayer packed, 6 bytes for every 4 pixels", 0, PixFmtBayer},
+	{V4L2_PIX_FMT_SGBRG12P, "SGBRG12P", "12bit raw bayer packed, 6 bytes for every 4 pixels", 0, PixFmtBayer},
+	{V4L2_PIX_FMT_SGRBG12P, "SGRBG12P", "12bit raw bayer packed, 6 bytes for every 4 pixels", 0, PixFmtBayer},
+	{V4L2_PIX_FMT_SRGGB12P, "SRGGB12P", "12bit raw bayer packed, 6 bytes for every 4 pixels", 0, PixFmtBayer},
+	{V4L2_PIX_FMT_SBGGR14, "SBGGR14", "BGBG.. GRGR..", 14, PixFmtBayer},
+	{V4L2_PIX_FMT_SGBRG14, "SGBRG14", "GBGB.. RGRG..", 14, PixFmtBayer},
+	{V4L2_PIX_FMT_SGRBG14, "SGRBG14", "GRGR.. BGBG..", 14, PixFmtBayer},
+	{V4L2_PIX_FMT_SRGGB14, "SRGGB14", "RGRG.. GBGB..", 14, PixFmtBayer},
+	{V4L2_PIX_FMT_SBGGR14P, "SBGGR14P", "14bit raw bayer packed, 7 bytes for every 4 pixels", 0, PixFmtBayer},
+	{V4L2_PIX_FMT_SGBRG14P, "SGBRG14P", "14bit raw bayer packed, 7 bytes for every 4 pixels", 0, PixFmtBayer},
+	{V4L2_PIX_FMT_SGRBG14P, "SGRBG14P", "14bit raw bayer packed, 7 bytes for every 4 pixels", 0, PixFmtBayer},
+	{V4L2_PIX_FMT_SRGGB14P, "SRGGB14P", "14bit raw bayer packed, 7 bytes for every 4 pixels", 0, PixFmtBayer},
+	{V4L2_PIX_FMT_SBGGR16, "SBGGR16", "BGBG.. GRGR..", 16, PixFmtBayer},
+	{V4L2_PIX_FMT_SGBRG16, "SGBRG16", "GBGB.. RGRG..", 16, PixFmtBayer},
+	{V4L2_PIX_FMT_SGRBG16, "SGRBG16", "GRGR.. BGBG..", 16, PixFmtBayer},
+	{V4L2_PIX_FMT_SRGGB16, "SRGGB16", "RGRG.. GBGB..", 16, PixFmtBayer},
+	{V4L2_PIX_FMT_HSV24, "HSV24", "", 0, PixFmtPacked},
+	{V4L2_PIX_FMT_HSV32, "HSV32", "", 0, PixFmtPacked},
+	{V4L2_PIX_FMT_MJPEG, "MJPEG", "Motion-JPEG", 0, PixFmtCompressed},
+	{V4L2_PIX_FMT_JPEG, "JPEG", "JFIF JPEG", 0, PixFmtCompressed},
+	{V4L2_PIX_FMT_DV, "DV", "1394", 0, PixFmtCompressed},
+	{V4L2_PIX_FMT_MPEG, "MPEG", "MPEG-1/2/4 Multiplexed", 0, PixFmtCompressed},
+	{V4L2_PIX_FMT_H264, "H264", "H264 with start codes", 0, PixFmtCompressed},
+	{V4L2_PIX_FMT_H264_NO_SC, "H264_NO_SC", "H264 without start codes", 0, PixFmtCompressed},
+	{V4L2_PIX_FMT_H264_MVC, "H264_MVC", "H264 MVC", 0, PixFmtCompressed},
+	{V4L2_PIX_FMT_H263, "H263", "H263", 0, PixFmtCompressed},
+	{V4L2_PIX_FMT_MPEG1, "MPEG1", "MPEG-1 ES", 0, PixFmtCompressed},
+	{V4L2_PIX_FMT_MPEG2, "MPEG2", "MPEG-2 ES", 0, PixFmtCompressed},
+	{V4L2_PIX_FMT_MPEG2_SLICE, "MPEG2_SLICE", "MPEG-2 parsed slice data", 0, PixFmtCompressed},
+	{V4L2_PIX_FMT_MPEG4, "MPEG4", "MPEG-4 part 2 ES", 0, PixFmtCompressed},
+	{V4L2_PIX_FMT_XVID, "XVID", "Xvid", 0, PixFmtCompressed},
+	{V4L2_PIX_FMT_VC1_ANNEX_G, "VC1_ANNEX_G", "SMPTE 421M Annex G compliant stream", 0, PixFmtCompressed},
+	{V4L2_PIX_FMT_VC1_ANNEX_L, "VC1_ANNEX_L", "SMPTE 421M Annex L compliant stream", 0, PixFmtCompressed},
+	{V4L2_PIX_FMT_VP8, "VP8", "VP8", 0, PixFmtCompressed},
+	{V4L2_PIX_FMT_VP8_FRAME, "VP8_FRAME", "VP8 parsed frame", 0, PixFmtCompressed},
+	{V4L2_PIX_FMT_VP9, "VP9", "VP9", 0, PixFmtCompressed},
+	{V4L2_PIX_FMT_VP9_FRAME, "VP9_FRAME", "VP9 parsed frame", 0, PixFmtCompressed},
+	{V4L2_PIX_FMT_HEVC, "HEVC", "HEVC aka H.265", 0, PixFmtCompressed},
+	{V4L2_PIX_FMT_FWHT, "FWHT", "Fast Walsh Hadamard Transform (vicodec)", 0, PixFmtCompressed},
+	{V4L2_PIX_FMT_FWHT_STATELESS, "FWHT_STATELESS", "Stateless FWHT (vicodec)", 0, PixFmtCompressed},
+	{V4L2_PIX_FMT_H264_SLICE, "H264_SLICE", "H264 parsed slices", 0, PixFmtCompressed},
+	{V4L2_PIX_FMT_HEVC_SLICE, "HEVC_SLICE", "HEVC parsed slices", 0, PixFmtCompressed},
+	{V4L2_PIX_FMT_CPIA1, "CPIA1", "cpia1 YUV", 0, 0},
+	{V4L2_PIX_FMT_WNVA, "WNVA", "Winnov hw compress", 0, 0},
+	{V4L2_PIX_FMT_SN9C10X, "SN9C10X", "SN9C10x compression", 0, 0},
+	{V4L2_PIX_FMT_SN9C20X_I420, "SN9C20X_I420", "SN9C20x YUV 4:2:0", 0, 0},
+	{V4L2_PIX_FMT_PWC1, "PWC1", "pwc older webcam", 0, 0},
+	{V4L2_PIX_FMT_PWC2, "PWC2", "pwc newer webcam", 0, 0},
+	{V4L2_PIX_FMT_ET61X251, "ET61X251", "ET61X251 compression", 0, 0},
+	{V4L2_PIX_FMT_SPCA501, "SPCA501", "YUYV per line", 0, 0},
+	{V4L2_PIX_FMT_SPCA505, "SPCA505", "YYUV per line", 0, 0},
+	{V4L2_PIX_FMT_SPCA508, "SPCA508", "YUVY per line", 0, 0},
+	{V4L2_PIX_FMT_SPCA561, "SPCA561", "compressed GBRG bayer", 0, 0},
+	{V4L2_PIX_FMT_PAC207, "PAC207", "compressed BGGR bayer", 0, 0},
+	{V4L2_PIX_FMT_MR97310A, "MR97310A", "compressed BGGR bayer", 0, 0},
+	{V4L2_PIX_FMT_JL2005BCD, "JL2005BCD", "compressed RGGB bayer", 0, 0},
+	{V4L2_PIX_FMT_SN9C2028, "SN9C2028", "compressed GBRG bayer", 0, 0},
+	{V4L2_PIX_FMT_SQ905C, "SQ905C", "compressed RGGB bayer", 0, 0},
+	{V4L2_PIX_FMT_PJPG, "PJPG", "Pixart 73xx JPEG", 0, 0},
+	{V4L2_PIX_FMT_OV511, "OV511", "ov511 JPEG", 0, 0},
+	{V4L2_PIX_FMT_OV518, "OV518", "ov518 JPEG", 0, 0},
+	{V4L2_PIX_FMT_STV0680, "STV0680", "stv0680 bayer", 0, 0},
+	{V4L2_PIX_FMT_TM6000, "TM6000", "tm5600/tm60x0", 0, 0},
+	{V4L2_PIX_FMT_CIT_YYVYUY, "CIT_YYVYUY", "one line of Y then 1 line of VYUY", 0, 0},
+	{V4L2_PIX_FMT_KONICA420, "KONICA420", "YUV420 planar in blocks of 256 pixels", 0, 0},
+	{V4L2_PIX_FMT_JPGL, "JPGL", "JPEG-Lite", 0, 0},
+	{V4L2_PIX_FMT_SE401, "SE401", "se401 janggu compressed rgb", 0, 0},
+	{V4L2_PIX_FMT_S5C_UYVY_JPG, "S5C_UYVY_JPG", "S5C73M3 interleaved UYVY/JPEG", 0, 0},
+	{V4L2_PIX_FMT_Y8I, "Y8I", "Greyscale 8-bit L/R interleaved", 0, 0},
+	{V4L2_PIX_FMT_Y12I, "Y12I", "Greyscale 12-bit L/R interleaved", 0, 0},
+	{V4L2_PIX_FMT_Z16, "Z16", "Depth data 16-bit", 0, 0},
+	{V4L2_PIX_FMT_MT21C, "MT21C", "Mediatek compressed block mode", 0, 0},
+	{V4L2_PIX_FMT_MM21, "MM21", "Mediatek 8-bit block mode, two non-contiguous planes", 0, 0},
+	{V4L2_PIX_FMT_INZI, "INZI", "Intel Planar Greyscale 10-bit and Depth 16-bit", 0, 0},
+	{V4L2_PIX_FMT_CNF4, "CNF4", "Intel 4-bit packed depth confidence information", 0, 0},
+	{V4L2_PIX_FMT_HI240, "HI240", "BTTV 8-bit dithered RGB", 0, 0},
+	{V4L2_PIX_FMT_QC08C, "QC08C", "Qualcomm 8-bit compressed", 0, 0},
+	{V4L2_PIX_FMT_QC10C, "QC10C", "Qualcomm 10-bit compressed", 0, 0},
+	{V4L2_PIX_FMT_IPU3_SBGGR10, "IPU3_SBGGR10", "IPU3 packed 10-bit BGGR bayer", 0, 0},
+	{V4L2_PIX_FMT_IPU3_SGBRG10, "IPU3_SGBRG10", "IPU3 packed 10-bit GBRG bayer", 0, 0},
+	{V4L2_PIX_FMT_IPU3_SGRBG10, "IPU3_SGRBG10", "IPU3 packed 10-bit GRBG bayer", 0, 0},
+	{V4L2_PIX_FMT_IPU3_SRGGB10, "IPU3_SRGGB10", "IPU3 packed 10-bit RGGB bayer", 0, 0},
+}
@@ -0,0 +1,137 @@
+package v4l2
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestPlaneSizes(t *testing.T) {
+	for _, tc := range []struct {
+		fourcc        uint32
+		width, height uint32
+		align         uint32
+		sizes         []PlaneSize
+	}{
+		// chroma lines of odd widths hold the rounded up chroma samples,
+		// as v4l2_fill_pixfmt computes them
+		{V4L2_PIX_FMT_NV12, 640, 480, 1, []PlaneSize{{640, 640*480 + 640*240}}},
+		{V4L2_PIX_FMT_NV12, 33, 17, 1, []PlaneSize{{33, 33*17 + 34*9}}},
+		{V4L2_PIX_FMT_NV12, 33, 17, 64, []PlaneSize{{64, 64*17 + 64*9}}},
+		{V4L2_PIX_FMT_NV21, 33, 17, 1, []PlaneSize{{33, 33*17 + 34*9}}},
+		{V4L2_PIX_FMT_NV16, 33, 17, 1, []PlaneSize{{33, 33*17 + 34*17}}},
+		{V4L2_PIX_FMT_NV16, 33, 17, 16, []PlaneSize{{48, 48*17 + 48*17}}},
+		{V4L2_PIX_FMT_NV24, 33, 17, 1, []PlaneSize{{33, 33*17 + 66*17}}},
+		{V4L2_PIX_FMT_P010, 33, 17, 1, []PlaneSize{{66, 66*17 + 68*9}}},
+		{V4L2_PIX_FMT_P010, 33, 17, 128, []PlaneSize{{128, 128*17 + 128*9}}},
+		{V4L2_PIX_FMT_YUV420, 33, 17, 1, []PlaneSize{{33, 33*17 + 2*17*9}}},
+		{V4L2_PIX_FMT_YUV420, 33, 17, 32, []PlaneSize{{64, 64*17 + 2*32*9}}},
+		{V4L2_PIX_FMT_YUV420, 31, 15, 1, []PlaneSize{{31, 31*15 + 2*16*8}}},
+		{V4L2_PIX_FMT_YUV410, 33, 17, 1, []PlaneSize{{33, 33*17 + 2*9*5}}},
+		{V4L2_PIX_FMT_YUV422P, 33, 17, 1, []PlaneSize{{33, 33*17 + 2*17*17}}},
+		{V4L2_PIX_FMT_YUYV, 33, 17, 1, []PlaneSize{{68, 68 * 17}}},
+		{V4L2_PIX_FMT_YUYV, 33, 17, 64, []PlaneSize{{128, 128 * 17}}},
+		{V4L2_PIX_FMT_RGB24, 33, 17, 4, []PlaneSize{{100, 100 * 17}}},
+		{V4L2_PIX_FMT_SBGGR10P, 33, 17, 1, []PlaneSize{{45, 45 * 17}}},
+
+		// memory planes of the M-variants are aligned each
+		{V4L2_PIX_FMT_NV12M, 33, 17, 1, []PlaneSize{{33, 33 * 17}, {34, 34 * 9}}},
+		{V4L2_PIX_FMT_NV12M, 33, 17, 64, []PlaneSize{{64, 64 * 17}, {64, 64 * 9}}},
+		{V4L2_PIX_FMT_NV16M, 33, 17, 1, []PlaneSize{{33, 33 * 17}, {34, 34 * 17}}},
+		{V4L2_PIX_FMT_YUV420M, 33, 17, 1, []PlaneSize{{33, 33 * 17}, {17, 17 * 9}, {17, 17 * 9}}},
+		{V4L2_PIX_FMT_YUV420M, 33, 17, 16, []PlaneSize{{48, 48 * 17}, {32, 32 * 9}, {32, 32 * 9}}},
+		{V4L2_PIX_FMT_YUV444M, 33, 17, 1, []PlaneSize{{33, 33 * 17}, {33, 33 * 17}, {33, 33 * 17}}},
+
+		// tiled formats cover whole tiles
+		{V4L2_PIX_FMT_NV12_4L4, 33, 17, 1, []PlaneSize{{36, 36*20 + 36*10}}},
+		{V4L2_PIX_FMT_NV12_16L16, 33, 17, 1, []PlaneSize{{48, 48*32 + 48*16}}},
+		{V4L2_PIX_FMT_NV12_32L32, 1920, 1080, 1, []PlaneSize{{1920, 1920*1088 + 1920*544}}},
+		{V4L2_PIX_FMT_P010_4L4, 33, 17, 1, []PlaneSize{{72, 72*20 + 72*10}}},
+		{V4L2_PIX_FMT_NV12MT, 33, 17, 1, []PlaneSize{{64, 64 * 32}, {64, 64 * 16}}},
+		{V4L2_PIX_FMT_NV12MT_16X16, 33, 17, 1, []PlaneSize{{48, 48 * 32}, {48, 48 * 16}}},
+		{V4L2_PIX_FMT_NV12M_8L128, 33, 17, 1, []PlaneSize{{40, 40 * 128}, {40, 40 * 64}}},
+		{V4L2_PIX_FMT_MM21, 33, 17, 1, []PlaneSize{{48, 48 * 32}, {48, 48 * 16}}},
+	} {
+		name := fmt.Sprintf("%s %dx%d align %d", GetNameByFourCC(tc.fourcc),
+			tc.width, tc.height, tc.align)
+		f, err := LookupPixelFormat(tc.fourcc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sizes, err := f.PlaneSizes(tc.width, tc.height, tc.align)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if fmt.Sprint(sizes) != fmt.Sprint(tc.sizes) {
+			t.Errorf("%s: plane sizes %v, want %v", name, sizes, tc.sizes)
+		}
+
+		bpl, total, err := f.SizeImage(tc.width, tc.height, tc.align)
+		var want uint32
+		for _, s := range tc.sizes {
+			want += s.SizeImage
+		}
+		if err != nil || bpl != tc.sizes[0].BytesPerLine || total != want {
+			t.Errorf("%s: size image %d, %d, %v, want %d, %d",
+				name, bpl, total, err, tc.sizes[0].BytesPerLine, want)
+		}
+	}
+}
+
+func TestPlaneSizesCompressed(t *testing.T) {
+	f, err := LookupPixelFormat(V4L2_PIX_FMT_MJPEG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.PlaneSizes(640, 480, 1); !errors.Is(err, ErrorNoPlaneLayout) {
+		t.Errorf("plane sizes of MJPEG: %v, want ErrorNoPlaneLayout", err)
+	}
+	if _, _, err := f.SizeImage(640, 480, 1); !errors.Is(err, ErrorNoPlaneLayout) {
+		t.Errorf("size image of MJPEG: %v, want ErrorNoPlaneLayout", err)
+	}
+}
+
+// TestPlaneSizesFrame checks that NewFrame accepts the buffers sized by
+// PlaneSizes
+func TestPlaneSizesFrame(t *testing.T) {
+	for _, fourcc := range []uint32{V4L2_PIX_FMT_NV12, V4L2_PIX_FMT_NV16,
+		V4L2_PIX_FMT_P010, V4L2_PIX_FMT_YUV420, V4L2_PIX_FMT_YUYV,
+		V4L2_PIX_FMT_NV12M, V4L2_PIX_FMT_YUV420M} {
+		f, err := LookupPixelFormat(fourcc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, size := range [][3]uint32{{33, 17, 1}, {31, 15, 1}, {33, 17, 64}, {640, 480, 1}} {
+			sizes, err := f.PlaneSizes(size[0], size[1], size[2])
+			if err != nil {
+				t.Fatal(err)
+			}
+			format := V4L2_Format{Type: V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE}
+			mplane := &V4L2_Pix_Format_Mplane{
+				Width:       size[0],
+				Height:      size[1],
+				PixelFormat: fourcc,
+				NumPlanes:   uint8(len(sizes)),
+			}
+			var mem [][]byte
+			for i, s := range sizes {
+				mplane.PlaneFmt[i].BytesPerLine = s.BytesPerLine
+				mplane.PlaneFmt[i].SizeImage = s.SizeImage
+				mem = append(mem, make([]byte, s.SizeImage))
+			}
+			format.Fmt = mplane
+			fr, err := NewFrame(&format, mem...)
+			if err != nil {
+				t.Errorf("%s %v: %v", f.Name, size, err)
+				continue
+			}
+			for i, s := range sizes {
+				if fr.Used[i] != s.SizeImage {
+					t.Errorf("%s %v: memory plane %d uses %d of %d bytes",
+						f.Name, size, i, fr.Used[i], s.SizeImage)
+				}
+			}
+		}
+	}
+}
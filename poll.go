@@ -0,0 +1,92 @@
+package v4l2
+
+import (
+	"syscall"
+)
+
+// poller waits for events on a device file descriptor. A pipe is polled
+// along with the device so that another goroutine can wake up the waiter.
+// The device of a Backend is polled through the pipes of its events.
+type poller struct {
+	epfd    int
+	pipe    [2]int // written to wake up the waiter
+	backend *backendFile
+}
+
+func newPoller(fd int, events uint32) (*poller, error) {
+	p := &poller{}
+
+	epfd, err := syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+	if err != nil {
+		return nil, err
+	}
+	p.epfd = epfd
+
+	if err := syscall.Pipe2(p.pipe[:], syscall.O_CLOEXEC|syscall.O_NONBLOCK); err != nil {
+		syscall.Close(epfd)
+		return nil, err
+	}
+
+	watch := []syscall.EpollEvent{{Events: events, Fd: int32(fd)}}
+	if p.backend = lookupBackend(fd); p.backend != nil {
+		watch = p.backend.pollFDs(events)
+	}
+	watch = append(watch, syscall.EpollEvent{Events: syscall.EPOLLIN, Fd: int32(p.pipe[0])})
+	for i := range watch {
+		if err := syscall.EpollCtl(epfd, syscall.EPOLL_CTL_ADD, int(watch[i].Fd), &watch[i]); err != nil {
+			p.close()
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func (p *poller) wakeup() {
+	syscall.Write(p.pipe[1], []byte{0})
+}
+
+func (p *poller) close() {
+	syscall.Close(p.pipe[0])
+	syscall.Close(p.pipe[1])
+	syscall.Close(p.epfd)
+}
+
+// wait blocks until the device is ready, wakeup is called, or timeout
+// milliseconds passed, and returns the ready events of the device, which
+// are 0 when woken up or timed out. A negative timeout waits forever.
+func (p *poller) wait(timeout int) (uint32, error) {
+	ready := make([]syscall.EpollEvent, 1+len(backendEvents))
+	for {
+		n, err := syscall.EpollWait(p.epfd, ready, timeout)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		var events uint32
+		for i := 0; i < n; i++ {
+			if int(ready[i].Fd) == p.pipe[0] {
+				p.drain()
+				continue
+			}
+			if p.backend != nil {
+				events |= p.backend.event(ready[i].Fd)
+				continue
+			}
+			events |= ready[i].Events
+		}
+		return events, nil
+	}
+}
+
+func (p *poller) drain() {
+	var buf [16]byte
+	for {
+		n, err := syscall.Read(p.pipe[0], buf[:])
+		if n <= 0 || err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package v4l2
+
+import (
+	"fmt"
+	"time"
+	"unsafe"
+)
+
+// BufferQueue owns the buffers of one queue, identified by its buffer type
+// and memory type, of a device. Both single- and multi-planar buffer types
+// are supported.
+type BufferQueue struct {
+	FD      int
+	Type    uint32
+	Memory  uint32
+	NPlanes uint32
+	Bufs    []*Buffer
+}
+
+// Buffer is one buffer of a BufferQueue. After Dequeue its fields describe
+// the frame the driver returned.
+type Buffer struct {
+	Index     uint32
+	Planes    []Plane
+	Flags     uint32
+	Field     uint32
+	Sequence  uint32
+	TimeStamp time.Duration
+	RequestFD int32 // valid with V4L2_BUF_FLAG_REQUEST_FD
+	Queued    bool  // owned by the driver
+}
+
+// Plane is one memory plane of a Buffer.
+type Plane struct {
+	Data       []byte // mapped or user memory, nil for V4L2_MEMORY_DMABUF
+	FD         int    // dmabuf fd of V4L2_MEMORY_DMABUF planes
+	Length     uint32
+	BytesUsed  uint32
+	DataOffset uint32
+}
+
+// Bytes returns the payload of the plane
+func (p *Plane) Bytes() []byte {
+	if p.Data == nil || p.BytesUsed < p.DataOffset {
+		return nil
+	}
+	return p.Data[p.DataOffset:p.BytesUsed]
+}
+
+func NewBufferQueue(fd int, bufType, memory uint32) *BufferQueue {
+	return &BufferQueue{
+		FD:     fd,
+		Type:   bufType,
+		Memory: memory,
+	}
+}
+
+// Alloc requests count buffers from the driver. MMAP buffers are mapped,
+// USERPTR buffers are allocated with AllocUserBuffer, and DMABUF buffers
+// must be given a FD per plane before they are queued.
+func (q *BufferQueue) Alloc(count uint32) error {
+	reqbufs := V4L2_Requestbuffers{
+		Count:  count,
+		Type:   q.Type,
+		Memory: q.Memory,
+	}
+	if err := IoctlRequestBuffers(q.FD, &reqbufs); err != nil {
+		return fmt.Errorf("Failed to request buffers: %w", err)
+	}
+	if reqbufs.Count == 0 {
+		return fmt.Errorf("%w: out of memory", ErrorBufferAlloc)
+	}
+
+	q.Bufs = make([]*Buffer, 0, reqbufs.Count)
+	for i := uint32(0); i < reqbufs.Count; i++ {
+		b, err := q.query(i)
+		if err != nil {
+			q.Release()
+			return err
+		}
+		q.Bufs = append(q.Bufs, b)
+	}
+	return nil
+}
+
+func (q *BufferQueue) query(index uint32) (*Buffer, error) {
+	var planes [VIDEO_MAX_PLANES]V4L2_Plane
+	vb := V4L2_Buffer{
+		Index:  index,
+		Type:   q.Type,
+		Memory: q.Memory,
+	}
+	if isMplane(q.Type) {
+		vb.Planes = planes[:]
+		vb.Length = VIDEO_MAX_PLANES
+	}
+	if err := IoctlQueryBuf(q.FD, &vb); err != nil {
+		return nil, fmt.Errorf("Failed to query buffers: %w", err)
+	}
+
+	b := &Buffer{Index: index}
+	if isMplane(q.Type) {
+		for i := uint32(0); i < vb.Length; i++ {
+			b.Planes = append(b.Planes, Plane{
+				FD:     -1,
+				Length: planes[i].Length,
+			})
+		}
+	} else {
+		b.Planes = []Plane{{FD: -1, Length: vb.Length}}
+		planes[0].Union = vb.M
+	}
+	q.NPlanes = uint32(len(b.Planes))
+
+	for i := range b.Planes {
+		p := &b.Planes[i]
+		switch q.Memory {
+		case V4L2_MEMORY_MMAP:
+			data, err := mmap(q.FD, int64(planes[i].Offset()), int(p.Length))
+			if err != nil {
+				b.free(q.Memory)
+				return nil, fmt.Errorf("%w: mmap: %w", ErrorBufferAlloc, err)
+			}
+			p.Data = data
+		case V4L2_MEMORY_USERPTR:
+			data, err := AllocUserBuffer(int(p.Length))
+			if err != nil {
+				b.free(q.Memory)
+				return nil, err
+			}
+			p.Data = data
+		}
+	}
+	return b, nil
+}
+
+func (b *Buffer) free(memory uint32) {
+	for i := range b.Planes {
+		p := &b.Planes[i]
+		if p.Data == nil {
+			continue
+		}
+		switch memory {
+		case V4L2_MEMORY_MMAP:
+			munmap(p.Data)
+		case V4L2_MEMORY_USERPTR:
+			FreeUserBuffer(p.Data)
+		}
+		p.Data = nil
+	}
+}
+
+// Queue hands b to the driver. For OUTPUT queues BytesUsed of each plane
+// must be set to the size of the payload.
+func (q *BufferQueue) Queue(b *Buffer) error {
+	var planes [VIDEO_MAX_PLANES]V4L2_Plane
+	vb := V4L2_Buffer{
+		Index:     b.Index,
+		Type:      q.Type,
+		Memory:    q.Memory,
+		Flags:     b.Flags,
+		Field:     b.Field,
+		TimeStamp: b.TimeStamp,
+		RequestFD: b.RequestFD,
+	}
+	for i := range b.Planes {
+		p := &b.Planes[i]
+		planes[i].BytesUsed = p.BytesUsed
+		planes[i].Length = p.Length
+		planes[i].DataOffset = p.DataOffset
+		switch q.Memory {
+		case V4L2_MEMORY_USERPTR:
+			planes[i].SetUserPtr(uintptr(unsafe.Pointer(&p.Data[0])))
+		case V4L2_MEMORY_DMABUF:
+			planes[i].SetFD(p.FD)
+		}
+	}
+	if isMplane(q.Type) {
+		vb.Planes = planes[:]
+		vb.Length = uint32(len(b.Planes))
+	} else {
+		vb.BytesUsed = planes[0].BytesUsed
+		vb.Length = planes[0].Length
+		vb.M = planes[0].Union
+	}
+
+	if err := IoctlQBuf(q.FD, &vb); err != nil {
+		return err
+	}
+	b.Queued = true
+	return nil
+}
+
+// QueueRequest binds the buffer to the request r instead of queueing it
+// right away. The buffer is queued to the driver along with the request.
+func (q *BufferQueue) QueueRequest(b *Buffer, r *Request) error {
+	b.Flags |= V4L2_BUF_FLAG_REQUEST_FD
+	b.RequestFD = int32(r.FD)
+	err := q.Queue(b)
+	b.Flags &^= V4L2_BUF_FLAG_REQUEST_FD
+	return err
+}
+
+// QueueAll hands every buffer not yet owned by the driver to it.
+func (q *BufferQueue) QueueAll() error {
+	for _, b := range q.Bufs {
+		if b.Queued {
+			continue
+		}
+		if err := q.Queue(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Dequeue takes the next filled (CAPTURE) or consumed (OUTPUT) buffer
+// back from the driver. For devices opened with O_NONBLOCK it returns
+// syscall.EAGAIN when no buffer is ready.
+func (q *BufferQueue) Dequeue() (*Buffer, error) {
+	var planes [VIDEO_MAX_PLANES]V4L2_Plane
+	vb := V4L2_Buffer{
+		Type:   q.Type,
+		Memory: q.Memory,
+	}
+	if isMplane(q.Type) {
+		vb.Planes = planes[:]
+		vb.Length = q.NPlanes
+	}
+	if err := IoctlDQBuf(q.FD, &vb); err != nil {
+		return nil, err
+	}
+	if int(vb.Index) >= len(q.Bufs) {
+		return nil, fmt.Errorf("Dequeued unknown buffer %d", vb.Index)
+	}
+
+	b := q.Bufs[vb.Index]
+	b.Queued = false
+	b.Flags = vb.Flags
+	b.Field = vb.Field
+	b.Sequence = vb.Sequence
+	b.TimeStamp = vb.TimeStamp
+	if isMplane(q.Type) {
+		for i := range b.Planes {
+			b.Planes[i].BytesUsed = planes[i].BytesUsed
+			b.Planes[i].DataOffset = planes[i].DataOffset
+		}
+	} else {
+		b.Planes[0].BytesUsed = vb.BytesUsed
+		b.Planes[0].DataOffset = 0
+	}
+	return b, nil
+}
+
+func (q *BufferQueue) StreamOn() error {
+	stream := int(q.Type)
+	return IoctlStreamOn(q.FD, &stream)
+}
+
+// StreamOff stops streaming, which returns all buffers to the caller.
+func (q *BufferQueue) StreamOff() error {
+	stream := int(q.Type)
+	if err := IoctlStreamOff(q.FD, &stream); err != nil {
+		return err
+	}
+	for _, b := range q.Bufs {
+		b.Queued = false
+	}
+	return nil
+}
+
+// Release frees all buffers of the queue. The queue must be streamed off.
+func (q *BufferQueue) Release() error {
+	// mapped buffers keep the driver's buffers busy, user memory must
+	// stay valid until the driver dropped its references
+	if q.Memory == V4L2_MEMORY_MMAP {
+		for _, b := range q.Bufs {
+			b.free(q.Memory)
+		}
+	}
+	reqbufs := V4L2_Requestbuffers{
+		Type:   q.Type,
+		Memory: q.Memory,
+	}
+	err := IoctlRequestBuffers(q.FD, &reqbufs)
+	if q.Memory != V4L2_MEMORY_MMAP {
+		for _, b := range q.Bufs {
+			b.free(q.Memory)
+		}
+	}
+	q.Bufs = nil
+	return err
+}
@@ -0,0 +1,238 @@
+package v4l2
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+func TestBufferQueueCapture(t *testing.T) {
+	for _, memory := range []uint32{V4L2_MEMORY_MMAP, V4L2_MEMORY_USERPTR} {
+		d, _ := openFake(t)
+		format := setFakeFormat(t, d, V4L2_PIX_FMT_NV12, 320, 240)
+		size := format.Fmt.(*V4L2_Pix_Format).SizeImage
+
+		q := NewBufferQueue(d.FD, V4L2_BUF_TYPE_VIDEO_CAPTURE, memory)
+		if err := q.Alloc(3); err != nil {
+			t.Fatal(err)
+		}
+		if len(q.Bufs) != 3 || q.NPlanes != 1 {
+			t.Fatalf("memory %d: %d buffers of %d planes", memory, len(q.Bufs), q.NPlanes)
+		}
+		if err := q.QueueAll(); err != nil {
+			t.Fatal(err)
+		}
+		if err := q.StreamOn(); err != nil {
+			t.Fatal(err)
+		}
+
+		var last *Buffer
+		var prev []byte
+		for i := uint32(0); i < 7; i++ {
+			b, err := q.Dequeue()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b.Index != i%3 || b.Sequence != i || b.Queued {
+				t.Errorf("memory %d: dequeued buffer %d of frame %d, want %d of %d",
+					memory, b.Index, b.Sequence, i%3, i)
+			}
+			if b.Flags&V4L2_BUF_FLAG_DONE == 0 ||
+				b.Flags&V4L2_BUF_FLAG_TIMESTAMP_MASK != V4L2_BUF_FLAG_TIMESTAMP_MONOTONIC {
+				t.Errorf("memory %d: flags %#x", memory, b.Flags)
+			}
+			if last != nil && b.TimeStamp < last.TimeStamp {
+				t.Errorf("memory %d: time went back from %v to %v", memory, last.TimeStamp, b.TimeStamp)
+			}
+			data := b.Planes[0].Bytes()
+			if uint32(len(data)) != size {
+				t.Fatalf("memory %d: %d bytes used, want %d", memory, len(data), size)
+			}
+			if prev != nil && !bytes.Equal(prev, data) {
+				t.Errorf("memory %d: frame %d differs", memory, i)
+			}
+			prev = append(prev[:0], data...)
+			last = &Buffer{TimeStamp: b.TimeStamp}
+			if err := q.Queue(b); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// the frame decodes to the color bars
+		img, err := q.Bufs[0].Image(format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, g, b, _ := img.At(320/16*5, 100).RGBA()
+		if r>>8 > 16 || g>>8 < 0xf0 || b>>8 < 0xf0 {
+			t.Errorf("memory %d: cyan bar decoded to %v", memory, color.RGBA64{uint16(r), uint16(g), uint16(b), 0xffff})
+		}
+
+		if err := q.StreamOff(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := q.Dequeue(); err != syscall.EINVAL {
+			t.Errorf("memory %d: dequeue after StreamOff: %v, want EINVAL", memory, err)
+		}
+		if err := q.Release(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBufferQueueErrors(t *testing.T) {
+	d, _ := openFake(t)
+	q := NewBufferQueue(d.FD, V4L2_BUF_TYPE_VIDEO_CAPTURE, V4L2_MEMORY_MMAP)
+	if err := q.StreamOn(); err != syscall.EINVAL {
+		t.Errorf("stream on without buffers: %v, want EINVAL", err)
+	}
+	if err := q.Alloc(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Queue(q.Bufs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Queue(q.Bufs[0]); err != syscall.EINVAL {
+		t.Errorf("queue twice: %v, want EINVAL", err)
+	}
+	if err := q.StreamOn(); err != nil {
+		t.Fatal(err)
+	}
+	reqbufs := V4L2_Requestbuffers{
+		Count:  2,
+		Type:   V4L2_BUF_TYPE_VIDEO_CAPTURE,
+		Memory: V4L2_MEMORY_MMAP,
+	}
+	if err := IoctlRequestBuffers(d.FD, &reqbufs); err != syscall.EBUSY {
+		t.Errorf("request buffers while streaming: %v, want EBUSY", err)
+	}
+
+	out := NewBufferQueue(d.FD, V4L2_BUF_TYPE_VIDEO_OUTPUT, V4L2_MEMORY_MMAP)
+	if err := out.Alloc(2); !errors.Is(err, syscall.EINVAL) {
+		t.Errorf("output queue of a capture device: %v, want EINVAL", err)
+	}
+}
+
+func TestBufferQueueRealloc(t *testing.T) {
+	d, _ := openFake(t)
+	q := NewBufferQueue(d.FD, V4L2_BUF_TYPE_VIDEO_CAPTURE, V4L2_MEMORY_MMAP)
+	mapped := backends.mapped.Load()
+	if err := q.Alloc(4); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if n := backends.mapped.Load() - mapped; n != 0 {
+		t.Errorf("%d mappings left after Release", n)
+	}
+
+	format := setFakeFormat(t, d, V4L2_PIX_FMT_YUYV, 1280, 720)
+	if err := q.Alloc(2); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := q.Bufs[1].Planes[0].Length, format.Fmt.(*V4L2_Pix_Format).SizeImage; got != want {
+		t.Errorf("buffer of %d bytes, want %d", got, want)
+	}
+	vb := V4L2_Buffer{Index: 1, Type: V4L2_BUF_TYPE_VIDEO_CAPTURE, Memory: V4L2_MEMORY_MMAP}
+	if err := IoctlQueryBuf(d.FD, &vb); err != nil {
+		t.Fatal(err)
+	}
+	if vb.Flags&V4L2_BUF_FLAG_MAPPED == 0 || vb.Offset()%4096 != 0 || vb.Offset() == 0 {
+		t.Errorf("buffer 1 flags %#x offset %#x", vb.Flags, vb.Offset())
+	}
+	if err := q.Release(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// planesBackend answers VIDIOC_QUERYBUF of a multi-planar queue with plane
+// i of buffer n of 1000*(i+1) bytes at offset 0x10000*n+0x1000*i
+type planesBackend struct{}
+
+func (planesBackend) Ioctl(request uint, argp unsafe.Pointer) error {
+	p := (*v4l2_buffer)(argp)
+	if request != VIDIOC_QUERYBUF || p._type != V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE {
+		return syscall.ENOTTY
+	}
+	planes := *(**v4l2_plane)(unsafe.Pointer(&p.m))
+	if planes == nil || p.length == 0 {
+		return syscall.EINVAL
+	}
+	ps := unsafe.Slice(planes, min(uint32(p.length), 2))
+	for i := range ps {
+		ps[i].length = __u32(1000 * (i + 1))
+		*(*__u32)(unsafe.Pointer(&ps[i].m)) = __u32(0x10000*uint32(p.index) + 0x1000*uint32(i))
+	}
+	p.length = __u32(len(ps))
+	return nil
+}
+
+func (planesBackend) Mmap(int64, int) ([]byte, error) { return nil, syscall.ENODEV }
+func (planesBackend) Munmap([]byte) error             { return syscall.EINVAL }
+func (planesBackend) Poll() uint32                    { return 0 }
+func (planesBackend) Close() error                    { return nil }
+
+func TestQueryBufPlanes(t *testing.T) {
+	d, err := OpenBackend(planesBackend{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	var planes [VIDEO_MAX_PLANES]V4L2_Plane
+	vb := V4L2_Buffer{
+		Index:  3,
+		Type:   V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE,
+		Memory: V4L2_MEMORY_MMAP,
+		Planes: planes[:],
+		Length: VIDEO_MAX_PLANES,
+	}
+	if err := IoctlQueryBuf(d.FD, &vb); err != nil {
+		t.Fatal(err)
+	}
+	if vb.Length != 2 || vb.M != nil {
+		t.Errorf("%d planes, m %v", vb.Length, vb.M)
+	}
+	for i, p := range planes[:2] {
+		if p.Length != uint32(1000*(i+1)) || p.Offset() != uint32(0x30000+0x1000*i) {
+			t.Errorf("plane %d of %d bytes at %#x", i, p.Length, p.Offset())
+		}
+	}
+	if planes[2].Length != 0 {
+		t.Errorf("plane 2 of %d bytes", planes[2].Length)
+	}
+
+	// Length limits the planes passed to the driver
+	vb = V4L2_Buffer{
+		Type:   V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE,
+		Memory: V4L2_MEMORY_MMAP,
+		Planes: make([]V4L2_Plane, 4),
+		Length: 1,
+	}
+	if err := IoctlQueryBuf(d.FD, &vb); err != nil {
+		t.Fatal(err)
+	}
+	if vb.Length != 1 || vb.Planes[0].Length != 1000 || vb.Planes[1].Length != 0 {
+		t.Errorf("%d planes: %+v", vb.Length, vb.Planes[:2])
+	}
+	vb.Planes = nil
+	if err := IoctlQueryBuf(d.FD, &vb); err != syscall.EINVAL {
+		t.Errorf("query without planes: %v, want EINVAL", err)
+	}
+}
+
+func TestBufferFD(t *testing.T) {
+	for _, fd := range []int{0, 7, 1<<31 - 1, -1} {
+		var b V4L2_Buffer
+		b.SetFD(fd)
+		var p V4L2_Plane
+		p.SetFD(fd)
+		if b.FD() != fd || p.FD() != fd {
+			t.Errorf("fd %d read back as %d from a buffer, %d from a plane", fd, b.FD(), p.FD())
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package v4l2
+
+import (
+	"context"
+	"syscall"
+	"unsafe"
+)
+
+// IoctlRequestAlloc allocates a request on the media device fd and stores
+// the file descriptor of the request into argp.
+func IoctlRequestAlloc(fd int, argp *int32) error {
+	var reqfd int32
+	err := ioctl(fd, MEDIA_IOC_REQUEST_ALLOC, unsafe.Pointer(&reqfd))
+	if err != nil {
+		return err
+	}
+	*argp = int32(reqfd)
+	return nil
+}
+
+// IoctlRequestQueue queues the request fd, which must have at least one
+// buffer bound to it.
+func IoctlRequestQueue(fd int) error {
+	return ioctl(fd, MEDIA_REQUEST_IOC_QUEUE, nil)
+}
+
+// IoctlRequestReinit resets the completed request fd so it can be reused.
+func IoctlRequestReinit(fd int) error {
+	return ioctl(fd, MEDIA_REQUEST_IOC_REINIT, nil)
+}
+
+// Request is a media request, used by stateless codecs to bind the
+// controls of a frame to the buffer holding its bitstream. A request is
+// filled with SetControls and BufferQueue.QueueRequest, queued with Queue,
+// and reused with Reinit once Wait returned.
+type Request struct {
+	FD   int
+	poll *poller
+}
+
+// NewRequest allocates a request on the media device mediaFD, which is the
+// media controller of the video device the request is used with.
+func NewRequest(mediaFD int) (*Request, error) {
+	var fd int32
+	if err := IoctlRequestAlloc(mediaFD, &fd); err != nil {
+		return nil, err
+	}
+	return &Request{FD: int(fd)}, nil
+}
+
+// SetControls sets the values of controls of the video device fd within
+// the request. They are applied when the request is processed.
+func (r *Request) SetControls(fd int, controls ...V4L2_Ext_Control) error {
+	ctrls := V4L2_Ext_Controls{
+		ClassWhich: V4L2_CTRL_WHICH_REQUEST_VAL,
+		Count:      uint32(len(controls)),
+		RequestFD:  int32(r.FD),
+		Controls:   controls,
+	}
+	return IoctlSetExtCtrls(fd, &ctrls)
+}
+
+// GetControls gets the values of controls of the video device fd of a
+// completed request.
+func (r *Request) GetControls(fd int, controls ...V4L2_Ext_Control) error {
+	ctrls := V4L2_Ext_Controls{
+		ClassWhich: V4L2_CTRL_WHICH_REQUEST_VAL,
+		Count:      uint32(len(controls)),
+		RequestFD:  int32(r.FD),
+		Controls:   controls,
+	}
+	return IoctlGetExtCtrls(fd, &ctrls)
+}
+
+func (r *Request) Queue() error {
+	return IoctlRequestQueue(r.FD)
+}
+
+func (r *Request) Reinit() error {
+	return IoctlRequestReinit(r.FD)
+}
+
+// Wait blocks until the queued request completed or ctx is done. The
+// buffers of the request can be dequeued afterwards.
+func (r *Request) Wait(ctx context.Context) error {
+	if r.poll == nil {
+		p, err := newPoller(r.FD, syscall.EPOLLPRI)
+		if err != nil {
+			return err
+		}
+		r.poll = p
+	}
+	stop := context.AfterFunc(ctx, r.poll.wakeup)
+	defer stop()
+
+	for {
+		events, err := r.poll.wait(-1)
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if events&syscall.EPOLLPRI != 0 {
+			return nil
+		}
+	}
+}
+
+func (r *Request) Close() error {
+	if r.poll != nil {
+		r.poll.close()
+		r.poll = nil
+	}
+	return syscall.Close(r.FD)
+}
@@ -0,0 +1,69 @@
+package v4l2
+
+import (
+	"syscall"
+)
+
+// GetSelection returns the selection rectangle of target for the given
+// buffer type. Drivers without selection support are queried through the
+// legacy VIDIOC_G_CROP and VIDIOC_CROPCAP ioctls, which only know about
+// the crop targets.
+func (d *Device) GetSelection(bufType, target uint32) (V4L2_Rect, error) {
+	sel := V4L2_Selection{Type: bufType, Target: target}
+	err := IoctlGetSelection(d.FD, &sel)
+	if err == nil {
+		return sel.R, nil
+	}
+	if err != syscall.ENOTTY {
+		return V4L2_Rect{}, err
+	}
+
+	switch target {
+	case V4L2_SEL_TGT_CROP:
+		crop := V4L2_Crop{Type: bufType}
+		if err := IoctlGetCrop(d.FD, &crop); err != nil {
+			return V4L2_Rect{}, err
+		}
+		return crop.C, nil
+	case V4L2_SEL_TGT_CROP_DEFAULT, V4L2_SEL_TGT_CROP_BOUNDS:
+		cropcap := V4L2_Cropcap{Type: bufType}
+		if err := IoctlCropCap(d.FD, &cropcap); err != nil {
+			return V4L2_Rect{}, err
+		}
+		if target == V4L2_SEL_TGT_CROP_DEFAULT {
+			return cropcap.Defrect, nil
+		}
+		return cropcap.Bounds, nil
+	}
+	return V4L2_Rect{}, err
+}
+
+// SetSelection sets the selection rectangle of target for the given buffer
+// type and returns the rectangle the driver actually applied. Drivers
+// without selection support fall back to VIDIOC_S_CROP for the crop target;
+// the adjustment flags are ignored in that case.
+func (d *Device) SetSelection(bufType, target, flags uint32, r V4L2_Rect) (V4L2_Rect, error) {
+	sel := V4L2_Selection{
+		Type:   bufType,
+		Target: target,
+		Flags:  flags,
+		R:      r,
+	}
+	err := IoctlSetSelection(d.FD, &sel)
+	if err == nil {
+		return sel.R, nil
+	}
+	if err != syscall.ENOTTY || target != V4L2_SEL_TGT_CROP {
+		return V4L2_Rect{}, err
+	}
+
+	crop := V4L2_Crop{Type: bufType, C: r}
+	if err := IoctlSetCrop(d.FD, &crop); err != nil {
+		return V4L2_Rect{}, err
+	}
+	// S_CROP is write-only, read back what the driver chose
+	if err := IoctlGetCrop(d.FD, &crop); err != nil {
+		return V4L2_Rect{}, err
+	}
+	return crop.C, nil
+}
@@ -0,0 +1,565 @@
+package v4l2
+
+import (
+	"time"
+	"unsafe"
+)
+
+// compoundControl is implemented by the payloads of compound controls. A
+// V4L2_Ext_Control whose Union holds one of them is marshalled into a C
+// struct of size bytes, and is unmarshalled from it after the ioctl.
+type compoundControl interface {
+	size() uint32
+	set(ptr unsafe.Pointer)
+	get(ptr unsafe.Pointer)
+}
+
+// TimestampToNs converts a buffer timestamp into the nanoseconds used to
+// reference CAPTURE buffers in stateless codec controls, the same as
+// v4l2_timeval_to_ns in videodev2.h does for the timeval the timestamp is
+// passed to the driver in
+func TimestampToNs(ts time.Duration) uint64 {
+	return uint64(ts.Truncate(time.Microsecond))
+}
+
+// V4L2_CID_STATELESS_H264_SPS payload
+type V4L2_Ctrl_H264_SPS struct {
+	ProfileIdc                     uint8
+	ConstraintSetFlags             uint8
+	LevelIdc                       uint8
+	SeqParameterSetID              uint8
+	ChromaFormatIdc                uint8
+	BitDepthLumaMinus8             uint8
+	BitDepthChromaMinus8           uint8
+	Log2MaxFrameNumMinus4          uint8
+	PicOrderCntType                uint8
+	Log2MaxPicOrderCntLsbMinus4    uint8
+	MaxNumRefFrames                uint8
+	NumRefFramesInPicOrderCntCycle uint8
+	OffsetForRefFrame              [255]int32
+	OffsetForNonRefPic             int32
+	OffsetForTopToBottomField      int32
+	PicWidthInMbsMinus1            uint16
+	PicHeightInMapUnitsMinus1      uint16
+	Flags                          uint32
+}
+
+func (s *V4L2_Ctrl_H264_SPS) size() uint32 {
+	return sizeof_v4l2_ctrl_h264_sps
+}
+
+func (s *V4L2_Ctrl_H264_SPS) set(ptr unsafe.Pointer) {
+	p := (*v4l2_ctrl_h264_sps)(ptr)
+	p.profile_idc = __u8(s.ProfileIdc)
+	p.constraint_set_flags = __u8(s.ConstraintSetFlags)
+	p.level_idc = __u8(s.LevelIdc)
+	p.seq_parameter_set_id = __u8(s.SeqParameterSetID)
+	p.chroma_format_idc = __u8(s.ChromaFormatIdc)
+	p.bit_depth_luma_minus8 = __u8(s.BitDepthLumaMinus8)
+	p.bit_depth_chroma_minus8 = __u8(s.BitDepthChromaMinus8)
+	p.log2_max_frame_num_minus4 = __u8(s.Log2MaxFrameNumMinus4)
+	p.pic_order_cnt_type = __u8(s.PicOrderCntType)
+	p.log2_max_pic_order_cnt_lsb_minus4 = __u8(s.Log2MaxPicOrderCntLsbMinus4)
+	p.max_num_ref_frames = __u8(s.MaxNumRefFrames)
+	p.num_ref_frames_in_pic_order_cnt_cycle = __u8(s.NumRefFramesInPicOrderCntCycle)
+	p.offset_for_ref_frame = *(*[255]__s32)(unsafe.Pointer(&s.OffsetForRefFrame))
+	p.offset_for_non_ref_pic = __s32(s.OffsetForNonRefPic)
+	p.offset_for_top_to_bottom_field = __s32(s.OffsetForTopToBottomField)
+	p.pic_width_in_mbs_minus1 = __u16(s.PicWidthInMbsMinus1)
+	p.pic_height_in_map_units_minus1 = __u16(s.PicHeightInMapUnitsMinus1)
+	p.flags = __u32(s.Flags)
+}
+
+func (s *V4L2_Ctrl_H264_SPS) get(ptr unsafe.Pointer) {
+	p := (*v4l2_ctrl_h264_sps)(ptr)
+	s.ProfileIdc = uint8(p.profile_idc)
+	s.ConstraintSetFlags = uint8(p.constraint_set_flags)
+	s.LevelIdc = uint8(p.level_idc)
+	s.SeqParameterSetID = uint8(p.seq_parameter_set_id)
+	s.ChromaFormatIdc = uint8(p.chroma_format_idc)
+	s.BitDepthLumaMinus8 = uint8(p.bit_depth_luma_minus8)
+	s.BitDepthChromaMinus8 = uint8(p.bit_depth_chroma_minus8)
+	s.Log2MaxFrameNumMinus4 = uint8(p.log2_max_frame_num_minus4)
+	s.PicOrderCntType = uint8(p.pic_order_cnt_type)
+	s.Log2MaxPicOrderCntLsbMinus4 = uint8(p.log2_max_pic_order_cnt_lsb_minus4)
+	s.MaxNumRefFrames = uint8(p.max_num_ref_frames)
+	s.NumRefFramesInPicOrderCntCycle = uint8(p.num_ref_frames_in_pic_order_cnt_cycle)
+	s.OffsetForRefFrame = *(*[255]int32)(unsafe.Pointer(&p.offset_for_ref_frame))
+	s.OffsetForNonRefPic = int32(p.offset_for_non_ref_pic)
+	s.OffsetForTopToBottomField = int32(p.offset_for_top_to_bottom_field)
+	s.PicWidthInMbsMinus1 = uint16(p.pic_width_in_mbs_minus1)
+	s.PicHeightInMapUnitsMinus1 = uint16(p.pic_height_in_map_units_minus1)
+	s.Flags = uint32(p.flags)
+}
+
+// V4L2_CID_STATELESS_H264_PPS payload
+type V4L2_Ctrl_H264_PPS struct {
+	PicParameterSetID              uint8
+	SeqParameterSetID              uint8
+	NumSliceGroupsMinus1           uint8
+	NumRefIdxL0DefaultActiveMinus1 uint8
+	NumRefIdxL1DefaultActiveMinus1 uint8
+	WeightedBipredIdc              uint8
+	PicInitQpMinus26               int8
+	PicInitQsMinus26               int8
+	ChromaQpIndexOffset            int8
+	SecondChromaQpIndexOffset      int8
+	Flags                          uint16
+}
+
+func (s *V4L2_Ctrl_H264_PPS) size() uint32 {
+	return sizeof_v4l2_ctrl_h264_pps
+}
+
+func (s *V4L2_Ctrl_H264_PPS) set(ptr unsafe.Pointer) {
+	p := (*v4l2_ctrl_h264_pps)(ptr)
+	p.pic_parameter_set_id = __u8(s.PicParameterSetID)
+	p.seq_parameter_set_id = __u8(s.SeqParameterSetID)
+	p.num_slice_groups_minus1 = __u8(s.NumSliceGroupsMinus1)
+	p.num_ref_idx_l0_default_active_minus1 = __u8(s.NumRefIdxL0DefaultActiveMinus1)
+	p.num_ref_idx_l1_default_active_minus1 = __u8(s.NumRefIdxL1DefaultActiveMinus1)
+	p.weighted_bipred_idc = __u8(s.WeightedBipredIdc)
+	p.pic_init_qp_minus26 = __s8(s.PicInitQpMinus26)
+	p.pic_init_qs_minus26 = __s8(s.PicInitQsMinus26)
+	p.chroma_qp_index_offset = __s8(s.ChromaQpIndexOffset)
+	p.second_chroma_qp_index_offset = __s8(s.SecondChromaQpIndexOffset)
+	p.flags = __u16(s.Flags)
+}
+
+func (s *V4L2_Ctrl_H264_PPS) get(ptr unsafe.Pointer) {
+	p := (*v4l2_ctrl_h264_pps)(ptr)
+	s.PicParameterSetID = uint8(p.pic_parameter_set_id)
+	s.SeqParameterSetID = uint8(p.seq_parameter_set_id)
+	s.NumSliceGroupsMinus1 = uint8(p.num_slice_groups_minus1)
+	s.NumRefIdxL0DefaultActiveMinus1 = uint8(p.num_ref_idx_l0_default_active_minus1)
+	s.NumRefIdxL1DefaultActiveMinus1 = uint8(p.num_ref_idx_l1_default_active_minus1)
+	s.WeightedBipredIdc = uint8(p.weighted_bipred_idc)
+	s.PicInitQpMinus26 = int8(p.pic_init_qp_minus26)
+	s.PicInitQsMinus26 = int8(p.pic_init_qs_minus26)
+	s.ChromaQpIndexOffset = int8(p.chroma_qp_index_offset)
+	s.SecondChromaQpIndexOffset = int8(p.second_chroma_qp_index_offset)
+	s.Flags = uint16(p.flags)
+}
+
+// V4L2_CID_STATELESS_H264_SCALING_MATRIX payload
+type V4L2_Ctrl_H264_Scaling_Matrix struct {
+	ScalingList4x4 [6][16]uint8
+	ScalingList8x8 [6][64]uint8
+}
+
+func (s *V4L2_Ctrl_H264_Scaling_Matrix) size() uint32 {
+	return sizeof_v4l2_ctrl_h264_scaling_matrix
+}
+
+func (s *V4L2_Ctrl_H264_Scaling_Matrix) set(ptr unsafe.Pointer) {
+	p := (*v4l2_ctrl_h264_scaling_matrix)(ptr)
+	p.scaling_list_4x4 = *(*[6][16]__u8)(unsafe.Pointer(&s.ScalingList4x4))
+	p.scaling_list_8x8 = *(*[6][64]__u8)(unsafe.Pointer(&s.ScalingList8x8))
+}
+
+func (s *V4L2_Ctrl_H264_Scaling_Matrix) get(ptr unsafe.Pointer) {
+	p := (*v4l2_ctrl_h264_scaling_matrix)(ptr)
+	s.ScalingList4x4 = *(*[6][16]uint8)(unsafe.Pointer(&p.scaling_list_4x4))
+	s.ScalingList8x8 = *(*[6][64]uint8)(unsafe.Pointer(&p.scaling_list_8x8))
+}
+
+type V4L2_H264_Weight_Factors struct {
+	LumaWeight   [32]int16
+	LumaOffset   [32]int16
+	ChromaWeight [32][2]int16
+	ChromaOffset [32][2]int16
+}
+
+func (w *V4L2_H264_Weight_Factors) set(ptr unsafe.Pointer) {
+	p := (*v4l2_h264_weight_factors)(ptr)
+	p.luma_weight = *(*[32]__s16)(unsafe.Pointer(&w.LumaWeight))
+	p.luma_offset = *(*[32]__s16)(unsafe.Pointer(&w.LumaOffset))
+	p.chroma_weight = *(*[32][2]__s16)(unsafe.Pointer(&w.ChromaWeight))
+	p.chroma_offset = *(*[32][2]__s16)(unsafe.Pointer(&w.ChromaOffset))
+}
+
+func (w *V4L2_H264_Weight_Factors) get(ptr unsafe.Pointer) {
+	p := (*v4l2_h264_weight_factors)(ptr)
+	w.LumaWeight = *(*[32]int16)(unsafe.Pointer(&p.luma_weight))
+	w.LumaOffset = *(*[32]int16)(unsafe.Pointer(&p.luma_offset))
+	w.ChromaWeight = *(*[32][2]int16)(unsafe.Pointer(&p.chroma_weight))
+	w.ChromaOffset = *(*[32][2]int16)(unsafe.Pointer(&p.chroma_offset))
+}
+
+// V4L2_CID_STATELESS_H264_PRED_WEIGHTS payload
+type V4L2_Ctrl_H264_Pred_Weights struct {
+	LumaLog2WeightDenom   uint16
+	ChromaLog2WeightDenom uint16
+	WeightFactors         [2]V4L2_H264_Weight_Factors
+}
+
+func (s *V4L2_Ctrl_H264_Pred_Weights) size() uint32 {
+	return sizeof_v4l2_ctrl_h264_pred_weights
+}
+
+func (s *V4L2_Ctrl_H264_Pred_Weights) set(ptr unsafe.Pointer) {
+	p := (*v4l2_ctrl_h264_pred_weights)(ptr)
+	p.luma_log2_weight_denom = __u16(s.LumaLog2WeightDenom)
+	p.chroma_log2_weight_denom = __u16(s.ChromaLog2WeightDenom)
+	for i := range s.WeightFactors {
+		s.WeightFactors[i].set(unsafe.Pointer(&p.weight_factors[i]))
+	}
+}
+
+func (s *V4L2_Ctrl_H264_Pred_Weights) get(ptr unsafe.Pointer) {
+	p := (*v4l2_ctrl_h264_pred_weights)(ptr)
+	s.LumaLog2WeightDenom = uint16(p.luma_log2_weight_denom)
+	s.ChromaLog2WeightDenom = uint16(p.chroma_log2_weight_denom)
+	for i := range s.WeightFactors {
+		s.WeightFactors[i].get(unsafe.Pointer(&p.weight_factors[i]))
+	}
+}
+
+// V4L2_H264_Reference is an entry of the reference picture lists, Index
+// refers to V4L2_Ctrl_H264_Decode_Params.DPB
+type V4L2_H264_Reference struct {
+	Fields uint8
+	Index  uint8
+}
+
+// V4L2_CID_STATELESS_H264_SLICE_PARAMS payload
+type V4L2_Ctrl_H264_Slice_Params struct {
+	HeaderBitSize              uint32
+	FirstMbInSlice             uint32
+	SliceType                  uint8
+	ColourPlaneID              uint8
+	RedundantPicCnt            uint8
+	CabacInitIdc               uint8
+	SliceQpDelta               int8
+	SliceQsDelta               int8
+	DisableDeblockingFilterIdc uint8
+	SliceAlphaC0OffsetDiv2     int8
+	SliceBetaOffsetDiv2        int8
+	NumRefIdxL0ActiveMinus1    uint8
+	NumRefIdxL1ActiveMinus1    uint8
+	RefPicList0                [V4L2_H264_REF_LIST_LEN]V4L2_H264_Reference
+	RefPicList1                [V4L2_H264_REF_LIST_LEN]V4L2_H264_Reference
+	Flags                      uint32
+}
+
+func (s *V4L2_Ctrl_H264_Slice_Params) size() uint32 {
+	return sizeof_v4l2_ctrl_h264_slice_params
+}
+
+func (s *V4L2_Ctrl_H264_Slice_Params) set(ptr unsafe.Pointer) {
+	p := (*v4l2_ctrl_h264_slice_params)(ptr)
+	p.header_bit_size = __u32(s.HeaderBitSize)
+	p.first_mb_in_slice = __u32(s.FirstMbInSlice)
+	p.slice_type = __u8(s.SliceType)
+	p.colour_plane_id = __u8(s.ColourPlaneID)
+	p.redundant_pic_cnt = __u8(s.RedundantPicCnt)
+	p.cabac_init_idc = __u8(s.CabacInitIdc)
+	p.slice_qp_delta = __s8(s.SliceQpDelta)
+	p.slice_qs_delta = __s8(s.SliceQsDelta)
+	p.disable_deblocking_filter_idc = __u8(s.DisableDeblockingFilterIdc)
+	p.slice_alpha_c0_offset_div2 = __s8(s.SliceAlphaC0OffsetDiv2)
+	p.slice_beta_offset_div2 = __s8(s.SliceBetaOffsetDiv2)
+	p.num_ref_idx_l0_active_minus1 = __u8(s.NumRefIdxL0ActiveMinus1)
+	p.num_ref_idx_l1_active_minus1 = __u8(s.NumRefIdxL1ActiveMinus1)
+	for i := range s.RefPicList0 {
+		p.ref_pic_list0[i].fields = __u8(s.RefPicList0[i].Fields)
+		p.ref_pic_list0[i].index = __u8(s.RefPicList0[i].Index)
+		p.ref_pic_list1[i].fields = __u8(s.RefPicList1[i].Fields)
+		p.ref_pic_list1[i].index = __u8(s.RefPicList1[i].Index)
+	}
+	p.flags = __u32(s.Flags)
+}
+
+func (s *V4L2_Ctrl_H264_Slice_Params) get(ptr unsafe.Pointer) {
+	p := (*v4l2_ctrl_h264_slice_params)(ptr)
+	s.HeaderBitSize = uint32(p.header_bit_size)
+	s.FirstMbInSlice = uint32(p.first_mb_in_slice)
+	s.SliceType = uint8(p.slice_type)
+	s.ColourPlaneID = uint8(p.colour_plane_id)
+	s.RedundantPicCnt = uint8(p.redundant_pic_cnt)
+	s.CabacInitIdc = uint8(p.cabac_init_idc)
+	s.SliceQpDelta = int8(p.slice_qp_delta)
+	s.SliceQsDelta = int8(p.slice_qs_delta)
+	s.DisableDeblockingFilterIdc = uint8(p.disable_deblocking_filter_idc)
+	s.SliceAlphaC0OffsetDiv2 = int8(p.slice_alpha_c0_offset_div2)
+	s.SliceBetaOffsetDiv2 = int8(p.slice_beta_offset_div2)
+	s.NumRefIdxL0ActiveMinus1 = uint8(p.num_ref_idx_l0_active_minus1)
+	s.NumRefIdxL1ActiveMinus1 = uint8(p.num_ref_idx_l1_active_minus1)
+	for i := range s.RefPicList0 {
+		s.RefPicList0[i].Fields = uint8(p.ref_pic_list0[i].fields)
+		s.RefPicList0[i].Index = uint8(p.ref_pic_list0[i].index)
+		s.RefPicList1[i].Fields = uint8(p.ref_pic_list1[i].fields)
+		s.RefPicList1[i].Index = uint8(p.ref_pic_list1[i].index)
+	}
+	s.Flags = uint32(p.flags)
+}
+
+// V4L2_H264_DPB_Entry is a decoded picture buffer entry. ReferenceTS is the
+// TimestampToNs of the CAPTURE buffer holding the reference picture.
+type V4L2_H264_DPB_Entry struct {
+	ReferenceTS         uint64
+	PicNum              uint32
+	FrameNum            uint16
+	Fields              uint8
+	TopFieldOrderCnt    int32
+	BottomFieldOrderCnt int32
+	Flags               uint32
+}
+
+func (e *V4L2_H264_DPB_Entry) set(ptr unsafe.Pointer) {
+	p := (*v4l2_h264_dpb_entry)(ptr)
+	p.reference_ts = __u64(e.ReferenceTS)
+	p.pic_num = __u32(e.PicNum)
+	p.frame_num = __u16(e.FrameNum)
+	p.fields = __u8(e.Fields)
+	p.top_field_order_cnt = __s32(e.TopFieldOrderCnt)
+	p.bottom_field_order_cnt = __s32(e.BottomFieldOrderCnt)
+	p.flags = __u32(e.Flags)
+}
+
+func (e *V4L2_H264_DPB_Entry) get(ptr unsafe.Pointer) {
+	p := (*v4l2_h264_dpb_entry)(ptr)
+	e.ReferenceTS = uint64(p.reference_ts)
+	e.PicNum = uint32(p.pic_num)
+	e.FrameNum = uint16(p.frame_num)
+	e.Fields = uint8(p.fields)
+	e.TopFieldOrderCnt = int32(p.top_field_order_cnt)
+	e.BottomFieldOrderCnt = int32(p.bottom_field_order_cnt)
+	e.Flags = uint32(p.flags)
+}
+
+// V4L2_CID_STATELESS_H264_DECODE_PARAMS payload
+type V4L2_Ctrl_H264_Decode_Params struct {
+	DPB                     [V4L2_H264_NUM_DPB_ENTRIES]V4L2_H264_DPB_Entry
+	NalRefIdc               uint16
+	FrameNum                uint16
+	TopFieldOrderCnt        int32
+	BottomFieldOrderCnt     int32
+	IdrPicID                uint16
+	PicOrderCntLsb          uint16
+	DeltaPicOrderCntBottom  int32
+	DeltaPicOrderCnt0       int32
+	DeltaPicOrderCnt1       int32
+	DecRefPicMarkingBitSize uint32
+	PicOrderCntBitSize      uint32
+	SliceGroupChangeCycle   uint32
+	Flags                   uint32
+}
+
+func (s *V4L2_Ctrl_H264_Decode_Params) size() uint32 {
+	return sizeof_v4l2_ctrl_h264_decode_params
+}
+
+func (s *V4L2_Ctrl_H264_Decode_Params) set(ptr unsafe.Pointer) {
+	p := (*v4l2_ctrl_h264_decode_params)(ptr)
+	for i := range s.DPB {
+		s.DPB[i].set(unsafe.Pointer(&p.dpb[i]))
+	}
+	p.nal_ref_idc = __u16(s.NalRefIdc)
+	p.frame_num = __u16(s.FrameNum)
+	p.top_field_order_cnt = __s32(s.TopFieldOrderCnt)
+	p.bottom_field_order_cnt = __s32(s.BottomFieldOrderCnt)
+	p.idr_pic_id = __u16(s.IdrPicID)
+	p.pic_order_cnt_lsb = __u16(s.PicOrderCntLsb)
+	p.delta_pic_order_cnt_bottom = __s32(s.DeltaPicOrderCntBottom)
+	p.delta_pic_order_cnt0 = __s32(s.DeltaPicOrderCnt0)
+	p.delta_pic_order_cnt1 = __s32(s.DeltaPicOrderCnt1)
+	p.dec_ref_pic_marking_bit_size = __u32(s.DecRefPicMarkingBitSize)
+	p.pic_order_cnt_bit_size = __u32(s.PicOrderCntBitSize)
+	p.slice_group_change_cycle = __u32(s.SliceGroupChangeCycle)
+	p.flags = __u32(s.Flags)
+}
+
+func (s *V4L2_Ctrl_H264_Decode_Params) get(ptr unsafe.Pointer) {
+	p := (*v4l2_ctrl_h264_decode_params)(ptr)
+	for i := range s.DPB {
+		s.DPB[i].get(unsafe.Pointer(&p.dpb[i]))
+	}
+	s.NalRefIdc = uint16(p.nal_ref_idc)
+	s.FrameNum = uint16(p.frame_num)
+	s.TopFieldOrderCnt = int32(p.top_field_order_cnt)
+	s.BottomFieldOrderCnt = int32(p.bottom_field_order_cnt)
+	s.IdrPicID = uint16(p.idr_pic_id)
+	s.PicOrderCntLsb = uint16(p.pic_order_cnt_lsb)
+	s.DeltaPicOrderCntBottom = int32(p.delta_pic_order_cnt_bottom)
+	s.DeltaPicOrderCnt0 = int32(p.delta_pic_order_cnt0)
+	s.DeltaPicOrderCnt1 = int32(p.delta_pic_order_cnt1)
+	s.DecRefPicMarkingBitSize = uint32(p.dec_ref_pic_marking_bit_size)
+	s.PicOrderCntBitSize = uint32(p.pic_order_cnt_bit_size)
+	s.SliceGroupChangeCycle = uint32(p.slice_group_change_cycle)
+	s.Flags = uint32(p.flags)
+}
+
+type V4L2_VP8_Segment struct {
+	QuantUpdate  [4]int8
+	LfUpdate     [4]int8
+	SegmentProbs [3]uint8
+	Flags        uint32
+}
+
+type V4L2_VP8_Loop_Filter struct {
+	RefFrmDelta    [4]int8
+	MbModeDelta    [4]int8
+	SharpnessLevel uint8
+	Level          uint8
+	Flags          uint32
+}
+
+type V4L2_VP8_Quantization struct {
+	YAcQi     uint8
+	YDcDelta  int8
+	Y2DcDelta int8
+	Y2AcDelta int8
+	UvDcDelta int8
+	UvAcDelta int8
+}
+
+type V4L2_VP8_Entropy struct {
+	CoeffProbs  [4][8][3][V4L2_VP8_COEFF_PROB_CNT]uint8
+	YModeProbs  [4]uint8
+	UvModeProbs [3]uint8
+	MvProbs     [2][V4L2_VP8_MV_PROB_CNT]uint8
+}
+
+type V4L2_VP8_Entropy_Coder_State struct {
+	Range    uint8
+	Value    uint8
+	BitCount uint8
+}
+
+// V4L2_CID_STATELESS_VP8_FRAME payload. LastFrameTS, GoldenFrameTS and
+// AltFrameTS are the TimestampToNs of the CAPTURE buffers of the references.
+type V4L2_Ctrl_VP8_Frame struct {
+	Segment             V4L2_VP8_Segment
+	LF                  V4L2_VP8_Loop_Filter
+	Quant               V4L2_VP8_Quantization
+	Entropy             V4L2_VP8_Entropy
+	CoderState          V4L2_VP8_Entropy_Coder_State
+	Width               uint16
+	Height              uint16
+	HorizontalScale     uint8
+	VerticalScale       uint8
+	Version             uint8
+	ProbSkipFalse       uint8
+	ProbIntra           uint8
+	ProbLast            uint8
+	ProbGF              uint8
+	NumDCTParts         uint8
+	FirstPartSize       uint32
+	FirstPartHeaderBits uint32
+	DCTPartSizes        [8]uint32
+	LastFrameTS         uint64
+	GoldenFrameTS       uint64
+	AltFrameTS          uint64
+	Flags               uint64
+}
+
+func (s *V4L2_Ctrl_VP8_Frame) size() uint32 {
+	return sizeof_v4l2_ctrl_vp8_frame
+}
+
+func (s *V4L2_Ctrl_VP8_Frame) set(ptr unsafe.Pointer) {
+	p := (*v4l2_ctrl_vp8_frame)(ptr)
+
+	p.segment.quant_update = *(*[4]__s8)(unsafe.Pointer(&s.Segment.QuantUpdate))
+	p.segment.lf_update = *(*[4]__s8)(unsafe.Pointer(&s.Segment.LfUpdate))
+	p.segment.segment_probs = *(*[3]__u8)(unsafe.Pointer(&s.Segment.SegmentProbs))
+	p.segment.flags = __u32(s.Segment.Flags)
+
+	p.lf.ref_frm_delta = *(*[4]__s8)(unsafe.Pointer(&s.LF.RefFrmDelta))
+	p.lf.mb_mode_delta = *(*[4]__s8)(unsafe.Pointer(&s.LF.MbModeDelta))
+	p.lf.sharpness_level = __u8(s.LF.SharpnessLevel)
+	p.lf.level = __u8(s.LF.Level)
+	p.lf.flags = __u32(s.LF.Flags)
+
+	p.quant.y_ac_qi = __u8(s.Quant.YAcQi)
+	p.quant.y_dc_delta = __s8(s.Quant.YDcDelta)
+	p.quant.y2_dc_delta = __s8(s.Quant.Y2DcDelta)
+	p.quant.y2_ac_delta = __s8(s.Quant.Y2AcDelta)
+	p.quant.uv_dc_delta = __s8(s.Quant.UvDcDelta)
+	p.quant.uv_ac_delta = __s8(s.Quant.UvAcDelta)
+
+	p.entropy.coeff_probs = *(*[4][8][3][V4L2_VP8_COEFF_PROB_CNT]__u8)(
+		unsafe.Pointer(&s.Entropy.CoeffProbs))
+	p.entropy.y_mode_probs = *(*[4]__u8)(unsafe.Pointer(&s.Entropy.YModeProbs))
+	p.entropy.uv_mode_probs = *(*[3]__u8)(unsafe.Pointer(&s.Entropy.UvModeProbs))
+	p.entropy.mv_probs = *(*[2][V4L2_VP8_MV_PROB_CNT]__u8)(
+		unsafe.Pointer(&s.Entropy.MvProbs))
+
+	// due to range field, it is keyword in golang, it is the first field
+	tmp := (*__u8)(unsafe.Pointer(&p.coder_state))
+	*tmp = __u8(s.CoderState.Range)
+	p.coder_state.value = __u8(s.CoderState.Value)
+	p.coder_state.bit_count = __u8(s.CoderState.BitCount)
+
+	p.width = __u16(s.Width)
+	p.height = __u16(s.Height)
+	p.horizontal_scale = __u8(s.HorizontalScale)
+	p.vertical_scale = __u8(s.VerticalScale)
+	p.version = __u8(s.Version)
+	p.prob_skip_false = __u8(s.ProbSkipFalse)
+	p.prob_intra = __u8(s.ProbIntra)
+	p.prob_last = __u8(s.ProbLast)
+	p.prob_gf = __u8(s.ProbGF)
+	p.num_dct_parts = __u8(s.NumDCTParts)
+	p.first_part_size = __u32(s.FirstPartSize)
+	p.first_part_header_bits = __u32(s.FirstPartHeaderBits)
+	p.dct_part_sizes = *(*[8]__u32)(unsafe.Pointer(&s.DCTPartSizes))
+	p.last_frame_ts = __u64(s.LastFrameTS)
+	p.golden_frame_ts = __u64(s.GoldenFrameTS)
+	p.alt_frame_ts = __u64(s.AltFrameTS)
+	p.flags = __u64(s.Flags)
+}
+
+func (s *V4L2_Ctrl_VP8_Frame) get(ptr unsafe.Pointer) {
+	p := (*v4l2_ctrl_vp8_frame)(ptr)
+
+	s.Segment.QuantUpdate = *(*[4]int8)(unsafe.Pointer(&p.segment.quant_update))
+	s.Segment.LfUpdate = *(*[4]int8)(unsafe.Pointer(&p.segment.lf_update))
+	s.Segment.SegmentProbs = *(*[3]uint8)(unsafe.Pointer(&p.segment.segment_probs))
+	s.Segment.Flags = uint32(p.segment.flags)
+
+	s.LF.RefFrmDelta = *(*[4]int8)(unsafe.Pointer(&p.lf.ref_frm_delta))
+	s.LF.MbModeDelta = *(*[4]int8)(unsafe.Pointer(&p.lf.mb_mode_delta))
+	s.LF.SharpnessLevel = uint8(p.lf.sharpness_level)
+	s.LF.Level = uint8(p.lf.level)
+	s.LF.Flags = uint32(p.lf.flags)
+
+	s.Quant.YAcQi = uint8(p.quant.y_ac_qi)
+	s.Quant.YDcDelta = int8(p.quant.y_dc_delta)
+	s.Quant.Y2DcDelta = int8(p.quant.y2_dc_delta)
+	s.Quant.Y2AcDelta = int8(p.quant.y2_ac_delta)
+	s.Quant.UvDcDelta = int8(p.quant.uv_dc_delta)
+	s.Quant.UvAcDelta = int8(p.quant.uv_ac_delta)
+
+	s.Entropy.CoeffProbs = *(*[4][8][3][V4L2_VP8_COEFF_PROB_CNT]uint8)(
+		unsafe.Pointer(&p.entropy.coeff_probs))
+	s.Entropy.YModeProbs = *(*[4]uint8)(unsafe.Pointer(&p.entropy.y_mode_probs))
+	s.Entropy.UvModeProbs = *(*[3]uint8)(unsafe.Pointer(&p.entropy.uv_mode_probs))
+	s.Entropy.MvProbs = *(*[2][V4L2_VP8_MV_PROB_CNT]uint8)(
+		unsafe.Pointer(&p.entropy.mv_probs))
+
+	// due to range field, it is keyword in golang, it is the first field
+	tmp := (*__u8)(unsafe.Pointer(&p.coder_state))
+	s.CoderState.Range = uint8(*tmp)
+	s.CoderState.Value = uint8(p.coder_state.value)
+	s.CoderState.BitCount = uint8(p.coder_state.bit_count)
+
+	s.Width = uint16(p.width)
+	s.Height = uint16(p.height)
+	s.HorizontalScale = uint8(p.horizontal_scale)
+	s.VerticalScale = uint8(p.vertical_scale)
+	s.Version = uint8(p.version)
+	s.ProbSkipFalse = uint8(p.prob_skip_false)
+	s.ProbIntra = uint8(p.prob_intra)
+	s.ProbLast = uint8(p.prob_last)
+	s.ProbGF = uint8(p.prob_gf)
+	s.NumDCTParts = uint8(p.num_dct_parts)
+	s.FirstPartSize = uint32(p.first_part_size)
+	s.FirstPartHeaderBits = uint32(p.first_part_header_bits)
+	s.DCTPartSizes = *(*[8]uint32)(unsafe.Pointer(&p.dct_part_sizes))
+	s.LastFrameTS = uint64(p.last_frame_ts)
+	s.GoldenFrameTS = uint64(p.golden_frame_ts)
+	s.AltFrameTS = uint64(p.alt_frame_ts)
+	s.Flags = uint64(p.flags)
+}
@@ -0,0 +1,454 @@
+package v4l2
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// which format of a pad
+const (
+	V4L2_SUBDEV_FORMAT_TRY    = 0
+	V4L2_SUBDEV_FORMAT_ACTIVE = 1
+)
+
+/*media bus formats */
+const (
+	MEDIA_BUS_FMT_FIXED = 0x0001
+
+	/* RGB */
+	MEDIA_BUS_FMT_RGB444_1X12    = 0x1016
+	MEDIA_BUS_FMT_RGB565_1X16    = 0x1017
+	MEDIA_BUS_FMT_RGB565_2X8_BE  = 0x1007
+	MEDIA_BUS_FMT_RGB565_2X8_LE  = 0x1008
+	MEDIA_BUS_FMT_BGR565_2X8_BE  = 0x1005
+	MEDIA_BUS_FMT_BGR565_2X8_LE  = 0x1006
+	MEDIA_BUS_FMT_RGB666_1X18    = 0x1009
+	MEDIA_BUS_FMT_RGB888_1X24    = 0x100a
+	MEDIA_BUS_FMT_BGR888_1X24    = 0x1013
+	MEDIA_BUS_FMT_RGB888_2X12_BE = 0x100b
+	MEDIA_BUS_FMT_RGB888_2X12_LE = 0x100c
+	MEDIA_BUS_FMT_ARGB8888_1X32  = 0x100d
+	MEDIA_BUS_FMT_RGB101010_1X30 = 0x1018
+
+	/* YUV */
+	MEDIA_BUS_FMT_Y8_1X8      = 0x2001
+	MEDIA_BUS_FMT_Y10_1X10    = 0x200a
+	MEDIA_BUS_FMT_Y12_1X12    = 0x2013
+	MEDIA_BUS_FMT_UYVY8_2X8   = 0x2006
+	MEDIA_BUS_FMT_VYUY8_2X8   = 0x2007
+	MEDIA_BUS_FMT_YUYV8_2X8   = 0x2008
+	MEDIA_BUS_FMT_YVYU8_2X8   = 0x2009
+	MEDIA_BUS_FMT_UYVY8_1X16  = 0x200f
+	MEDIA_BUS_FMT_VYUY8_1X16  = 0x2010
+	MEDIA_BUS_FMT_YUYV8_1X16  = 0x2011
+	MEDIA_BUS_FMT_YVYU8_1X16  = 0x2012
+	MEDIA_BUS_FMT_UYVY10_2X10 = 0x2018
+	MEDIA_BUS_FMT_YUYV10_2X10 = 0x200b
+	MEDIA_BUS_FMT_UYVY10_1X20 = 0x201a
+	MEDIA_BUS_FMT_YUYV10_1X20 = 0x200d
+	MEDIA_BUS_FMT_YUV8_1X24   = 0x2025
+	MEDIA_BUS_FMT_AYUV8_1X32  = 0x2017
+
+	/* Bayer */
+	MEDIA_BUS_FMT_SBGGR8_1X8   = 0x3001
+	MEDIA_BUS_FMT_SGBRG8_1X8   = 0x3013
+	MEDIA_BUS_FMT_SGRBG8_1X8   = 0x3002
+	MEDIA_BUS_FMT_SRGGB8_1X8   = 0x3014
+	MEDIA_BUS_FMT_SBGGR10_1X10 = 0x3007
+	MEDIA_BUS_FMT_SGBRG10_1X10 = 0x300e
+	MEDIA_BUS_FMT_SGRBG10_1X10 = 0x300a
+	MEDIA_BUS_FMT_SRGGB10_1X10 = 0x300f
+	MEDIA_BUS_FMT_SBGGR12_1X12 = 0x3008
+	MEDIA_BUS_FMT_SGBRG12_1X12 = 0x3010
+	MEDIA_BUS_FMT_SGRBG12_1X12 = 0x3011
+	MEDIA_BUS_FMT_SRGGB12_1X12 = 0x3012
+	MEDIA_BUS_FMT_SBGGR14_1X14 = 0x3019
+	MEDIA_BUS_FMT_SGBRG14_1X14 = 0x301a
+	MEDIA_BUS_FMT_SGRBG14_1X14 = 0x301b
+	MEDIA_BUS_FMT_SRGGB14_1X14 = 0x301c
+	MEDIA_BUS_FMT_SBGGR16_1X16 = 0x301d
+	MEDIA_BUS_FMT_SGBRG16_1X16 = 0x301e
+	MEDIA_BUS_FMT_SGRBG16_1X16 = 0x301f
+	MEDIA_BUS_FMT_SRGGB16_1X16 = 0x3020
+
+	/*compressed */
+	MEDIA_BUS_FMT_JPEG_1X8 = 0x4001
+)
+
+type V4L2_Mbus_Framefmt struct {
+	Width        uint32
+	Height       uint32
+	Code         uint32
+	Field        uint32
+	ColorSpace   uint32
+	Encoding     uint16 // ycbcr_enc or hsv_enc
+	Quantization uint16
+	XferFunc     uint16
+	Flags        uint16
+}
+
+func (f *V4L2_Mbus_Framefmt) set(ptr unsafe.Pointer) {
+	p := (*v4l2_mbus_framefmt)(ptr)
+	p.width = __u32(f.Width)
+	p.height = __u32(f.Height)
+	p.code = __u32(f.Code)
+	p.field = __u32(f.Field)
+	p.colorspace = __u32(f.ColorSpace)
+
+	// due to anonymous union, cannot get it's field pointer
+	tmp := (*__u16)(unsafe.Pointer(
+		uintptr(ptr) + offset_mbus_framefmt_encoding))
+	*tmp = __u16(f.Encoding)
+
+	p.quantization = __u16(f.Quantization)
+	p.xfer_func = __u16(f.XferFunc)
+	p.flags = __u16(f.Flags)
+}
+
+func (f *V4L2_Mbus_Framefmt) get(ptr unsafe.Pointer) {
+	p := (*v4l2_mbus_framefmt)(ptr)
+	f.Width = uint32(p.width)
+	f.Height = uint32(p.height)
+	f.Code = uint32(p.code)
+	f.Field = uint32(p.field)
+	f.ColorSpace = uint32(p.colorspace)
+
+	tmp := (*__u16)(unsafe.Pointer(
+		uintptr(ptr) + offset_mbus_framefmt_encoding))
+	f.Encoding = uint16(*tmp)
+
+	f.Quantization = uint16(p.quantization)
+	f.XferFunc = uint16(p.xfer_func)
+	f.Flags = uint16(p.flags)
+}
+
+type V4L2_Subdev_Format struct {
+	Which  uint32
+	Pad    uint32
+	Format V4L2_Mbus_Framefmt
+}
+
+func (f *V4L2_Subdev_Format) set(ptr unsafe.Pointer) {
+	p := (*v4l2_subdev_format)(ptr)
+	p.which = __u32(f.Which)
+	p.pad = __u32(f.Pad)
+	f.Format.set(unsafe.Pointer(&p.format))
+}
+
+func (f *V4L2_Subdev_Format) get(ptr unsafe.Pointer) {
+	p := (*v4l2_subdev_format)(ptr)
+	f.Which = uint32(p.which)
+	f.Pad = uint32(p.pad)
+	f.Format.get(unsafe.Pointer(&p.format))
+}
+
+func ioctlSubdevFormat(fd int, request uint, argp *V4L2_Subdev_Format) error {
+	var format v4l2_subdev_format
+	p := unsafe.Pointer(&format)
+	argp.set(p)
+	err := ioctl(fd, request, p)
+	if err != nil {
+		return err
+	}
+	argp.get(p)
+	return nil
+}
+
+func IoctlSubdevGetFormat(fd int, argp *V4L2_Subdev_Format) error {
+	return ioctlSubdevFormat(fd, VIDIOC_SUBDEV_G_FMT, argp)
+}
+
+func IoctlSubdevSetFormat(fd int, argp *V4L2_Subdev_Format) error {
+	return ioctlSubdevFormat(fd, VIDIOC_SUBDEV_S_FMT, argp)
+}
+
+type V4L2_Subdev_Mbus_Code_Enum struct {
+	Pad   uint32
+	Index uint32
+	Code  uint32
+	Which uint32
+	Flags uint32
+}
+
+func (e *V4L2_Subdev_Mbus_Code_Enum) set(ptr unsafe.Pointer) {
+	p := (*v4l2_subdev_mbus_code_enum)(ptr)
+	p.pad = __u32(e.Pad)
+	p.index = __u32(e.Index)
+	p.which = __u32(e.Which)
+}
+
+func (e *V4L2_Subdev_Mbus_Code_Enum) get(ptr unsafe.Pointer) {
+	p := (*v4l2_subdev_mbus_code_enum)(ptr)
+	e.Code = uint32(p.code)
+	e.Flags = uint32(p.flags)
+}
+
+func IoctlSubdevEnumMbusCode(fd int, argp *V4L2_Subdev_Mbus_Code_Enum) error {
+	var code v4l2_subdev_mbus_code_enum
+	p := unsafe.Pointer(&code)
+	argp.set(p)
+	err := ioctl(fd, VIDIOC_SUBDEV_ENUM_MBUS_CODE, p)
+	if err != nil {
+		return err
+	}
+	argp.get(p)
+	return nil
+}
+
+type V4L2_Subdev_Frame_Size_Enum struct {
+	Index     uint32
+	Pad       uint32
+	Code      uint32
+	MinWidth  uint32
+	MaxWidth  uint32
+	MinHeight uint32
+	MaxHeight uint32
+	Which     uint32
+}
+
+func (e *V4L2_Subdev_Frame_Size_Enum) set(ptr unsafe.Pointer) {
+	p := (*v4l2_subdev_frame_size_enum)(ptr)
+	p.index = __u32(e.Index)
+	p.pad = __u32(e.Pad)
+	p.code = __u32(e.Code)
+	p.which = __u32(e.Which)
+}
+
+func (e *V4L2_Subdev_Frame_Size_Enum) get(ptr unsafe.Pointer) {
+	p := (*v4l2_subdev_frame_size_enum)(ptr)
+	e.MinWidth = uint32(p.min_width)
+	e.MaxWidth = uint32(p.max_width)
+	e.MinHeight = uint32(p.min_height)
+	e.MaxHeight = uint32(p.max_height)
+}
+
+func IoctlSubdevEnumFrameSize(fd int, argp *V4L2_Subdev_Frame_Size_Enum) error {
+	var size v4l2_subdev_frame_size_enum
+	p := unsafe.Pointer(&size)
+	argp.set(p)
+	err := ioctl(fd, VIDIOC_SUBDEV_ENUM_FRAME_SIZE, p)
+	if err != nil {
+		return err
+	}
+	argp.get(p)
+	return nil
+}
+
+type V4L2_Subdev_Selection struct {
+	Which  uint32
+	Pad    uint32
+	Target uint32
+	Flags  uint32
+	R      V4L2_Rect
+}
+
+func (s *V4L2_Subdev_Selection) set(ptr unsafe.Pointer) {
+	p := (*v4l2_subdev_selection)(ptr)
+	p.which = __u32(s.Which)
+	p.pad = __u32(s.Pad)
+	p.target = __u32(s.Target)
+	p.flags = __u32(s.Flags)
+	s.R.set(unsafe.Pointer(&p.r))
+}
+
+func (s *V4L2_Subdev_Selection) get(ptr unsafe.Pointer) {
+	p := (*v4l2_subdev_selection)(ptr)
+	s.Flags = uint32(p.flags)
+	s.R.get(unsafe.Pointer(&p.r))
+}
+
+func ioctlSubdevSelection(fd int, request uint, argp *V4L2_Subdev_Selection) error {
+	var sel v4l2_subdev_selection
+	p := unsafe.Pointer(&sel)
+	argp.set(p)
+	err := ioctl(fd, request, p)
+	if err != nil {
+		return err
+	}
+	argp.get(p)
+	return nil
+}
+
+func IoctlSubdevGetSelection(fd int, argp *V4L2_Subdev_Selection) error {
+	return ioctlSubdevSelection(fd, VIDIOC_SUBDEV_G_SELECTION, argp)
+}
+
+func IoctlSubdevSetSelection(fd int, argp *V4L2_Subdev_Selection) error {
+	return ioctlSubdevSelection(fd, VIDIOC_SUBDEV_S_SELECTION, argp)
+}
+
+type V4L2_Subdev_Frame_Interval struct {
+	Pad      uint32
+	Interval V4L2_Fract
+}
+
+func (f *V4L2_Subdev_Frame_Interval) set(ptr unsafe.Pointer) {
+	p := (*v4l2_subdev_frame_interval)(ptr)
+	p.pad = __u32(f.Pad)
+	f.Interval.set(unsafe.Pointer(&p.interval))
+}
+
+func (f *V4L2_Subdev_Frame_Interval) get(ptr unsafe.Pointer) {
+	p := (*v4l2_subdev_frame_interval)(ptr)
+	f.Interval.get(unsafe.Pointer(&p.interval))
+}
+
+func ioctlSubdevFrameInterval(fd int, request uint, argp *V4L2_Subdev_Frame_Interval) error {
+	var ival v4l2_subdev_frame_interval
+	p := unsafe.Pointer(&ival)
+	argp.set(p)
+	err := ioctl(fd, request, p)
+	if err != nil {
+		return err
+	}
+	argp.get(p)
+	return nil
+}
+
+func IoctlSubdevGetFrameInterval(fd int, argp *V4L2_Subdev_Frame_Interval) error {
+	return ioctlSubdevFrameInterval(fd, VIDIOC_SUBDEV_G_FRAME_INTERVAL, argp)
+}
+
+func IoctlSubdevSetFrameInterval(fd int, argp *V4L2_Subdev_Frame_Interval) error {
+	return ioctlSubdevFrameInterval(fd, VIDIOC_SUBDEV_S_FRAME_INTERVAL, argp)
+}
+
+// SubDevice is a V4L2 sub-device node, e.g. /dev/v4l-subdev0 of a sensor
+// or a bridge. Formats are configured per pad; which is either
+// V4L2_SUBDEV_FORMAT_ACTIVE or V4L2_SUBDEV_FORMAT_TRY. Controls are
+// accessed through the embedded Device.
+type SubDevice struct {
+	Device
+}
+
+func OpenSubDevice(name string) (*SubDevice, error) {
+	d, err := Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &SubDevice{Device: *d}, nil
+}
+
+func (s *SubDevice) GetFormat(pad, which uint32) (V4L2_Mbus_Framefmt, error) {
+	f := V4L2_Subdev_Format{Which: which, Pad: pad}
+	if err := IoctlSubdevGetFormat(s.FD, &f); err != nil {
+		return V4L2_Mbus_Framefmt{}, err
+	}
+	return f.Format, nil
+}
+
+// SetFormat sets the format of pad and returns the format the driver
+// actually applied.
+func (s *SubDevice) SetFormat(pad, which uint32, format V4L2_Mbus_Framefmt) (V4L2_Mbus_Framefmt, error) {
+	f := V4L2_Subdev_Format{Which: which, Pad: pad, Format: format}
+	if err := IoctlSubdevSetFormat(s.FD, &f); err != nil {
+		return V4L2_Mbus_Framefmt{}, err
+	}
+	return f.Format, nil
+}
+
+// EnumMbusCodes returns the media bus codes supported on pad
+func (s *SubDevice) EnumMbusCodes(pad, which uint32) ([]uint32, error) {
+	var codes []uint32
+	for i := uint32(0); ; i++ {
+		e := V4L2_Subdev_Mbus_Code_Enum{Pad: pad, Index: i, Which: which}
+		err := IoctlSubdevEnumMbusCode(s.FD, &e)
+		if err == syscall.EINVAL {
+			return codes, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, e.Code)
+	}
+}
+
+// EnumFrameSizes returns the frame size ranges supported on pad for code
+func (s *SubDevice) EnumFrameSizes(pad, code, which uint32) ([]V4L2_Subdev_Frame_Size_Enum, error) {
+	var sizes []V4L2_Subdev_Frame_Size_Enum
+	for i := uint32(0); ; i++ {
+		e := V4L2_Subdev_Frame_Size_Enum{Index: i, Pad: pad, Code: code, Which: which}
+		err := IoctlSubdevEnumFrameSize(s.FD, &e)
+		if err == syscall.EINVAL {
+			return sizes, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		sizes = append(sizes, e)
+	}
+}
+
+func (s *SubDevice) GetSelection(pad, which, target uint32) (V4L2_Rect, error) {
+	sel := V4L2_Subdev_Selection{Which: which, Pad: pad, Target: target}
+	if err := IoctlSubdevGetSelection(s.FD, &sel); err != nil {
+		return V4L2_Rect{}, err
+	}
+	return sel.R, nil
+}
+
+// SetSelection sets the selection rectangle of target on pad and returns
+// the rectangle the driver actually applied.
+func (s *SubDevice) SetSelection(pad, which, target, flags uint32, r V4L2_Rect) (V4L2_Rect, error) {
+	sel := V4L2_Subdev_Selection{
+		Which:  which,
+		Pad:    pad,
+		Target: target,
+		Flags:  flags,
+		R:      r,
+	}
+	if err := IoctlSubdevSetSelection(s.FD, &sel); err != nil {
+		return V4L2_Rect{}, err
+	}
+	return sel.R, nil
+}
+
+func (s *SubDevice) GetFrameInterval(pad uint32) (V4L2_Fract, error) {
+	f := V4L2_Subdev_Frame_Interval{Pad: pad}
+	if err := IoctlSubdevGetFrameInterval(s.FD, &f); err != nil {
+		return V4L2_Fract{}, err
+	}
+	return f.Interval, nil
+}
+
+// SetFrameInterval sets the frame interval of pad, e.g. 1/30 for 30 fps,
+// and returns the interval the driver actually applied.
+func (s *SubDevice) SetFrameInterval(pad uint32, interval V4L2_Fract) (V4L2_Fract, error) {
+	f := V4L2_Subdev_Frame_Interval{Pad: pad, Interval: interval}
+	if err := IoctlSubdevSetFrameInterval(s.FD, &f); err != nil {
+		return V4L2_Fract{}, err
+	}
+	return f.Interval, nil
+}
+
+// SubDevicePad is a pad of a sub-device
+type SubDevicePad struct {
+	SubDev *SubDevice
+	Pad    uint32
+}
+
+// PropagateFormat sets format on every pad of chain in order, e.g. the
+// source pad of a sensor, then the sink and source pads of a bridge. Each
+// pad is given the format applied on the previous one, so the format the
+// first pad settles on flows down the pipeline. It fails if a pad changes
+// the size or media bus code, as the link to it would not validate, so a
+// scaler must end a chain. It returns the format applied on the last pad.
+func PropagateFormat(chain []SubDevicePad, which uint32, format V4L2_Mbus_Framefmt) (V4L2_Mbus_Framefmt, error) {
+	for i, p := range chain {
+		applied, err := p.SubDev.SetFormat(p.Pad, which, format)
+		if err != nil {
+			return V4L2_Mbus_Framefmt{}, fmt.Errorf("Failed to set format of %s pad %d: %w",
+				p.SubDev.Path, p.Pad, err)
+		}
+		if i > 0 && (applied.Width != format.Width ||
+			applied.Height != format.Height || applied.Code != format.Code) {
+			return applied, fmt.Errorf("%w: %s pad %d: %dx%d 0x%x, want %dx%d 0x%x",
+				ErrorFormatMismatch, p.SubDev.Path, p.Pad,
+				applied.Width, applied.Height, applied.Code,
+				format.Width, format.Height, format.Code)
+		}
+		format = applied
+	}
+	return format, nil
+}
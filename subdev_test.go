@@ -0,0 +1,124 @@
+package v4l2
+
+import (
+	"errors"
+	"strings"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+// fakeSubdev is a Backend serving the pad formats of a sub-device. Each pad
+// applies the non-zero size and code of its entry in fixed, and accepts
+// any other value.
+type fakeSubdev struct {
+	fixed   []V4L2_Mbus_Framefmt
+	formats []V4L2_Mbus_Framefmt
+}
+
+func (s *fakeSubdev) Ioctl(request uint, argp unsafe.Pointer) error {
+	if request != VIDIOC_SUBDEV_G_FMT && request != VIDIOC_SUBDEV_S_FMT {
+		return syscall.ENOTTY
+	}
+	p := (*v4l2_subdev_format)(argp)
+	if int(p.pad) >= len(s.fixed) || p.which != V4L2_SUBDEV_FORMAT_ACTIVE {
+		return syscall.EINVAL
+	}
+	if s.formats == nil {
+		s.formats = make([]V4L2_Mbus_Framefmt, len(s.fixed))
+	}
+
+	f := &s.formats[p.pad]
+	if request == VIDIOC_SUBDEV_S_FMT {
+		f.get(unsafe.Pointer(&p.format))
+		fixed := s.fixed[p.pad]
+		if fixed.Width != 0 {
+			f.Width, f.Height = fixed.Width, fixed.Height
+		}
+		if fixed.Code != 0 {
+			f.Code = fixed.Code
+		}
+	}
+	f.set(unsafe.Pointer(&p.format))
+	return nil
+}
+
+func (s *fakeSubdev) Mmap(int64, int) ([]byte, error) { return nil, syscall.ENODEV }
+func (s *fakeSubdev) Munmap([]byte) error             { return syscall.EINVAL }
+func (s *fakeSubdev) Poll() uint32                    { return 0 }
+func (s *fakeSubdev) Close() error                    { return nil }
+
+func openSubdev(t *testing.T, path string, fixed ...V4L2_Mbus_Framefmt) *SubDevice {
+	t.Helper()
+	d, err := OpenBackend(&fakeSubdev{fixed: fixed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(d.Close)
+	d.Path = path
+	return &SubDevice{Device: *d}
+}
+
+func TestPropagateFormat(t *testing.T) {
+	sensorFmt := V4L2_Mbus_Framefmt{Width: 1920, Height: 1080, Code: MEDIA_BUS_FMT_SRGGB10_1X10}
+	sensor := openSubdev(t, "/dev/v4l-subdev0", sensorFmt)
+	csi := openSubdev(t, "/dev/v4l-subdev1", V4L2_Mbus_Framefmt{}, V4L2_Mbus_Framefmt{})
+	chain := []SubDevicePad{{sensor, 0}, {csi, 0}, {csi, 1}}
+
+	// the first pad may settle on another format, which flows down
+	want := sensorFmt
+	want.Field = V4L2_FIELD_NONE
+	format, err := PropagateFormat(chain, V4L2_SUBDEV_FORMAT_ACTIVE,
+		V4L2_Mbus_Framefmt{Width: 640, Height: 480, Code: MEDIA_BUS_FMT_YUYV8_2X8, Field: V4L2_FIELD_NONE})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != want {
+		t.Errorf("format %+v, want %+v", format, want)
+	}
+	for pad := uint32(0); pad < 2; pad++ {
+		if f, err := csi.GetFormat(pad, V4L2_SUBDEV_FORMAT_ACTIVE); err != nil || f != want {
+			t.Errorf("pad %d: format %+v, %v", pad, f, err)
+		}
+	}
+
+	if _, err := PropagateFormat(chain, V4L2_SUBDEV_FORMAT_TRY, sensorFmt); !errors.Is(err, syscall.EINVAL) ||
+		!strings.Contains(err.Error(), "/dev/v4l-subdev0 pad 0") {
+		t.Errorf("set format failing: %v", err)
+	}
+}
+
+func TestPropagateFormatMismatch(t *testing.T) {
+	sensorFmt := V4L2_Mbus_Framefmt{Width: 1920, Height: 1080, Code: MEDIA_BUS_FMT_SRGGB10_1X10}
+	tests := []struct {
+		name  string
+		fixed V4L2_Mbus_Framefmt // of the source pad of the bridge
+	}{
+		{"code", V4L2_Mbus_Framefmt{Code: MEDIA_BUS_FMT_UYVY8_2X8}},
+		{"size", V4L2_Mbus_Framefmt{Width: 1280, Height: 720}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sensor := openSubdev(t, "/dev/v4l-subdev0", sensorFmt)
+			bridge := openSubdev(t, "/dev/v4l-subdev1", V4L2_Mbus_Framefmt{}, tt.fixed)
+			chain := []SubDevicePad{{sensor, 0}, {bridge, 0}, {bridge, 1}}
+
+			format, err := PropagateFormat(chain, V4L2_SUBDEV_FORMAT_ACTIVE, sensorFmt)
+			if !errors.Is(err, ErrorFormatMismatch) ||
+				!strings.Contains(err.Error(), "/dev/v4l-subdev1 pad 1") {
+				t.Fatalf("error %v", err)
+			}
+
+			// the format the pad changed to is returned
+			want := sensorFmt
+			if tt.fixed.Code != 0 {
+				want.Code = tt.fixed.Code
+			} else {
+				want.Width, want.Height = tt.fixed.Width, tt.fixed.Height
+			}
+			if format != want {
+				t.Errorf("format %+v, want %+v", format, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package v4l2
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// timeInt is time_t, suseconds_t or long of any of the time layouts of the
+// kernel ABI
+type timeInt interface {
+	~int32 | ~int64
+}
+
+// timevalDuration returns the timeval sec, usec as a duration
+func timevalDuration[S, U timeInt](sec S, usec U) time.Duration {
+	return time.Duration(sec)*time.Second + time.Duration(usec)*time.Microsecond
+}
+
+// setTimeval stores d as the timeval *sec, *usec
+func setTimeval[S, U timeInt](sec *S, usec *U, d time.Duration) {
+	s, us := d/time.Second, d%time.Second/time.Microsecond
+	if us < 0 {
+		s, us = s-1, us+1000000
+	}
+	*sec, *usec = S(s), U(us)
+}
+
+// timespecDuration returns the timespec sec, nsec as a duration
+func timespecDuration[S, N timeInt](sec S, nsec N) time.Duration {
+	return time.Duration(sec)*time.Second + time.Duration(nsec)
+}
+
+// setTimespec stores d as the timespec *sec, *nsec
+func setTimespec[S, N timeInt](sec *S, nsec *N, d time.Duration) {
+	s, ns := d/time.Second, d%time.Second
+	if ns < 0 {
+		s, ns = s-1, ns+time.Second
+	}
+	*sec, *nsec = S(s), N(ns)
+}
+
+// MonotonicTime returns the wall clock time of ts, a time of the
+// CLOCK_MONOTONIC clock as the TimeStamp of events and of buffers with
+// V4L2_BUF_FLAG_TIMESTAMP_MONOTONIC are.
+func MonotonicTime(ts time.Duration) time.Time {
+	return time.Now().Add(ts - monotonicNow())
+}
+
+// monotonicNow returns the current time of the CLOCK_MONOTONIC clock
+func monotonicNow() time.Duration {
+	const clockMonotonic = 1
+	var now syscall.Timespec
+	syscall.Syscall(syscall.SYS_CLOCK_GETTIME, clockMonotonic, uintptr(unsafe.Pointer(&now)), 0)
+	return time.Duration(now.Nano())
+}
@@ -0,0 +1,114 @@
+//go:build 386 || arm || mips || mipsle
+
+package v4l2
+
+import (
+	"errors"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+)
+
+// On 32-bit ABIs v4l2_buffer and v4l2_event have the layouts of 64-bit
+// time_t, which kernels before 5.6 do not know. Their ioctls fail with
+// ENOTTY there, and are retried with the layouts of 32-bit time_t under the
+// _TIME32 ioctls, which all kernels take. Once that works the time64 ioctls
+// are not tried any more.
+var time32 atomic.Bool
+
+// bufferIoctl issues an ioctl taking a v4l2_buffer in the layout the
+// kernel knows
+func bufferIoctl(fd int, request uint, p *v4l2_buffer) error {
+	if !time32.Load() {
+		err := ioctl(fd, request, unsafe.Pointer(p))
+		if !errors.Is(err, syscall.ENOTTY) {
+			return err
+		}
+	}
+	var p32 v4l2_buffer_time32
+	p32.set(p)
+	if err := ioctl(fd, bufferRequestTime32(request), unsafe.Pointer(&p32)); err != nil {
+		return err
+	}
+	time32.Store(true)
+	p32.get(p)
+	return nil
+}
+
+func bufferRequestTime32(request uint) uint {
+	switch request {
+	case VIDIOC_QUERYBUF:
+		return vidioc_querybuf_time32
+	case VIDIOC_QBUF:
+		return vidioc_qbuf_time32
+	case VIDIOC_DQBUF:
+		return vidioc_dqbuf_time32
+	}
+	return request
+}
+
+// eventIoctl issues an ioctl taking a v4l2_event in the layout the kernel
+// knows
+func eventIoctl(fd int, request uint, p *v4l2_event) error {
+	if !time32.Load() {
+		err := ioctl(fd, request, unsafe.Pointer(p))
+		if !errors.Is(err, syscall.ENOTTY) {
+			return err
+		}
+	}
+	var p32 v4l2_event_time32
+	if request == VIDIOC_DQEVENT {
+		request = vidioc_dqevent_time32
+	}
+	if err := ioctl(fd, request, unsafe.Pointer(&p32)); err != nil {
+		return err
+	}
+	time32.Store(true)
+	p32.get(p)
+	return nil
+}
+
+func (b *v4l2_buffer_time32) set(p *v4l2_buffer) {
+	b.index = p.index
+	b._type = p._type
+	b.bytesused = p.bytesused
+	b.flags = p.flags
+	b.field = p.field
+	setTimeval(&b.timestamp.tv_sec, &b.timestamp.tv_usec,
+		timevalDuration(p.timestamp.tv_sec, p.timestamp.tv_usec))
+	b.timecode = p.timecode
+	b.sequence = p.sequence
+	b.memory = p.memory
+	b.m = p.m
+	b.length = p.length
+	b.reserved2 = p.reserved2
+	b.anon0 = p.anon0
+}
+
+func (b *v4l2_buffer_time32) get(p *v4l2_buffer) {
+	p.index = b.index
+	p._type = b._type
+	p.bytesused = b.bytesused
+	p.flags = b.flags
+	p.field = b.field
+	setTimeval(&p.timestamp.tv_sec, &p.timestamp.tv_usec,
+		timevalDuration(b.timestamp.tv_sec, b.timestamp.tv_usec))
+	p.timecode = b.timecode
+	p.sequence = b.sequence
+	p.memory = b.memory
+	p.m = b.m
+	p.length = b.length
+	p.reserved2 = b.reserved2
+	p.anon0 = b.anon0
+}
+
+func (e *v4l2_event_time32) get(p *v4l2_event) {
+	p._type = e._type
+	p.u = e.u
+	p.pending = e.pending
+	p.sequence = e.sequence
+	setTimespec(&p.timestamp.tv_sec, &p.timestamp.tv_nsec,
+		timespecDuration(e.timestamp.tv_sec, e.timestamp.tv_nsec))
+	p.id = e.id
+	p.reserved = e.reserved
+}
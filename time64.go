@@ -0,0 +1,16 @@
+//go:build !(386 || arm || mips || mipsle)
+
+package v4l2
+
+import "unsafe"
+
+// bufferIoctl issues an ioctl taking a v4l2_buffer, whose timeval has a
+// single layout on 64-bit ABIs
+func bufferIoctl(fd int, request uint, p *v4l2_buffer) error {
+	return ioctl(fd, request, unsafe.Pointer(p))
+}
+
+// eventIoctl issues an ioctl taking a v4l2_event
+func eventIoctl(fd int, request uint, p *v4l2_event) error {
+	return ioctl(fd, request, unsafe.Pointer(p))
+}
@@ -0,0 +1,590 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// headers defining the integer types and the ioctl macros, their types are
+// built in
+var skipHeaders = map[string]bool{
+	"linux/types.h":        true,
+	"linux/ioctl.h":        true,
+	"linux/posix_types.h":  true,
+	"linux/const.h":        true,
+	"linux/compiler.h":     true,
+	"linux/compiler_types": true,
+}
+
+const (
+	kindScalar = iota
+	kindPointer
+	kindArray
+	kindStruct
+	kindUnion
+)
+
+// ctype is a C type of a header
+type ctype struct {
+	kind   int
+	name   string // scalar name or struct tag, empty for anonymous structs
+	elem   *ctype // of pointers and arrays
+	n      int    // of arrays
+	fields []cfield
+	packed bool
+	done   bool // the body of a struct was seen
+}
+
+type cfield struct {
+	name string // empty for anonymous members
+	t    *ctype
+}
+
+// scalars by their name, sizes and signs are those of the abi
+var scalars = map[string]*ctype{}
+
+func scalar(name string) *ctype {
+	t, ok := scalars[name]
+	if !ok {
+		t = &ctype{kind: kindScalar, name: name}
+		scalars[name] = t
+	}
+	return t
+}
+
+// builtin typedefs of linux/types.h and asm-generic/int-ll64.h
+var builtinTypedefs = map[string]string{
+	"__u8": "uchar", "__s8": "schar",
+	"__u16": "ushort", "__s16": "short", "__le16": "ushort", "__be16": "ushort",
+	"__u32": "uint", "__s32": "int", "__le32": "uint", "__be32": "uint",
+	"__u64": "ullong", "__s64": "llong", "__le64": "ullong", "__be64": "ullong",
+	"__kernel_long_t": "long", "__kernel_ulong_t": "ulong",
+	"__kernel_size_t": "ulong", "__kernel_ssize_t": "long",
+	"size_t": "ulong", "time_t": "llong", "suseconds_t": "llong",
+}
+
+// headerParser collects the macros, typedefs and structs of a set of headers
+type headerParser struct {
+	dir      string
+	included map[string]bool
+	defines  map[string]string
+	typedefs map[string]*ctype
+	structs  map[string]*ctype // "struct x" or "union x"
+
+	// funcs evaluates the function-like macros of the skipped headers,
+	// e.g. _IOWR, from their arguments
+	funcs map[string]func(args [][]string) (int64, error)
+}
+
+func newParser(dir string) *headerParser {
+	p := &headerParser{
+		dir:      dir,
+		included: map[string]bool{},
+		defines:  map[string]string{},
+		typedefs: map[string]*ctype{},
+		structs:  map[string]*ctype{},
+		funcs:    map[string]func([][]string) (int64, error){},
+	}
+	for name, s := range builtinTypedefs {
+		p.typedefs[name] = scalar(s)
+	}
+	// the userspace definitions of <sys/time.h> and <time.h> with 64-bit
+	// time_t, as the kernel takes them from 32-bit userspace too, see
+	// __kernel_v4l2_timeval and __kernel_timespec
+	p.structs["struct timeval"] = &ctype{kind: kindStruct, name: "timeval", done: true,
+		fields: []cfield{{"tv_sec", scalar("llong")}, {"tv_usec", scalar("llong")}}}
+	p.structs["struct timespec"] = &ctype{kind: kindStruct, name: "timespec", done: true,
+		fields: []cfield{{"tv_sec", scalar("llong")}, {"tv_nsec", scalar("llong")}}}
+	return p
+}
+
+var (
+	reComments = regexp.MustCompile(`(?s)/\*.*?\*/|//[^\n]*`)
+	reInclude  = regexp.MustCompile(`^#\s*include\s*<([^>]+)>`)
+	reDefine   = regexp.MustCompile(`^#\s*define\s+(\w+)(\(?)\s*(.*)$`)
+)
+
+// include parses the header name, relative to the include directory
+func (p *headerParser) include(name string) error {
+	if p.included[name] || skipHeaders[name] {
+		return nil
+	}
+	p.included[name] = true
+	if !strings.HasPrefix(name, "linux/") {
+		// <sys/time.h> and friends, their structs are built in
+		return nil
+	}
+	b, err := os.ReadFile(filepath.Join(p.dir, name))
+	if err != nil {
+		return err
+	}
+	return p.parse(name, string(b))
+}
+
+// parse parses the C source src of the file name
+func (p *headerParser) parse(name, src string) error {
+	src = strings.ReplaceAll(src, "\\\n", " ")
+	src = reComments.ReplaceAllStringFunc(src, func(c string) string {
+		// keep the lines of directives apart
+		return strings.Repeat("\n", strings.Count(c, "\n")) + " "
+	})
+
+	var code strings.Builder
+	for _, l := range strings.Split(src, "\n") {
+		t := strings.TrimSpace(l)
+		if !strings.HasPrefix(t, "#") {
+			code.WriteString(l)
+			code.WriteByte('\n')
+			continue
+		}
+		if m := reInclude.FindStringSubmatch(t); m != nil {
+			if err := p.include(m[1]); err != nil {
+				return err
+			}
+		} else if m := reDefine.FindStringSubmatch(t); m != nil && m[2] == "" {
+			p.defines[m[1]] = strings.TrimSpace(m[3])
+		}
+	}
+	toks := tokenize(code.String())
+	if err := p.parseDecls(toks); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+var reToken = regexp.MustCompile(`[A-Za-z_]\w*|0[xX][0-9a-fA-F]+[uUlL]*|\d+[uUlL]*|'(?:\\.|[^'])'|"(?:\\.|[^"])*"|<<|>>|\S`)
+
+func tokenize(s string) []string {
+	return reToken.FindAllString(s, -1)
+}
+
+// parseDecls parses the top level declarations of a header
+func (p *headerParser) parseDecls(toks []string) error {
+	for i := 0; i < len(toks); {
+		switch toks[i] {
+		case "struct", "union":
+			if i+2 < len(toks) && toks[i+2] == "{" {
+				t, n, err := p.parseType(toks[i:])
+				if err != nil {
+					return err
+				}
+				i += n
+				_ = t
+			}
+		case "typedef":
+			t, n, err := p.parseType(toks[i+1:])
+			if err != nil {
+				return err
+			}
+			i += 1 + n
+			name, dt, n, err := p.parseDeclarator(toks[i:], t)
+			if err != nil {
+				return err
+			}
+			i += n
+			p.typedefs[name] = dt
+		}
+		i = skipStatement(toks, i)
+	}
+	return nil
+}
+
+// skipStatement returns the index after the ; ending the statement at i,
+// or after the } ending the body of an inline function
+func skipStatement(toks []string, i int) int {
+	depth := 0
+	for ; i < len(toks); i++ {
+		switch toks[i] {
+		case "{", "(", "[":
+			depth++
+		case ")", "]":
+			depth--
+		case "}":
+			depth--
+			if depth == 0 && i+1 < len(toks) && toks[i+1] != ";" && toks[i+1] != "__attribute__" {
+				return i + 1
+			}
+		case ";":
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return i
+}
+
+var qualifiers = map[string]bool{
+	"const": true, "volatile": true, "__user": true, "__extension__": true,
+}
+
+// parseType parses a type specifier, returning the tokens it took
+func (p *headerParser) parseType(toks []string) (*ctype, int, error) {
+	i := 0
+	for i < len(toks) && qualifiers[toks[i]] {
+		i++
+	}
+	if i >= len(toks) {
+		return nil, 0, fmt.Errorf("unexpected end of declaration")
+	}
+	switch kw := toks[i]; kw {
+	case "struct", "union":
+		i++
+		tag := ""
+		if i < len(toks) && toks[i] != "{" {
+			tag = toks[i]
+			i++
+		}
+		var t *ctype
+		if tag != "" {
+			key := kw + " " + tag
+			t = p.structs[key]
+			if t == nil {
+				t = &ctype{name: tag}
+				p.structs[key] = t
+			}
+		} else {
+			t = &ctype{}
+		}
+		t.kind = kindStruct
+		if kw == "union" {
+			t.kind = kindUnion
+		}
+		if i < len(toks) && toks[i] == "{" {
+			n, err := p.parseFields(toks[i+1:], t)
+			if err != nil {
+				return nil, 0, fmt.Errorf("%s %s: %w", kw, tag, err)
+			}
+			i += 1 + n
+			t.done = true
+			// } __attribute__ ((packed))
+			for i < len(toks) && toks[i] == "__attribute__" {
+				end := skipParens(toks, i+1)
+				if strings.Contains(strings.Join(toks[i:end], " "), "packed") {
+					t.packed = true
+				}
+				i = end
+			}
+		}
+		return t, i, nil
+	case "enum":
+		i++
+		if i < len(toks) && toks[i] != "{" {
+			i++
+		}
+		if i < len(toks) && toks[i] == "{" {
+			for i < len(toks) && toks[i] != "}" {
+				i++
+			}
+			i++
+		}
+		return scalar("uint"), i, nil
+	}
+
+	// base types and typedef names
+	var words []string
+	for ; i < len(toks); i++ {
+		w := toks[i]
+		if qualifiers[w] {
+			continue
+		}
+		if _, ok := p.typedefs[w]; ok && len(words) == 0 {
+			words = append(words, w)
+			i++
+			break
+		}
+		switch w {
+		case "signed", "unsigned", "char", "short", "int", "long", "void":
+			words = append(words, w)
+			continue
+		}
+		break
+	}
+	if len(words) == 0 {
+		return nil, 0, fmt.Errorf("unknown type %q", toks[i])
+	}
+	if t, ok := p.typedefs[words[0]]; ok {
+		return t, i, nil
+	}
+	t, err := baseType(words)
+	return t, i, err
+}
+
+func skipParens(toks []string, i int) int {
+	depth := 0
+	for ; i < len(toks); i++ {
+		switch toks[i] {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return i
+}
+
+// baseType returns the scalar of the words of a C base type
+func baseType(words []string) (*ctype, error) {
+	unsigned, signed, long := false, false, 0
+	base := "int"
+	for _, w := range words {
+		switch w {
+		case "unsigned":
+			unsigned = true
+		case "signed":
+			signed = true
+		case "long":
+			long++
+		case "char", "short", "void":
+			base = w
+		}
+	}
+	switch {
+	case base == "void":
+		return scalar("void"), nil
+	case base == "char" && unsigned:
+		return scalar("uchar"), nil
+	case base == "char" && signed:
+		return scalar("schar"), nil
+	case base == "char":
+		return scalar("char"), nil
+	case base == "short":
+		return scalar(prefix(unsigned) + "short"), nil
+	case long == 1:
+		return scalar(prefix(unsigned) + "long"), nil
+	case long == 2:
+		return scalar(prefix(unsigned) + "llong"), nil
+	}
+	return scalar(prefix(unsigned) + "int"), nil
+}
+
+func prefix(unsigned bool) string {
+	if unsigned {
+		return "u"
+	}
+	return ""
+}
+
+// parseFields parses the members of a struct or union up to its }
+func (p *headerParser) parseFields(toks []string, t *ctype) (int, error) {
+	i := 0
+	for i < len(toks) && toks[i] != "}" {
+		ft, n, err := p.parseType(toks[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n
+		if toks[i] == ";" {
+			// anonymous struct or union
+			t.fields = append(t.fields, cfield{t: ft})
+			i++
+			continue
+		}
+		for {
+			name, dt, n, err := p.parseDeclarator(toks[i:], ft)
+			if err != nil {
+				return 0, err
+			}
+			i += n
+			t.fields = append(t.fields, cfield{name, dt})
+			if toks[i] == "," {
+				i++
+				continue
+			}
+			if toks[i] != ";" {
+				return 0, fmt.Errorf("unexpected %q after %s", toks[i], name)
+			}
+			i++
+			break
+		}
+	}
+	return i + 1, nil
+}
+
+// parseDeclarator parses pointers, the name and array dimensions of a
+// declaration of type t
+func (p *headerParser) parseDeclarator(toks []string, t *ctype) (string, *ctype, int, error) {
+	i := 0
+	for toks[i] == "*" || qualifiers[toks[i]] {
+		if toks[i] == "*" {
+			t = &ctype{kind: kindPointer, elem: t}
+		}
+		i++
+	}
+	name := toks[i]
+	i++
+	var dims []int
+	for i < len(toks) && toks[i] == "[" {
+		end := i + 1
+		for toks[end] != "]" {
+			end++
+		}
+		n, err := p.eval(toks[i+1 : end])
+		if err != nil {
+			return "", nil, 0, fmt.Errorf("%s: %w", name, err)
+		}
+		dims = append(dims, int(n))
+		i = end + 1
+	}
+	for d := len(dims) - 1; d >= 0; d-- {
+		t = &ctype{kind: kindArray, elem: t, n: dims[d]}
+	}
+	return name, t, i, nil
+}
+
+// eval evaluates the integer constant expression toks, expanding macros
+func (p *headerParser) eval(toks []string) (int64, error) {
+	e := &evaluator{p: p, toks: p.expand(toks, 0)}
+	v, err := e.expr(0)
+	if err == nil && e.i != len(e.toks) {
+		err = fmt.Errorf("unexpected %q", e.toks[e.i])
+	}
+	return v, err
+}
+
+func (p *headerParser) expand(toks []string, depth int) []string {
+	var out []string
+	for _, t := range toks {
+		if d, ok := p.defines[t]; ok && depth < 16 {
+			out = append(out, p.expand(tokenize(d), depth+1)...)
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+type evaluator struct {
+	p    *headerParser
+	toks []string
+	i    int
+}
+
+var precedence = map[string]int{
+	"|": 1, "^": 2, "&": 3, "<<": 4, ">>": 4, "+": 5, "-": 5, "*": 6, "/": 6, "%": 6,
+}
+
+func (e *evaluator) expr(min int) (int64, error) {
+	v, err := e.unary()
+	if err != nil {
+		return 0, err
+	}
+	for e.i < len(e.toks) {
+		op := e.toks[e.i]
+		prec, ok := precedence[op]
+		if !ok || prec <= min {
+			break
+		}
+		e.i++
+		r, err := e.expr(prec)
+		if err != nil {
+			return 0, err
+		}
+		switch op {
+		case "|":
+			v |= r
+		case "^":
+			v ^= r
+		case "&":
+			v &= r
+		case "<<":
+			v <<= uint(r)
+		case ">>":
+			v >>= uint(r)
+		case "+":
+			v += r
+		case "-":
+			v -= r
+		case "*":
+			v *= r
+		case "/", "%":
+			if r == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			if op == "/" {
+				v /= r
+			} else {
+				v %= r
+			}
+		}
+	}
+	return v, nil
+}
+
+func (e *evaluator) unary() (int64, error) {
+	if e.i >= len(e.toks) {
+		return 0, fmt.Errorf("unexpected end of expression")
+	}
+	t := e.toks[e.i]
+	e.i++
+	switch t {
+	case "(":
+		v, err := e.expr(0)
+		if err != nil {
+			return 0, err
+		}
+		if e.i >= len(e.toks) || e.toks[e.i] != ")" {
+			return 0, fmt.Errorf("missing )")
+		}
+		e.i++
+		return v, nil
+	case "-":
+		v, err := e.unary()
+		return -v, err
+	case "~":
+		v, err := e.unary()
+		return ^v, err
+	}
+	if f, ok := e.p.funcs[t]; ok {
+		args, err := e.args()
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", t, err)
+		}
+		return f(args)
+	}
+	if strings.HasPrefix(t, "'") {
+		r, _, _, err := strconv.UnquoteChar(t[1:len(t)-1], '\'')
+		return int64(r), err
+	}
+	v, err := strconv.ParseInt(strings.TrimRight(t, "uUlL"), 0, 64)
+	if err != nil {
+		return 0, fmt.Errorf("not a constant: %q", t)
+	}
+	return v, nil
+}
+
+// args returns the arguments of a function-like macro, split at the commas
+// outside parentheses
+func (e *evaluator) args() ([][]string, error) {
+	if e.i >= len(e.toks) || e.toks[e.i] != "(" {
+		return nil, fmt.Errorf("missing (")
+	}
+	end := skipParens(e.toks, e.i)
+	if e.toks[end-1] != ")" {
+		return nil, fmt.Errorf("missing )")
+	}
+	var args [][]string
+	depth, start := 0, e.i+1
+	for j := start; j < end-1; j++ {
+		switch e.toks[j] {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		case ",":
+			if depth == 0 {
+				args = append(args, e.toks[start:j])
+				start = j + 1
+			}
+		}
+	}
+	args = append(args, e.toks[start:end-1])
+	e.i = end
+	return args, nil
+}
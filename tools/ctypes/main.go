@@ -0,0 +1,630 @@
+// Command ctypes generates the Go definitions of the kernel structs and
+// ioctls a package wraps, laid out and encoded as the kernel ABI of a
+// GOARCH does, so the package builds without cgo and for any GOARCH from
+// any host. e.g.
+//
+//	go run ./tools/ctypes -arch arm -o ztypes_linux_arm.go types_cgo.go
+//
+// The input is the cgo variant of the definitions: its preamble is parsed
+// along with the linux headers it includes and each alias of a C struct,
+// e.g.
+//
+//	type v4l2_format = C.struct_v4l2_format
+//
+// is generated as a Go struct of the same name, with the field names cgo
+// gives the fields. Unions are byte arrays as in cgo, anonymous members are
+// named anon0, anon1 etc. and fields named after a Go keyword get a leading
+// _. The constants of the input are generated with their values for the
+// GOARCH, they may be
+//
+//	sizeof_v4l2_format = C.sizeof_struct_v4l2_format
+//	offset_format_type = unsafe.Offsetof(v4l2_format{}._type)
+//	VIDIOC_S_FMT       = C.VIDIOC_S_FMT
+//
+// where a macro may use the _IO, _IOR, _IOW and _IOWR macros of the ioctl
+// encoding of the GOARCH.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var archFlag = flag.String("arch", "amd64", "GOARCH to lay the structs out for")
+var output = flag.String("o", "", "generated go source, stdout if empty")
+var includeDir = flag.String("I", "/usr/include", "directory of the linux headers")
+
+// abi describes the C data model of a GOARCH and how Go aligns the same
+// fields
+type abi struct {
+	ptrSize    int  // pointers and long
+	align64    int  // alignment of 64-bit integers in C
+	goAlign64  int  // alignment of 64-bit integers in Go
+	charSigned bool // plain char is signed
+	ioc        iocEncoding
+}
+
+// iocEncoding is the layout of the ioctl request codes, see
+// <asm-generic/ioctl.h> and the <asm/ioctl.h> overriding it
+type iocEncoding struct {
+	sizeBits          int
+	none, read, write int64
+}
+
+var (
+	iocGeneric = iocEncoding{sizeBits: 14, none: 0, read: 2, write: 1}
+	// powerpc and mips have a third direction bit
+	iocPowerMIPS = iocEncoding{sizeBits: 13, none: 1, read: 2, write: 4}
+)
+
+var abis = map[string]abi{
+	"386":     {ptrSize: 4, align64: 4, goAlign64: 4, charSigned: true, ioc: iocGeneric},
+	"amd64":   {ptrSize: 8, align64: 8, goAlign64: 8, charSigned: true, ioc: iocGeneric},
+	"arm":     {ptrSize: 4, align64: 8, goAlign64: 4, charSigned: false, ioc: iocGeneric},
+	"arm64":   {ptrSize: 8, align64: 8, goAlign64: 8, charSigned: false, ioc: iocGeneric},
+	"mips":    {ptrSize: 4, align64: 8, goAlign64: 4, charSigned: true, ioc: iocPowerMIPS},
+	"mipsle":  {ptrSize: 4, align64: 8, goAlign64: 4, charSigned: true, ioc: iocPowerMIPS},
+	"ppc64le": {ptrSize: 8, align64: 8, goAlign64: 8, charSigned: false, ioc: iocPowerMIPS},
+	"riscv64": {ptrSize: 8, align64: 8, goAlign64: 8, charSigned: false, ioc: iocGeneric},
+}
+
+// ioc encodes a request code as the _IOC macro does
+func (e iocEncoding) ioc(dir, typ, nr, size int64) (int64, error) {
+	if size >= 1<<e.sizeBits {
+		return 0, fmt.Errorf("argument of %d bytes too large for an ioctl", size)
+	}
+	return dir<<(16+e.sizeBits) | size<<16 | typ<<8 | nr, nil
+}
+
+// input is what the cgo file tells
+type input struct {
+	pkg      string
+	preamble string
+	includes []string
+	structs  []string // tags of the aliased structs
+	consts   []constDecl
+}
+
+// constDecl is a const declaration of the input
+type constDecl struct {
+	doc  []string // comment lines
+	defs []constDef
+}
+
+// constDef is a constant of the input, one of
+//
+//	name = C.sizeof_struct_tag
+//	name = unsafe.Offsetof(typ{}.field)
+//	name = C.macro
+type constDef struct {
+	doc, comment []string
+	name         string
+	sizeof       string
+	typ, field   string
+	macro        string
+}
+
+var reIncludeLine = regexp.MustCompile(`#include\s*<([^>]+)>`)
+
+func readInput(name string) (*input, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, name, nil, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	in := &input{pkg: f.Name.Name}
+	for _, d := range f.Decls {
+		g, ok := d.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		if g.Tok == token.IMPORT && g.Doc != nil {
+			for _, m := range reIncludeLine.FindAllStringSubmatch(g.Doc.Text(), -1) {
+				in.includes = append(in.includes, m[1])
+			}
+			in.preamble = g.Doc.Text()
+		}
+		if g.Tok == token.CONST {
+			defs, err := readConsts(fset, g)
+			if err != nil {
+				return nil, err
+			}
+			in.consts = append(in.consts, constDecl{comments(g.Doc), defs})
+			continue
+		}
+		if g.Tok != token.TYPE {
+			continue
+		}
+		for _, s := range g.Specs {
+			ts := s.(*ast.TypeSpec)
+			sel, ok := ts.Type.(*ast.SelectorExpr)
+			if !ok || !ts.Assign.IsValid() {
+				continue
+			}
+			if x, ok := sel.X.(*ast.Ident); !ok || x.Name != "C" {
+				continue
+			}
+			tag, ok := strings.CutPrefix(sel.Sel.Name, "struct_")
+			if !ok {
+				continue
+			}
+			if tag != ts.Name.Name {
+				return nil, fmt.Errorf("%s: alias %s of struct %s must be named after it",
+					fset.Position(ts.Pos()), ts.Name.Name, tag)
+			}
+			in.structs = append(in.structs, tag)
+		}
+	}
+	if len(in.includes) == 0 {
+		return nil, fmt.Errorf("%s: no #include in the cgo preamble", name)
+	}
+	return in, nil
+}
+
+func readConsts(fset *token.FileSet, g *ast.GenDecl) ([]constDef, error) {
+	var defs []constDef
+	for _, s := range g.Specs {
+		vs := s.(*ast.ValueSpec)
+		if len(vs.Names) != 1 || len(vs.Values) != 1 {
+			return nil, fmt.Errorf("%s: one constant per line", fset.Position(vs.Pos()))
+		}
+		d := constDef{doc: comments(vs.Doc), comment: comments(vs.Comment), name: vs.Names[0].Name}
+		switch v := vs.Values[0].(type) {
+		case *ast.SelectorExpr:
+			if x, ok := v.X.(*ast.Ident); ok && x.Name == "C" {
+				if tag, ok := strings.CutPrefix(v.Sel.Name, "sizeof_struct_"); ok {
+					d.sizeof = tag
+				} else {
+					d.macro = v.Sel.Name
+				}
+			}
+		case *ast.CallExpr:
+			// unsafe.Offsetof(typ{}.field)
+			if sel, ok := v.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Offsetof" && len(v.Args) == 1 {
+				if f, ok := v.Args[0].(*ast.SelectorExpr); ok {
+					if lit, ok := f.X.(*ast.CompositeLit); ok {
+						if id, ok := lit.Type.(*ast.Ident); ok {
+							d.typ, d.field = id.Name, f.Sel.Name
+						}
+					}
+				}
+			}
+		}
+		if d.macro == "" && d.sizeof == "" && d.field == "" {
+			return nil, fmt.Errorf("%s: unsupported value of %s", fset.Position(vs.Pos()), d.name)
+		}
+		defs = append(defs, d)
+	}
+	return defs, nil
+}
+
+func comments(g *ast.CommentGroup) []string {
+	if g == nil {
+		return nil
+	}
+	var lines []string
+	for _, c := range g.List {
+		lines = append(lines, c.Text)
+	}
+	return lines
+}
+
+// layout is the C layout of a struct or union
+type layout struct {
+	size, align int
+	offsets     []int
+}
+
+// goStruct is a struct as written in Go
+type goStruct struct {
+	name   string
+	fields []goField
+	align  int
+}
+
+type goField struct {
+	name, typ string
+	off       int
+}
+
+type generator struct {
+	abi      abi
+	p        *headerParser
+	layouts  map[*ctype]*layout
+	emitted  map[*ctype]*goStruct
+	usesPtr  bool
+	building map[*ctype]bool
+}
+
+func (g *generator) scalarSize(name string) int {
+	switch name {
+	case "char", "schar", "uchar":
+		return 1
+	case "short", "ushort":
+		return 2
+	case "int", "uint":
+		return 4
+	case "long", "ulong":
+		return g.abi.ptrSize
+	case "llong", "ullong":
+		return 8
+	}
+	return 0
+}
+
+func (g *generator) goScalar(name string) string {
+	size := g.scalarSize(name)
+	switch name {
+	case "char":
+		if g.abi.charSigned {
+			return "int8"
+		}
+		return "uint8"
+	case "uchar", "ushort", "uint", "ulong", "ullong":
+		return fmt.Sprintf("uint%d", size*8)
+	}
+	return fmt.Sprintf("int%d", size*8)
+}
+
+// sizeAlign returns the C size and alignment of t
+func (g *generator) sizeAlign(t *ctype) (int, int, error) {
+	switch t.kind {
+	case kindScalar:
+		size := g.scalarSize(t.name)
+		if size == 0 {
+			return 0, 0, fmt.Errorf("no size of %s", t.name)
+		}
+		if size == 8 {
+			return 8, g.abi.align64, nil
+		}
+		return size, size, nil
+	case kindPointer:
+		return g.abi.ptrSize, g.abi.ptrSize, nil
+	case kindArray:
+		size, align, err := g.sizeAlign(t.elem)
+		return size * t.n, align, err
+	}
+	l, err := g.layout(t)
+	if err != nil {
+		return 0, 0, err
+	}
+	return l.size, l.align, nil
+}
+
+func (g *generator) layout(t *ctype) (*layout, error) {
+	if l, ok := g.layouts[t]; ok {
+		return l, nil
+	}
+	if !t.done {
+		return nil, fmt.Errorf("struct %s is incomplete", t.name)
+	}
+	l := &layout{align: 1}
+	off := 0
+	for _, f := range t.fields {
+		size, align, err := g.sizeAlign(f.t)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", t.name, f.name, err)
+		}
+		if t.packed {
+			align = 1
+		}
+		l.align = max(l.align, align)
+		if t.kind == kindUnion {
+			l.offsets = append(l.offsets, 0)
+			off = max(off, size)
+			continue
+		}
+		off = roundUp(off, align)
+		l.offsets = append(l.offsets, off)
+		off += size
+	}
+	l.size = roundUp(off, l.align)
+	g.layouts[t] = l
+	return l, nil
+}
+
+func roundUp(n, align int) int {
+	return (n + align - 1) / align * align
+}
+
+// goType returns the Go type of a field of type t and its Go alignment
+func (g *generator) goType(t *ctype) (string, int, error) {
+	switch t.kind {
+	case kindScalar:
+		size, _, err := g.sizeAlign(t)
+		if err != nil {
+			return "", 0, err
+		}
+		if size == 8 {
+			return g.goScalar(t.name), g.abi.goAlign64, nil
+		}
+		return g.goScalar(t.name), size, nil
+	case kindPointer:
+		e := t.elem
+		switch {
+		case e.kind == kindStruct && e.name != "":
+			if _, err := g.emit(e); err != nil {
+				return "", 0, err
+			}
+			return "*" + e.name, g.abi.ptrSize, nil
+		case e.kind == kindScalar && e.name != "void":
+			return "*" + g.goScalar(e.name), g.abi.ptrSize, nil
+		}
+		g.usesPtr = true
+		return "unsafe.Pointer", g.abi.ptrSize, nil
+	case kindArray:
+		s, align, err := g.goType(t.elem)
+		return fmt.Sprintf("[%d]%s", t.n, s), align, err
+	case kindStruct:
+		if t.name == "" {
+			return "", 0, fmt.Errorf("anonymous struct members are not supported")
+		}
+		s, err := g.emit(t)
+		if err != nil {
+			return "", 0, err
+		}
+		return t.name, s.align, nil
+	}
+	size, _, err := g.sizeAlign(t)
+	return fmt.Sprintf("[%d]byte", size), 1, err
+}
+
+var keywords = map[string]bool{
+	"break": true, "case": true, "chan": true, "const": true, "continue": true,
+	"default": true, "defer": true, "else": true, "fallthrough": true,
+	"for": true, "func": true, "go": true, "goto": true, "if": true,
+	"import": true, "interface": true, "map": true, "package": true,
+	"range": true, "return": true, "select": true, "struct": true,
+	"switch": true, "type": true, "var": true,
+}
+
+// emit lays out the struct t in Go, padding it to the C layout
+func (g *generator) emit(t *ctype) (*goStruct, error) {
+	if s, ok := g.emitted[t]; ok {
+		return s, nil
+	}
+	if g.building[t] {
+		// a pointer to a struct being laid out, e.g. a list
+		return &goStruct{name: t.name, align: 1}, nil
+	}
+	g.building[t] = true
+	defer delete(g.building, t)
+
+	l, err := g.layout(t)
+	if err != nil {
+		return nil, err
+	}
+	s := &goStruct{name: t.name, align: 1}
+	off, anon, pad := 0, 0, 0
+	addPad := func(n int) {
+		s.fields = append(s.fields, goField{"_", fmt.Sprintf("[%d]byte", n), off})
+		pad++
+	}
+	for i, f := range t.fields {
+		name := f.name
+		if name == "" {
+			name = fmt.Sprintf("anon%d", anon)
+			anon++
+		}
+		if keywords[name] {
+			name = "_" + name
+		}
+		size, _, err := g.sizeAlign(f.t)
+		if err != nil {
+			return nil, err
+		}
+		typ, align, err := g.goType(f.t)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", t.name, name, err)
+		}
+		coff := l.offsets[i]
+		if coff%align != 0 || roundUp(off, align) > coff {
+			// Go would place it elsewhere, keep its bytes
+			typ, align = fmt.Sprintf("[%d]byte", size), 1
+		}
+		if off < coff {
+			addPad(coff - off)
+		}
+		s.fields = append(s.fields, goField{name, typ, coff})
+		s.align = max(s.align, align)
+		off = coff + size
+	}
+	if off < l.size {
+		addPad(l.size - off)
+	}
+	if roundUp(l.size, s.align) != l.size {
+		return nil, fmt.Errorf("struct %s: size %d is not a multiple of its Go alignment %d",
+			t.name, l.size, s.align)
+	}
+	g.emitted[t] = s
+	return s, nil
+}
+
+// iocMacros returns the _IO, _IOR, _IOW and _IOWR macros of the ioctl
+// encoding of g
+func (g *generator) iocMacros() map[string]func([][]string) (int64, error) {
+	e := g.abi.ioc
+	macro := func(dir int64, sized bool) func([][]string) (int64, error) {
+		return func(args [][]string) (int64, error) {
+			if sized != (len(args) == 3) || len(args) < 2 {
+				return 0, fmt.Errorf("%d arguments", len(args))
+			}
+			typ, err := g.p.eval(args[0])
+			if err != nil {
+				return 0, err
+			}
+			nr, err := g.p.eval(args[1])
+			if err != nil {
+				return 0, err
+			}
+			size := 0
+			if sized {
+				t, n, err := g.p.parseType(args[2])
+				if err != nil {
+					return 0, err
+				}
+				if n != len(args[2]) {
+					return 0, fmt.Errorf("unsupported type %s", strings.Join(args[2], " "))
+				}
+				if size, _, err = g.sizeAlign(t); err != nil {
+					return 0, err
+				}
+			}
+			return e.ioc(dir, typ, nr, int64(size))
+		}
+	}
+	return map[string]func([][]string) (int64, error){
+		"_IO":   macro(e.none, false),
+		"_IOR":  macro(e.read, true),
+		"_IOW":  macro(e.write, true),
+		"_IOWR": macro(e.read|e.write, true),
+	}
+}
+
+// constValue returns the value of d for the GOARCH of g
+func (g *generator) constValue(d constDef) (string, error) {
+	switch {
+	case d.sizeof != "":
+		t := g.p.structs["struct "+d.sizeof]
+		if t == nil || !t.done {
+			return "", fmt.Errorf("%s: struct %s not found", d.name, d.sizeof)
+		}
+		size, _, err := g.sizeAlign(t)
+		return fmt.Sprint(size), err
+	case d.field != "":
+		t := g.p.structs["struct "+d.typ]
+		if t == nil || !t.done {
+			return "", fmt.Errorf("%s: struct %s not found", d.name, d.typ)
+		}
+		s, err := g.emit(t)
+		if err != nil {
+			return "", err
+		}
+		for _, f := range s.fields {
+			if f.name == d.field {
+				return fmt.Sprint(f.off), nil
+			}
+		}
+		return "", fmt.Errorf("%s: no field %s in %s", d.name, d.field, d.typ)
+	}
+	v, err := g.p.eval([]string{d.macro})
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", d.name, err)
+	}
+	if v < 10 {
+		return fmt.Sprint(v), nil
+	}
+	return fmt.Sprintf("%#x", v), nil
+}
+
+// generate returns the Go source of in for the GOARCH arch, name is the
+// name of the input file
+func generate(in *input, name, arch string) ([]byte, error) {
+	a, ok := abis[arch]
+	if !ok {
+		return nil, fmt.Errorf("unsupported GOARCH %s", arch)
+	}
+	p := newParser(*includeDir)
+	if err := p.parse(name+" preamble", in.preamble); err != nil {
+		return nil, err
+	}
+	g := &generator{
+		abi:      a,
+		p:        p,
+		layouts:  map[*ctype]*layout{},
+		emitted:  map[*ctype]*goStruct{},
+		building: map[*ctype]bool{},
+	}
+	p.funcs = g.iocMacros()
+	p.defines["__SIZEOF_POINTER__"] = fmt.Sprint(a.ptrSize)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by tools/ctypes from %s for linux/%s; DO NOT EDIT.\n\n",
+		filepath.Base(name), arch)
+	fmt.Fprintf(&b, "//go:build !v4l2cgo\n\npackage %s\n\n", in.pkg)
+
+	for _, tag := range in.structs {
+		t := p.structs["struct "+tag]
+		if t == nil || !t.done {
+			return nil, fmt.Errorf("struct %s not found in %v", tag, in.includes)
+		}
+		if _, err := g.emit(t); err != nil {
+			return nil, err
+		}
+	}
+	var consts bytes.Buffer
+	for _, c := range in.consts {
+		consts.WriteString("\n")
+		for _, l := range c.doc {
+			consts.WriteString(l + "\n")
+		}
+		consts.WriteString("const (\n")
+		for i, d := range c.defs {
+			v, err := g.constValue(d)
+			if err != nil {
+				return nil, err
+			}
+			if d.doc != nil && i > 0 {
+				consts.WriteString("\n")
+			}
+			for _, l := range d.doc {
+				consts.WriteString("\t" + l + "\n")
+			}
+			fmt.Fprintf(&consts, "\t%s = %s %s\n", d.name, v, strings.Join(d.comment, " "))
+		}
+		consts.WriteString(")\n")
+	}
+	if g.usesPtr {
+		b.WriteString("import \"unsafe\"\n")
+	}
+	b.Write(consts.Bytes())
+
+	var structs []*goStruct
+	for _, s := range g.emitted {
+		structs = append(structs, s)
+	}
+	sort.Slice(structs, func(i, j int) bool { return structs[i].name < structs[j].name })
+	for _, s := range structs {
+		fmt.Fprintf(&b, "\ntype %s struct {\n", s.name)
+		for _, f := range s.fields {
+			fmt.Fprintf(&b, "\t%s %s\n", f.name, f.typ)
+		}
+		b.WriteString("}\n")
+	}
+	return format.Source(b.Bytes())
+}
+
+func main() {
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("ctypes: ")
+	if flag.NArg() != 1 {
+		log.Fatal("usage: ctypes [-arch GOARCH] [-o output] types_cgo.go")
+	}
+	in, err := readInput(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err := generate(in, flag.Arg(0), *archFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *output == "" {
+		os.Stdout.Write(src)
+		return
+	}
+	if err := os.WriteFile(*output, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
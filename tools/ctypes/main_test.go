@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestGenerated fails when a checked-in ztypes_linux_$GOARCH.go differs from
+// what go generate makes of types_cgo.go and the headers
+func TestGenerated(t *testing.T) {
+	if _, err := os.Stat(filepath.Join(*includeDir, "linux/videodev2.h")); err != nil {
+		t.Skip("no linux headers:", err)
+	}
+	for _, dir := range []string{"../..", "../../media"} {
+		in, err := readInput(filepath.Join(dir, "types_cgo.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for arch := range abis {
+			name := filepath.Join(dir, "ztypes_linux_"+arch+".go")
+			want, err := generate(in, "types_cgo.go", arch)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			got, err := os.ReadFile(name)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s is stale, run go generate in %s", name, filepath.Clean(dir))
+			}
+		}
+	}
+}
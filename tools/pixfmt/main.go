@@ -0,0 +1,193 @@
+// Command pixfmt generates the pixel format table of v4l2-go from the
+// V4L2_PIX_FMT_* definitions of linux/videodev2.h. e.g.
+//
+//	go run ./tools/pixfmt -i /usr/include/linux/videodev2.h -o pixfmt_table.go
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var input = flag.String("i", "/usr/include/linux/videodev2.h", "videodev2.h to parse")
+var output = flag.String("o", "pixfmt_table.go", "generated go source")
+
+var (
+	// #define V4L2_PIX_FMT_RGB332  v4l2_fourcc('R', 'G', 'B', '1') /*  8  RGB-3-3-2 */
+	reFourCC = regexp.MustCompile(`^#define\s+V4L2_PIX_FMT_(\w+)\s+v4l2_fourcc(_be)?\(\s*'(.)',\s*'(.)',\s*'(.)',\s*'(.)'\s*\)\s*(?:/\*\s*(.*?)\s*\*/)?`)
+	// #define V4L2_PIX_FMT_HM12 V4L2_PIX_FMT_NV12_16L16
+	reAlias = regexp.MustCompile(`^#define\s+V4L2_PIX_FMT_(\w+)\s+V4L2_PIX_FMT_(\w+)\s*$`)
+	// /* RGB formats (1 or 2 bytes per pixel) */
+	reComment = regexp.MustCompile(`^(\s*)/\*\s*(.*?)\s*\*/\s*$`)
+	// 16  YUV 4:2:2
+	reDepth = regexp.MustCompile(`^(\d+)\s+(.*)$`)
+)
+
+type pixfmt struct {
+	name        string
+	fourcc      uint32
+	chars       string
+	depth       int
+	description string
+	section     string
+	flags       []string
+}
+
+type alias struct {
+	name, target string
+}
+
+// sectionFlags returns the flags shared by all formats below the section
+// comment of videodev2.h
+func sectionFlags(section string) []string {
+	s := strings.ToLower(section)
+	switch {
+	case strings.HasPrefix(s, "rgb formats"),
+		strings.HasPrefix(s, "chrominance formats"),
+		strings.HasPrefix(s, "luminance+chrominance formats"),
+		strings.HasPrefix(s, "hsv formats"):
+		return []string{"PixFmtPacked"}
+	case strings.HasPrefix(s, "bayer formats"):
+		return []string{"PixFmtBayer"}
+	case strings.HasPrefix(s, "tiled "):
+		return []string{"PixFmtTiled"}
+	case strings.HasPrefix(s, "compressed formats"):
+		return []string{"PixFmtCompressed"}
+	}
+	return nil
+}
+
+func parse(name string) ([]pixfmt, []alias, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	var formats []pixfmt
+	var aliases []alias
+	var section, note string
+	started := false
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if !started {
+			// the table starts with its heading comment
+			started = strings.Contains(line, "Pixel format") && strings.Contains(line, "FOURCC")
+			continue
+		}
+		if m := reAlias.FindStringSubmatch(line); m != nil {
+			aliases = append(aliases, alias{m[1], m[2]})
+			continue
+		}
+		if strings.Contains(line, "V4L2_PIX_FMT_PRIV_MAGIC") {
+			// end of the table, only the aliases of deprecated names follow
+			section = ""
+			continue
+		}
+		if m := reComment.FindStringSubmatch(line); m != nil {
+			if m[1] == "" {
+				section, note = m[2], ""
+			} else {
+				// indented comments describe the following uncommented formats
+				note = m[2]
+			}
+			continue
+		}
+		m := reFourCC.FindStringSubmatch(line)
+		if m == nil || section == "" {
+			continue
+		}
+		p := pixfmt{
+			name:    m[1],
+			chars:   m[3] + m[4] + m[5] + m[6],
+			section: section,
+			flags:   sectionFlags(section),
+		}
+		p.fourcc = uint32(m[3][0]) | uint32(m[4][0])<<8 | uint32(m[5][0])<<16 | uint32(m[6][0])<<24
+		if m[2] != "" {
+			p.fourcc |= 1 << 31
+		}
+		p.description = m[7]
+		if d := reDepth.FindStringSubmatch(m[7]); d != nil {
+			p.depth, _ = strconv.Atoi(d[1])
+			p.description = d[2]
+		}
+		if p.description == "" {
+			p.description = note
+		}
+		p.description = strings.Join(strings.Fields(p.description), " ")
+		formats = append(formats, p)
+	}
+	if err := s.Err(); err != nil {
+		return nil, nil, err
+	}
+	if len(formats) == 0 {
+		return nil, nil, fmt.Errorf("no pixel formats found in %s", name)
+	}
+	return formats, aliases, nil
+}
+
+func generate(formats []pixfmt, aliases []alias) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by \"go run ./tools/pixfmt\" from linux/videodev2.h; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package v4l2\n\n")
+
+	fmt.Fprintf(&b, "// Pixel format FOURCC depth Description\n")
+	fmt.Fprintf(&b, "const (\n")
+	section := ""
+	for _, p := range formats {
+		if p.section != section {
+			if section != "" {
+				fmt.Fprintf(&b, "\n")
+			}
+			fmt.Fprintf(&b, "\t/* %s */\n", p.section)
+			section = p.section
+		}
+		fmt.Fprintf(&b, "\tV4L2_PIX_FMT_%s = 0x%08x // %q\n", p.name, p.fourcc, p.chars)
+	}
+	if len(aliases) > 0 {
+		fmt.Fprintf(&b, "\n\t/* deprecated names */\n")
+		for _, a := range aliases {
+			fmt.Fprintf(&b, "\tV4L2_PIX_FMT_%s = V4L2_PIX_FMT_%s\n", a.name, a.target)
+		}
+	}
+	fmt.Fprintf(&b, ")\n\n")
+
+	fmt.Fprintf(&b, "var pixelFormatTable = []pixelFormatEntry{\n")
+	for _, p := range formats {
+		flags := "0"
+		if len(p.flags) > 0 {
+			flags = strings.Join(p.flags, " | ")
+		}
+		fmt.Fprintf(&b, "\t{V4L2_PIX_FMT_%s, %q, %q, %d, %s},\n",
+			p.name, p.name, p.description, p.depth, flags)
+	}
+	fmt.Fprintf(&b, "}\n")
+
+	return format.Source(b.Bytes())
+}
+
+func main() {
+	flag.Parse()
+
+	formats, aliases, err := parse(*input)
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err := generate(formats, aliases)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*output, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,27 @@
+//go:build !v4l2cgo
+
+package v4l2
+
+// The structs of the kernel ABI are defined in ztypes_linux_$GOARCH.go,
+// generated from types_cgo.go, which takes them from the C headers when
+// built with the v4l2cgo tag.
+
+//go:generate go run ./tools/ctypes -arch 386 -o ztypes_linux_386.go types_cgo.go
+//go:generate go run ./tools/ctypes -arch amd64 -o ztypes_linux_amd64.go types_cgo.go
+//go:generate go run ./tools/ctypes -arch arm -o ztypes_linux_arm.go types_cgo.go
+//go:generate go run ./tools/ctypes -arch arm64 -o ztypes_linux_arm64.go types_cgo.go
+//go:generate go run ./tools/ctypes -arch mips -o ztypes_linux_mips.go types_cgo.go
+//go:generate go run ./tools/ctypes -arch mipsle -o ztypes_linux_mipsle.go types_cgo.go
+//go:generate go run ./tools/ctypes -arch ppc64le -o ztypes_linux_ppc64le.go types_cgo.go
+//go:generate go run ./tools/ctypes -arch riscv64 -o ztypes_linux_riscv64.go types_cgo.go
+
+type (
+	__u8  = uint8
+	__s8  = int8
+	__u16 = uint16
+	__s16 = int16
+	__u32 = uint32
+	__s32 = int32
+	__u64 = uint64
+	__s64 = int64
+)
@@ -0,0 +1,336 @@
+//go:build v4l2cgo
+
+package v4l2
+
+/*
+#cgo CFLAGS: -D_TIME_BITS=64 -D_FILE_OFFSET_BITS=64
+
+#include <linux/videodev2.h>
+#include <linux/v4l2-subdev.h>
+#include <linux/media.h>
+
+// The layouts of v4l2_buffer and v4l2_event with 32-bit time_t, which the
+// kernel still takes from 32-bit userspace under the _TIME32 ioctls, see
+// include/media/v4l2-ioctl.h of the kernel.
+struct old_timeval32 {
+	__s32 tv_sec;
+	__s32 tv_usec;
+};
+
+struct old_timespec32 {
+	__s32 tv_sec;
+	__s32 tv_nsec;
+};
+
+struct v4l2_buffer_time32 {
+	__u32 index;
+	__u32 type;
+	__u32 bytesused;
+	__u32 flags;
+	__u32 field;
+	struct old_timeval32 timestamp;
+	struct v4l2_timecode timecode;
+	__u32 sequence;
+	__u32 memory;
+	union {
+		__u32 offset;
+		unsigned long userptr;
+		struct v4l2_plane *planes;
+		__s32 fd;
+	} m;
+	__u32 length;
+	__u32 reserved2;
+	union {
+		__s32 request_fd;
+		__u32 reserved;
+	};
+};
+
+struct v4l2_event_time32 {
+	__u32 type;
+	union {
+		struct v4l2_event_vsync vsync;
+		struct v4l2_event_ctrl ctrl;
+		struct v4l2_event_frame_sync frame_sync;
+		struct v4l2_event_src_change src_change;
+		struct v4l2_event_motion_det motion_det;
+		__u8 data[64];
+	} u;
+	__u32 pending;
+	__u32 sequence;
+	struct old_timespec32 timestamp;
+	__u32 id;
+	__u32 reserved[8];
+};
+
+#define VIDIOC_QUERYBUF_TIME32 _IOWR('V', 9, struct v4l2_buffer_time32)
+#define VIDIOC_QBUF_TIME32 _IOWR('V', 15, struct v4l2_buffer_time32)
+#define VIDIOC_DQBUF_TIME32 _IOWR('V', 17, struct v4l2_buffer_time32)
+#define VIDIOC_DQEVENT_TIME32 _IOR('V', 89, struct v4l2_event_time32)
+*/
+import "C"
+
+import "unsafe"
+
+// Built with the v4l2cgo tag, the package takes the structs of the kernel
+// ABI from the C headers instead of ztypes_linux_$GOARCH.go, which is
+// generated from this file.
+
+type (
+	__u8  = C.__u8
+	__s8  = C.__s8
+	__u16 = C.__u16
+	__s16 = C.__s16
+	__u32 = C.__u32
+	__s32 = C.__s32
+	__u64 = C.__u64
+	__s64 = C.__s64
+)
+
+type (
+	v4l2_buffer                   = C.struct_v4l2_buffer
+	v4l2_buffer_time32            = C.struct_v4l2_buffer_time32
+	v4l2_capability               = C.struct_v4l2_capability
+	v4l2_captureparm              = C.struct_v4l2_captureparm
+	v4l2_control                  = C.struct_v4l2_control
+	v4l2_crop                     = C.struct_v4l2_crop
+	v4l2_cropcap                  = C.struct_v4l2_cropcap
+	v4l2_ctrl_h264_decode_params  = C.struct_v4l2_ctrl_h264_decode_params
+	v4l2_ctrl_h264_pps            = C.struct_v4l2_ctrl_h264_pps
+	v4l2_ctrl_h264_pred_weights   = C.struct_v4l2_ctrl_h264_pred_weights
+	v4l2_ctrl_h264_scaling_matrix = C.struct_v4l2_ctrl_h264_scaling_matrix
+	v4l2_ctrl_h264_slice_params   = C.struct_v4l2_ctrl_h264_slice_params
+	v4l2_ctrl_h264_sps            = C.struct_v4l2_ctrl_h264_sps
+	v4l2_ctrl_vp8_frame           = C.struct_v4l2_ctrl_vp8_frame
+	v4l2_decoder_cmd              = C.struct_v4l2_decoder_cmd
+	v4l2_encoder_cmd              = C.struct_v4l2_encoder_cmd
+	v4l2_event                    = C.struct_v4l2_event
+	v4l2_event_time32             = C.struct_v4l2_event_time32
+	v4l2_event_ctrl               = C.struct_v4l2_event_ctrl
+	v4l2_event_frame_sync         = C.struct_v4l2_event_frame_sync
+	v4l2_event_motion_det         = C.struct_v4l2_event_motion_det
+	v4l2_event_src_change         = C.struct_v4l2_event_src_change
+	v4l2_event_subscription       = C.struct_v4l2_event_subscription
+	v4l2_event_vsync              = C.struct_v4l2_event_vsync
+	v4l2_exportbuffer             = C.struct_v4l2_exportbuffer
+	v4l2_ext_control              = C.struct_v4l2_ext_control
+	v4l2_ext_controls             = C.struct_v4l2_ext_controls
+	v4l2_fmtdesc                  = C.struct_v4l2_fmtdesc
+	v4l2_format                   = C.struct_v4l2_format
+	v4l2_fract                    = C.struct_v4l2_fract
+	v4l2_frmival_stepwise         = C.struct_v4l2_frmival_stepwise
+	v4l2_frmivalenum              = C.struct_v4l2_frmivalenum
+	v4l2_frmsize_discrete         = C.struct_v4l2_frmsize_discrete
+	v4l2_frmsize_stepwise         = C.struct_v4l2_frmsize_stepwise
+	v4l2_frmsizeenum              = C.struct_v4l2_frmsizeenum
+	v4l2_h264_dpb_entry           = C.struct_v4l2_h264_dpb_entry
+	v4l2_h264_weight_factors      = C.struct_v4l2_h264_weight_factors
+	v4l2_input                    = C.struct_v4l2_input
+	v4l2_mbus_framefmt            = C.struct_v4l2_mbus_framefmt
+	v4l2_output                   = C.struct_v4l2_output
+	v4l2_outputparm               = C.struct_v4l2_outputparm
+	v4l2_pix_format               = C.struct_v4l2_pix_format
+	v4l2_pix_format_mplane        = C.struct_v4l2_pix_format_mplane
+	v4l2_plane                    = C.struct_v4l2_plane
+	v4l2_plane_pix_format         = C.struct_v4l2_plane_pix_format
+	v4l2_query_ext_ctrl           = C.struct_v4l2_query_ext_ctrl
+	v4l2_queryctrl                = C.struct_v4l2_queryctrl
+	v4l2_querymenu                = C.struct_v4l2_querymenu
+	v4l2_rect                     = C.struct_v4l2_rect
+	v4l2_requestbuffers           = C.struct_v4l2_requestbuffers
+	v4l2_selection                = C.struct_v4l2_selection
+	v4l2_streamparm               = C.struct_v4l2_streamparm
+	v4l2_subdev_format            = C.struct_v4l2_subdev_format
+	v4l2_subdev_frame_interval    = C.struct_v4l2_subdev_frame_interval
+	v4l2_subdev_frame_size_enum   = C.struct_v4l2_subdev_frame_size_enum
+	v4l2_subdev_mbus_code_enum    = C.struct_v4l2_subdev_mbus_code_enum
+	v4l2_subdev_selection         = C.struct_v4l2_subdev_selection
+	v4l2_timecode                 = C.struct_v4l2_timecode
+)
+
+const (
+	sizeof_v4l2_buffer                   = C.sizeof_struct_v4l2_buffer
+	sizeof_v4l2_capability               = C.sizeof_struct_v4l2_capability
+	sizeof_v4l2_captureparm              = C.sizeof_struct_v4l2_captureparm
+	sizeof_v4l2_control                  = C.sizeof_struct_v4l2_control
+	sizeof_v4l2_crop                     = C.sizeof_struct_v4l2_crop
+	sizeof_v4l2_cropcap                  = C.sizeof_struct_v4l2_cropcap
+	sizeof_v4l2_ctrl_h264_decode_params  = C.sizeof_struct_v4l2_ctrl_h264_decode_params
+	sizeof_v4l2_ctrl_h264_pps            = C.sizeof_struct_v4l2_ctrl_h264_pps
+	sizeof_v4l2_ctrl_h264_pred_weights   = C.sizeof_struct_v4l2_ctrl_h264_pred_weights
+	sizeof_v4l2_ctrl_h264_scaling_matrix = C.sizeof_struct_v4l2_ctrl_h264_scaling_matrix
+	sizeof_v4l2_ctrl_h264_slice_params   = C.sizeof_struct_v4l2_ctrl_h264_slice_params
+	sizeof_v4l2_ctrl_h264_sps            = C.sizeof_struct_v4l2_ctrl_h264_sps
+	sizeof_v4l2_ctrl_vp8_frame           = C.sizeof_struct_v4l2_ctrl_vp8_frame
+	sizeof_v4l2_decoder_cmd              = C.sizeof_struct_v4l2_decoder_cmd
+	sizeof_v4l2_encoder_cmd              = C.sizeof_struct_v4l2_encoder_cmd
+	sizeof_v4l2_event                    = C.sizeof_struct_v4l2_event
+	sizeof_v4l2_event_ctrl               = C.sizeof_struct_v4l2_event_ctrl
+	sizeof_v4l2_event_frame_sync         = C.sizeof_struct_v4l2_event_frame_sync
+	sizeof_v4l2_event_motion_det         = C.sizeof_struct_v4l2_event_motion_det
+	sizeof_v4l2_event_src_change         = C.sizeof_struct_v4l2_event_src_change
+	sizeof_v4l2_event_subscription       = C.sizeof_struct_v4l2_event_subscription
+	sizeof_v4l2_event_vsync              = C.sizeof_struct_v4l2_event_vsync
+	sizeof_v4l2_exportbuffer             = C.sizeof_struct_v4l2_exportbuffer
+	sizeof_v4l2_ext_control              = C.sizeof_struct_v4l2_ext_control
+	sizeof_v4l2_ext_controls             = C.sizeof_struct_v4l2_ext_controls
+	sizeof_v4l2_fmtdesc                  = C.sizeof_struct_v4l2_fmtdesc
+	sizeof_v4l2_format                   = C.sizeof_struct_v4l2_format
+	sizeof_v4l2_fract                    = C.sizeof_struct_v4l2_fract
+	sizeof_v4l2_frmival_stepwise         = C.sizeof_struct_v4l2_frmival_stepwise
+	sizeof_v4l2_frmivalenum              = C.sizeof_struct_v4l2_frmivalenum
+	sizeof_v4l2_frmsize_discrete         = C.sizeof_struct_v4l2_frmsize_discrete
+	sizeof_v4l2_frmsize_stepwise         = C.sizeof_struct_v4l2_frmsize_stepwise
+	sizeof_v4l2_frmsizeenum              = C.sizeof_struct_v4l2_frmsizeenum
+	sizeof_v4l2_h264_dpb_entry           = C.sizeof_struct_v4l2_h264_dpb_entry
+	sizeof_v4l2_h264_weight_factors      = C.sizeof_struct_v4l2_h264_weight_factors
+	sizeof_v4l2_input                    = C.sizeof_struct_v4l2_input
+	sizeof_v4l2_mbus_framefmt            = C.sizeof_struct_v4l2_mbus_framefmt
+	sizeof_v4l2_output                   = C.sizeof_struct_v4l2_output
+	sizeof_v4l2_outputparm               = C.sizeof_struct_v4l2_outputparm
+	sizeof_v4l2_pix_format               = C.sizeof_struct_v4l2_pix_format
+	sizeof_v4l2_pix_format_mplane        = C.sizeof_struct_v4l2_pix_format_mplane
+	sizeof_v4l2_plane                    = C.sizeof_struct_v4l2_plane
+	sizeof_v4l2_plane_pix_format         = C.sizeof_struct_v4l2_plane_pix_format
+	sizeof_v4l2_query_ext_ctrl           = C.sizeof_struct_v4l2_query_ext_ctrl
+	sizeof_v4l2_queryctrl                = C.sizeof_struct_v4l2_queryctrl
+	sizeof_v4l2_querymenu                = C.sizeof_struct_v4l2_querymenu
+	sizeof_v4l2_rect                     = C.sizeof_struct_v4l2_rect
+	sizeof_v4l2_requestbuffers           = C.sizeof_struct_v4l2_requestbuffers
+	sizeof_v4l2_selection                = C.sizeof_struct_v4l2_selection
+	sizeof_v4l2_streamparm               = C.sizeof_struct_v4l2_streamparm
+	sizeof_v4l2_subdev_format            = C.sizeof_struct_v4l2_subdev_format
+	sizeof_v4l2_subdev_frame_interval    = C.sizeof_struct_v4l2_subdev_frame_interval
+	sizeof_v4l2_subdev_frame_size_enum   = C.sizeof_struct_v4l2_subdev_frame_size_enum
+	sizeof_v4l2_subdev_mbus_code_enum    = C.sizeof_struct_v4l2_subdev_mbus_code_enum
+	sizeof_v4l2_subdev_selection         = C.sizeof_struct_v4l2_subdev_selection
+	sizeof_v4l2_timecode                 = C.sizeof_struct_v4l2_timecode
+)
+
+// offsets of the fields read and written in place
+const (
+	offset_format_type                = unsafe.Offsetof(v4l2_format{}._type)
+	offset_streamparm_type            = unsafe.Offsetof(v4l2_streamparm{}._type)
+	offset_requestbuffers_type        = unsafe.Offsetof(v4l2_requestbuffers{}._type)
+	offset_buffer_type                = unsafe.Offsetof(v4l2_buffer{}._type)
+	offset_cropcap_type               = unsafe.Offsetof(v4l2_cropcap{}._type)
+	offset_crop_type                  = unsafe.Offsetof(v4l2_crop{}._type)
+	offset_fmtdesc_type               = unsafe.Offsetof(v4l2_fmtdesc{}._type)
+	offset_frmsizeenum_type           = unsafe.Offsetof(v4l2_frmsizeenum{}._type)
+	offset_frmivalenum_type           = unsafe.Offsetof(v4l2_frmivalenum{}._type)
+	offset_frmsizeenum_union          = unsafe.Offsetof(v4l2_frmsizeenum{}.anon0)
+	offset_frmivalenum_union          = unsafe.Offsetof(v4l2_frmivalenum{}.anon0)
+	offset_queryctrl_type             = unsafe.Offsetof(v4l2_queryctrl{}._type)
+	offset_query_ext_ctrl_type        = unsafe.Offsetof(v4l2_query_ext_ctrl{}._type)
+	offset_event_subscription_type    = unsafe.Offsetof(v4l2_event_subscription{}._type)
+	offset_event_type                 = unsafe.Offsetof(v4l2_event{}._type)
+	offset_querymenu_union            = unsafe.Offsetof(v4l2_querymenu{}.anon0)
+	offset_input_type                 = unsafe.Offsetof(v4l2_input{}._type)
+	offset_output_type                = unsafe.Offsetof(v4l2_output{}._type)
+	offset_selection_type             = unsafe.Offsetof(v4l2_selection{}._type)
+	offset_timecode_type              = unsafe.Offsetof(v4l2_timecode{}._type)
+	offset_pix_format_encoding        = unsafe.Offsetof(v4l2_pix_format{}.anon0)
+	offset_pix_format_mplane_encoding = unsafe.Offsetof(v4l2_pix_format_mplane{}.anon0)
+	offset_ext_controls_ctrl_class    = unsafe.Offsetof(v4l2_ext_controls{}.anon0)
+	offset_ext_control_union          = unsafe.Offsetof(v4l2_ext_control{}.anon0)
+	offset_event_ctrl_type            = unsafe.Offsetof(v4l2_event_ctrl{}._type)
+	offset_event_ctrl_value           = unsafe.Offsetof(v4l2_event_ctrl{}.anon0)
+	offset_exportbuffer_type          = unsafe.Offsetof(v4l2_exportbuffer{}._type)
+	offset_decoder_cmd_union          = unsafe.Offsetof(v4l2_decoder_cmd{}.anon0)
+	offset_buffer_request_fd          = unsafe.Offsetof(v4l2_buffer{}.anon0)
+	offset_mbus_framefmt_encoding     = unsafe.Offsetof(v4l2_mbus_framefmt{}.anon0)
+)
+
+const (
+	__SIZEOF_POINTER__ = C.__SIZEOF_POINTER__
+)
+
+// the ioctls of the time32 layouts, see time32.go
+const (
+	vidioc_querybuf_time32 = C.VIDIOC_QUERYBUF_TIME32
+	vidioc_qbuf_time32     = C.VIDIOC_QBUF_TIME32
+	vidioc_dqbuf_time32    = C.VIDIOC_DQBUF_TIME32
+	vidioc_dqevent_time32  = C.VIDIOC_DQEVENT_TIME32
+)
+
+const (
+	VIDIOC_QUERYCAP       = C.VIDIOC_QUERYCAP // Query device capabilities
+	VIDIOC_ENUM_FMT       = C.VIDIOC_ENUM_FMT // Enumerate image formats
+	VIDIOC_G_FMT          = C.VIDIOC_G_FMT    // Get or set the data format, try a format
+	VIDIOC_S_FMT          = C.VIDIOC_S_FMT
+	VIDIOC_TRY_FMT        = C.VIDIOC_TRY_FMT
+	VIDIOC_G_CTRL         = C.VIDIOC_G_CTRL
+	VIDIOC_S_CTRL         = C.VIDIOC_S_CTRL
+	VIDIOC_QUERYCTRL      = C.VIDIOC_QUERYCTRL
+	VIDIOC_QUERYMENU      = C.VIDIOC_QUERYMENU //  Enumerate controls and menu control items
+	VIDIOC_QUERY_EXT_CTRL = C.VIDIOC_QUERY_EXT_CTRL
+	VIDIOC_G_CROP         = C.VIDIOC_G_CROP // Get or set the current cropping rectangle
+	VIDIOC_S_CROP         = C.VIDIOC_S_CROP
+	VIDIOC_CROPCAP        = C.VIDIOC_CROPCAP  // Information about the video cropping and scaling abilities
+	VIDIOC_QUERYBUF       = C.VIDIOC_QUERYBUF // Query the status of a buffer
+	VIDIOC_REQBUFS        = C.VIDIOC_REQBUFS  //  Initiate Memory Mapping, User Pointer I/O or DMA buffer I/O
+	VIDIOC_QBUF           = C.VIDIOC_QBUF     // Exchange a buffer with the driver
+	VIDIOC_DQBUF          = C.VIDIOC_DQBUF
+	VIDIOC_G_PARM         = C.VIDIOC_G_PARM // Get or set streaming parameters
+	VIDIOC_S_PARM         = C.VIDIOC_S_PARM
+
+	// Enumerate frame sizes and frame intervals
+	VIDIOC_ENUM_FRAMESIZES     = C.VIDIOC_ENUM_FRAMESIZES
+	VIDIOC_ENUM_FRAMEINTERVALS = C.VIDIOC_ENUM_FRAMEINTERVALS
+
+	// Get or set one of the selection rectangles
+	VIDIOC_G_SELECTION = C.VIDIOC_G_SELECTION
+	VIDIOC_S_SELECTION = C.VIDIOC_S_SELECTION
+
+	// Enumerate, get or set video inputs and outputs
+	VIDIOC_ENUMINPUT  = C.VIDIOC_ENUMINPUT
+	VIDIOC_G_INPUT    = C.VIDIOC_G_INPUT
+	VIDIOC_S_INPUT    = C.VIDIOC_S_INPUT
+	VIDIOC_ENUMOUTPUT = C.VIDIOC_ENUMOUTPUT
+	VIDIOC_G_OUTPUT   = C.VIDIOC_G_OUTPUT
+	VIDIOC_S_OUTPUT   = C.VIDIOC_S_OUTPUT
+
+	// Subscribe or unsubscribe event
+	VIDIOC_SUBSCRIBE_EVENT   = C.VIDIOC_SUBSCRIBE_EVENT
+	VIDIOC_UNSUBSCRIBE_EVENT = C.VIDIOC_UNSUBSCRIBE_EVENT
+	VIDIOC_DQEVENT           = C.VIDIOC_DQEVENT // Dequeue event
+
+	// Get or set the value of several controls, try control values
+	VIDIOC_G_EXT_CTRLS   = C.VIDIOC_G_EXT_CTRLS
+	VIDIOC_S_EXT_CTRLS   = C.VIDIOC_S_EXT_CTRLS
+	VIDIOC_TRY_EXT_CTRLS = C.VIDIOC_TRY_EXT_CTRLS
+
+	// Execute a decoder command
+	VIDIOC_DECODER_CMD     = C.VIDIOC_DECODER_CMD
+	VIDIOC_TRY_DECODER_CMD = C.VIDIOC_TRY_DECODER_CMD
+
+	// Execute an encoder command
+	VIDIOC_ENCODER_CMD     = C.VIDIOC_ENCODER_CMD
+	VIDIOC_TRY_ENCODER_CMD = C.VIDIOC_TRY_ENCODER_CMD
+
+	// Export a buffer as a DMABUF file descriptor
+	VIDIOC_EXPBUF = C.VIDIOC_EXPBUF
+
+	// Start or stop streaming I/O
+	VIDIOC_STREAMON  = C.VIDIOC_STREAMON
+	VIDIOC_STREAMOFF = C.VIDIOC_STREAMOFF
+)
+
+// Sub-device ioctls
+const (
+	VIDIOC_SUBDEV_G_FMT            = C.VIDIOC_SUBDEV_G_FMT
+	VIDIOC_SUBDEV_S_FMT            = C.VIDIOC_SUBDEV_S_FMT
+	VIDIOC_SUBDEV_G_FRAME_INTERVAL = C.VIDIOC_SUBDEV_G_FRAME_INTERVAL
+	VIDIOC_SUBDEV_S_FRAME_INTERVAL = C.VIDIOC_SUBDEV_S_FRAME_INTERVAL
+	VIDIOC_SUBDEV_ENUM_MBUS_CODE   = C.VIDIOC_SUBDEV_ENUM_MBUS_CODE
+	VIDIOC_SUBDEV_ENUM_FRAME_SIZE  = C.VIDIOC_SUBDEV_ENUM_FRAME_SIZE
+	VIDIOC_SUBDEV_G_SELECTION      = C.VIDIOC_SUBDEV_G_SELECTION
+	VIDIOC_SUBDEV_S_SELECTION      = C.VIDIOC_SUBDEV_S_SELECTION
+)
+
+// Media request ioctls
+const (
+	MEDIA_IOC_REQUEST_ALLOC  = C.MEDIA_IOC_REQUEST_ALLOC
+	MEDIA_REQUEST_IOC_QUEUE  = C.MEDIA_REQUEST_IOC_QUEUE
+	MEDIA_REQUEST_IOC_REINIT = C.MEDIA_REQUEST_IOC_REINIT
+)
@@ -0,0 +1,26 @@
+package v4l2
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// AllocUserBuffer allocates a page aligned buffer of at least length bytes
+// outside the Go heap, suitable for V4L2_MEMORY_USERPTR streaming. The
+// garbage collector neither moves nor frees it; release it with
+// FreeUserBuffer once the driver no longer owns it.
+func AllocUserBuffer(length int) ([]byte, error) {
+	pagesize := syscall.Getpagesize()
+	size := (length + pagesize - 1) / pagesize * pagesize
+	buf, err := syscall.Mmap(-1, 0, size, syscall.PROT_READ|syscall.PROT_WRITE,
+		syscall.MAP_PRIVATE|syscall.MAP_ANONYMOUS)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrorBufferAlloc, err)
+	}
+	return buf, nil
+}
+
+// FreeUserBuffer releases a buffer returned by AllocUserBuffer.
+func FreeUserBuffer(buf []byte) error {
+	return syscall.Munmap(buf)
+}
@@ -1,148 +1,335 @@
 package v4l2
 
-/*
-#include <linux/videodev2.h>
-*/
-import "C"
-
 import (
-	"log"
+	"fmt"
 	"syscall"
 	"unsafe"
 )
 
 const (
-	VIDIOC_QUERYCAP       = C.VIDIOC_QUERYCAP // Query device capabilities
-	VIDIOC_ENUM_FMT       = C.VIDIOC_ENUM_FMT // Enumerate image formats
-	VIDIOC_G_FMT          = C.VIDIOC_G_FMT    // Get or set the data format, try a format
-	VIDIOC_S_FMT          = C.VIDIOC_S_FMT
-	VIDIOC_TRY_FMT        = C.VIDIOC_TRY_FMT
-	VIDIOC_G_CTRL         = C.VIDIOC_G_CTRL
-	VIDIOC_S_CTRL         = C.VIDIOC_S_CTRL
-	VIDIOC_QUERYCTRL      = C.VIDIOC_QUERYCTRL
-	VIDIOC_QUERYMENU      = C.VIDIOC_QUERYMENU //  Enumerate controls and menu control items
-	VIDIOC_QUERY_EXT_CTRL = C.VIDIOC_QUERY_EXT_CTRL
-	VIDIOC_G_CROP         = C.VIDIOC_G_CROP // Get or set the current cropping rectangle
-	VIDIOC_S_CROP         = C.VIDIOC_S_CROP
-	VIDIOC_CROPCAP        = C.VIDIOC_CROPCAP  // Information about the video cropping and scaling abilities
-	VIDIOC_QUERYBUF       = C.VIDIOC_QUERYBUF // Query the status of a buffer
-	VIDIOC_REQBUFS        = C.VIDIOC_REQBUFS  //  Initiate Memory Mapping, User Pointer I/O or DMA buffer I/O
-	VIDIOC_QBUF           = C.VIDIOC_QBUF     // Exchange a buffer with the driver
-	VIDIOC_DQBUF          = C.VIDIOC_DQBUF
-	VIDIOC_G_PARM         = C.VIDIOC_G_PARM // Get or set streaming parameters
-	VIDIOC_S_PARM         = C.VIDIOC_S_PARM
+	V4L2_CAP_VIDEO_CAPTURE        = 0x00000001
+	V4L2_CAP_VIDEO_CAPTURE_MPLANE = 0x00001000
+	V4L2_CAP_VIDEO_OUTPUT         = 0x00000002
+	V4L2_CAP_VIDEO_OUTPUT_MPLANE  = 0x00002000
+	V4L2_CAP_VIDEO_M2M            = 0x00008000
+	V4L2_CAP_VIDEO_M2M_MPLANE     = 0x00004000
+	V4L2_CAP_STREAMING            = 0x04000000
+	V4L2_CAP_DEVICE_CAPS          = 0x80000000
+)
 
-	// Subscribe or unsubscribe event
-	VIDIOC_SUBSCRIBE_EVENT   = C.VIDIOC_SUBSCRIBE_EVENT
-	VIDIOC_UNSUBSCRIBE_EVENT = C.VIDIOC_UNSUBSCRIBE_EVENT
-	VIDIOC_DQEVENT           = C.VIDIOC_DQEVENT // Dequeue event
+/*field order */
+const (
+	V4L2_FIELD_ANY  = 0
+	V4L2_FIELD_NONE = 1
+)
 
-	// Get or set the value of several controls, try control values
-	VIDIOC_G_EXT_CTRLS   = C.VIDIOC_G_EXT_CTRLS
-	VIDIOC_S_EXT_CTRLS   = C.VIDIOC_S_EXT_CTRLS
-	VIDIOC_TRY_EXT_CTRLS = C.VIDIOC_TRY_EXT_CTRLS
+// v4l2 buffer type
+const (
+	V4L2_BUF_TYPE_VIDEO_CAPTURE        = 1
+	V4L2_BUF_TYPE_VIDEO_OUTPUT         = 2
+	V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE = 9
+	V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE  = 10
+)
 
-	// Start or stop streaming I/O
-	VIDIOC_STREAMON  = C.VIDIOC_STREAMON
-	VIDIOC_STREAMOFF = C.VIDIOC_STREAMOFF
+const (
+	VIDEO_MAX_PLANES = 8
 )
 
+// video input type
 const (
-	V4L2_CAP_VIDEO_CAPTURE        = C.V4L2_CAP_VIDEO_CAPTURE
-	V4L2_CAP_VIDEO_CAPTURE_MPLANE = C.V4L2_CAP_VIDEO_CAPTURE_MPLANE
-	V4L2_CAP_VIDEO_OUTPUT         = C.V4L2_CAP_VIDEO_OUTPUT
-	V4L2_CAP_VIDEO_OUTPUT_MPLANE  = C.V4L2_CAP_VIDEO_OUTPUT_MPLANE
-	V4L2_CAP_VIDEO_M2M            = C.V4L2_CAP_VIDEO_M2M
-	V4L2_CAP_VIDEO_M2M_MPLANE     = C.V4L2_CAP_VIDEO_M2M_MPLANE
-	V4L2_CAP_STREAMING            = C.V4L2_CAP_STREAMING
-	V4L2_CAP_DEVICE_CAPS          = C.V4L2_CAP_DEVICE_CAPS
+	V4L2_INPUT_TYPE_TUNER  = 1
+	V4L2_INPUT_TYPE_CAMERA = 2
+	V4L2_INPUT_TYPE_TOUCH  = 3
 )
 
-/* field order */
+// video input status
 const (
-	V4L2_FIELD_ANY  = C.V4L2_FIELD_ANY
-	V4L2_FIELD_NONE = C.V4L2_FIELD_NONE
+	V4L2_IN_ST_NO_POWER    = 0x00000001
+	V4L2_IN_ST_NO_SIGNAL   = 0x00000002
+	V4L2_IN_ST_NO_COLOR    = 0x00000004
+	V4L2_IN_ST_HFLIP       = 0x00000010
+	V4L2_IN_ST_VFLIP       = 0x00000020
+	V4L2_IN_ST_NO_H_LOCK   = 0x00000100
+	V4L2_IN_ST_COLOR_KILL  = 0x00000200
+	V4L2_IN_ST_NO_V_LOCK   = 0x00000400
+	V4L2_IN_ST_NO_STD_LOCK = 0x00000800
+	V4L2_IN_ST_NO_SYNC     = 0x00010000
+	V4L2_IN_ST_NO_EQU      = 0x00020000
+	V4L2_IN_ST_NO_CARRIER  = 0x00040000
+	V4L2_IN_ST_MACROVISION = 0x01000000
+	V4L2_IN_ST_NO_ACCESS   = 0x02000000
+	V4L2_IN_ST_VTR         = 0x04000000
 )
 
-// v4l2 buffer type
+// video input capabilities
+const (
+	V4L2_IN_CAP_DV_TIMINGS  = 0x00000002
+	V4L2_IN_CAP_STD         = 0x00000004
+	V4L2_IN_CAP_NATIVE_SIZE = 0x00000008
+)
+
+// video output type
+const (
+	V4L2_OUTPUT_TYPE_MODULATOR        = 1
+	V4L2_OUTPUT_TYPE_ANALOG           = 2
+	V4L2_OUTPUT_TYPE_ANALOGVGAOVERLAY = 3
+)
+
+// video output capabilities
+const (
+	V4L2_OUT_CAP_DV_TIMINGS  = 0x00000002
+	V4L2_OUT_CAP_STD         = 0x00000004
+	V4L2_OUT_CAP_NATIVE_SIZE = 0x00000008
+)
+
+// selection targets
+const (
+	V4L2_SEL_TGT_CROP            = 0x0000
+	V4L2_SEL_TGT_CROP_DEFAULT    = 0x0001
+	V4L2_SEL_TGT_CROP_BOUNDS     = 0x0002
+	V4L2_SEL_TGT_NATIVE_SIZE     = 0x0003
+	V4L2_SEL_TGT_COMPOSE         = 0x0100
+	V4L2_SEL_TGT_COMPOSE_DEFAULT = 0x0101
+	V4L2_SEL_TGT_COMPOSE_BOUNDS  = 0x0102
+	V4L2_SEL_TGT_COMPOSE_PADDED  = 0x0103
+)
+
+// selection flags
+const (
+	V4L2_SEL_FLAG_GE          = 1
+	V4L2_SEL_FLAG_LE          = 2
+	V4L2_SEL_FLAG_KEEP_CONFIG = 4
+)
+
+// frame size type
 const (
-	V4L2_BUF_TYPE_VIDEO_CAPTURE        = C.V4L2_BUF_TYPE_VIDEO_CAPTURE
-	V4L2_BUF_TYPE_VIDEO_OUTPUT         = C.V4L2_BUF_TYPE_VIDEO_OUTPUT
-	V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE = C.V4L2_BUF_TYPE_VIDEO_CAPTURE_MPLANE
-	V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE  = C.V4L2_BUF_TYPE_VIDEO_OUTPUT_MPLANE
+	V4L2_FRMSIZE_TYPE_DISCRETE   = 1
+	V4L2_FRMSIZE_TYPE_CONTINUOUS = 2
+	V4L2_FRMSIZE_TYPE_STEPWISE   = 3
 )
 
+// frame interval type
 const (
-	VIDEO_MAX_PLANES = C.VIDEO_MAX_PLANES
+	V4L2_FRMIVAL_TYPE_DISCRETE   = 1
+	V4L2_FRMIVAL_TYPE_CONTINUOUS = 2
+	V4L2_FRMIVAL_TYPE_STEPWISE   = 3
 )
 
-// Pixel format FOURCC depth Description
+// colorspaces
 const (
-	/* Luminance+Chrominance formats */
-	V4L2_PIX_FMT_YVU410  = C.V4L2_PIX_FMT_YVU410
-	V4L2_PIX_FMT_YVU420  = C.V4L2_PIX_FMT_YVU420
-	V4L2_PIX_FMT_YUYV    = C.V4L2_PIX_FMT_YUYV
-	V4L2_PIX_FMT_YYUV    = C.V4L2_PIX_FMT_YYUV
-	V4L2_PIX_FMT_YVYU    = C.V4L2_PIX_FMT_YVYU
-	V4L2_PIX_FMT_UYVY    = C.V4L2_PIX_FMT_UYVY
-	V4L2_PIX_FMT_VYUY    = C.V4L2_PIX_FMT_VYUY
-	V4L2_PIX_FMT_YUV422P = C.V4L2_PIX_FMT_YUV422P
-	V4L2_PIX_FMT_YUV411P = C.V4L2_PIX_FMT_YUV411P
-	V4L2_PIX_FMT_Y41P    = C.V4L2_PIX_FMT_Y41P
-	V4L2_PIX_FMT_YUV444  = C.V4L2_PIX_FMT_YUV444
-	V4L2_PIX_FMT_YUV555  = C.V4L2_PIX_FMT_YUV555
-	V4L2_PIX_FMT_YUV565  = C.V4L2_PIX_FMT_YUV565
-	V4L2_PIX_FMT_YUV32   = C.V4L2_PIX_FMT_YUV32
-	V4L2_PIX_FMT_YUV410  = C.V4L2_PIX_FMT_YUV410
-	V4L2_PIX_FMT_YUV420  = C.V4L2_PIX_FMT_YUV420
-	V4L2_PIX_FMT_HI240   = C.V4L2_PIX_FMT_HI240
-	V4L2_PIX_FMT_HM12    = C.V4L2_PIX_FMT_HM12
-	V4L2_PIX_FMT_M420    = C.V4L2_PIX_FMT_M420
+	V4L2_COLORSPACE_DEFAULT       = 0
+	V4L2_COLORSPACE_SMPTE170M     = 1
+	V4L2_COLORSPACE_SMPTE240M     = 2
+	V4L2_COLORSPACE_REC709        = 3
+	V4L2_COLORSPACE_BT878         = 4
+	V4L2_COLORSPACE_470_SYSTEM_M  = 5
+	V4L2_COLORSPACE_470_SYSTEM_BG = 6
+	V4L2_COLORSPACE_JPEG          = 7
+	V4L2_COLORSPACE_SRGB          = 8
+	V4L2_COLORSPACE_OPRGB         = 9
+	V4L2_COLORSPACE_BT2020        = 10
+	V4L2_COLORSPACE_RAW           = 11
+	V4L2_COLORSPACE_DCI_P3        = 12
+)
 
-	/* two non contiguous planes - one Y, one Cr + Cb interleaved */
-	V4L2_PIX_FMT_NV12M = C.V4L2_PIX_FMT_NV12M
-	V4L2_PIX_FMT_NV21M = C.V4L2_PIX_FMT_NV21M
+// transfer functions
+const (
+	V4L2_XFER_FUNC_DEFAULT   = 0
+	V4L2_XFER_FUNC_709       = 1
+	V4L2_XFER_FUNC_SRGB      = 2
+	V4L2_XFER_FUNC_OPRGB     = 3
+	V4L2_XFER_FUNC_SMPTE240M = 4
+	V4L2_XFER_FUNC_NONE      = 5
+	V4L2_XFER_FUNC_DCI_P3    = 6
+	V4L2_XFER_FUNC_SMPTE2084 = 7
+)
 
-	/* compressed formats */
-	V4L2_PIX_FMT_MJPEG = C.V4L2_PIX_FMT_MJPEG
-	V4L2_PIX_FMT_JPEG  = C.V4L2_PIX_FMT_JPEG
-	V4L2_PIX_FMT_H264  = C.V4L2_PIX_FMT_H264
-	V4L2_PIX_FMT_MPEG4 = C.V4L2_PIX_FMT_MPEG4
-	V4L2_PIX_FMT_VP8   = C.V4L2_PIX_FMT_VP8
+// Y'CbCr encodings
+const (
+	V4L2_YCBCR_ENC_DEFAULT          = 0
+	V4L2_YCBCR_ENC_601              = 1
+	V4L2_YCBCR_ENC_709              = 2
+	V4L2_YCBCR_ENC_XV601            = 3
+	V4L2_YCBCR_ENC_XV709            = 4
+	V4L2_YCBCR_ENC_SYCC             = 5
+	V4L2_YCBCR_ENC_BT2020           = 6
+	V4L2_YCBCR_ENC_BT2020_CONST_LUM = 7
+	V4L2_YCBCR_ENC_SMPTE240M        = 8
+)
+
+// quantization ranges
+const (
+	V4L2_QUANTIZATION_DEFAULT    = 0
+	V4L2_QUANTIZATION_FULL_RANGE = 1
+	V4L2_QUANTIZATION_LIM_RANGE  = 2
 )
 
 const (
 	/* Query flags, to be ORed with the control ID */
-	V4L2_CTRL_FLAG_NEXT_CTRL = C.V4L2_CTRL_FLAG_NEXT_CTRL
+	V4L2_CTRL_FLAG_NEXT_CTRL     = 0x80000000
+	V4L2_CTRL_FLAG_NEXT_COMPOUND = 0x40000000
 
 	/*  Control flags  */
-	V4L2_CTRL_FLAG_DISABLED = C.V4L2_CTRL_FLAG_DISABLED
+	V4L2_CTRL_FLAG_DISABLED         = 0x0001
+	V4L2_CTRL_FLAG_GRABBED          = 0x0002
+	V4L2_CTRL_FLAG_READ_ONLY        = 0x0004
+	V4L2_CTRL_FLAG_UPDATE           = 0x0008
+	V4L2_CTRL_FLAG_INACTIVE         = 0x0010
+	V4L2_CTRL_FLAG_SLIDER           = 0x0020
+	V4L2_CTRL_FLAG_WRITE_ONLY       = 0x0040
+	V4L2_CTRL_FLAG_VOLATILE         = 0x0080
+	V4L2_CTRL_FLAG_HAS_PAYLOAD      = 0x0100
+	V4L2_CTRL_FLAG_EXECUTE_ON_WRITE = 0x0200
+	V4L2_CTRL_FLAG_MODIFY_LAYOUT    = 0x0400
+)
+
+// which value of extended controls
+const (
+	V4L2_CTRL_WHICH_CUR_VAL     = 0
+	V4L2_CTRL_WHICH_DEF_VAL     = 0x0f000000
+	V4L2_CTRL_WHICH_REQUEST_VAL = 0x0f010000
 )
 
 // control type
 const (
-	V4L2_CTRL_TYPE_MENU         = C.V4L2_CTRL_TYPE_MENU
-	V4L2_CTRL_TYPE_INTEGER_MENU = C.V4L2_CTRL_TYPE_INTEGER_MENU
+	V4L2_CTRL_TYPE_INTEGER      = 1
+	V4L2_CTRL_TYPE_BOOLEAN      = 2
+	V4L2_CTRL_TYPE_MENU         = 3
+	V4L2_CTRL_TYPE_BUTTON       = 4
+	V4L2_CTRL_TYPE_INTEGER64    = 5
+	V4L2_CTRL_TYPE_CTRL_CLASS   = 6
+	V4L2_CTRL_TYPE_STRING       = 7
+	V4L2_CTRL_TYPE_BITMASK      = 8
+	V4L2_CTRL_TYPE_INTEGER_MENU = 9
+	V4L2_CTRL_TYPE_U8           = 256
+	V4L2_CTRL_TYPE_U16          = 257
+	V4L2_CTRL_TYPE_U32          = 258
 )
 
+// compound control types of stateless codecs
 const (
-	V4L2_CTRL_MAX_DIMS = C.V4L2_CTRL_MAX_DIMS
+	V4L2_CTRL_TYPE_H264_SPS            = 512
+	V4L2_CTRL_TYPE_H264_PPS            = 513
+	V4L2_CTRL_TYPE_H264_SCALING_MATRIX = 514
+	V4L2_CTRL_TYPE_H264_SLICE_PARAMS   = 515
+	V4L2_CTRL_TYPE_H264_DECODE_PARAMS  = 516
+	V4L2_CTRL_TYPE_H264_PRED_WEIGHTS   = 517
+	V4L2_CTRL_TYPE_VP8_FRAME           = 576
+)
+
+const (
+	V4L2_CTRL_MAX_DIMS = 4
 )
 
 // memory type
 const (
-	V4L2_MEMORY_MMAP    = C.V4L2_MEMORY_MMAP
-	V4L2_MEMORY_USERPTR = C.V4L2_MEMORY_USERPTR
-	V4L2_MEMORY_OVERLAY = C.V4L2_MEMORY_OVERLAY
-	V4L2_MEMORY_DMABUF  = C.V4L2_MEMORY_DMABUF
+	V4L2_MEMORY_MMAP    = 1
+	V4L2_MEMORY_USERPTR = 2
+	V4L2_MEMORY_OVERLAY = 3
+	V4L2_MEMORY_DMABUF  = 4
+)
+
+// buffer flags
+const (
+	V4L2_BUF_FLAG_MAPPED               = 0x00000001
+	V4L2_BUF_FLAG_QUEUED               = 0x00000002
+	V4L2_BUF_FLAG_DONE                 = 0x00000004
+	V4L2_BUF_FLAG_KEYFRAME             = 0x00000008
+	V4L2_BUF_FLAG_PFRAME               = 0x00000010
+	V4L2_BUF_FLAG_BFRAME               = 0x00000020
+	V4L2_BUF_FLAG_ERROR                = 0x00000040
+	V4L2_BUF_FLAG_IN_REQUEST           = 0x00000080
+	V4L2_BUF_FLAG_TIMECODE             = 0x00000100
+	V4L2_BUF_FLAG_M2M_HOLD_CAPTURE_BUF = 0x00000200
+	V4L2_BUF_FLAG_PREPARED             = 0x00000400
+	V4L2_BUF_FLAG_NO_CACHE_INVALIDATE  = 0x00000800
+	V4L2_BUF_FLAG_NO_CACHE_CLEAN       = 0x00001000
+	V4L2_BUF_FLAG_TIMESTAMP_MASK       = 0x0000e000
+	V4L2_BUF_FLAG_TIMESTAMP_UNKNOWN    = 0x00000000
+	V4L2_BUF_FLAG_TIMESTAMP_MONOTONIC  = 0x00002000
+	V4L2_BUF_FLAG_TIMESTAMP_COPY       = 0x00004000
+	V4L2_BUF_FLAG_TSTAMP_SRC_MASK      = 0x00070000
+	V4L2_BUF_FLAG_TSTAMP_SRC_EOF       = 0x00000000
+	V4L2_BUF_FLAG_TSTAMP_SRC_SOE       = 0x00010000
+	V4L2_BUF_FLAG_LAST                 = 0x00100000
+	V4L2_BUF_FLAG_REQUEST_FD           = 0x00800000
+)
+
+// buffer capabilities reported by VIDIOC_REQBUFS
+const (
+	V4L2_BUF_CAP_SUPPORTS_MMAP          = 0x00000001
+	V4L2_BUF_CAP_SUPPORTS_USERPTR       = 0x00000002
+	V4L2_BUF_CAP_SUPPORTS_DMABUF        = 0x00000004
+	V4L2_BUF_CAP_SUPPORTS_REQUESTS      = 0x00000008
+	V4L2_BUF_CAP_SUPPORTS_ORPHANED_BUFS = 0x00000010
+)
+
+// encoder commands
+const (
+	V4L2_ENC_CMD_START  = 0
+	V4L2_ENC_CMD_STOP   = 1
+	V4L2_ENC_CMD_PAUSE  = 2
+	V4L2_ENC_CMD_RESUME = 3
+)
+
+// encoder command flags
+const (
+	V4L2_ENC_CMD_STOP_AT_GOP_END = 1
+)
+
+// decoder commands
+const (
+	V4L2_DEC_CMD_START  = 0
+	V4L2_DEC_CMD_STOP   = 1
+	V4L2_DEC_CMD_PAUSE  = 2
+	V4L2_DEC_CMD_RESUME = 3
+	V4L2_DEC_CMD_FLUSH  = 4
+)
+
+// decoder command flags
+const (
+	V4L2_DEC_CMD_START_MUTE_AUDIO = 1
+	V4L2_DEC_CMD_PAUSE_TO_BLACK   = 1
+	V4L2_DEC_CMD_STOP_TO_BLACK    = 1
+	V4L2_DEC_CMD_STOP_IMMEDIATELY = 2
+)
+
+// decoder start formats
+const (
+	V4L2_DEC_START_FMT_NONE = 0
+	V4L2_DEC_START_FMT_GOP  = 1
 )
 
 // Event types
 const (
-	V4L2_EVENT_ALL   = C.V4L2_EVENT_ALL
-	V4L2_EVENT_VSYNC = C.V4L2_EVENT_VSYNC
-	V4L2_EVENT_EOS   = C.V4L2_EVENT_EOS
-	V4L2_EVENT_CTRL  = C.V4L2_EVENT_CTRL
+	V4L2_EVENT_ALL           = 0
+	V4L2_EVENT_VSYNC         = 1
+	V4L2_EVENT_EOS           = 2
+	V4L2_EVENT_CTRL          = 3
+	V4L2_EVENT_FRAME_SYNC    = 4
+	V4L2_EVENT_SOURCE_CHANGE = 5
+	V4L2_EVENT_MOTION_DET    = 6
+	V4L2_EVENT_PRIVATE_START = 0x08000000
+)
+
+// Payload flags for V4L2_EVENT_CTRL
+const (
+	V4L2_EVENT_CTRL_CH_VALUE      = 1
+	V4L2_EVENT_CTRL_CH_FLAGS      = 2
+	V4L2_EVENT_CTRL_CH_RANGE      = 4
+	V4L2_EVENT_CTRL_CH_DIMENSIONS = 8
+)
+
+// Payload flags for V4L2_EVENT_SOURCE_CHANGE and V4L2_EVENT_MOTION_DET
+const (
+	V4L2_EVENT_SRC_CH_RESOLUTION    = 1
+	V4L2_EVENT_MD_FL_HAVE_FRAME_SEQ = 1
+)
+
+// Event subscription flags
+const (
+	V4L2_EVENT_SUB_FL_SEND_INITIAL   = 1
+	V4L2_EVENT_SUB_FL_ALLOW_FEEDBACK = 2
 )
 
 func GetNameByFourCC(fourcc uint32) string {
@@ -155,71 +342,90 @@ func GetNameByFourCC(fourcc uint32) string {
 	return string(b)
 }
 
-func GetFourCCByName(name string) uint32 {
+func GetFourCCByName(name string) (uint32, error) {
 	switch name {
 	case "YVU9", "YVU 4:1:0":
-		return V4L2_PIX_FMT_YVU410
+		return V4L2_PIX_FMT_YVU410, nil
 	case "YV12", "YVU 4:2:0":
-		return V4L2_PIX_FMT_YVU420
+		return V4L2_PIX_FMT_YVU420, nil
 	case "YUYV", "YUV 4:2:2":
-		return V4L2_PIX_FMT_YUYV
+		return V4L2_PIX_FMT_YUYV, nil
 	case "YYUV":
-		return V4L2_PIX_FMT_YYUV
+		return V4L2_PIX_FMT_YYUV, nil
 	case "UYVY":
-		return V4L2_PIX_FMT_UYVY
+		return V4L2_PIX_FMT_UYVY, nil
 	case "VYUY":
-		return V4L2_PIX_FMT_VYUY
+		return V4L2_PIX_FMT_VYUY, nil
 	case "422P", "YVU422 planar", "YVU422P":
-		return V4L2_PIX_FMT_YUV422P
+		return V4L2_PIX_FMT_YUV422P, nil
 	case "411P", "YVU411P":
-		return V4L2_PIX_FMT_YUV411P
+		return V4L2_PIX_FMT_YUV411P, nil
 	case "Y41P", "YUV 4:1:1":
-		return V4L2_PIX_FMT_Y41P
+		return V4L2_PIX_FMT_Y41P, nil
 	case "Y444":
-		return V4L2_PIX_FMT_YUV444
+		return V4L2_PIX_FMT_YUV444, nil
 	case "YUVO":
-		return V4L2_PIX_FMT_YUV555
+		return V4L2_PIX_FMT_YUV555, nil
 	case "YUVP":
-		return V4L2_PIX_FMT_YUV565
+		return V4L2_PIX_FMT_YUV565, nil
 	case "YUV4":
-		return V4L2_PIX_FMT_YUV32
+		return V4L2_PIX_FMT_YUV32, nil
 	case "YUV9":
-		return V4L2_PIX_FMT_YUV410
+		return V4L2_PIX_FMT_YUV410, nil
 	case "YU12":
-		return V4L2_PIX_FMT_YUV420
+		return V4L2_PIX_FMT_YUV420, nil
 	case "HI24":
-		return V4L2_PIX_FMT_HI240
+		return V4L2_PIX_FMT_HI240, nil
 	case "HM12":
-		return V4L2_PIX_FMT_HM12
+		return V4L2_PIX_FMT_HM12, nil
 	case "M420":
-		return V4L2_PIX_FMT_HM12
+		return V4L2_PIX_FMT_M420, nil
 
 	case "NM12", "Y/CbCr 4:2:0":
-		return V4L2_PIX_FMT_NV12M
+		return V4L2_PIX_FMT_NV12M, nil
 	case "NM21", "Y/CrCb 4:2:0":
-		return V4L2_PIX_FMT_NV21M
+		return V4L2_PIX_FMT_NV21M, nil
 	case "MJPG", "Motion-JPEG":
-		return V4L2_PIX_FMT_MJPEG
+		return V4L2_PIX_FMT_MJPEG, nil
 	case "JPEG", "JFIF JPEG":
-		return V4L2_PIX_FMT_JPEG
+		return V4L2_PIX_FMT_JPEG, nil
 	case "H264":
-		return V4L2_PIX_FMT_H264
+		return V4L2_PIX_FMT_H264, nil
 	case "MPG4", "MPEG4":
-		return V4L2_PIX_FMT_MPEG4
+		return V4L2_PIX_FMT_MPEG4, nil
 	case "VP8":
-		return V4L2_PIX_FMT_VP8
-
-	default:
-		log.Fatal("Unexpected FourCC name")
+		return V4L2_PIX_FMT_VP8, nil
+	}
+	if f, err := LookupPixelFormatByName(name); err == nil {
+		return f.FourCC, nil
 	}
-	return 0
+	return 0, fmt.Errorf("%w: %q", ErrorUnknownFourCC, name)
 }
 
 func ioctl(fd int, request uint, argp unsafe.Pointer) error {
-	_, _, err := syscall.Syscall(syscall.SYS_IOCTL,
-		uintptr(fd), uintptr(request), uintptr(argp))
-	if err != 0 {
-		return err
+	var err error
+	if f := lookupBackend(fd); f != nil {
+		err = f.ioctl(request, argp)
+	} else if _, _, e := syscall.Syscall(syscall.SYS_IOCTL,
+		uintptr(fd), uintptr(request), uintptr(argp)); e != 0 {
+		err = e
 	}
-	return nil
+	if err == syscall.ENODEV {
+		return fmt.Errorf("%w: %w", ErrorDisconnected, err)
+	}
+	return err
+}
+
+// goString returns the NUL terminated string at p
+func goString(p unsafe.Pointer) string {
+	n := 0
+	for *(*byte)(unsafe.Add(p, n)) != 0 {
+		n++
+	}
+	return string(unsafe.Slice((*byte)(p), n))
+}
+
+// goBytes returns a copy of the n bytes at p
+func goBytes(p unsafe.Pointer, n int) []byte {
+	return append([]byte(nil), unsafe.Slice((*byte)(p), n)...)
 }